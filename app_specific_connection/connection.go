@@ -3,6 +3,7 @@ package app_specific_connection
 import (
 	"github.com/hashicorp/hcl/v2"
 	"github.com/turbot/pipe-fittings/connection"
+	"github.com/turbot/pipe-fittings/sanitize"
 )
 
 type ConnectionFunc func(string, hcl.Range) connection.PipelingConnection
@@ -22,5 +23,7 @@ func RegisterConnections(funcs ...ConnectionFunc) {
 	for _, f := range funcs {
 		c := f("", hcl.Range{})
 		ConnectionTypeRegistry[c.GetConnectionType()] = f
+		// redact the fields of the connection which are tagged as sensitive
+		sanitize.Instance.RegisterSensitiveFields(c)
 	}
 }
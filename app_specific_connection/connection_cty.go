@@ -3,6 +3,7 @@ package app_specific_connection
 import (
 	"fmt"
 	"reflect"
+	"time"
 
 	"github.com/turbot/go-kit/helpers"
 	"github.com/turbot/pipe-fittings/connection"
@@ -36,8 +37,8 @@ func CtyValueToConnection(value cty.Value) (_ connection.PipelingConnection, err
 	}
 
 	// split the cty value into fields for ConnectionImpl and the derived connection,
-	// (NOTE: exclude the 'env', 'type', 'resource_type' fields, which are manually added)
-	baseValue, derivedValue, err := getKnownCtyFields(value, conn.GetConnectionImpl(), "env", "type", "resource_type")
+	// (NOTE: exclude the 'env', 'type', 'resource_type' and 'expiry' fields, which are manually added)
+	baseValue, derivedValue, err := getKnownCtyFields(value, conn.GetConnectionImpl(), "env", "type", "resource_type", "expiry")
 	if err != nil {
 		return nil, perr.BadRequestWithMessage("unable to decode connection: " + err.Error())
 	}
@@ -46,6 +47,13 @@ func CtyValueToConnection(value cty.Value) (_ connection.PipelingConnection, err
 	if err != nil {
 		return nil, perr.BadRequestWithMessage("unable to decode ConnectionImpl: " + err.Error())
 	}
+	if value.Type().HasAttribute("expiry") && !value.GetAttr("expiry").IsNull() {
+		expiry, err := time.Parse(time.RFC3339, value.GetAttr("expiry").AsString())
+		if err != nil {
+			return nil, perr.BadRequestWithMessage("unable to decode expiry: " + err.Error())
+		}
+		conn.GetConnectionImpl().Expiry = &expiry
+	}
 	// decode remaining fields into the derived connection
 	err = gocty.FromCtyValue(derivedValue, &conn)
 	if err != nil {
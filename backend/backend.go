@@ -47,6 +47,10 @@ func FromConnectionString(ctx context.Context, str string) (Backend, error) {
 		return NewDuckDBBackend(str), nil
 	case IsSqliteConnectionString(str):
 		return NewSqliteBackend(str), nil
+	case IsMSSQLConnectionString(str):
+		return NewMSSQLBackend(str), nil
+	case IsSnowflakeConnectionString(str):
+		return NewSnowflakeBackend(str), nil
 	default:
 
 		return nil, sperr.WrapWithMessage(ErrUnknownBackend, "could not evaluate backend: %s", str)
@@ -59,7 +63,9 @@ func HasBackend(str string) bool {
 		IsPostgresConnectionString(str),
 		IsMySqlConnectionString(str),
 		IsDuckDBConnectionString(str),
-		IsSqliteConnectionString(str):
+		IsSqliteConnectionString(str),
+		IsMSSQLConnectionString(str),
+		IsSnowflakeConnectionString(str):
 		return true
 	default:
 
@@ -121,3 +127,15 @@ func IsDuckDBConnectionString(connString string) bool {
 func IsMySqlConnectionString(connString string) bool {
 	return strings.HasPrefix(connString, mysqlConnectionStringPrefix)
 }
+
+// IsMSSQLConnectionString returns true if the connection string is for sql server
+// looks for the sqlserver:// prefix
+func IsMSSQLConnectionString(connString string) bool {
+	return strings.HasPrefix(connString, mssqlConnectionStringPrefix)
+}
+
+// IsSnowflakeConnectionString returns true if the connection string is for snowflake
+// looks for the snowflake:// prefix
+func IsSnowflakeConnectionString(connString string) bool {
+	return strings.HasPrefix(connString, snowflakeConnectionStringPrefix)
+}
@@ -0,0 +1,106 @@
+package backend
+
+import (
+	"context"
+	"database/sql"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/turbot/pipe-fittings/constants"
+	"github.com/turbot/pipe-fittings/queryresult"
+	"github.com/turbot/pipe-fittings/sperr"
+)
+
+const (
+	mssqlConnectionStringPrefix = "sqlserver://"
+)
+
+type MSSQLBackend struct {
+	connectionString string
+	rowreader        RowReader
+}
+
+func NewMSSQLBackend(connString string) *MSSQLBackend {
+	// the driver accepts the connection string url as is, including the sqlserver:// prefix
+	connString = strings.TrimSpace(connString) // remove any leading or trailing whitespace
+
+	return &MSSQLBackend{
+		connectionString: connString,
+		rowreader:        newMSSQLRowReader(),
+	}
+}
+
+// Connect implements Backend.
+func (b *MSSQLBackend) Connect(_ context.Context, options ...ConnectOption) (*sql.DB, error) {
+	config := NewConnectConfig(options)
+	db, err := sql.Open("sqlserver", b.connectionString)
+	if err != nil {
+		return nil, sperr.WrapWithMessage(err, "could not connect to mssql backend")
+	}
+	db.SetConnMaxIdleTime(config.MaxConnIdleTime)
+	db.SetConnMaxLifetime(config.MaxConnLifeTime)
+	db.SetMaxOpenConns(config.MaxOpenConns)
+	return db, nil
+}
+
+func (b *MSSQLBackend) ConnectionString() string {
+	return b.connectionString
+}
+
+func (b *MSSQLBackend) Name() string {
+	return constants.MSSQLBackendName
+}
+
+// RowReader implements Backend.
+func (b *MSSQLBackend) RowReader() RowReader {
+	return b.rowreader
+}
+
+type mssqlRowReader struct {
+	BasicRowReader
+}
+
+func newMSSQLRowReader() RowReader {
+	return &mssqlRowReader{
+		BasicRowReader: BasicRowReader{
+			CellReader: mssqlReadCell,
+		},
+	}
+}
+
+// mssqlReadCell converts the values the sql server driver returns as bytes - the driver returns other types
+// (ints, floats, bools, strings and times) as native go values
+func mssqlReadCell(columnValue any, col *queryresult.ColumnDef) (result any, err error) {
+	if columnValue != nil {
+		switch t := columnValue.(type) {
+		case []byte:
+			switch col.DataType {
+			case "DECIMAL", "NUMERIC", "MONEY", "SMALLMONEY":
+				result, err = strconv.ParseFloat(string(t), 64)
+			case "UNIQUEIDENTIFIER":
+				result, err = mssqlGuidToString(t)
+			// case "VARBINARY", "BINARY", "IMAGE":
+			default:
+				result = t
+			}
+		default:
+			return columnValue, nil
+		}
+	}
+	return result, err
+}
+
+// mssqlGuidToString formats a uniqueidentifier - sql server stores the first three groups of the guid little endian
+func mssqlGuidToString(b []byte) (string, error) {
+	if len(b) != 16 {
+		return "", sperr.New("invalid uniqueidentifier length %d", len(b))
+	}
+
+	var u uuid.UUID
+	copy(u[:], b)
+	u[0], u[1], u[2], u[3] = b[3], b[2], b[1], b[0]
+	u[4], u[5] = b[5], b[4]
+	u[6], u[7] = b[7], b[6]
+	return strings.ToUpper(u.String()), nil
+}
@@ -0,0 +1,107 @@
+package backend
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"strconv"
+	"strings"
+
+	"github.com/turbot/pipe-fittings/constants"
+	"github.com/turbot/pipe-fittings/queryresult"
+	"github.com/turbot/pipe-fittings/sperr"
+)
+
+const (
+	snowflakeConnectionStringPrefix = "snowflake://"
+)
+
+type SnowflakeBackend struct {
+	connectionString string
+	rowreader        RowReader
+}
+
+func NewSnowflakeBackend(connString string) *SnowflakeBackend {
+	connString = strings.TrimSpace(connString) // remove any leading or trailing whitespace
+	connString = strings.TrimPrefix(connString, snowflakeConnectionStringPrefix)
+
+	return &SnowflakeBackend{
+		connectionString: connString,
+		rowreader:        newSnowflakeRowReader(),
+	}
+}
+
+// Connect implements Backend.
+func (b *SnowflakeBackend) Connect(_ context.Context, options ...ConnectOption) (*sql.DB, error) {
+	config := NewConnectConfig(options)
+	db, err := sql.Open("snowflake", b.connectionString)
+	if err != nil {
+		return nil, sperr.WrapWithMessage(err, "could not connect to snowflake backend")
+	}
+	db.SetConnMaxIdleTime(config.MaxConnIdleTime)
+	db.SetConnMaxLifetime(config.MaxConnLifeTime)
+	db.SetMaxOpenConns(config.MaxOpenConns)
+	return db, nil
+}
+
+func (b *SnowflakeBackend) ConnectionString() string {
+	return b.connectionString
+}
+
+func (b *SnowflakeBackend) Name() string {
+	return constants.SnowflakeBackendName
+}
+
+// RowReader implements Backend.
+func (b *SnowflakeBackend) RowReader() RowReader {
+	return b.rowreader
+}
+
+type snowflakeRowReader struct {
+	BasicRowReader
+}
+
+func newSnowflakeRowReader() RowReader {
+	return &snowflakeRowReader{
+		BasicRowReader: BasicRowReader{
+			CellReader: snowflakeReadCell,
+		},
+	}
+}
+
+// snowflakeReadCell converts the values the snowflake driver returns as strings - the driver returns dates and
+// times as time.Time and binary as bytes, but numbers, booleans and semi-structured values as strings
+func snowflakeReadCell(columnValue any, col *queryresult.ColumnDef) (any, error) {
+	asStr, ok := columnValue.(string)
+	if !ok {
+		return columnValue, nil
+	}
+
+	switch col.DataType {
+	case "FIXED":
+		// NUMBER columns with a scale are returned with a decimal point
+		if !strings.Contains(asStr, ".") {
+			if i, err := strconv.ParseInt(asStr, 10, 64); err == nil {
+				return i, nil
+			}
+		}
+		if f, err := strconv.ParseFloat(asStr, 64); err == nil {
+			return f, nil
+		}
+	case "REAL":
+		if f, err := strconv.ParseFloat(asStr, 64); err == nil {
+			return f, nil
+		}
+	case "BOOLEAN":
+		if b, err := strconv.ParseBool(asStr); err == nil {
+			return b, nil
+		}
+	case "VARIANT", "OBJECT", "ARRAY":
+		var v any
+		if err := json.Unmarshal([]byte(asStr), &v); err == nil {
+			return v, nil
+		}
+	}
+	// if the value cannot be converted, e.g. a number too large for an int64, return it as a string
+	return asStr, nil
+}
@@ -15,7 +15,7 @@ const AbuseIPDBConnectionType = "abuseipdb"
 type AbuseIPDBConnection struct {
 	ConnectionImpl
 
-	APIKey *string `json:"api_key,omitempty" cty:"api_key" hcl:"api_key,optional"`
+	APIKey *string `json:"api_key,omitempty" cty:"api_key" hcl:"api_key,optional" sensitive:"true"`
 }
 
 func NewAbuseIPDBConnection(shortName string, declRange hcl.Range) PipelingConnection {
@@ -33,6 +33,12 @@ func (c *AbuseIPDBConnection) Resolve(ctx context.Context) (PipelingConnection,
 		return c.Pipes.Resolve(ctx, &AzureConnection{ConnectionImpl: c.ConnectionImpl})
 	}
 
+	// replace any secret references with the secrets they refer to
+	c, err := resolveSecrets(ctx, c)
+	if err != nil {
+		return nil, err
+	}
+
 	if c.APIKey == nil {
 		abuseIPDBAPIKeyEnvVar := os.Getenv("ABUSEIPDB_API_KEY")
 
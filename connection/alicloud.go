@@ -15,8 +15,8 @@ const AlicloudConnectionType = "alicloud"
 type AlicloudConnection struct {
 	ConnectionImpl
 
-	AccessKey *string `json:"access_key,omitempty" cty:"access_key" hcl:"access_key,optional"`
-	SecretKey *string `json:"secret_key,omitempty" cty:"secret_key" hcl:"secret_key,optional"`
+	AccessKey *string `json:"access_key,omitempty" cty:"access_key" hcl:"access_key,optional" sensitive:"true"`
+	SecretKey *string `json:"secret_key,omitempty" cty:"secret_key" hcl:"secret_key,optional" sensitive:"true"`
 }
 
 func NewAlicloudConnection(shortName string, declRange hcl.Range) PipelingConnection {
@@ -34,6 +34,12 @@ func (c *AlicloudConnection) Resolve(ctx context.Context) (PipelingConnection, e
 		return c.Pipes.Resolve(ctx, &AlicloudConnection{ConnectionImpl: c.ConnectionImpl})
 	}
 
+	// replace any secret references with the secrets they refer to
+	c, err := resolveSecrets(ctx, c)
+	if err != nil {
+		return nil, err
+	}
+
 	// The order of precedence for the environment variable
 	// 1. ALIBABACLOUD_ACCESS_KEY_ID
 	// 2. ALICLOUD_ACCESS_KEY_ID
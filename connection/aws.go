@@ -2,11 +2,12 @@ package connection
 
 import (
 	"context"
+	"fmt"
 
-	"github.com/aws/aws-sdk-go-v2/aws"
-	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
 	"github.com/hashicorp/hcl/v2"
 	"github.com/turbot/go-kit/helpers"
+	"github.com/turbot/pipe-fittings/perr"
 	"github.com/turbot/pipe-fittings/utils"
 	"github.com/zclconf/go-cty/cty"
 )
@@ -19,10 +20,22 @@ const (
 type AwsConnection struct {
 	ConnectionImpl
 
-	AccessKey    *string `json:"access_key,omitempty" cty:"access_key" hcl:"access_key,optional"`
-	SecretKey    *string `json:"secret_key,omitempty" cty:"secret_key" hcl:"secret_key,optional"`
-	SessionToken *string `json:"session_token,omitempty" cty:"session_token" hcl:"session_token,optional"`
+	AccessKey    *string `json:"access_key,omitempty" cty:"access_key" hcl:"access_key,optional" sensitive:"true"`
+	SecretKey    *string `json:"secret_key,omitempty" cty:"secret_key" hcl:"secret_key,optional" sensitive:"true"`
+	SessionToken *string `json:"session_token,omitempty" cty:"session_token" hcl:"session_token,optional" sensitive:"true"`
 	Profile      *string `json:"profile,omitempty" cty:"profile" hcl:"profile,optional"`
+	Region       *string `json:"region,omitempty" cty:"region" hcl:"region,optional"`
+
+	// role assumption
+	RoleArn              *string `json:"role_arn,omitempty" cty:"role_arn" hcl:"role_arn,optional"`
+	ExternalId           *string `json:"external_id,omitempty" cty:"external_id" hcl:"external_id,optional"`
+	SessionName          *string `json:"session_name,omitempty" cty:"session_name" hcl:"session_name,optional"`
+	Duration             *int    `json:"duration,omitempty" cty:"duration" hcl:"duration,optional"` // in seconds
+	WebIdentityTokenFile *string `json:"web_identity_token_file,omitempty" cty:"web_identity_token_file" hcl:"web_identity_token_file,optional"`
+
+	// SourceConnection is the name of the aws connection whose credentials are used to assume the role
+	SourceConnection *string `json:"source_connection,omitempty" cty:"source_connection" hcl:"source_connection,optional"`
+	sourceConnection *AwsConnection
 }
 
 func NewAwsConnection(shortName string, declRange hcl.Range) PipelingConnection {
@@ -44,30 +57,39 @@ func (c *AwsConnection) Resolve(ctx context.Context) (PipelingConnection, error)
 		return c.Pipes.Resolve(ctx, &AwsConnection{ConnectionImpl: c.ConnectionImpl})
 	}
 
-	// if access key and secret key are provided, just return it
-	if c.AccessKey != nil && c.SecretKey != nil {
+	// replace any secret references with the secrets they refer to
+	c, err := resolveSecrets(ctx, c)
+	if err != nil {
+		return nil, err
+	}
+
+	// if access key and secret key are provided and there is no role to assume, just return it
+	if c.AccessKey != nil && c.SecretKey != nil && c.RoleArn == nil {
 		return c, nil
 	}
 
-	var cfg aws.Config
-	var err error
+	opts := c.credentialOptions()
 
-	// Load the AWS configuration from the shared credentials file
-	if c.Profile != nil {
-		cfg, err = config.LoadDefaultConfig(ctx, config.WithSharedConfigProfile(*c.Profile), config.WithRegion("us-east-1"))
-		if err != nil {
-			return nil, err
+	// chained role assumption - use the credentials of the source connection
+	if c.SourceConnection != nil {
+		if c.sourceConnection == nil {
+			return nil, perr.BadRequestWithMessage(fmt.Sprintf("source connection %s of connection %s not found", *c.SourceConnection, c.Name()))
 		}
-	} else {
-		cfg, err = config.LoadDefaultConfig(context.TODO(), config.WithRegion("us-east-1"))
+
+		resolvedSource, err := c.sourceConnection.Resolve(ctx)
 		if err != nil {
 			return nil, err
 		}
-
+		source := resolvedSource.(*AwsConnection)
+		opts.AccessKey = source.AccessKey
+		opts.SecretKey = source.SecretKey
+		opts.SessionToken = source.SessionToken
+		if opts.Region == nil {
+			opts.Region = source.Region
+		}
 	}
 
-	// Access the credentials from the configuration
-	creds, err := cfg.Credentials.Retrieve(context.TODO())
+	creds, err := RetrieveAwsCredentials(ctx, opts)
 	if err != nil {
 		return nil, err
 	}
@@ -77,15 +99,60 @@ func (c *AwsConnection) Resolve(ctx context.Context) (PipelingConnection, error)
 		ConnectionImpl: c.ConnectionImpl,
 		AccessKey:      &creds.AccessKeyID,
 		SecretKey:      &creds.SecretAccessKey,
+		Region:         c.Region,
 	}
 
 	if creds.SessionToken != "" {
 		newConnection.SessionToken = &creds.SessionToken
 	}
 
+	// temporary credentials must be refreshed before they expire
+	newConnection.SetTtl(AwsCredentialTtl(creds, c.GetTtl()))
+	if creds.CanExpire {
+		newConnection.Expiry = &creds.Expires
+	}
+
 	return newConnection, nil
 }
 
+func (c *AwsConnection) credentialOptions() AwsCredentialOptions {
+	return AwsCredentialOptions{
+		AccessKey:            c.AccessKey,
+		SecretKey:            c.SecretKey,
+		SessionToken:         c.SessionToken,
+		Profile:              c.Profile,
+		Region:               c.Region,
+		RoleArn:              c.RoleArn,
+		ExternalId:           c.ExternalId,
+		SessionName:          c.SessionName,
+		Duration:             c.Duration,
+		WebIdentityTokenFile: c.WebIdentityTokenFile,
+	}
+}
+
+// GetSourceConnectionName returns the name of the connection whose credentials are used to assume the role
+func (c *AwsConnection) GetSourceConnectionName() *string {
+	return c.SourceConnection
+}
+
+// SetSourceConnection sets the connection whose credentials are used to assume the role
+func (c *AwsConnection) SetSourceConnection(source PipelingConnection) error {
+	awsSource, ok := source.(*AwsConnection)
+	if !ok {
+		return perr.BadRequestWithMessage(fmt.Sprintf("source connection %s of connection %s must be an aws connection", source.Name(), c.Name()))
+	}
+	c.sourceConnection = awsSource
+	return nil
+}
+
+// GetSourceConnection returns the connection whose credentials are used to assume the role, if it has been set
+func (c *AwsConnection) GetSourceConnection() PipelingConnection {
+	if c.sourceConnection == nil {
+		return nil
+	}
+	return c.sourceConnection
+}
+
 func (c *AwsConnection) Equals(otherConnection PipelingConnection) bool {
 	// If both pointers are nil, they are considered equal
 	if c == nil && helpers.IsNil(otherConnection) {
@@ -113,11 +180,39 @@ func (c *AwsConnection) Equals(otherConnection PipelingConnection) bool {
 		return false
 	}
 
+	if !utils.PtrEqual(c.Region, other.Region) {
+		return false
+	}
+
+	if !utils.PtrEqual(c.RoleArn, other.RoleArn) {
+		return false
+	}
+
+	if !utils.PtrEqual(c.ExternalId, other.ExternalId) {
+		return false
+	}
+
+	if !utils.PtrEqual(c.SessionName, other.SessionName) {
+		return false
+	}
+
+	if !utils.PtrEqual(c.Duration, other.Duration) {
+		return false
+	}
+
+	if !utils.PtrEqual(c.WebIdentityTokenFile, other.WebIdentityTokenFile) {
+		return false
+	}
+
+	if !utils.PtrEqual(c.SourceConnection, other.SourceConnection) {
+		return false
+	}
+
 	return c.GetConnectionImpl().Equals(otherConnection.GetConnectionImpl())
 }
 
 func (c *AwsConnection) Validate() hcl.Diagnostics {
-	if c.Pipes != nil && (c.AccessKey != nil || c.SecretKey != nil || c.Profile != nil || c.SessionToken != nil) {
+	if c.Pipes != nil && (c.AccessKey != nil || c.SecretKey != nil || c.Profile != nil || c.SessionToken != nil || c.RoleArn != nil || c.SourceConnection != nil || c.WebIdentityTokenFile != nil) {
 		return hcl.Diagnostics{
 			{
 				Severity: hcl.DiagError,
@@ -147,7 +242,7 @@ func (c *AwsConnection) Validate() hcl.Diagnostics {
 		}
 	}
 
-	return hcl.Diagnostics{}
+	return ValidateAwsCredentialOptions(c.credentialOptions(), c.SourceConnection != nil, c.DeclRange.HclRangePointer())
 }
 
 func (c *AwsConnection) CtyValue() (cty.Value, error) {
@@ -167,5 +262,25 @@ func (c *AwsConnection) GetEnv() map[string]cty.Value {
 	if c.SessionToken != nil {
 		env["AWS_SESSION_TOKEN"] = cty.StringVal(*c.SessionToken)
 	}
+	if c.Region != nil {
+		env["AWS_REGION"] = cty.StringVal(*c.Region)
+		env["AWS_DEFAULT_REGION"] = cty.StringVal(*c.Region)
+	}
 	return env
 }
+
+// Test calls STS GetCallerIdentity, returning the ARN of the caller as the identity
+func (c *AwsConnection) Test(ctx context.Context) *TestResult {
+	return runConnectionTest(ctx, func(ctx context.Context) (string, error) {
+		cfg, err := loadAwsConfig(ctx, c.credentialOptions())
+		if err != nil {
+			return "", err
+		}
+
+		output, err := sts.NewFromConfig(cfg).GetCallerIdentity(ctx, &sts.GetCallerIdentityInput{})
+		if err != nil {
+			return "", err
+		}
+		return *output.Arn, nil
+	})
+}
@@ -0,0 +1,184 @@
+package connection
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/credentials/stscreds"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+	"github.com/hashicorp/hcl/v2"
+)
+
+const (
+	defaultAwsRegion = "us-east-1"
+
+	// the limits AWS applies to the duration of an assumed role session, in seconds
+	minAwsRoleDuration = 15 * 60
+	maxAwsRoleDuration = 12 * 60 * 60
+
+	// temporary credentials are refreshed this many seconds before they expire
+	credentialExpiryWindow = 60
+)
+
+// AwsCredentialOptions describes how to obtain AWS credentials. It is shared by the aws connection and the
+// aws credential
+type AwsCredentialOptions struct {
+	AccessKey    *string
+	SecretKey    *string
+	SessionToken *string
+	Profile      *string
+	Region       *string
+
+	RoleArn              *string
+	ExternalId           *string
+	SessionName          *string
+	Duration             *int
+	WebIdentityTokenFile *string
+}
+
+// RetrieveAwsCredentials loads the base credentials, from static keys, a profile or the default credential chain,
+// then assumes the role if one is specified
+func RetrieveAwsCredentials(ctx context.Context, opts AwsCredentialOptions) (aws.Credentials, error) {
+	cfg, err := loadAwsConfig(ctx, opts)
+	if err != nil {
+		return aws.Credentials{}, err
+	}
+
+	return cfg.Credentials.Retrieve(ctx)
+}
+
+// loadAwsConfig loads the aws config, with a credentials provider for the given options
+func loadAwsConfig(ctx context.Context, opts AwsCredentialOptions) (aws.Config, error) {
+	var loadOptions []func(*config.LoadOptions) error
+	if opts.Region != nil {
+		loadOptions = append(loadOptions, config.WithRegion(*opts.Region))
+	}
+	switch {
+	case opts.AccessKey != nil && opts.SecretKey != nil:
+		sessionToken := ""
+		if opts.SessionToken != nil {
+			sessionToken = *opts.SessionToken
+		}
+		loadOptions = append(loadOptions, config.WithCredentialsProvider(
+			credentials.NewStaticCredentialsProvider(*opts.AccessKey, *opts.SecretKey, sessionToken)))
+	case opts.Profile != nil:
+		loadOptions = append(loadOptions, config.WithSharedConfigProfile(*opts.Profile))
+	}
+
+	cfg, err := config.LoadDefaultConfig(ctx, loadOptions...)
+	if err != nil {
+		return aws.Config{}, err
+	}
+	// if no region is configured or set in the environment or profile, sts needs a region to call
+	if cfg.Region == "" {
+		cfg.Region = defaultAwsRegion
+	}
+
+	if opts.RoleArn != nil {
+		stsClient := sts.NewFromConfig(cfg)
+
+		if opts.WebIdentityTokenFile != nil {
+			cfg.Credentials = aws.NewCredentialsCache(stscreds.NewWebIdentityRoleProvider(stsClient, *opts.RoleArn,
+				stscreds.IdentityTokenFile(*opts.WebIdentityTokenFile),
+				func(o *stscreds.WebIdentityRoleOptions) {
+					if opts.SessionName != nil {
+						o.RoleSessionName = *opts.SessionName
+					}
+					if opts.Duration != nil {
+						o.Duration = time.Duration(*opts.Duration) * time.Second
+					}
+				}))
+		} else {
+			cfg.Credentials = aws.NewCredentialsCache(stscreds.NewAssumeRoleProvider(stsClient, *opts.RoleArn,
+				func(o *stscreds.AssumeRoleOptions) {
+					o.ExternalID = opts.ExternalId
+					if opts.SessionName != nil {
+						o.RoleSessionName = *opts.SessionName
+					}
+					if opts.Duration != nil {
+						o.Duration = time.Duration(*opts.Duration) * time.Second
+					}
+				}))
+		}
+	}
+
+	return cfg, nil
+}
+
+// AwsCredentialTtl returns the ttl to use for the given credentials - if they expire before the configured ttl has
+// elapsed, the ttl is reduced so they are refreshed before expiry
+func AwsCredentialTtl(creds aws.Credentials, ttl int) int {
+	if !creds.CanExpire {
+		return ttl
+	}
+
+	return expiringCredentialTtl(creds.Expires, ttl)
+}
+
+// expiringCredentialTtl returns the ttl to use for credentials which expire at the given time
+func expiringCredentialTtl(expires time.Time, ttl int) int {
+	expiresIn := int(time.Until(expires).Seconds()) - credentialExpiryWindow
+	if expiresIn < 0 {
+		expiresIn = 0
+	}
+	if ttl < 0 || expiresIn < ttl {
+		return expiresIn
+	}
+	return ttl
+}
+
+// ValidateAwsCredentialOptions validates the role assumption settings
+func ValidateAwsCredentialOptions(opts AwsCredentialOptions, hasSource bool, subject *hcl.Range) hcl.Diagnostics {
+	var diags hcl.Diagnostics
+
+	if opts.RoleArn == nil {
+		for _, attr := range []struct {
+			name string
+			set  bool
+		}{
+			{"external_id", opts.ExternalId != nil},
+			{"session_name", opts.SessionName != nil},
+			{"duration", opts.Duration != nil},
+			{"web_identity_token_file", opts.WebIdentityTokenFile != nil},
+			{"source_connection", hasSource},
+		} {
+			if attr.set {
+				diags = append(diags, &hcl.Diagnostic{
+					Severity: hcl.DiagError,
+					Summary:  attr.name + " defined without role_arn",
+					Subject:  subject,
+				})
+			}
+		}
+	}
+
+	if opts.Duration != nil && (*opts.Duration < minAwsRoleDuration || *opts.Duration > maxAwsRoleDuration) {
+		diags = append(diags, &hcl.Diagnostic{
+			Severity: hcl.DiagError,
+			Summary:  fmt.Sprintf("duration must be between %d and %d seconds", minAwsRoleDuration, maxAwsRoleDuration),
+			Subject:  subject,
+		})
+	}
+
+	if opts.WebIdentityTokenFile != nil && opts.ExternalId != nil {
+		diags = append(diags, &hcl.Diagnostic{
+			Severity: hcl.DiagError,
+			Summary:  "external_id cannot be used with web_identity_token_file",
+			Subject:  subject,
+		})
+	}
+
+	if hasSource && (opts.AccessKey != nil || opts.SecretKey != nil || opts.SessionToken != nil || opts.Profile != nil || opts.WebIdentityTokenFile != nil) {
+		diags = append(diags, &hcl.Diagnostic{
+			Severity: hcl.DiagError,
+			Summary:  "source_connection cannot be used with access_key, secret_key, session_token, profile or web_identity_token_file",
+			Subject:  subject,
+		})
+	}
+
+	return diags
+}
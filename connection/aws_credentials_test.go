@@ -0,0 +1,48 @@
+package connection
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/turbot/pipe-fittings/utils"
+)
+
+func TestLoadAwsConfig_Region(t *testing.T) {
+	assert := assert.New(t)
+
+	// isolate from the shared config and credentials of the environment running the test
+	dir := t.TempDir()
+	configFile := filepath.Join(dir, "config")
+	err := os.WriteFile(configFile, []byte("[profile eu]\nregion = eu-west-1\n"), 0600)
+	assert.Nil(err)
+	t.Setenv("AWS_CONFIG_FILE", configFile)
+	t.Setenv("AWS_SHARED_CREDENTIALS_FILE", filepath.Join(dir, "credentials"))
+	t.Setenv("AWS_REGION", "")
+	t.Setenv("AWS_DEFAULT_REGION", "")
+	t.Setenv("AWS_PROFILE", "")
+
+	opts := AwsCredentialOptions{AccessKey: utils.ToStringPointer("AKIA0000"), SecretKey: utils.ToStringPointer("secret")}
+
+	cfg, err := loadAwsConfig(context.Background(), opts)
+	assert.Nil(err)
+	assert.Equal(defaultAwsRegion, cfg.Region)
+
+	t.Setenv("AWS_REGION", "ap-southeast-2")
+	cfg, err = loadAwsConfig(context.Background(), opts)
+	assert.Nil(err)
+	assert.Equal("ap-southeast-2", cfg.Region)
+
+	// a configured region takes precedence over the environment
+	opts.Region = utils.ToStringPointer("us-west-2")
+	cfg, err = loadAwsConfig(context.Background(), opts)
+	assert.Nil(err)
+	assert.Equal("us-west-2", cfg.Region)
+
+	t.Setenv("AWS_REGION", "")
+	cfg, err = loadAwsConfig(context.Background(), AwsCredentialOptions{Profile: utils.ToStringPointer("eu")})
+	assert.Nil(err)
+	assert.Equal("eu-west-1", cfg.Region)
+}
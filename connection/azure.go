@@ -16,7 +16,7 @@ type AzureConnection struct {
 	ConnectionImpl
 
 	ClientID     *string `json:"client_id,omitempty" cty:"client_id" hcl:"client_id,optional"`
-	ClientSecret *string `json:"client_secret,omitempty" cty:"client_secret" hcl:"client_secret,optional"`
+	ClientSecret *string `json:"client_secret,omitempty" cty:"client_secret" hcl:"client_secret,optional" sensitive:"true"`
 	TenantID     *string `json:"tenant_id,omitempty" cty:"tenant_id" hcl:"tenant_id,optional"`
 	Environment  *string `json:"environment,omitempty" cty:"environment" hcl:"environment,optional"`
 }
@@ -35,6 +35,12 @@ func (c *AzureConnection) Resolve(ctx context.Context) (PipelingConnection, erro
 		return c.Pipes.Resolve(ctx, &AzureConnection{ConnectionImpl: c.ConnectionImpl})
 	}
 
+	// replace any secret references with the secrets they refer to
+	c, err := resolveSecrets(ctx, c)
+	if err != nil {
+		return nil, err
+	}
+
 	if c.ClientID == nil && c.ClientSecret == nil && c.TenantID == nil && c.Environment == nil {
 		clientIDEnvVar := os.Getenv("AZURE_CLIENT_ID")
 		clientSecretEnvVar := os.Getenv("AZURE_CLIENT_SECRET")
@@ -17,7 +17,7 @@ type BitbucketConnection struct {
 
 	BaseURL  *string `json:"base_url,omitempty" cty:"base_url" hcl:"base_url,optional"`
 	Username *string `json:"username,omitempty" cty:"username" hcl:"username,optional"`
-	Password *string `json:"password,omitempty" cty:"password" hcl:"password,optional"`
+	Password *string `json:"password,omitempty" cty:"password" hcl:"password,optional" sensitive:"true"`
 }
 
 func NewBitbucketConnection(shortName string, declRange hcl.Range) PipelingConnection {
@@ -35,6 +35,12 @@ func (c *BitbucketConnection) Resolve(ctx context.Context) (PipelingConnection,
 		return c.Pipes.Resolve(ctx, &BitbucketConnection{ConnectionImpl: c.ConnectionImpl})
 	}
 
+	// replace any secret references with the secrets they refer to
+	c, err := resolveSecrets(ctx, c)
+	if err != nil {
+		return nil, err
+	}
+
 	if c.Password == nil && c.BaseURL == nil && c.Username == nil {
 		bitbucketURLEnvVar := os.Getenv("BITBUCKET_API_BASE_URL")
 		bitbucketUsernameEnvVar := os.Getenv("BITBUCKET_USERNAME")
@@ -15,7 +15,7 @@ const ClickUpConnectionType = "clickup"
 type ClickUpConnection struct {
 	ConnectionImpl
 
-	Token *string `json:"token,omitempty" cty:"token" hcl:"token,optional"`
+	Token *string `json:"token,omitempty" cty:"token" hcl:"token,optional" sensitive:"true"`
 }
 
 func NewClickUpConnection(shortName string, declRange hcl.Range) PipelingConnection {
@@ -33,6 +33,12 @@ func (c *ClickUpConnection) Resolve(ctx context.Context) (PipelingConnection, er
 		return c.Pipes.Resolve(ctx, &ClickUpConnection{ConnectionImpl: c.ConnectionImpl})
 	}
 
+	// replace any secret references with the secrets they refer to
+	c, err := resolveSecrets(ctx, c)
+	if err != nil {
+		return nil, err
+	}
+
 	if c.Token == nil {
 		clickUpAPITokenEnvVar := os.Getenv("CLICKUP_TOKEN")
 
@@ -3,6 +3,7 @@ package connection
 import (
 	"fmt"
 	"maps"
+	"time"
 
 	"github.com/hashicorp/hcl/v2"
 	"github.com/turbot/pipe-fittings/cty_helpers"
@@ -21,6 +22,9 @@ type ConnectionImpl struct {
 	DeclRange hclhelpers.Range `json:"decl_range,omitempty" cty:"decl_range"`
 	// cache ttl in seconds
 	Ttl int `json:"ttl,omitempty" cty:"ttl" hcl:"ttl,optional"`
+	// Expiry is the time the connection credentials expire, if known - this is set when the connection is resolved
+	// or its expiry is checked
+	Expiry *time.Time `json:"expiry,omitempty"`
 }
 
 func NewConnectionImpl(connectionType, shortName string, declRange hcl.Range) ConnectionImpl {
@@ -102,5 +106,9 @@ func ctyValueForConnection(connection PipelingConnection) (cty.Value, error) {
 	mergedValueMap["env"] = cty.ObjectVal(connection.GetEnv())
 	mergedValueMap["type"] = cty.StringVal(connection.GetConnectionType())
 	mergedValueMap["resource_type"] = cty.StringVal("connection")
+	mergedValueMap["expiry"] = cty.NullVal(cty.String)
+	if impl.Expiry != nil {
+		mergedValueMap["expiry"] = cty.StringVal(impl.Expiry.UTC().Format(time.RFC3339))
+	}
 	return cty.ObjectVal(mergedValueMap), nil
 }
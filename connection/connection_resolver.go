@@ -0,0 +1,190 @@
+package connection
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/turbot/pipe-fittings/constants"
+	"golang.org/x/sync/singleflight"
+)
+
+// DefaultRefreshAheadFraction is the fraction of a resolved connection's ttl, before expiry, at which it is
+// refreshed in the background
+const DefaultRefreshAheadFraction = 0.1
+
+// Clock provides the current time - it is replaced in tests to control expiry
+type Clock interface {
+	Now() time.Time
+}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
+
+type resolvedConnectionEntry struct {
+	// the unresolved connection - used to detect that the connection config has changed
+	source     PipelingConnection
+	resolved   PipelingConnection
+	refreshAt  time.Time
+	expires    time.Time
+	refreshing bool
+}
+
+// ConnectionResolver memoises the results of PipelingConnection.Resolve until the ttl of the resolved connection
+// expires. Connections are refreshed in the background shortly before they expire, and concurrent resolves of the
+// same connection share a single call to Resolve
+type ConnectionResolver struct {
+	clock                Clock
+	refreshAheadFraction float64
+
+	mut     sync.Mutex
+	entries map[string]*resolvedConnectionEntry
+	// generation is incremented on invalidation so results of in flight resolves are not cached
+	generation uint64
+	group      singleflight.Group
+}
+
+type ConnectionResolverOption func(*ConnectionResolver)
+
+// WithClock sets the clock used to determine expiry
+func WithClock(clock Clock) ConnectionResolverOption {
+	return func(r *ConnectionResolver) {
+		r.clock = clock
+	}
+}
+
+// WithRefreshAheadFraction sets the fraction of the ttl before expiry at which connections are refreshed
+// in the background. A fraction of zero disables refresh ahead
+func WithRefreshAheadFraction(fraction float64) ConnectionResolverOption {
+	return func(r *ConnectionResolver) {
+		r.refreshAheadFraction = fraction
+	}
+}
+
+func NewConnectionResolver(opts ...ConnectionResolverOption) *ConnectionResolver {
+	r := &ConnectionResolver{
+		clock:                systemClock{},
+		refreshAheadFraction: DefaultRefreshAheadFraction,
+		entries:              make(map[string]*resolvedConnectionEntry),
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+// Resolve returns the resolved connection, using the cached result if it has not expired
+func (r *ConnectionResolver) Resolve(ctx context.Context, conn PipelingConnection) (PipelingConnection, error) {
+	name := conn.Name()
+	now := r.clock.Now()
+
+	r.mut.Lock()
+	entry, ok := r.entries[name]
+	if ok && entry.source.Equals(conn) && now.Before(entry.expires) {
+		resolved := entry.resolved
+		if !entry.refreshing && r.refreshAheadFraction > 0 && !now.Before(entry.refreshAt) {
+			entry.refreshing = true
+			go r.refresh(context.WithoutCancel(ctx), conn)
+		}
+		r.mut.Unlock()
+		return resolved, nil
+	}
+	r.mut.Unlock()
+
+	return r.resolve(ctx, conn)
+}
+
+// resolve calls Resolve on the connection, sharing the call with any concurrent resolves of the same connection
+func (r *ConnectionResolver) resolve(ctx context.Context, conn PipelingConnection) (PipelingConnection, error) {
+	// don't let one caller cancelling the shared resolve fail the other callers
+	resultChan := r.group.DoChan(conn.Name(), func() (any, error) {
+		return r.resolveAndStore(context.WithoutCancel(ctx), conn)
+	})
+
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case res := <-resultChan:
+		if res.Err != nil {
+			return nil, res.Err
+		}
+		return res.Val.(PipelingConnection), nil
+	}
+}
+
+func (r *ConnectionResolver) refresh(ctx context.Context, conn PipelingConnection) {
+	_, err := r.resolve(ctx, conn)
+	if err != nil {
+		// keep the existing entry until it expires
+		slog.Warn("Unable to refresh connection", "connection", conn.Name(), "error", err)
+
+		r.mut.Lock()
+		if entry, ok := r.entries[conn.Name()]; ok {
+			entry.refreshing = false
+		}
+		r.mut.Unlock()
+	}
+}
+
+func (r *ConnectionResolver) resolveAndStore(ctx context.Context, conn PipelingConnection) (PipelingConnection, error) {
+	r.mut.Lock()
+	generation := r.generation
+	r.mut.Unlock()
+
+	resolved, err := conn.Resolve(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	ttl := resolved.GetTtl()
+	if ttl < 0 {
+		ttl = constants.DefaultConnectionTtl
+	}
+
+	r.mut.Lock()
+	defer r.mut.Unlock()
+
+	// if the cache was invalidated while resolving, the result may be stale so don't cache it
+	if ttl == 0 || generation != r.generation {
+		delete(r.entries, conn.Name())
+		return resolved, nil
+	}
+
+	now := r.clock.Now()
+	ttlDuration := time.Duration(ttl) * time.Second
+	r.entries[conn.Name()] = &resolvedConnectionEntry{
+		source:    conn,
+		resolved:  resolved,
+		refreshAt: now.Add(ttlDuration - time.Duration(float64(ttlDuration)*r.refreshAheadFraction)),
+		expires:   now.Add(ttlDuration),
+	}
+	return resolved, nil
+}
+
+// Invalidate removes the given connections from the cache
+func (r *ConnectionResolver) Invalidate(names ...string) {
+	r.mut.Lock()
+	defer r.mut.Unlock()
+
+	r.generation++
+	for _, name := range names {
+		delete(r.entries, name)
+		r.group.Forget(name)
+	}
+}
+
+// InvalidateAll removes all connections from the cache
+func (r *ConnectionResolver) InvalidateAll() {
+	r.mut.Lock()
+	defer r.mut.Unlock()
+
+	r.generation++
+	for name := range r.entries {
+		r.group.Forget(name)
+	}
+	r.entries = make(map[string]*resolvedConnectionEntry)
+}
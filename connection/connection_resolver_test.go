@@ -0,0 +1,290 @@
+package connection
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/zclconf/go-cty/cty"
+)
+
+type fakeClock struct {
+	mut sync.Mutex
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+	c.now = c.now.Add(d)
+}
+
+// fakeConnection counts calls to Resolve, and resolves to a connection whose Value identifies the call
+type fakeConnection struct {
+	ConnectionImpl
+
+	Value string
+
+	calls    *atomic.Int32
+	err      error
+	gate     chan struct{}
+	resolved chan struct{}
+}
+
+func newFakeConnection(name string, ttl int) *fakeConnection {
+	c := &fakeConnection{
+		ConnectionImpl: NewConnectionImpl("fake", name, hcl.Range{}),
+		calls:          &atomic.Int32{},
+	}
+	c.SetTtl(ttl)
+	return c
+}
+
+func (c *fakeConnection) GetConnectionType() string {
+	return "fake"
+}
+
+func (c *fakeConnection) Resolve(context.Context) (PipelingConnection, error) {
+	call := c.calls.Add(1)
+	if c.gate != nil {
+		<-c.gate
+	}
+	if c.resolved != nil {
+		defer func() { c.resolved <- struct{}{} }()
+	}
+	if c.err != nil {
+		return nil, c.err
+	}
+
+	return &fakeConnection{
+		ConnectionImpl: c.ConnectionImpl,
+		Value:          c.Value + "-" + string(rune('0'+call)),
+	}, nil
+}
+
+func (c *fakeConnection) Validate() hcl.Diagnostics {
+	return hcl.Diagnostics{}
+}
+
+func (c *fakeConnection) CtyValue() (cty.Value, error) {
+	return ctyValueForConnection(c)
+}
+
+func (c *fakeConnection) GetEnv() map[string]cty.Value {
+	return map[string]cty.Value{}
+}
+
+func (c *fakeConnection) Equals(other PipelingConnection) bool {
+	o, ok := other.(*fakeConnection)
+	return ok && c.Value == o.Value && c.GetConnectionImpl().Equals(o.GetConnectionImpl())
+}
+
+func resolvedValue(t *testing.T, r *ConnectionResolver, conn PipelingConnection) string {
+	t.Helper()
+	resolved, err := r.Resolve(context.TODO(), conn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resolved.(*fakeConnection).Value
+}
+
+func TestConnectionResolverCachesUntilExpiry(t *testing.T) {
+	assert := assert.New(t)
+
+	clock := &fakeClock{now: time.Now()}
+	r := NewConnectionResolver(WithClock(clock), WithRefreshAheadFraction(0))
+
+	conn := newFakeConnection("default", 60)
+	conn.Value = "a"
+
+	assert.Equal("a-1", resolvedValue(t, r, conn))
+	assert.Equal("a-1", resolvedValue(t, r, conn))
+	assert.Equal(int32(1), conn.calls.Load())
+
+	clock.Advance(59 * time.Second)
+	assert.Equal("a-1", resolvedValue(t, r, conn))
+
+	clock.Advance(time.Second)
+	assert.Equal("a-2", resolvedValue(t, r, conn))
+	assert.Equal(int32(2), conn.calls.Load())
+
+	// if the connection config changes, the cached connection is not used
+	changed := newFakeConnection("default", 60)
+	changed.Value = "b"
+	assert.Equal("b-1", resolvedValue(t, r, changed))
+}
+
+func TestConnectionResolverTtl(t *testing.T) {
+	assert := assert.New(t)
+
+	clock := &fakeClock{now: time.Now()}
+	r := NewConnectionResolver(WithClock(clock), WithRefreshAheadFraction(0))
+
+	// a ttl of zero disables caching
+	uncached := newFakeConnection("uncached", 0)
+	resolvedValue(t, r, uncached)
+	resolvedValue(t, r, uncached)
+	assert.Equal(int32(2), uncached.calls.Load())
+
+	// an unset ttl uses the default
+	unset := newFakeConnection("unset", -1)
+	resolvedValue(t, r, unset)
+	clock.Advance(3599 * time.Second)
+	resolvedValue(t, r, unset)
+	assert.Equal(int32(1), unset.calls.Load())
+	clock.Advance(time.Second)
+	resolvedValue(t, r, unset)
+	assert.Equal(int32(2), unset.calls.Load())
+
+	// errors are not cached
+	failing := newFakeConnection("failing", 60)
+	failing.err = errors.New("unable to resolve")
+	_, err := r.Resolve(context.TODO(), failing)
+	assert.NotNil(err)
+	_, err = r.Resolve(context.TODO(), failing)
+	assert.NotNil(err)
+	assert.Equal(int32(2), failing.calls.Load())
+}
+
+func TestConnectionResolverRefreshAhead(t *testing.T) {
+	assert := assert.New(t)
+
+	clock := &fakeClock{now: time.Now()}
+	r := NewConnectionResolver(WithClock(clock))
+
+	conn := newFakeConnection("default", 100)
+	conn.Value = "a"
+	conn.resolved = make(chan struct{}, 2)
+
+	assert.Equal("a-1", resolvedValue(t, r, conn))
+	<-conn.resolved
+
+	// within the refresh ahead window, the cached connection is returned and refreshed in the background
+	clock.Advance(91 * time.Second)
+	assert.Equal("a-1", resolvedValue(t, r, conn))
+
+	select {
+	case <-conn.resolved:
+	case <-time.After(5 * time.Second):
+		assert.Fail("connection was not refreshed")
+		return
+	}
+
+	// wait for the refreshed connection to be stored
+	assert.Eventually(func() bool {
+		return resolvedValue(t, r, conn) == "a-2"
+	}, 5*time.Second, 10*time.Millisecond)
+	assert.Equal(int32(2), conn.calls.Load())
+}
+
+func TestConnectionResolverSingleflight(t *testing.T) {
+	assert := assert.New(t)
+
+	r := NewConnectionResolver()
+
+	conn := newFakeConnection("default", 60)
+	conn.Value = "a"
+	conn.gate = make(chan struct{})
+
+	const callers = 10
+	var wg sync.WaitGroup
+	results := make([]string, callers)
+	for i := 0; i < callers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			results[i] = resolvedValue(t, r, conn)
+		}(i)
+	}
+
+	// wait for the first resolve to start, give the other callers time to join it, then release it
+	assert.Eventually(func() bool { return conn.calls.Load() == 1 }, 5*time.Second, time.Millisecond)
+	time.Sleep(50 * time.Millisecond)
+	close(conn.gate)
+	wg.Wait()
+
+	assert.Equal(int32(1), conn.calls.Load())
+	for _, result := range results {
+		assert.Equal("a-1", result)
+	}
+}
+
+func TestConnectionResolverInvalidate(t *testing.T) {
+	assert := assert.New(t)
+
+	r := NewConnectionResolver()
+
+	conn := newFakeConnection("default", 60)
+	conn.Value = "a"
+	other := newFakeConnection("other", 60)
+	other.Value = "b"
+
+	assert.Equal("a-1", resolvedValue(t, r, conn))
+	assert.Equal("b-1", resolvedValue(t, r, other))
+
+	r.Invalidate(conn.Name())
+	assert.Equal("a-2", resolvedValue(t, r, conn))
+	assert.Equal("b-1", resolvedValue(t, r, other))
+
+	r.InvalidateAll()
+	assert.Equal("a-3", resolvedValue(t, r, conn))
+	assert.Equal("b-2", resolvedValue(t, r, other))
+
+	// a resolve in flight when the cache is invalidated is not cached
+	conn.gate = make(chan struct{})
+	r.InvalidateAll()
+	done := make(chan string)
+	go func() {
+		done <- resolvedValue(t, r, conn)
+	}()
+	assert.Eventually(func() bool { return conn.calls.Load() == 4 }, 5*time.Second, time.Millisecond)
+	r.InvalidateAll()
+	close(conn.gate)
+	assert.Equal("a-4", <-done)
+
+	conn.gate = nil
+	assert.Equal("a-5", resolvedValue(t, r, conn))
+}
+
+func TestConnectionResolverCallerCancellation(t *testing.T) {
+	assert := assert.New(t)
+
+	r := NewConnectionResolver()
+
+	conn := newFakeConnection("default", 60)
+	conn.Value = "a"
+	conn.gate = make(chan struct{})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errChan := make(chan error)
+	go func() {
+		_, err := r.Resolve(ctx, conn)
+		errChan <- err
+	}()
+	assert.Eventually(func() bool { return conn.calls.Load() == 1 }, 5*time.Second, time.Millisecond)
+
+	// a second caller joins the resolve, the first caller cancelling does not fail it
+	resultChan := make(chan string)
+	go func() {
+		resultChan <- resolvedValue(t, r, conn)
+	}()
+	time.Sleep(50 * time.Millisecond)
+
+	cancel()
+	assert.ErrorIs(<-errChan, context.Canceled)
+
+	close(conn.gate)
+	assert.Equal("a-1", <-resultChan)
+	assert.Equal(int32(1), conn.calls.Load())
+}
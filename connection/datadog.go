@@ -3,6 +3,7 @@ package connection
 import (
 	"context"
 	"os"
+	"strings"
 
 	"github.com/hashicorp/hcl/v2"
 	"github.com/turbot/go-kit/helpers"
@@ -15,8 +16,8 @@ const DatadogConnectionType = "datadog"
 type DatadogConnection struct {
 	ConnectionImpl
 
-	APIKey *string `json:"api_key,omitempty" cty:"api_key" hcl:"api_key,optional"`
-	AppKey *string `json:"app_key,omitempty" cty:"app_key" hcl:"app_key,optional"`
+	APIKey *string `json:"api_key,omitempty" cty:"api_key" hcl:"api_key,optional" sensitive:"true"`
+	AppKey *string `json:"app_key,omitempty" cty:"app_key" hcl:"app_key,optional" sensitive:"true"`
 	APIUrl *string `json:"api_url,omitempty" cty:"api_url" hcl:"api_url,optional"`
 }
 
@@ -35,6 +36,12 @@ func (c *DatadogConnection) Resolve(ctx context.Context) (PipelingConnection, er
 		return c.Pipes.Resolve(ctx, &DatadogConnection{ConnectionImpl: c.ConnectionImpl})
 	}
 
+	// replace any secret references with the secrets they refer to
+	c, err := resolveSecrets(ctx, c)
+	if err != nil {
+		return nil, err
+	}
+
 	datadogAPIKeyEnvVar := os.Getenv("DD_CLIENT_API_KEY")
 	datadogAppKeyEnvVar := os.Getenv("DD_CLIENT_APP_KEY")
 
@@ -118,3 +125,17 @@ func (c *DatadogConnection) GetEnv() map[string]cty.Value {
 	}
 	return env
 }
+
+// Test validates the Datadog api key
+func (c *DatadogConnection) Test(ctx context.Context) *TestResult {
+	return runConnectionTest(ctx, func(ctx context.Context) (string, error) {
+		if !hasTestValue(c.APIKey) {
+			return "", missingAttributeError(c, "api_key")
+		}
+		if !hasTestValue(c.APIUrl) {
+			return "", missingAttributeError(c, "api_url")
+		}
+
+		return testGetJson[any](ctx, strings.TrimSuffix(*c.APIUrl, "/")+"/api/v1/validate", map[string]string{"DD-API-KEY": *c.APIKey}, nil)
+	})
+}
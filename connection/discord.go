@@ -15,7 +15,7 @@ const DiscordConnectionType = "discord"
 type DiscordConnection struct {
 	ConnectionImpl
 
-	Token *string `json:"token,omitempty" cty:"token" hcl:"token,optional"`
+	Token *string `json:"token,omitempty" cty:"token" hcl:"token,optional" sensitive:"true"`
 }
 
 func NewDiscordConnection(shortName string, declRange hcl.Range) PipelingConnection {
@@ -32,6 +32,12 @@ func (c *DiscordConnection) Resolve(ctx context.Context) (PipelingConnection, er
 		return c.Pipes.Resolve(ctx, &DiscordConnection{ConnectionImpl: c.ConnectionImpl})
 	}
 
+	// replace any secret references with the secrets they refer to
+	c, err := resolveSecrets(ctx, c)
+	if err != nil {
+		return nil, err
+	}
+
 	if c.Token == nil {
 		discordTokenEnvVar := os.Getenv("DISCORD_TOKEN")
 
@@ -94,3 +100,21 @@ func (c *DiscordConnection) GetEnv() map[string]cty.Value {
 	}
 	return env
 }
+
+var discordApiUrl = "https://discord.com/api/v10"
+
+// Test calls the Discord /users/@me endpoint, returning the username of the bot as the identity
+func (c *DiscordConnection) Test(ctx context.Context) *TestResult {
+	return runConnectionTest(ctx, func(ctx context.Context) (string, error) {
+		if !hasTestValue(c.Token) {
+			return "", missingAttributeError(c, "token")
+		}
+
+		return testGetJson(ctx, discordApiUrl+"/users/@me", map[string]string{"Authorization": "Bot " + *c.Token},
+			func(user struct {
+				Username string `json:"username"`
+			}) string {
+				return user.Username
+			})
+	})
+}
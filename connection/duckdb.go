@@ -33,6 +33,12 @@ func (c *DuckDbConnection) Resolve(ctx context.Context) (PipelingConnection, err
 		return c.Pipes.Resolve(ctx, &DuckDbConnection{ConnectionImpl: c.ConnectionImpl})
 	}
 
+	// replace any secret references with the secrets they refer to
+	c, err := resolveSecrets(ctx, c)
+	if err != nil {
+		return nil, err
+	}
+
 	return c, nil
 }
 
@@ -87,3 +93,10 @@ func (c *DuckDbConnection) getFileName() any {
 	}
 	return os.Getenv("DUCKDB_FILENAME")
 }
+
+// Test runs SELECT 1 against the database
+func (c *DuckDbConnection) Test(ctx context.Context) *TestResult {
+	return runConnectionTest(ctx, func(ctx context.Context) (string, error) {
+		return testDatabase(ctx, c.GetConnectionString())
+	})
+}
@@ -0,0 +1,55 @@
+package connection
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+	"time"
+)
+
+// ExpiryProvider is implemented by connection types which can determine when their credentials expire by
+// querying the service which issued them
+type ExpiryProvider interface {
+	// GetExpiry returns the time the connection credentials expire, or nil if they do not expire
+	GetExpiry(ctx context.Context) (*time.Time, error)
+}
+
+// ConnectionExpiry resolves the connection and returns the time its credentials expire, or nil if they do not
+// expire or the expiry cannot be determined
+func ConnectionExpiry(ctx context.Context, conn PipelingConnection) (*time.Time, error) {
+	resolved, err := conn.Resolve(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if provider, ok := resolved.(ExpiryProvider); ok {
+		return provider.GetExpiry(ctx)
+	}
+	// otherwise use the expiry of the resolved credentials, if it is known
+	return resolved.GetConnectionImpl().Expiry, nil
+}
+
+// jwtExpiry returns the expiry of a JWT from its exp claim, or nil if the token is not a JWT or has no expiry.
+// The signature is not verified - the expiry is only used for reporting
+func jwtExpiry(token string) *time.Time {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(parts[1], "="))
+	if err != nil {
+		return nil
+	}
+
+	var claims struct {
+		Exp *float64 `json:"exp"`
+	}
+	if err := json.Unmarshal(payload, &claims); err != nil || claims.Exp == nil {
+		return nil
+	}
+
+	expiry := time.Unix(int64(*claims.Exp), 0)
+	return &expiry
+}
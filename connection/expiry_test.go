@@ -0,0 +1,158 @@
+package connection
+
+import (
+	"context"
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/turbot/pipe-fittings/perr"
+	"github.com/turbot/pipe-fittings/utils"
+)
+
+func testJwt(payload string) string {
+	return "eyJhbGciOiJIUzI1NiJ9." + base64.RawURLEncoding.EncodeToString([]byte(payload)) + ".c2lnbmF0dXJl"
+}
+
+func TestJwtExpiry(t *testing.T) {
+	assert := assert.New(t)
+
+	expiry := jwtExpiry(testJwt(`{"sub":"flowpipe","exp":1767225600}`))
+	assert.NotNil(expiry)
+	assert.Equal(time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC), expiry.UTC())
+
+	assert.Nil(jwtExpiry(testJwt(`{"sub":"flowpipe"}`)))
+	assert.Nil(jwtExpiry("ghp_notajwt"))
+	assert.Nil(jwtExpiry("a.!!!.c"))
+}
+
+func TestHttpConnectionExpiry(t *testing.T) {
+	assert := assert.New(t)
+
+	conn := NewHttpConnection("jwt", hcl.Range{}).(*HttpConnection)
+	conn.BaseUrl = utils.ToPointer("https://api.example.com")
+	conn.Auth = &HttpConnectionAuth{
+		Type:  HttpAuthTypeBearer,
+		Token: utils.ToPointer(testJwt(`{"exp":1767225600}`)),
+	}
+
+	expiry, err := ConnectionExpiry(context.TODO(), conn)
+	assert.Nil(err)
+	assert.Equal(time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC), expiry.UTC())
+	// the configured connection is not modified
+	assert.Nil(conn.Expiry)
+
+	// the expiry is available to pipelines
+	resolved, err := conn.Resolve(context.TODO())
+	assert.Nil(err)
+	ctyValue, err := resolved.CtyValue()
+	assert.Nil(err)
+	assert.Equal("2026-01-01T00:00:00Z", ctyValue.GetAttr("expiry").AsString())
+
+	// tokens which are not JWTs have no known expiry
+	conn.Auth.Token = utils.ToPointer("opaque")
+	expiry, err = ConnectionExpiry(context.TODO(), conn)
+	assert.Nil(err)
+	assert.Nil(expiry)
+	ctyValue, err = conn.CtyValue()
+	assert.Nil(err)
+	assert.True(ctyValue.GetAttr("expiry").IsNull())
+}
+
+func TestGithubConnectionExpiry(t *testing.T) {
+	assert := assert.New(t)
+
+	expiration := "2026-11-01 12:00:00 UTC"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal("/user", r.URL.Path)
+		if expiration != "" {
+			w.Header().Set("GitHub-Authentication-Token-Expiration", expiration)
+		}
+		_, _ = w.Write([]byte(`{"login": "octocat"}`))
+	}))
+	defer server.Close()
+
+	defaultUrl := githubApiUrl
+	githubApiUrl = server.URL
+	defer func() { githubApiUrl = defaultUrl }()
+
+	conn := NewGithubConnection("default", hcl.Range{}).(*GithubConnection)
+	conn.Token = utils.ToPointer("github_pat_abc")
+
+	expiry, err := ConnectionExpiry(context.TODO(), conn)
+	assert.Nil(err)
+	assert.Equal(time.Date(2026, 11, 1, 12, 0, 0, 0, time.UTC), expiry.UTC())
+
+	// fine-grained tokens report the expiration with a numeric offset
+	expiration = "2026-11-01 13:00:00 +0100"
+	expiry, err = ConnectionExpiry(context.TODO(), conn)
+	assert.Nil(err)
+	assert.Equal(time.Date(2026, 11, 1, 12, 0, 0, 0, time.UTC), expiry.UTC())
+
+	expiration = "next tuesday"
+	_, err = ConnectionExpiry(context.TODO(), conn)
+	assert.NotNil(err)
+
+	// classic tokens without an expiry have no header
+	expiration = ""
+	expiry, err = ConnectionExpiry(context.TODO(), conn)
+	assert.Nil(err)
+	assert.Nil(expiry)
+}
+
+func TestGitLabConnectionExpiry(t *testing.T) {
+	assert := assert.New(t)
+
+	response := `{"id": 1, "expires_at": "2026-12-31"}`
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal("/personal_access_tokens/self", r.URL.Path)
+		assert.Equal("glpat-abc", r.Header.Get("PRIVATE-TOKEN"))
+		_, _ = w.Write([]byte(response))
+	}))
+	defer server.Close()
+
+	defaultUrl := gitlabApiUrl
+	gitlabApiUrl = server.URL
+	defer func() { gitlabApiUrl = defaultUrl }()
+
+	conn := NewGitLabConnection("default", hcl.Range{}).(*GitLabConnection)
+	conn.Token = utils.ToPointer("glpat-abc")
+
+	expiry, err := ConnectionExpiry(context.TODO(), conn)
+	assert.Nil(err)
+	assert.Equal(time.Date(2026, 12, 31, 0, 0, 0, 0, time.UTC), *expiry)
+
+	response = `{"id": 1, "expires_at": null}`
+	expiry, err = ConnectionExpiry(context.TODO(), conn)
+	assert.Nil(err)
+	assert.Nil(expiry)
+}
+
+func TestVaultConnectionExpiry(t *testing.T) {
+	assert := assert.New(t)
+
+	status := http.StatusOK
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal("/v1/auth/token/lookup-self", r.URL.Path)
+		w.WriteHeader(status)
+		_, _ = w.Write([]byte(`{"data": {"display_name": "token", "expire_time": "2026-10-20T09:30:00.123456Z"}}`))
+	}))
+	defer server.Close()
+
+	conn := NewVaultConnection("default", hcl.Range{}).(*VaultConnection)
+	conn.Address = utils.ToPointer(server.URL + "/")
+	conn.Token = utils.ToPointer("hvs.abc")
+
+	expiry, err := ConnectionExpiry(context.TODO(), conn)
+	assert.Nil(err)
+	assert.Equal(time.Date(2026, 10, 20, 9, 30, 0, 123456000, time.UTC), *expiry)
+
+	// a revoked token
+	status = http.StatusForbidden
+	_, err = ConnectionExpiry(context.TODO(), conn)
+	assert.True(perr.IsForbidden(err))
+}
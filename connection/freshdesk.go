@@ -15,7 +15,7 @@ const FreshdeskConnectionType = "freshdesk"
 type FreshdeskConnection struct {
 	ConnectionImpl
 
-	APIKey    *string `json:"api_key,omitempty" cty:"api_key" hcl:"api_key,optional"`
+	APIKey    *string `json:"api_key,omitempty" cty:"api_key" hcl:"api_key,optional" sensitive:"true"`
 	Subdomain *string `json:"subdomain,omitempty" cty:"subdomain" hcl:"subdomain,optional"`
 }
 
@@ -34,6 +34,12 @@ func (c *FreshdeskConnection) Resolve(ctx context.Context) (PipelingConnection,
 		return c.Pipes.Resolve(ctx, &FreshdeskConnection{ConnectionImpl: c.ConnectionImpl})
 	}
 
+	// replace any secret references with the secrets they refer to
+	c, err := resolveSecrets(ctx, c)
+	if err != nil {
+		return nil, err
+	}
+
 	freshdeskAPIKeyEnvVar := os.Getenv("FRESHDESK_API_KEY")
 	freshdeskSubdomainEnvVar := os.Getenv("FRESHDESK_SUBDOMAIN")
 
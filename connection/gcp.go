@@ -23,7 +23,7 @@ type GcpConnection struct {
 	ConnectionImpl
 
 	Credentials *string `json:"credentials,omitempty" cty:"credentials" hcl:"credentials,optional"`
-	AccessToken *string `json:"access_token,omitempty" cty:"access_token" hcl:"access_token,optional"`
+	AccessToken *string `json:"access_token,omitempty" cty:"access_token" hcl:"access_token,optional" sensitive:"true"`
 }
 
 func NewGcpConnection(shortName string, declRange hcl.Range) PipelingConnection {
@@ -44,6 +44,12 @@ func (c *GcpConnection) Resolve(ctx context.Context) (PipelingConnection, error)
 		return c.Pipes.Resolve(ctx, &GcpConnection{ConnectionImpl: c.ConnectionImpl})
 	}
 
+	// replace any secret references with the secrets they refer to
+	c, err := resolveSecrets(ctx, c)
+	if err != nil {
+		return nil, err
+	}
+
 	// First check if the credential file is supplied
 	var credentialFile string
 	if c.Credentials != nil && *c.Credentials != "" {
@@ -102,6 +108,9 @@ func (c *GcpConnection) Resolve(ctx context.Context) (PipelingConnection, error)
 			AccessToken:    &token.AccessToken,
 			Credentials:    &credentialFile,
 		}
+		if !token.Expiry.IsZero() {
+			newConnection.Expiry = &token.Expiry
+		}
 		return newConnection, nil
 	}
 
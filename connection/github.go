@@ -2,10 +2,13 @@ package connection
 
 import (
 	"context"
+	"fmt"
 	"os"
+	"time"
 
 	"github.com/hashicorp/hcl/v2"
 	"github.com/turbot/go-kit/helpers"
+	"github.com/turbot/pipe-fittings/perr"
 	"github.com/turbot/pipe-fittings/utils"
 	"github.com/zclconf/go-cty/cty"
 )
@@ -15,7 +18,7 @@ const GithubConnectionType = "github"
 type GithubConnection struct {
 	ConnectionImpl
 
-	Token *string `json:"token,omitempty" cty:"token" hcl:"token,optional"`
+	Token *string `json:"token,omitempty" cty:"token" hcl:"token,optional" sensitive:"true"`
 }
 
 func (c *GithubConnection) Resolve(ctx context.Context) (PipelingConnection, error) {
@@ -24,6 +27,12 @@ func (c *GithubConnection) Resolve(ctx context.Context) (PipelingConnection, err
 		return c.Pipes.Resolve(ctx, &GithubConnection{ConnectionImpl: c.ConnectionImpl})
 	}
 
+	// replace any secret references with the secrets they refer to
+	c, err := resolveSecrets(ctx, c)
+	if err != nil {
+		return nil, err
+	}
+
 	if c.Token == nil {
 		githubAccessTokenEnvVar := os.Getenv("GITHUB_TOKEN")
 
@@ -95,3 +104,46 @@ func (c *GithubConnection) GetEnv() map[string]cty.Value {
 	}
 	return env
 }
+
+var githubApiUrl = "https://api.github.com"
+
+// Test calls the GitHub /user endpoint, returning the login of the user as the identity
+func (c *GithubConnection) Test(ctx context.Context) *TestResult {
+	return runConnectionTest(ctx, func(ctx context.Context) (string, error) {
+		if !hasTestValue(c.Token) {
+			return "", missingAttributeError(c, "token")
+		}
+
+		return testGetJson(ctx, githubApiUrl+"/user", map[string]string{"Authorization": "Bearer " + *c.Token},
+			func(user struct {
+				Login string `json:"login"`
+			}) string {
+				return user.Login
+			})
+	})
+}
+
+// GetExpiry returns the token expiry reported by the GitHub API - tokens which do not expire have no expiry header
+func (c *GithubConnection) GetExpiry(ctx context.Context) (*time.Time, error) {
+	if !hasTestValue(c.Token) {
+		return nil, missingAttributeError(c, "token")
+	}
+
+	headers, err := getJson(ctx, githubApiUrl+"/user", map[string]string{"Authorization": "Bearer " + *c.Token}, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	header := headers.Get("GitHub-Authentication-Token-Expiration")
+	if header == "" {
+		return nil, nil
+	}
+	// the expiration is usually in UTC, e.g. "2026-11-01 12:00:00 UTC", but may have a numeric offset instead, e.g.
+	// "2026-11-01 12:00:00 +0100"
+	for _, layout := range []string{"2006-01-02 15:04:05 -0700", "2006-01-02 15:04:05 MST"} {
+		if expiry, err := time.Parse(layout, header); err == nil {
+			return &expiry, nil
+		}
+	}
+	return nil, perr.InternalWithMessage(fmt.Sprintf("unable to parse token expiration '%s'", header))
+}
@@ -2,10 +2,13 @@ package connection
 
 import (
 	"context"
+	"fmt"
 	"os"
+	"time"
 
 	"github.com/hashicorp/hcl/v2"
 	"github.com/turbot/go-kit/helpers"
+	"github.com/turbot/pipe-fittings/perr"
 	"github.com/turbot/pipe-fittings/utils"
 	"github.com/zclconf/go-cty/cty"
 )
@@ -15,7 +18,7 @@ const GitLabConnectionType = "gitlab"
 type GitLabConnection struct {
 	ConnectionImpl
 
-	Token *string `json:"token,omitempty" cty:"token" hcl:"token,optional"`
+	Token *string `json:"token,omitempty" cty:"token" hcl:"token,optional" sensitive:"true"`
 }
 
 func NewGitLabConnection(shortName string, declRange hcl.Range) PipelingConnection {
@@ -33,6 +36,12 @@ func (c *GitLabConnection) Resolve(ctx context.Context) (PipelingConnection, err
 		return c.Pipes.Resolve(ctx, &GitLabConnection{ConnectionImpl: c.ConnectionImpl})
 	}
 
+	// replace any secret references with the secrets they refer to
+	c, err := resolveSecrets(ctx, c)
+	if err != nil {
+		return nil, err
+	}
+
 	if c.Token == nil {
 		gitlabAccessTokenEnvVar := os.Getenv("GITLAB_TOKEN")
 
@@ -93,3 +102,45 @@ func (c *GitLabConnection) GetEnv() map[string]cty.Value {
 	// https://github.com/xanzy/go-gitlab
 	return nil
 }
+
+var gitlabApiUrl = "https://gitlab.com/api/v4"
+
+// Test calls the GitLab /user endpoint, returning the username of the user as the identity
+func (c *GitLabConnection) Test(ctx context.Context) *TestResult {
+	return runConnectionTest(ctx, func(ctx context.Context) (string, error) {
+		if !hasTestValue(c.Token) {
+			return "", missingAttributeError(c, "token")
+		}
+
+		return testGetJson(ctx, gitlabApiUrl+"/user", map[string]string{"PRIVATE-TOKEN": *c.Token},
+			func(user struct {
+				Username string `json:"username"`
+			}) string {
+				return user.Username
+			})
+	})
+}
+
+// GetExpiry returns the expiry date of the access token, which expires at the start of that day
+func (c *GitLabConnection) GetExpiry(ctx context.Context) (*time.Time, error) {
+	if !hasTestValue(c.Token) {
+		return nil, missingAttributeError(c, "token")
+	}
+
+	var res struct {
+		ExpiresAt *string `json:"expires_at"`
+	}
+	_, err := getJson(ctx, gitlabApiUrl+"/personal_access_tokens/self", map[string]string{"PRIVATE-TOKEN": *c.Token}, &res)
+	if err != nil {
+		return nil, err
+	}
+
+	if res.ExpiresAt == nil {
+		return nil, nil
+	}
+	expiry, err := time.Parse(time.DateOnly, *res.ExpiresAt)
+	if err != nil {
+		return nil, perr.InternalWithMessage(fmt.Sprintf("unable to parse token expiry '%s': %s", *res.ExpiresAt, err.Error()))
+	}
+	return &expiry, nil
+}
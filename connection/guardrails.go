@@ -15,8 +15,8 @@ const GuardrailsConnectionType = "guardrails"
 type GuardrailsConnection struct {
 	ConnectionImpl
 
-	AccessKey *string `json:"access_key,omitempty" cty:"access_key" hcl:"access_key,optional"`
-	SecretKey *string `json:"secret_key,omitempty" cty:"secret_key" hcl:"secret_key,optional"`
+	AccessKey *string `json:"access_key,omitempty" cty:"access_key" hcl:"access_key,optional" sensitive:"true"`
+	SecretKey *string `json:"secret_key,omitempty" cty:"secret_key" hcl:"secret_key,optional" sensitive:"true"`
 	Workspace *string `json:"workspace,omitempty" cty:"workspace" hcl:"workspace,optional"`
 }
 
@@ -35,6 +35,12 @@ func (c *GuardrailsConnection) Resolve(ctx context.Context) (PipelingConnection,
 		return c.Pipes.Resolve(ctx, &GuardrailsConnection{ConnectionImpl: c.ConnectionImpl})
 	}
 
+	// replace any secret references with the secrets they refer to
+	c, err := resolveSecrets(ctx, c)
+	if err != nil {
+		return nil, err
+	}
+
 	guardrailsAccessKeyEnvVar := os.Getenv("TURBOT_ACCESS_KEY")
 	guardrailsSecretKeyEnvVar := os.Getenv("TURBOT_SECRET_KEY")
 	guardrailsWorkspaceEnvVar := os.Getenv("TURBOT_WORKSPACE")
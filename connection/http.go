@@ -0,0 +1,467 @@
+package connection
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"maps"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/turbot/go-kit/helpers"
+	"github.com/turbot/pipe-fittings/constants"
+	"github.com/turbot/pipe-fittings/perr"
+	"github.com/turbot/pipe-fittings/sanitize"
+	"github.com/turbot/pipe-fittings/utils"
+	"github.com/zclconf/go-cty/cty"
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/clientcredentials"
+)
+
+const HttpConnectionType = "http"
+
+const (
+	HttpAuthTypeBearer = "bearer"
+	HttpAuthTypeBasic  = "basic"
+	HttpAuthTypeApiKey = "api_key"
+	HttpAuthTypeOAuth2 = "oauth2"
+
+	HttpApiKeyInHeader = "header"
+	HttpApiKeyInQuery  = "query"
+)
+
+var ValidHttpAuthTypes = []string{HttpAuthTypeBearer, HttpAuthTypeBasic, HttpAuthTypeApiKey, HttpAuthTypeOAuth2}
+
+// HttpConnection is a generic connection for REST APIs which do not have a dedicated connection type
+type HttpConnection struct {
+	ConnectionImpl
+
+	BaseUrl *string             `json:"base_url,omitempty" cty:"base_url" hcl:"base_url,optional"`
+	Headers map[string]string   `json:"headers,omitempty" cty:"headers" hcl:"headers,optional"`
+	Auth    *HttpConnectionAuth `json:"auth,omitempty" cty:"auth" hcl:"auth,block"`
+
+	// AccessToken is the OAuth2 access token, populated when the connection is resolved
+	AccessToken *string `json:"access_token,omitempty" cty:"access_token" sensitive:"true"`
+}
+
+// HttpConnectionAuth is the auth block of an http connection - the attributes which apply depend on the Type
+type HttpConnectionAuth struct {
+	Type string `json:"type" cty:"type" hcl:"type"`
+
+	// bearer
+	Token *string `json:"token,omitempty" cty:"token" hcl:"token,optional" sensitive:"true"`
+
+	// basic
+	Username *string `json:"username,omitempty" cty:"username" hcl:"username,optional"`
+	Password *string `json:"password,omitempty" cty:"password" hcl:"password,optional" sensitive:"true"`
+
+	// api_key
+	ApiKey  *string `json:"api_key,omitempty" cty:"api_key" hcl:"api_key,optional" sensitive:"true"`
+	KeyName *string `json:"key_name,omitempty" cty:"key_name" hcl:"key_name,optional"`
+	In      *string `json:"in,omitempty" cty:"in" hcl:"in,optional"`
+
+	// oauth2 client credentials
+	ClientId     *string   `json:"client_id,omitempty" cty:"client_id" hcl:"client_id,optional"`
+	ClientSecret *string   `json:"client_secret,omitempty" cty:"client_secret" hcl:"client_secret,optional" sensitive:"true"`
+	TokenUrl     *string   `json:"token_url,omitempty" cty:"token_url" hcl:"token_url,optional"`
+	Scopes       *[]string `json:"scopes,omitempty" cty:"scopes" hcl:"scopes,optional"`
+	Audience     *string   `json:"audience,omitempty" cty:"audience" hcl:"audience,optional"`
+}
+
+func (a *HttpConnectionAuth) Equals(other *HttpConnectionAuth) bool {
+	if a == nil || other == nil {
+		return a == nil && other == nil
+	}
+
+	return a.Type == other.Type &&
+		utils.PtrEqual(a.Token, other.Token) &&
+		utils.PtrEqual(a.Username, other.Username) &&
+		utils.PtrEqual(a.Password, other.Password) &&
+		utils.PtrEqual(a.ApiKey, other.ApiKey) &&
+		utils.PtrEqual(a.KeyName, other.KeyName) &&
+		utils.PtrEqual(a.In, other.In) &&
+		utils.PtrEqual(a.ClientId, other.ClientId) &&
+		utils.PtrEqual(a.ClientSecret, other.ClientSecret) &&
+		utils.PtrEqual(a.TokenUrl, other.TokenUrl) &&
+		utils.SlicePtrEqual(a.Scopes, other.Scopes) &&
+		utils.PtrEqual(a.Audience, other.Audience)
+}
+
+func (a *HttpConnectionAuth) apiKeyInQuery() bool {
+	return a.In != nil && *a.In == HttpApiKeyInQuery
+}
+
+func NewHttpConnection(shortName string, declRange hcl.Range) PipelingConnection {
+	return &HttpConnection{
+		ConnectionImpl: NewConnectionImpl(HttpConnectionType, shortName, declRange),
+	}
+}
+
+func (c *HttpConnection) GetConnectionType() string {
+	return HttpConnectionType
+}
+
+func (c *HttpConnection) Resolve(ctx context.Context) (PipelingConnection, error) {
+	// if pipes metadata is set, call pipes to retrieve the creds
+	if c.Pipes != nil {
+		return c.Pipes.Resolve(ctx, &HttpConnection{ConnectionImpl: c.ConnectionImpl})
+	}
+
+	// replace any secret references with the secrets they refer to
+	c, err := resolveSecrets(ctx, c)
+	if err != nil {
+		return nil, err
+	}
+
+	if c.Auth == nil {
+		return c, nil
+	}
+
+	// make sure the credentials do not leak into logs or output
+	for _, v := range []*string{c.Auth.Token, c.Auth.Password, c.Auth.ApiKey, c.Auth.ClientSecret} {
+		if v != nil {
+			sanitize.Instance.AddSecretValue(*v)
+		}
+	}
+
+	if c.Auth.Type != HttpAuthTypeOAuth2 {
+		// bearer tokens are often JWTs, which carry their expiry
+		if c.Auth.Type == HttpAuthTypeBearer && c.Auth.Token != nil {
+			if expiry := jwtExpiry(*c.Auth.Token); expiry != nil {
+				newConnection := *c
+				newConnection.Expiry = expiry
+				return &newConnection, nil
+			}
+		}
+		return c, nil
+	}
+
+	token, err := oauth2TokenCache.Token(ctx, c.Auth)
+	if err != nil {
+		return nil, perr.UnauthorizedWithMessage(fmt.Sprintf("unable to retrieve oauth2 token for %s: %s", c.Name(), err.Error()))
+	}
+	sanitize.Instance.AddSecretValue(token.AccessToken)
+
+	// Don't modify existing connection, resolve to a new one
+	newConnection := *c
+	newConnection.AccessToken = &token.AccessToken
+	// refresh the connection before the token expires
+	if !token.Expiry.IsZero() {
+		newConnection.Ttl = expiringCredentialTtl(token.Expiry, c.Ttl)
+		newConnection.Expiry = &token.Expiry
+	}
+	return &newConnection, nil
+}
+
+func (c *HttpConnection) Equals(otherConnection PipelingConnection) bool {
+	// If both pointers are nil, they are considered equal
+	if c == nil && helpers.IsNil(otherConnection) {
+		return true
+	}
+
+	if (c == nil && !helpers.IsNil(otherConnection)) || (c != nil && helpers.IsNil(otherConnection)) {
+		return false
+	}
+
+	other, ok := otherConnection.(*HttpConnection)
+	if !ok {
+		return false
+	}
+
+	if !utils.PtrEqual(c.BaseUrl, other.BaseUrl) {
+		return false
+	}
+
+	if !maps.Equal(c.Headers, other.Headers) {
+		return false
+	}
+
+	if !c.Auth.Equals(other.Auth) {
+		return false
+	}
+
+	if !utils.PtrEqual(c.AccessToken, other.AccessToken) {
+		return false
+	}
+
+	return c.GetConnectionImpl().Equals(otherConnection.GetConnectionImpl())
+}
+
+func (c *HttpConnection) Validate() hcl.Diagnostics {
+	if c.Pipes != nil && (c.BaseUrl != nil || c.Headers != nil || c.Auth != nil) {
+		return hcl.Diagnostics{
+			{
+				Severity: hcl.DiagError,
+				Summary:  "if pipes block is defined, no other auth properties should be set",
+				Subject:  c.DeclRange.HclRangePointer(),
+			},
+		}
+	}
+
+	var diags hcl.Diagnostics
+	if c.BaseUrl != nil {
+		if u, err := url.Parse(*c.BaseUrl); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			diags = append(diags, &hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "base_url must be an absolute http or https url",
+				Subject:  c.DeclRange.HclRangePointer(),
+			})
+		}
+	}
+
+	if c.Auth != nil {
+		diags = append(diags, c.validateAuth()...)
+	}
+
+	return diags
+}
+
+func (c *HttpConnection) validateAuth() hcl.Diagnostics {
+	auth := c.Auth
+
+	if !slices.Contains(ValidHttpAuthTypes, auth.Type) {
+		return hcl.Diagnostics{
+			{
+				Severity: hcl.DiagError,
+				Summary:  fmt.Sprintf("invalid auth type '%s', must be one of: %s", auth.Type, strings.Join(ValidHttpAuthTypes, ", ")),
+				Subject:  c.DeclRange.HclRangePointer(),
+			},
+		}
+	}
+
+	var diags hcl.Diagnostics
+	// the attributes which are required or allowed for each auth type
+	for _, attr := range []struct {
+		name     string
+		set      bool
+		authType string
+		required bool
+	}{
+		{"token", auth.Token != nil, HttpAuthTypeBearer, true},
+		{"username", auth.Username != nil, HttpAuthTypeBasic, true},
+		{"password", auth.Password != nil, HttpAuthTypeBasic, false},
+		{"api_key", auth.ApiKey != nil, HttpAuthTypeApiKey, true},
+		{"key_name", auth.KeyName != nil, HttpAuthTypeApiKey, true},
+		{"in", auth.In != nil, HttpAuthTypeApiKey, false},
+		{"client_id", auth.ClientId != nil, HttpAuthTypeOAuth2, true},
+		{"client_secret", auth.ClientSecret != nil, HttpAuthTypeOAuth2, true},
+		{"token_url", auth.TokenUrl != nil, HttpAuthTypeOAuth2, true},
+		{"scopes", auth.Scopes != nil, HttpAuthTypeOAuth2, false},
+		{"audience", auth.Audience != nil, HttpAuthTypeOAuth2, false},
+	} {
+		switch {
+		case attr.authType == auth.Type && attr.required && !attr.set:
+			diags = append(diags, &hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  fmt.Sprintf("%s is required for auth type '%s'", attr.name, auth.Type),
+				Subject:  c.DeclRange.HclRangePointer(),
+			})
+		case attr.authType != auth.Type && attr.set:
+			diags = append(diags, &hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  fmt.Sprintf("%s cannot be used with auth type '%s'", attr.name, auth.Type),
+				Subject:  c.DeclRange.HclRangePointer(),
+			})
+		}
+	}
+
+	if auth.In != nil && *auth.In != HttpApiKeyInHeader && *auth.In != HttpApiKeyInQuery {
+		diags = append(diags, &hcl.Diagnostic{
+			Severity: hcl.DiagError,
+			Summary:  fmt.Sprintf("in must be '%s' or '%s'", HttpApiKeyInHeader, HttpApiKeyInQuery),
+			Subject:  c.DeclRange.HclRangePointer(),
+		})
+	}
+
+	return diags
+}
+
+func (c *HttpConnection) CtyValue() (cty.Value, error) {
+	return ctyValueForConnection(c)
+}
+
+func (c *HttpConnection) GetEnv() map[string]cty.Value {
+	return map[string]cty.Value{}
+}
+
+// PrepareRequest resolves the request url against the base url and returns it, along with the request headers
+// with the connection headers and auth applied. Headers in requestHeaders take precedence over connection headers.
+// If the connection has a base url, auth is only applied to requests with the same scheme and host, so credentials
+// are not sent to other services, or in plaintext when the base url is https
+func (c *HttpConnection) PrepareRequest(requestUrl string, requestHeaders map[string]string) (string, map[string]string, error) {
+	u, err := url.Parse(requestUrl)
+	if err != nil {
+		return "", nil, perr.BadRequestWithMessage(fmt.Sprintf("invalid url %s: %s", requestUrl, err.Error()))
+	}
+
+	applyAuth := true
+	if c.BaseUrl != nil {
+		base, err := url.Parse(*c.BaseUrl)
+		if err != nil {
+			return "", nil, perr.BadRequestWithMessage(fmt.Sprintf("invalid base_url for %s: %s", c.Name(), err.Error()))
+		}
+		if !u.IsAbs() {
+			u = resolveHttpUrl(base, u)
+		}
+		applyAuth = strings.EqualFold(u.Scheme, base.Scheme) && strings.EqualFold(u.Host, base.Host)
+	} else if !u.IsAbs() {
+		return "", nil, perr.BadRequestWithMessage(fmt.Sprintf("url %s is relative but %s has no base_url", requestUrl, c.Name()))
+	}
+
+	headers := maps.Clone(c.Headers)
+	if headers == nil {
+		headers = make(map[string]string)
+	}
+
+	if applyAuth && c.Auth != nil {
+		if c.Auth.Type == HttpAuthTypeApiKey && c.Auth.apiKeyInQuery() {
+			query := u.Query()
+			query.Set(*c.Auth.KeyName, *c.Auth.ApiKey)
+			u.RawQuery = query.Encode()
+		} else if name, value, ok := c.authHeader(); ok {
+			headers[name] = value
+		}
+	}
+
+	// request headers override connection headers - header names are case insensitive
+	for name, value := range requestHeaders {
+		for existing := range headers {
+			if strings.EqualFold(existing, name) {
+				delete(headers, existing)
+			}
+		}
+		headers[name] = value
+	}
+
+	return u.String(), headers, nil
+}
+
+// resolveHttpUrl resolves the relative url against the base url, treating the base url path as a directory so
+// a base url of https://example.com/api/v1 and url users resolves to https://example.com/api/v1/users
+func resolveHttpUrl(base, rel *url.URL) *url.URL {
+	if rel.Path != "" && !strings.HasPrefix(rel.Path, "/") && !strings.HasSuffix(base.Path, "/") {
+		dirBase := *base
+		dirBase.Path += "/"
+		base = &dirBase
+	}
+	return base.ResolveReference(rel)
+}
+
+// authHeader returns the header the connection auth is sent in
+func (c *HttpConnection) authHeader() (string, string, bool) {
+	switch c.Auth.Type {
+	case HttpAuthTypeBearer:
+		return "Authorization", "Bearer " + *c.Auth.Token, true
+	case HttpAuthTypeBasic:
+		password := ""
+		if c.Auth.Password != nil {
+			password = *c.Auth.Password
+		}
+		return "Authorization", "Basic " + base64.StdEncoding.EncodeToString([]byte(*c.Auth.Username+":"+password)), true
+	case HttpAuthTypeApiKey:
+		return *c.Auth.KeyName, *c.Auth.ApiKey, true
+	case HttpAuthTypeOAuth2:
+		if c.AccessToken != nil {
+			return "Authorization", "Bearer " + *c.AccessToken, true
+		}
+	}
+	return "", "", false
+}
+
+// Test sends a GET request to the base url with the connection headers and auth
+func (c *HttpConnection) Test(ctx context.Context) *TestResult {
+	return runConnectionTest(ctx, func(ctx context.Context) (string, error) {
+		if !hasTestValue(c.BaseUrl) {
+			return "", missingAttributeError(c, "base_url")
+		}
+
+		requestUrl, headers, err := c.PrepareRequest(*c.BaseUrl, nil)
+		if err != nil {
+			return "", err
+		}
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, requestUrl, nil)
+		if err != nil {
+			return "", err
+		}
+		for k, v := range headers {
+			req.Header.Set(k, v)
+		}
+		_, err = testHttpEndpoint(req, nil)
+		return "", err
+	})
+}
+
+// oauth2TokenCache shares OAuth2 tokens between resolves of the same client credentials, so tokens are reused until
+// they expire rather than requested on every resolve
+var oauth2TokenCache = &httpOAuth2TokenCache{tokens: make(map[string]cachedOAuth2Token)}
+
+// oauth2TokenTimeout is the timeout of requests to the OAuth2 token endpoint
+const oauth2TokenTimeout = 30 * time.Second
+
+type httpOAuth2TokenCache struct {
+	mut    sync.Mutex
+	tokens map[string]cachedOAuth2Token
+}
+
+type cachedOAuth2Token struct {
+	token *oauth2.Token
+	// when the token is evicted - tokens with no expiry are kept for the default connection ttl
+	expires time.Time
+}
+
+// Token returns a token for the client credentials, requesting one from the token endpoint if there is no valid
+// cached token. Expired tokens are evicted, so the cache only holds tokens which may still be used
+func (t *httpOAuth2TokenCache) Token(ctx context.Context, auth *HttpConnectionAuth) (*oauth2.Token, error) {
+	config := &clientcredentials.Config{
+		ClientID:     *auth.ClientId,
+		ClientSecret: *auth.ClientSecret,
+		TokenURL:     *auth.TokenUrl,
+	}
+	if auth.Scopes != nil {
+		config.Scopes = *auth.Scopes
+	}
+	if auth.Audience != nil {
+		config.EndpointParams = url.Values{"audience": {*auth.Audience}}
+	}
+
+	// the secret is hashed so it is not held in the key
+	secretHash := sha256.Sum256([]byte(config.ClientSecret))
+	key := strings.Join([]string{config.TokenURL, config.ClientID, hex.EncodeToString(secretHash[:]), strings.Join(config.Scopes, " "), config.EndpointParams.Encode()}, "\n")
+
+	t.mut.Lock()
+	now := time.Now()
+	for k, cached := range t.tokens {
+		if !now.Before(cached.expires) {
+			delete(t.tokens, k)
+		}
+	}
+	cached, ok := t.tokens[key]
+	t.mut.Unlock()
+	if ok && cached.token.Valid() {
+		return cached.token, nil
+	}
+
+	// request the token with the resolve context, so a hung token endpoint does not hang the resolve
+	ctx = context.WithValue(ctx, oauth2.HTTPClient, &http.Client{Timeout: oauth2TokenTimeout})
+	token, err := config.Token(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	expires := token.Expiry
+	if expires.IsZero() {
+		expires = time.Now().Add(time.Duration(constants.DefaultConnectionTtl) * time.Second)
+	}
+	t.mut.Lock()
+	t.tokens[key] = cachedOAuth2Token{token: token, expires: expires}
+	t.mut.Unlock()
+
+	return token, nil
+}
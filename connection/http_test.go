@@ -0,0 +1,249 @@
+package connection
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/turbot/pipe-fittings/sanitize"
+	"github.com/turbot/pipe-fittings/utils"
+	"golang.org/x/oauth2"
+)
+
+func TestHttpConnectionEquals(t *testing.T) {
+	assert := assert.New(t)
+
+	var conn1 *HttpConnection
+	var conn2 *HttpConnection
+	assert.True(conn1.Equals(conn2), "Both connections should be nil and equal")
+
+	conn1 = &HttpConnection{
+		BaseUrl: utils.ToPointer("https://api.example.com"),
+		Headers: map[string]string{"X-Team": "platform"},
+		Auth: &HttpConnectionAuth{
+			Type:   HttpAuthTypeOAuth2,
+			Scopes: &[]string{"read", "write"},
+		},
+	}
+	assert.False(conn1.Equals(conn2), "Connections should not be equal when one is nil")
+
+	conn2 = &HttpConnection{
+		BaseUrl: utils.ToPointer("https://api.example.com"),
+		Headers: map[string]string{"X-Team": "platform"},
+		Auth: &HttpConnectionAuth{
+			Type:   HttpAuthTypeOAuth2,
+			Scopes: &[]string{"read", "write"},
+		},
+	}
+	assert.True(conn1.Equals(conn2), "Connections with the same config should be equal")
+
+	conn2.Headers = map[string]string{"X-Team": "security"}
+	assert.False(conn1.Equals(conn2), "Connections with different headers should not be equal")
+
+	conn2.Headers = map[string]string{"X-Team": "platform"}
+	conn2.Auth.Scopes = &[]string{"read"}
+	assert.False(conn1.Equals(conn2), "Connections with different scopes should not be equal")
+
+	conn2.Auth = nil
+	assert.False(conn1.Equals(conn2), "Connections with and without auth should not be equal")
+}
+
+func TestHttpConnectionValidate(t *testing.T) {
+	tests := []struct {
+		name  string
+		conn  *HttpConnection
+		diags []string
+	}{
+		{
+			name: "no auth",
+			conn: &HttpConnection{BaseUrl: utils.ToPointer("https://api.example.com")},
+		},
+		{
+			name:  "relative base url",
+			conn:  &HttpConnection{BaseUrl: utils.ToPointer("api.example.com")},
+			diags: []string{"base_url must be an absolute http or https url"},
+		},
+		{
+			name: "bearer",
+			conn: &HttpConnection{Auth: &HttpConnectionAuth{Type: HttpAuthTypeBearer, Token: utils.ToPointer("token")}},
+		},
+		{
+			name:  "bearer without token",
+			conn:  &HttpConnection{Auth: &HttpConnectionAuth{Type: HttpAuthTypeBearer, Username: utils.ToPointer("user")}},
+			diags: []string{"token is required for auth type 'bearer'", "username cannot be used with auth type 'bearer'"},
+		},
+		{
+			name: "api key in query",
+			conn: &HttpConnection{Auth: &HttpConnectionAuth{Type: HttpAuthTypeApiKey, ApiKey: utils.ToPointer("key"), KeyName: utils.ToPointer("api_key"), In: utils.ToPointer("query")}},
+		},
+		{
+			name:  "api key in body",
+			conn:  &HttpConnection{Auth: &HttpConnectionAuth{Type: HttpAuthTypeApiKey, ApiKey: utils.ToPointer("key"), KeyName: utils.ToPointer("api_key"), In: utils.ToPointer("body")}},
+			diags: []string{"in must be 'header' or 'query'"},
+		},
+		{
+			name:  "oauth2 without client secret",
+			conn:  &HttpConnection{Auth: &HttpConnectionAuth{Type: HttpAuthTypeOAuth2, ClientId: utils.ToPointer("id"), TokenUrl: utils.ToPointer("https://auth.example.com/token")}},
+			diags: []string{"client_secret is required for auth type 'oauth2'"},
+		},
+		{
+			name:  "unknown auth type",
+			conn:  &HttpConnection{Auth: &HttpConnectionAuth{Type: "digest"}},
+			diags: []string{"invalid auth type 'digest', must be one of: bearer, basic, api_key, oauth2"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			diags := tt.conn.Validate()
+			var summaries []string
+			for _, d := range diags {
+				summaries = append(summaries, d.Summary)
+			}
+			assert.Equal(t, tt.diags, summaries)
+		})
+	}
+}
+
+func TestHttpConnectionPrepareRequest(t *testing.T) {
+	assert := assert.New(t)
+
+	conn := &HttpConnection{
+		BaseUrl: utils.ToPointer("https://api.example.com/v1"),
+		Headers: map[string]string{"Accept": "application/json", "X-Team": "platform"},
+		Auth:    &HttpConnectionAuth{Type: HttpAuthTypeBasic, Username: utils.ToPointer("user"), Password: utils.ToPointer("pass")},
+	}
+
+	requestUrl, headers, err := conn.PrepareRequest("users/1", map[string]string{"x-team": "security"})
+	assert.Nil(err)
+	assert.Equal("https://api.example.com/v1/users/1", requestUrl)
+	assert.Equal(map[string]string{
+		"Accept":        "application/json",
+		"Authorization": "Basic dXNlcjpwYXNz",
+		"x-team":        "security",
+	}, headers)
+
+	// absolute paths replace the base url path
+	requestUrl, _, err = conn.PrepareRequest("/health", nil)
+	assert.Nil(err)
+	assert.Equal("https://api.example.com/health", requestUrl)
+
+	// auth is not sent to other hosts
+	requestUrl, headers, err = conn.PrepareRequest("https://other.example.com/users", nil)
+	assert.Nil(err)
+	assert.Equal("https://other.example.com/users", requestUrl)
+	assert.NotContains(headers, "Authorization")
+
+	// nor over http to the host of an https base url
+	requestUrl, headers, err = conn.PrepareRequest("http://api.example.com/v1/users", nil)
+	assert.Nil(err)
+	assert.Equal("http://api.example.com/v1/users", requestUrl)
+	assert.NotContains(headers, "Authorization")
+
+	// api keys can be sent in the query string
+	conn.Auth = &HttpConnectionAuth{Type: HttpAuthTypeApiKey, ApiKey: utils.ToPointer("secret"), KeyName: utils.ToPointer("key"), In: utils.ToPointer(HttpApiKeyInQuery)}
+	requestUrl, _, err = conn.PrepareRequest("users?active=true", nil)
+	assert.Nil(err)
+	assert.Equal("https://api.example.com/v1/users?active=true&key=secret", requestUrl)
+
+	// relative urls require a base url
+	conn.BaseUrl = nil
+	_, _, err = conn.PrepareRequest("users", nil)
+	assert.NotNil(err)
+}
+
+func TestHttpConnectionOAuth2(t *testing.T) {
+	assert := assert.New(t)
+
+	var tokenRequests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil || r.Form.Get("grant_type") != "client_credentials" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		clientId, clientSecret, ok := r.BasicAuth()
+		if !ok || clientId != "client" || clientSecret != "client-secret-from-env" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		tokenRequests.Add(1)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"access_token":"oauth2-access-token","token_type":"bearer","expires_in":600}`))
+	}))
+	defer server.Close()
+
+	t.Setenv("PIPE_FITTINGS_TEST_CLIENT_SECRET", "client-secret-from-env")
+	clientSecretRef, err := NewSecretReferenceValue("env://PIPE_FITTINGS_TEST_CLIENT_SECRET")
+	assert.Nil(err)
+
+	conn := NewHttpConnection("default", hcl.Range{}).(*HttpConnection)
+	conn.BaseUrl = utils.ToPointer("https://api.example.com")
+	conn.Auth = &HttpConnectionAuth{
+		Type:         HttpAuthTypeOAuth2,
+		ClientId:     utils.ToPointer("client"),
+		ClientSecret: &clientSecretRef,
+		TokenUrl:     utils.ToPointer(server.URL),
+		Scopes:       &[]string{"read"},
+	}
+
+	resolved, err := conn.Resolve(context.TODO())
+	assert.Nil(err)
+	resolvedConn := resolved.(*HttpConnection)
+	assert.Equal("oauth2-access-token", *resolvedConn.AccessToken)
+	// the ttl is limited by the expiry of the token
+	assert.InDelta(540, resolvedConn.GetTtl(), 2)
+	assert.Equal(clientSecretRef, *conn.Auth.ClientSecret, "the original connection should keep the secret reference")
+
+	_, headers, err := resolvedConn.PrepareRequest("users", nil)
+	assert.Nil(err)
+	assert.Equal("Bearer oauth2-access-token", headers["Authorization"])
+	assert.Equal("token is "+sanitize.RedactedStr, sanitize.Instance.SanitizeString("token is oauth2-access-token"))
+
+	// the token is reused until it expires
+	_, err = conn.Resolve(context.TODO())
+	assert.Nil(err)
+	assert.Equal(int32(1), tokenRequests.Load())
+
+	conn.Auth.ClientId = utils.ToPointer("unknown")
+	_, err = conn.Resolve(context.TODO())
+	assert.NotNil(err)
+}
+
+func TestHttpOAuth2TokenCache(t *testing.T) {
+	assert := assert.New(t)
+
+	// a hung token endpoint does not hang the resolve
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+	defer server.Close()
+	defer close(release)
+
+	cache := &httpOAuth2TokenCache{tokens: make(map[string]cachedOAuth2Token)}
+	auth := &HttpConnectionAuth{
+		Type:         HttpAuthTypeOAuth2,
+		ClientId:     utils.ToPointer("client"),
+		ClientSecret: utils.ToPointer("secret"),
+		TokenUrl:     utils.ToPointer(server.URL),
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	_, err := cache.Token(ctx, auth)
+	assert.NotNil(err)
+	assert.Less(time.Since(start), 5*time.Second)
+
+	// expired tokens are evicted
+	cache.tokens["expired"] = cachedOAuth2Token{token: &oauth2.Token{AccessToken: "expired"}, expires: time.Now().Add(-time.Minute)}
+	_, _ = cache.Token(ctx, auth)
+	assert.NotContains(cache.tokens, "expired")
+}
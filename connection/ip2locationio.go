@@ -15,7 +15,7 @@ const IP2LocationIOConnectionType = "ip2locationio"
 type IP2LocationIOConnection struct {
 	ConnectionImpl
 
-	APIKey *string `json:"api_key,omitempty" cty:"api_key" hcl:"api_key,optional"`
+	APIKey *string `json:"api_key,omitempty" cty:"api_key" hcl:"api_key,optional" sensitive:"true"`
 }
 
 func NewIP2LocationIOConnection(shortName string, declRange hcl.Range) PipelingConnection {
@@ -34,6 +34,12 @@ func (c *IP2LocationIOConnection) Resolve(ctx context.Context) (PipelingConnecti
 		return c.Pipes.Resolve(ctx, &IP2LocationIOConnection{ConnectionImpl: c.ConnectionImpl})
 	}
 
+	// replace any secret references with the secrets they refer to
+	c, err := resolveSecrets(ctx, c)
+	if err != nil {
+		return nil, err
+	}
+
 	if c.APIKey == nil {
 		ip2locationAPIKeyEnvVar := os.Getenv("IP2LOCATIONIO_API_KEY")
 
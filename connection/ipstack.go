@@ -15,7 +15,7 @@ const IPstackConnectionType = "ipstack"
 type IPstackConnection struct {
 	ConnectionImpl
 
-	AccessKey *string `json:"access_key,omitempty" cty:"access_key" hcl:"access_key,optional"`
+	AccessKey *string `json:"access_key,omitempty" cty:"access_key" hcl:"access_key,optional" sensitive:"true"`
 }
 
 func NewIPstackConnection(shortName string, declRange hcl.Range) PipelingConnection {
@@ -33,6 +33,12 @@ func (c *IPstackConnection) Resolve(ctx context.Context) (PipelingConnection, er
 		return c.Pipes.Resolve(ctx, &IPstackConnection{ConnectionImpl: c.ConnectionImpl})
 	}
 
+	// replace any secret references with the secrets they refer to
+	c, err := resolveSecrets(ctx, c)
+	if err != nil {
+		return nil, err
+	}
+
 	if c.AccessKey == nil {
 		// The order of precedence for the IPstack access key environment variable
 		// 1. IPSTACK_ACCESS_KEY
@@ -2,7 +2,9 @@ package connection
 
 import (
 	"context"
+	"encoding/base64"
 	"os"
+	"strings"
 
 	"github.com/hashicorp/hcl/v2"
 	"github.com/turbot/go-kit/helpers"
@@ -15,7 +17,7 @@ const JiraConnectionType = "jira"
 type JiraConnection struct {
 	ConnectionImpl
 
-	APIToken *string `json:"api_token,omitempty" cty:"api_token" hcl:"api_token,optional"`
+	APIToken *string `json:"api_token,omitempty" cty:"api_token" hcl:"api_token,optional" sensitive:"true"`
 	BaseURL  *string `json:"base_url,omitempty" cty:"base_url" hcl:"base_url,optional"`
 	Username *string `json:"username,omitempty" cty:"username" hcl:"username,optional"`
 }
@@ -35,6 +37,12 @@ func (c *JiraConnection) Resolve(ctx context.Context) (PipelingConnection, error
 		return c.Pipes.Resolve(ctx, &JiraConnection{ConnectionImpl: c.ConnectionImpl})
 	}
 
+	// replace any secret references with the secrets they refer to
+	c, err := resolveSecrets(ctx, c)
+	if err != nil {
+		return nil, err
+	}
+
 	if c.APIToken == nil && c.BaseURL == nil && c.Username == nil {
 		// The order of precedence for the Jira API token environment variable
 		// 1. JIRA_API_TOKEN
@@ -112,3 +120,23 @@ func (c *JiraConnection) CtyValue() (cty.Value, error) {
 func (c *JiraConnection) GetEnv() map[string]cty.Value {
 	return nil
 }
+
+// Test calls the Jira /myself endpoint, returning the email address of the user as the identity
+func (c *JiraConnection) Test(ctx context.Context) *TestResult {
+	return runConnectionTest(ctx, func(ctx context.Context) (string, error) {
+		if !hasTestValue(c.BaseURL) {
+			return "", missingAttributeError(c, "base_url")
+		}
+		if !hasTestValue(c.Username) || !hasTestValue(c.APIToken) {
+			return "", missingAttributeError(c, "username and api_token")
+		}
+
+		auth := base64.StdEncoding.EncodeToString([]byte(*c.Username + ":" + *c.APIToken))
+		return testGetJson(ctx, strings.TrimSuffix(*c.BaseURL, "/")+"/rest/api/2/myself", map[string]string{"Authorization": "Basic " + auth},
+			func(user struct {
+				EmailAddress string `json:"emailAddress"`
+			}) string {
+				return user.EmailAddress
+			})
+	})
+}
@@ -14,7 +14,7 @@ const MastodonConnectionType = "mastodon"
 type MastodonConnection struct {
 	ConnectionImpl
 
-	AccessToken *string `json:"access_token,omitempty" cty:"access_token" hcl:"access_token,optional"`
+	AccessToken *string `json:"access_token,omitempty" cty:"access_token" hcl:"access_token,optional" sensitive:"true"`
 	Server      *string `json:"server,omitempty" cty:"server" hcl:"server,optional"`
 }
 
@@ -32,6 +32,12 @@ func (c *MastodonConnection) Resolve(ctx context.Context) (PipelingConnection, e
 	if c.Pipes != nil {
 		return c.Pipes.Resolve(ctx, &MastodonConnection{ConnectionImpl: c.ConnectionImpl})
 	}
+
+	// replace any secret references with the secrets they refer to
+	c, err := resolveSecrets(ctx, c)
+	if err != nil {
+		return nil, err
+	}
 	return c, nil
 }
 
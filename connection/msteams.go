@@ -15,7 +15,7 @@ const MicrosoftTeamsConnectionType = "teams"
 type MicrosoftTeamsConnection struct {
 	ConnectionImpl
 
-	AccessToken *string `json:"access_token,omitempty" cty:"access_token" hcl:"access_token,optional"`
+	AccessToken *string `json:"access_token,omitempty" cty:"access_token" hcl:"access_token,optional" sensitive:"true"`
 }
 
 func NewMicrosoftTeamsConnection(shortName string, declRange hcl.Range) PipelingConnection {
@@ -33,6 +33,12 @@ func (c *MicrosoftTeamsConnection) Resolve(ctx context.Context) (PipelingConnect
 		return c.Pipes.Resolve(ctx, &MicrosoftTeamsConnection{ConnectionImpl: c.ConnectionImpl})
 	}
 
+	// replace any secret references with the secrets they refer to
+	c, err := resolveSecrets(ctx, c)
+	if err != nil {
+		return nil, err
+	}
+
 	if c.AccessToken == nil {
 		msTeamsAccessTokenEnvVar := os.Getenv("TEAMS_ACCESS_TOKEN")
 
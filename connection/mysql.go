@@ -3,12 +3,13 @@ package connection
 import (
 	"context"
 	"fmt"
+	"strconv"
+
 	"github.com/hashicorp/hcl/v2"
 	"github.com/turbot/go-kit/helpers"
 	typehelpers "github.com/turbot/go-kit/types"
 	"github.com/turbot/pipe-fittings/utils"
 	"github.com/zclconf/go-cty/cty"
-	"strconv"
 )
 
 const MysqlConnectionType = "mysql"
@@ -26,7 +27,7 @@ type MysqlConnection struct {
 	UserName         *string `json:"username,omitempty" cty:"username" hcl:"username,optional"`
 	Host             *string `json:"host,omitempty" cty:"host" hcl:"host,optional"`
 	Port             *int    `json:"port,omitempty" cty:"port" hcl:"port,optional"`
-	Password         *string `json:"password,omitempty" cty:"password" hcl:"password,optional"`
+	Password         *string `json:"password,omitempty" cty:"password" hcl:"password,optional" sensitive:"true"`
 	ConnectionString *string `json:"connection_string,omitempty" cty:"connection_string"`
 }
 
@@ -45,6 +46,12 @@ func (c *MysqlConnection) Resolve(ctx context.Context) (PipelingConnection, erro
 		return c.Pipes.Resolve(ctx, &AwsConnection{ConnectionImpl: c.ConnectionImpl})
 	}
 
+	// replace any secret references with the secrets they refer to
+	c, err := resolveSecrets(ctx, c)
+	if err != nil {
+		return nil, err
+	}
+
 	// we must have a connection string or validaiton would have failed
 	return c, nil
 }
@@ -169,3 +176,10 @@ func (c *MysqlConnection) getUserName() string {
 	}
 	return defaultMysqlUser
 }
+
+// Test runs SELECT 1 against the database
+func (c *MysqlConnection) Test(ctx context.Context) *TestResult {
+	return runConnectionTest(ctx, func(ctx context.Context) (string, error) {
+		return testDatabase(ctx, c.GetConnectionString())
+	})
+}
@@ -3,6 +3,7 @@ package connection
 import (
 	"context"
 	"os"
+	"strings"
 
 	"github.com/hashicorp/hcl/v2"
 	"github.com/turbot/go-kit/helpers"
@@ -16,7 +17,7 @@ type OktaConnection struct {
 	ConnectionImpl
 
 	Domain *string `json:"domain,omitempty" cty:"domain" hcl:"domain,optional"`
-	Token  *string `json:"token,omitempty" cty:"token" hcl:"token,optional"`
+	Token  *string `json:"token,omitempty" cty:"token" hcl:"token,optional" sensitive:"true"`
 }
 
 func NewOktaConnection(shortName string, declRange hcl.Range) PipelingConnection {
@@ -34,6 +35,12 @@ func (c *OktaConnection) Resolve(ctx context.Context) (PipelingConnection, error
 		return c.Pipes.Resolve(ctx, &OktaConnection{ConnectionImpl: c.ConnectionImpl})
 	}
 
+	// replace any secret references with the secrets they refer to
+	c, err := resolveSecrets(ctx, c)
+	if err != nil {
+		return nil, err
+	}
+
 	if c.Token == nil && c.Domain == nil {
 		apiTokenEnvVar := os.Getenv("OKTA_CLIENT_TOKEN")
 		domainEnvVar := os.Getenv("OKTA_ORGURL")
@@ -107,3 +114,24 @@ func (c *OktaConnection) GetEnv() map[string]cty.Value {
 	}
 	return env
 }
+
+// Test calls the Okta /users/me endpoint, returning the login of the user as the identity
+func (c *OktaConnection) Test(ctx context.Context) *TestResult {
+	return runConnectionTest(ctx, func(ctx context.Context) (string, error) {
+		if !hasTestValue(c.Domain) {
+			return "", missingAttributeError(c, "domain")
+		}
+		if !hasTestValue(c.Token) {
+			return "", missingAttributeError(c, "token")
+		}
+
+		return testGetJson(ctx, strings.TrimSuffix(*c.Domain, "/")+"/api/v1/users/me", map[string]string{"Authorization": "SSWS " + *c.Token},
+			func(user struct {
+				Profile struct {
+					Login string `json:"login"`
+				} `json:"profile"`
+			}) string {
+				return user.Profile.Login
+			})
+	})
+}
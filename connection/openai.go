@@ -15,7 +15,7 @@ const OpenAIConnectionType = "openai"
 type OpenAIConnection struct {
 	ConnectionImpl
 
-	APIKey *string `json:"api_key,omitempty" cty:"api_key" hcl:"api_key,optional"`
+	APIKey *string `json:"api_key,omitempty" cty:"api_key" hcl:"api_key,optional" sensitive:"true"`
 }
 
 func NewOpenAIConnection(shortName string, declRange hcl.Range) PipelingConnection {
@@ -33,6 +33,12 @@ func (c *OpenAIConnection) Resolve(ctx context.Context) (PipelingConnection, err
 		return c.Pipes.Resolve(ctx, &OpenAIConnection{ConnectionImpl: c.ConnectionImpl})
 	}
 
+	// replace any secret references with the secrets they refer to
+	c, err := resolveSecrets(ctx, c)
+	if err != nil {
+		return nil, err
+	}
+
 	if c.APIKey == nil {
 		apiKeyEnvVar := os.Getenv("OPENAI_API_KEY")
 
@@ -95,3 +101,16 @@ func (c *OpenAIConnection) GetEnv() map[string]cty.Value {
 	}
 	return env
 }
+
+var openAIApiUrl = "https://api.openai.com/v1"
+
+// Test lists the OpenAI models available to the api key
+func (c *OpenAIConnection) Test(ctx context.Context) *TestResult {
+	return runConnectionTest(ctx, func(ctx context.Context) (string, error) {
+		if !hasTestValue(c.APIKey) {
+			return "", missingAttributeError(c, "api_key")
+		}
+
+		return testGetJson[any](ctx, openAIApiUrl+"/models", map[string]string{"Authorization": "Bearer " + *c.APIKey}, nil)
+	})
+}
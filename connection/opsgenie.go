@@ -15,8 +15,8 @@ const OpsgenieConnectionType = "opsgenie"
 type OpsgenieConnection struct {
 	ConnectionImpl
 
-	AlertAPIKey    *string `json:"alert_api_key,omitempty" cty:"alert_api_key" hcl:"alert_api_key,optional"`
-	IncidentAPIKey *string `json:"incident_api_key,omitempty" cty:"incident_api_key" hcl:"incident_api_key,optional"`
+	AlertAPIKey    *string `json:"alert_api_key,omitempty" cty:"alert_api_key" hcl:"alert_api_key,optional" sensitive:"true"`
+	IncidentAPIKey *string `json:"incident_api_key,omitempty" cty:"incident_api_key" hcl:"incident_api_key,optional" sensitive:"true"`
 }
 
 func NewOpsgenieConnection(shortName string, declRange hcl.Range) PipelingConnection {
@@ -34,6 +34,12 @@ func (c *OpsgenieConnection) Resolve(ctx context.Context) (PipelingConnection, e
 		return c.Pipes.Resolve(ctx, &OpsgenieConnection{ConnectionImpl: c.ConnectionImpl})
 	}
 
+	// replace any secret references with the secrets they refer to
+	c, err := resolveSecrets(ctx, c)
+	if err != nil {
+		return nil, err
+	}
+
 	if c.AlertAPIKey == nil && c.IncidentAPIKey == nil {
 		alertAPIKeyEnvVar := os.Getenv("OPSGENIE_ALERT_API_KEY")
 		incidentAPIKeyEnvVar := os.Getenv("OPSGENIE_INCIDENT_API_KEY")
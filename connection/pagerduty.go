@@ -15,7 +15,7 @@ const PagerDutyConnectionType = "pagerduty"
 type PagerDutyConnection struct {
 	ConnectionImpl
 
-	Token *string `json:"token,omitempty" cty:"token" hcl:"token,optional"`
+	Token *string `json:"token,omitempty" cty:"token" hcl:"token,optional" sensitive:"true"`
 }
 
 func NewPagerDutyConnection(shortName string, declRange hcl.Range) PipelingConnection {
@@ -33,6 +33,12 @@ func (c *PagerDutyConnection) Resolve(ctx context.Context) (PipelingConnection,
 		return c.Pipes.Resolve(ctx, &PagerDutyConnection{ConnectionImpl: c.ConnectionImpl})
 	}
 
+	// replace any secret references with the secrets they refer to
+	c, err := resolveSecrets(ctx, c)
+	if err != nil {
+		return nil, err
+	}
+
 	if c.Token == nil {
 		pagerDutyTokenEnvVar := os.Getenv("PAGERDUTY_TOKEN")
 
@@ -91,3 +97,16 @@ func (c *PagerDutyConnection) CtyValue() (cty.Value, error) {
 func (c *PagerDutyConnection) GetEnv() map[string]cty.Value {
 	return nil
 }
+
+var pagerDutyApiUrl = "https://api.pagerduty.com"
+
+// Test lists the abilities of the PagerDuty account
+func (c *PagerDutyConnection) Test(ctx context.Context) *TestResult {
+	return runConnectionTest(ctx, func(ctx context.Context) (string, error) {
+		if !hasTestValue(c.Token) {
+			return "", missingAttributeError(c, "token")
+		}
+
+		return testGetJson[any](ctx, pagerDutyApiUrl+"/abilities", map[string]string{"Authorization": "Token token=" + *c.Token}, nil)
+	})
+}
@@ -38,6 +38,13 @@ type SearchPathProvider interface {
 	GetSearchPathPrefix() []string
 }
 
+// SourceConnectionConsumer is implemented by all connections which derive their credentials from another connection
+type SourceConnectionConsumer interface {
+	GetSourceConnectionName() *string
+	SetSourceConnection(PipelingConnection) error
+	GetSourceConnection() PipelingConnection
+}
+
 func ConnectionTypeMeetsRequiredType(requiredType, actualResourceType, actualType string) bool {
 	// handle type connection and connection.<subtype>
 	requiredTypeParts := strings.Split(requiredType, ".")
@@ -15,7 +15,7 @@ const PipesConnectionType = "pipes"
 type PipesConnection struct {
 	ConnectionImpl
 
-	Token *string `json:"token,omitempty" cty:"token" hcl:"token,optional"`
+	Token *string `json:"token,omitempty" cty:"token" hcl:"token,optional" sensitive:"true"`
 }
 
 func NewPipesConnection(shortName string, declRange hcl.Range) PipelingConnection {
@@ -33,6 +33,12 @@ func (c *PipesConnection) Resolve(ctx context.Context) (PipelingConnection, erro
 		return c.Pipes.Resolve(ctx, &PipesConnection{ConnectionImpl: c.ConnectionImpl})
 	}
 
+	// replace any secret references with the secrets they refer to
+	c, err := resolveSecrets(ctx, c)
+	if err != nil {
+		return nil, err
+	}
+
 	if c.Token == nil {
 		pipesTokenEnvVar := os.Getenv("PIPES_TOKEN")
 
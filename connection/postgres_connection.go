@@ -28,7 +28,7 @@ type PostgresConnection struct {
 	UserName         *string   `json:"username,omitempty" cty:"username" hcl:"username,optional"`
 	Host             *string   `json:"host,omitempty" cty:"host" hcl:"host,optional"`
 	Port             *int      `json:"port,omitempty" cty:"port" hcl:"port,optional"`
-	Password         *string   `json:"password,omitempty" cty:"password" hcl:"password,optional"`
+	Password         *string   `json:"password,omitempty" cty:"password" hcl:"password,optional" sensitive:"true"`
 	SearchPath       *[]string `json:"search_path,omitempty" cty:"search_path" hcl:"search_path,optional"`
 	SearchPathPrefix *[]string `json:"search_path_prefix,omitempty" cty:"search_path_prefix" hcl:"search_path_prefix,optional"`
 	SslMode          *string   `json:"sslmode,omitempty" cty:"sslmode" hcl:"sslmode,optional"`
@@ -50,6 +50,12 @@ func (c *PostgresConnection) Resolve(ctx context.Context) (PipelingConnection, e
 		return c.Pipes.Resolve(ctx, &PostgresConnection{ConnectionImpl: c.ConnectionImpl})
 	}
 
+	// replace any secret references with the secrets they refer to
+	c, err := resolveSecrets(ctx, c)
+	if err != nil {
+		return nil, err
+	}
+
 	// if pipes is nil, we must have a connection string, so there is nothing to so
 	return c, nil
 }
@@ -265,3 +271,10 @@ func (c *PostgresConnection) getUserName() string {
 	}
 	return defaultPostgresUser
 }
+
+// Test runs SELECT 1 against the database
+func (c *PostgresConnection) Test(ctx context.Context) *TestResult {
+	return runConnectionTest(ctx, func(ctx context.Context) (string, error) {
+		return testDatabase(ctx, c.GetConnectionString())
+	})
+}
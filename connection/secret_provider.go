@@ -0,0 +1,303 @@
+package connection
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/turbot/pipe-fittings/constants"
+	"github.com/turbot/pipe-fittings/perr"
+	"github.com/turbot/pipe-fittings/sanitize"
+)
+
+// SecretReferencePrefix marks a connection attribute value as a reference to a secret, rather than the secret itself.
+// Values with this prefix are produced by the secret() HCL function and replaced with the secret when the
+// connection is resolved
+const SecretReferencePrefix = "secret::"
+
+// SecretReference identifies a secret held by a SecretProvider, e.g. vault://kv/path#field
+type SecretReference struct {
+	// Scheme selects the provider, e.g. vault or aws-sm
+	Scheme string
+	// Path identifies the secret within the provider
+	Path string
+	// Field optionally selects a single key of a structured secret
+	Field string
+}
+
+// ParseSecretReference parses a reference of the form <scheme>://<path>[#<field>]
+func ParseSecretReference(ref string) (*SecretReference, error) {
+	scheme, rest, ok := strings.Cut(ref, "://")
+	if !ok || scheme == "" {
+		return nil, perr.BadRequestWithMessage(fmt.Sprintf("invalid secret reference %s, expected <scheme>://<path>[#<field>]", ref))
+	}
+
+	path, field, _ := strings.Cut(rest, "#")
+	if path == "" {
+		return nil, perr.BadRequestWithMessage(fmt.Sprintf("invalid secret reference %s, no path specified", ref))
+	}
+
+	return &SecretReference{
+		Scheme: scheme,
+		Path:   path,
+		Field:  field,
+	}, nil
+}
+
+func (r *SecretReference) String() string {
+	if r.Field == "" {
+		return r.Scheme + "://" + r.Path
+	}
+	return r.Scheme + "://" + r.Path + "#" + r.Field
+}
+
+// NewSecretReferenceValue validates the reference and returns the value to store in place of the secret
+func NewSecretReferenceValue(ref string) (string, error) {
+	parsed, err := ParseSecretReference(ref)
+	if err != nil {
+		return "", err
+	}
+	return SecretReferencePrefix + parsed.String(), nil
+}
+
+// IsSecretReferenceValue returns whether the value is a reference to a secret which has not been resolved
+func IsSecretReferenceValue(value string) bool {
+	return strings.HasPrefix(value, SecretReferencePrefix)
+}
+
+// SecretProvider retrieves secrets from a secret manager
+type SecretProvider interface {
+	GetSecret(ctx context.Context, ref *SecretReference) (string, error)
+}
+
+type cachedSecret struct {
+	value   string
+	expires time.Time
+}
+
+// SecretProviderRegistry maps reference schemes to the provider which resolves them, and caches resolved secrets
+type SecretProviderRegistry struct {
+	mut       sync.RWMutex
+	providers map[string]SecretProvider
+	cache     map[string]cachedSecret
+}
+
+func NewSecretProviderRegistry() *SecretProviderRegistry {
+	return &SecretProviderRegistry{
+		providers: make(map[string]SecretProvider),
+		cache:     make(map[string]cachedSecret),
+	}
+}
+
+// DefaultSecretProviders is the registry used to resolve connection secrets. The Vault and AWS Secrets Manager
+// providers use the same environment based defaults as the vault and aws connections. They can be replaced with
+// providers bootstrapped from a configured connection using Register.
+var DefaultSecretProviders = newDefaultSecretProviderRegistry()
+
+func newDefaultSecretProviderRegistry() *SecretProviderRegistry {
+	r := NewSecretProviderRegistry()
+	r.Register(EnvSecretScheme, &EnvSecretProvider{})
+	r.Register(FileSecretScheme, &FileSecretProvider{})
+	r.Register(OnePasswordSecretScheme, &OnePasswordSecretProvider{})
+	r.Register(VaultSecretScheme, NewVaultSecretProvider(nil))
+	r.Register(AwsSecretsManagerSecretScheme, NewAwsSecretsManagerProvider(nil))
+	return r
+}
+
+// Register sets the provider for a scheme, replacing any existing provider and clearing its cached secrets
+func (r *SecretProviderRegistry) Register(scheme string, provider SecretProvider) {
+	r.mut.Lock()
+	defer r.mut.Unlock()
+
+	r.providers[scheme] = provider
+	for key := range r.cache {
+		if strings.HasPrefix(key, scheme+"://") {
+			delete(r.cache, key)
+		}
+	}
+}
+
+// ClearCache removes all cached secrets
+func (r *SecretProviderRegistry) ClearCache() {
+	r.mut.Lock()
+	defer r.mut.Unlock()
+
+	r.cache = make(map[string]cachedSecret)
+}
+
+// Resolve returns the secret for the given reference, using the cached value if it was retrieved less than ttl
+// seconds ago. A negative ttl uses the default connection ttl, a ttl of zero disables caching
+func (r *SecretProviderRegistry) Resolve(ctx context.Context, ref string, ttl int) (string, error) {
+	parsed, err := ParseSecretReference(ref)
+	if err != nil {
+		return "", err
+	}
+	key := parsed.String()
+
+	r.mut.RLock()
+	provider, ok := r.providers[parsed.Scheme]
+	cached, isCached := r.cache[key]
+	r.mut.RUnlock()
+
+	if !ok {
+		return "", perr.BadRequestWithMessage(fmt.Sprintf("no secret provider registered for scheme %s", parsed.Scheme))
+	}
+
+	if isCached && time.Now().Before(cached.expires) {
+		// register the value again, so it is redacted even if the value known for the reference has changed since
+		sanitize.Instance.SetSecretValue(key, cached.value)
+		return cached.value, nil
+	}
+
+	value, err := provider.GetSecret(ctx, parsed)
+	if err != nil {
+		return "", err
+	}
+
+	// make sure the secret does not leak into logs or output - keyed by the reference, so a rotated secret replaces
+	// the previous value
+	sanitize.Instance.SetSecretValue(key, value)
+
+	if ttl < 0 {
+		ttl = constants.DefaultConnectionTtl
+	}
+	if ttl > 0 {
+		r.mut.Lock()
+		r.cache[key] = cachedSecret{value: value, expires: time.Now().Add(time.Duration(ttl) * time.Second)}
+		r.mut.Unlock()
+	}
+
+	return value, nil
+}
+
+// resolveSecrets returns a copy of the connection with every secret reference replaced by its secret. If the
+// connection has no secret references it is returned unchanged. Secret references in nested blocks are also resolved
+func resolveSecrets[T PipelingConnection](ctx context.Context, c T) (T, error) {
+	src := reflect.ValueOf(c)
+	if src.Kind() != reflect.Pointer || src.IsNil() || src.Elem().Kind() != reflect.Struct {
+		return c, nil
+	}
+
+	res, err := resolveStructSecrets(ctx, c, src.Elem())
+	if err != nil {
+		return c, err
+	}
+	if !res.IsValid() {
+		return c, nil
+	}
+
+	// don't modify the existing connection, resolve to a new one
+	resPtr := reflect.New(res.Type())
+	resPtr.Elem().Set(res)
+	return resPtr.Interface().(T), nil
+}
+
+// resolveStructSecrets returns a copy of the struct with its secret references resolved, or an invalid value if the
+// struct has no secret references
+func resolveStructSecrets(ctx context.Context, c PipelingConnection, elem reflect.Value) (reflect.Value, error) {
+	var res reflect.Value
+	for i := 0; i < elem.NumField(); i++ {
+		field := elem.Field(i)
+		if !field.CanSet() {
+			continue
+		}
+
+		// nested blocks are decoded into struct pointers
+		if field.Kind() == reflect.Pointer && !field.IsNil() && field.Elem().Kind() == reflect.Struct {
+			nested, err := resolveStructSecrets(ctx, c, field.Elem())
+			if err != nil {
+				return res, err
+			}
+			if nested.IsValid() {
+				if !res.IsValid() {
+					res = reflect.New(elem.Type()).Elem()
+					res.Set(elem)
+				}
+				nestedPtr := reflect.New(nested.Type())
+				nestedPtr.Elem().Set(nested)
+				res.Field(i).Set(nestedPtr)
+			}
+			continue
+		}
+
+		// map attributes, e.g. headers, may hold secret references in their values
+		if field.Kind() == reflect.Map && !field.IsNil() && field.Type().Key().Kind() == reflect.String && field.Type().Elem().Kind() == reflect.String {
+			resolved, err := resolveMapSecrets(ctx, c, elem.Type().Field(i).Name, field)
+			if err != nil {
+				return res, err
+			}
+			if resolved.IsValid() {
+				if !res.IsValid() {
+					res = reflect.New(elem.Type()).Elem()
+					res.Set(elem)
+				}
+				res.Field(i).Set(resolved)
+			}
+			continue
+		}
+
+		var ref string
+		switch {
+		case field.Kind() == reflect.String:
+			ref = field.String()
+		case field.Kind() == reflect.Pointer && !field.IsNil() && field.Elem().Kind() == reflect.String:
+			ref = field.Elem().String()
+		default:
+			continue
+		}
+
+		if !IsSecretReferenceValue(ref) {
+			continue
+		}
+
+		value, err := DefaultSecretProviders.Resolve(ctx, strings.TrimPrefix(ref, SecretReferencePrefix), c.GetTtl())
+		if err != nil {
+			return res, perr.BadRequestWithMessage(fmt.Sprintf("unable to resolve secret for %s attribute %s: %s", c.Name(), elem.Type().Field(i).Name, err.Error()))
+		}
+
+		if !res.IsValid() {
+			res = reflect.New(elem.Type()).Elem()
+			res.Set(elem)
+		}
+
+		target := res.Field(i)
+		if target.Kind() == reflect.String {
+			target.SetString(value)
+		} else {
+			target.Set(reflect.ValueOf(&value))
+		}
+	}
+
+	return res, nil
+}
+
+// resolveMapSecrets returns a copy of the map with the secret references in its values resolved, or an invalid
+// value if the map has no secret references
+func resolveMapSecrets(ctx context.Context, c PipelingConnection, attribute string, m reflect.Value) (reflect.Value, error) {
+	var res reflect.Value
+	iter := m.MapRange()
+	for iter.Next() {
+		ref := iter.Value().String()
+		if !IsSecretReferenceValue(ref) {
+			continue
+		}
+
+		value, err := DefaultSecretProviders.Resolve(ctx, strings.TrimPrefix(ref, SecretReferencePrefix), c.GetTtl())
+		if err != nil {
+			return res, perr.BadRequestWithMessage(fmt.Sprintf("unable to resolve secret for %s attribute %s[%s]: %s", c.Name(), attribute, iter.Key().String(), err.Error()))
+		}
+
+		// don't modify the existing map, resolve to a copy
+		if !res.IsValid() {
+			res = reflect.MakeMapWithSize(m.Type(), m.Len())
+			for _, k := range m.MapKeys() {
+				res.SetMapIndex(k, m.MapIndex(k))
+			}
+		}
+		res.SetMapIndex(iter.Key(), reflect.ValueOf(value).Convert(m.Type().Elem()))
+	}
+	return res, nil
+}
@@ -0,0 +1,108 @@
+package connection
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/service/secretsmanager"
+	"github.com/aws/aws-sdk-go-v2/service/secretsmanager/types"
+	"github.com/turbot/pipe-fittings/perr"
+)
+
+const (
+	AwsSecretsManagerSecretScheme = "aws-sm"
+	defaultAwsSecretsRegion       = "us-east-1"
+)
+
+// AwsSecretsManagerProvider resolves references of the form aws-sm://<name or arn>#<key> from AWS Secrets Manager.
+// If a key is specified the secret string is parsed as a JSON object
+type AwsSecretsManagerProvider struct {
+	connection *AwsConnection
+	// Region is the region used when the secret is not referenced by ARN - if it is not set, the region is
+	// resolved from the environment or profile, falling back to us-east-1
+	Region string
+}
+
+// NewAwsSecretsManagerProvider returns a provider which authenticates using the given connection. If the
+// connection is nil, the default AWS credential chain is used
+func NewAwsSecretsManagerProvider(connection *AwsConnection) *AwsSecretsManagerProvider {
+	return &AwsSecretsManagerProvider{
+		connection: connection,
+	}
+}
+
+func (p *AwsSecretsManagerProvider) GetSecret(ctx context.Context, ref *SecretReference) (string, error) {
+	cfg, err := p.config(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	client := secretsmanager.NewFromConfig(cfg)
+	output, err := client.GetSecretValue(ctx, &secretsmanager.GetSecretValueInput{
+		SecretId: aws.String(ref.Path),
+	})
+	if err != nil {
+		var notFound *types.ResourceNotFoundException
+		if errors.As(err, &notFound) {
+			return "", perr.NotFoundWithMessage(fmt.Sprintf("aws secret %s not found", ref.Path))
+		}
+		return "", err
+	}
+
+	var secret string
+	switch {
+	case output.SecretString != nil:
+		secret = *output.SecretString
+	case output.SecretBinary != nil:
+		secret = string(output.SecretBinary)
+	}
+
+	if ref.Field == "" {
+		return secret, nil
+	}
+
+	var data map[string]any
+	if err := json.Unmarshal([]byte(secret), &data); err != nil {
+		return "", perr.BadRequestWithMessage(fmt.Sprintf("aws secret %s is not a JSON object, unable to select key %s", ref.Path, ref.Field))
+	}
+	return selectSecretField(ref, data)
+}
+
+func (p *AwsSecretsManagerProvider) config(ctx context.Context) (aws.Config, error) {
+	var opts []func(*config.LoadOptions) error
+	if p.Region != "" {
+		opts = append(opts, config.WithRegion(p.Region))
+	}
+
+	if p.connection != nil {
+		resolved, err := p.connection.Resolve(ctx)
+		if err != nil {
+			return aws.Config{}, err
+		}
+		awsConnection := resolved.(*AwsConnection)
+		if awsConnection.AccessKey != nil && awsConnection.SecretKey != nil {
+			sessionToken := ""
+			if awsConnection.SessionToken != nil {
+				sessionToken = *awsConnection.SessionToken
+			}
+			opts = append(opts, config.WithCredentialsProvider(
+				credentials.NewStaticCredentialsProvider(*awsConnection.AccessKey, *awsConnection.SecretKey, sessionToken)))
+		} else if awsConnection.Profile != nil {
+			opts = append(opts, config.WithSharedConfigProfile(*awsConnection.Profile))
+		}
+	}
+
+	cfg, err := config.LoadDefaultConfig(ctx, opts...)
+	if err != nil {
+		return aws.Config{}, err
+	}
+	if cfg.Region == "" {
+		cfg.Region = defaultAwsSecretsRegion
+	}
+	return cfg, nil
+}
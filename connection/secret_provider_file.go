@@ -0,0 +1,97 @@
+package connection
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/turbot/pipe-fittings/perr"
+)
+
+const (
+	EnvSecretScheme  = "env"
+	FileSecretScheme = "file"
+)
+
+// EnvSecretProvider resolves references of the form env://NAME from the environment
+type EnvSecretProvider struct{}
+
+func (p *EnvSecretProvider) GetSecret(_ context.Context, ref *SecretReference) (string, error) {
+	value, ok := os.LookupEnv(ref.Path)
+	if !ok {
+		return "", perr.NotFoundWithMessage(fmt.Sprintf("environment variable %s is not set", ref.Path))
+	}
+	return value, nil
+}
+
+// FileSecretProvider resolves references of the form file://path#KEY from a .env file. If no key is specified,
+// the trimmed content of the whole file is returned
+type FileSecretProvider struct{}
+
+func (p *FileSecretProvider) GetSecret(_ context.Context, ref *SecretReference) (string, error) {
+	data, err := os.ReadFile(ref.Path)
+	if err != nil {
+		return "", perr.NotFoundWithMessage(fmt.Sprintf("unable to read secret file %s: %s", ref.Path, err.Error()))
+	}
+
+	if ref.Field == "" {
+		return strings.TrimSpace(string(data)), nil
+	}
+
+	values, err := parseEnvFile(string(data))
+	if err != nil {
+		return "", perr.BadRequestWithMessage(fmt.Sprintf("unable to parse secret file %s: %s", ref.Path, err.Error()))
+	}
+
+	value, ok := values[ref.Field]
+	if !ok {
+		return "", perr.NotFoundWithMessage(fmt.Sprintf("key %s not found in secret file %s", ref.Field, ref.Path))
+	}
+	return value, nil
+}
+
+// parseEnvFile parses KEY=VALUE lines, ignoring blank lines, comments and an optional export prefix
+func parseEnvFile(content string) (map[string]string, error) {
+	res := make(map[string]string)
+
+	scanner := bufio.NewScanner(strings.NewReader(content))
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = strings.TrimPrefix(line, "export ")
+
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			return nil, fmt.Errorf("line %d is not of the form KEY=VALUE", lineNumber)
+		}
+		key = strings.TrimSpace(key)
+		value = strings.TrimSpace(value)
+
+		switch {
+		case strings.HasPrefix(value, `"`):
+			unquoted, err := strconv.Unquote(value)
+			if err != nil {
+				return nil, fmt.Errorf("line %d has an invalid quoted value", lineNumber)
+			}
+			value = unquoted
+		case strings.HasPrefix(value, "'") && strings.HasSuffix(value, "'") && len(value) > 1:
+			value = value[1 : len(value)-1]
+		default:
+			// strip trailing comments from unquoted values
+			if idx := strings.Index(value, " #"); idx >= 0 {
+				value = strings.TrimSpace(value[:idx])
+			}
+		}
+
+		res[key] = value
+	}
+
+	return res, scanner.Err()
+}
@@ -0,0 +1,43 @@
+package connection
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+	"strings"
+
+	"github.com/turbot/pipe-fittings/perr"
+)
+
+const OnePasswordSecretScheme = "op"
+
+// OnePasswordSecretProvider resolves 1Password secret references (op://<vault>/<item>/<field>) using the op CLI,
+// which must be installed and signed in
+type OnePasswordSecretProvider struct {
+	// Command is the path of the op CLI, defaults to op
+	Command string
+}
+
+func (p *OnePasswordSecretProvider) GetSecret(ctx context.Context, ref *SecretReference) (string, error) {
+	command := p.Command
+	if command == "" {
+		command = "op"
+	}
+
+	//nolint:gosec // the reference is passed as a single argument, not interpreted by a shell
+	cmd := exec.CommandContext(ctx, command, "read", "--no-newline", ref.String())
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		msg := strings.TrimSpace(stderr.String())
+		if msg == "" {
+			msg = err.Error()
+		}
+		return "", perr.BadRequestWithMessage(fmt.Sprintf("unable to read 1Password secret %s: %s", ref.String(), msg))
+	}
+
+	return stdout.String(), nil
+}
@@ -0,0 +1,158 @@
+package connection
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/turbot/pipe-fittings/perr"
+)
+
+const VaultSecretScheme = "vault"
+
+// VaultSecretProvider resolves references of the form vault://<mount>/<path>#<field> from a Vault KV secrets
+// engine. Version 2 of the engine is tried first, falling back to version 1. If no field is specified the secret
+// must contain a single key
+type VaultSecretProvider struct {
+	connection *VaultConnection
+	client     *http.Client
+}
+
+// NewVaultSecretProvider returns a provider which authenticates using the given connection. If the connection is
+// nil, VAULT_ADDR and VAULT_TOKEN are used
+func NewVaultSecretProvider(connection *VaultConnection) *VaultSecretProvider {
+	return &VaultSecretProvider{
+		connection: connection,
+		client:     &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+func (p *VaultSecretProvider) GetSecret(ctx context.Context, ref *SecretReference) (string, error) {
+	address, token, err := p.credentials(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	mount, path, ok := strings.Cut(strings.Trim(ref.Path, "/"), "/")
+	if !ok || path == "" {
+		return "", perr.BadRequestWithMessage(fmt.Sprintf("invalid vault secret reference %s, expected vault://<mount>/<path>", ref.String()))
+	}
+
+	// KV version 2 nests the secret in a data object
+	var v2 struct {
+		Data struct {
+			Data map[string]any `json:"data"`
+		} `json:"data"`
+	}
+	found, err := p.get(ctx, address, token, fmt.Sprintf("%s/v1/%s/data/%s", address, mount, path), &v2)
+	if err != nil {
+		return "", err
+	}
+	if found && v2.Data.Data != nil {
+		return selectSecretField(ref, v2.Data.Data)
+	}
+
+	var v1 struct {
+		Data map[string]any `json:"data"`
+	}
+	found, err = p.get(ctx, address, token, fmt.Sprintf("%s/v1/%s/%s", address, mount, path), &v1)
+	if err != nil {
+		return "", err
+	}
+	if !found || v1.Data == nil {
+		return "", perr.NotFoundWithMessage(fmt.Sprintf("vault secret %s not found", ref.String()))
+	}
+	return selectSecretField(ref, v1.Data)
+}
+
+func (p *VaultSecretProvider) credentials(ctx context.Context) (string, string, error) {
+	var address, token string
+	if p.connection != nil {
+		resolved, err := p.connection.Resolve(ctx)
+		if err != nil {
+			return "", "", err
+		}
+		vaultConnection := resolved.(*VaultConnection)
+		if vaultConnection.Address != nil {
+			address = *vaultConnection.Address
+		}
+		if vaultConnection.Token != nil {
+			token = *vaultConnection.Token
+		}
+	} else {
+		address = os.Getenv("VAULT_ADDR")
+		token = os.Getenv("VAULT_TOKEN")
+	}
+
+	if address == "" {
+		return "", "", perr.BadRequestWithMessage("vault address is not set")
+	}
+	return strings.TrimSuffix(address, "/"), token, nil
+}
+
+// get returns false if the path does not exist
+func (p *VaultSecretProvider) get(ctx context.Context, address, token, url string, target any) (bool, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("X-Vault-Token", token)
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return false, perr.ServiceUnavailableWithMessage(fmt.Sprintf("unable to reach vault at %s: %s", address, err.Error()))
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return false, nil
+	case resp.StatusCode == http.StatusForbidden || resp.StatusCode == http.StatusUnauthorized:
+		return false, perr.ForbiddenWithMessage("vault denied access to the secret")
+	case resp.StatusCode >= 300:
+		return false, perr.InternalWithMessage(fmt.Sprintf("vault returned status %d", resp.StatusCode))
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return false, err
+	}
+	if err := json.Unmarshal(body, target); err != nil {
+		return false, perr.InternalWithMessage("unable to parse vault response: " + err.Error())
+	}
+	return true, nil
+}
+
+// selectSecretField returns the field of a structured secret selected by the reference
+func selectSecretField(ref *SecretReference, data map[string]any) (string, error) {
+	if ref.Field == "" {
+		if len(data) != 1 {
+			return "", perr.BadRequestWithMessage(fmt.Sprintf("secret %s contains %d keys, a field must be specified", ref.String(), len(data)))
+		}
+		for _, v := range data {
+			return secretValueToString(v), nil
+		}
+	}
+
+	v, ok := data[ref.Field]
+	if !ok {
+		return "", perr.NotFoundWithMessage(fmt.Sprintf("field %s not found in secret %s", ref.Field, ref.String()))
+	}
+	return secretValueToString(v), nil
+}
+
+func secretValueToString(v any) string {
+	if s, ok := v.(string); ok {
+		return s
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprintf("%v", v)
+	}
+	return string(data)
+}
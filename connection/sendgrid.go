@@ -15,7 +15,7 @@ const SendGridConnectionType = "sendgrid"
 type SendGridConnection struct {
 	ConnectionImpl
 
-	APIKey *string `json:"api_key,omitempty" cty:"api_key" hcl:"api_key,optional"`
+	APIKey *string `json:"api_key,omitempty" cty:"api_key" hcl:"api_key,optional" sensitive:"true"`
 }
 
 func NewSendGridConnection(shortName string, declRange hcl.Range) PipelingConnection {
@@ -33,6 +33,12 @@ func (c *SendGridConnection) Resolve(ctx context.Context) (PipelingConnection, e
 		return c.Pipes.Resolve(ctx, &SendGridConnection{ConnectionImpl: c.ConnectionImpl})
 	}
 
+	// replace any secret references with the secrets they refer to
+	c, err := resolveSecrets(ctx, c)
+	if err != nil {
+		return nil, err
+	}
+
 	if c.APIKey == nil {
 		sendGridAPIKeyEnvVar := os.Getenv("SENDGRID_API_KEY")
 
@@ -95,3 +101,16 @@ func (c *SendGridConnection) GetEnv() map[string]cty.Value {
 	}
 	return env
 }
+
+var sendGridApiUrl = "https://api.sendgrid.com/v3"
+
+// Test lists the scopes of the SendGrid api key
+func (c *SendGridConnection) Test(ctx context.Context) *TestResult {
+	return runConnectionTest(ctx, func(ctx context.Context) (string, error) {
+		if !hasTestValue(c.APIKey) {
+			return "", missingAttributeError(c, "api_key")
+		}
+
+		return testGetJson[any](ctx, sendGridApiUrl+"/scopes", map[string]string{"Authorization": "Bearer " + *c.APIKey}, nil)
+	})
+}
@@ -17,7 +17,7 @@ type ServiceNowConnection struct {
 
 	InstanceURL *string `json:"instance_url,omitempty" cty:"instance_url" hcl:"instance_url,optional"`
 	Username    *string `json:"username,omitempty" cty:"username" hcl:"username,optional"`
-	Password    *string `json:"password,omitempty" cty:"password" hcl:"password,optional"`
+	Password    *string `json:"password,omitempty" cty:"password" hcl:"password,optional" sensitive:"true"`
 }
 
 func NewServiceNowConnection(shortName string, declRange hcl.Range) PipelingConnection {
@@ -35,6 +35,12 @@ func (c *ServiceNowConnection) Resolve(ctx context.Context) (PipelingConnection,
 		return c.Pipes.Resolve(ctx, &ServiceNowConnection{ConnectionImpl: c.ConnectionImpl})
 	}
 
+	// replace any secret references with the secrets they refer to
+	c, err := resolveSecrets(ctx, c)
+	if err != nil {
+		return nil, err
+	}
+
 	servicenowInstanceURLEnvVar := os.Getenv("SERVICENOW_INSTANCE_URL")
 	servicenowUsernameEnvVar := os.Getenv("SERVICENOW_USERNAME")
 	servicenowPasswordEnvVar := os.Getenv("SERVICENOW_PASSWORD")
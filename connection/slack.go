@@ -2,10 +2,12 @@ package connection
 
 import (
 	"context"
+	"net/http"
 	"os"
 
 	"github.com/hashicorp/hcl/v2"
 	"github.com/turbot/go-kit/helpers"
+	"github.com/turbot/pipe-fittings/perr"
 	"github.com/turbot/pipe-fittings/utils"
 	"github.com/zclconf/go-cty/cty"
 )
@@ -15,7 +17,7 @@ const SlackConnectionType = "slack"
 type SlackConnection struct {
 	ConnectionImpl
 
-	Token *string `json:"token,omitempty" cty:"token" hcl:"token,optional"`
+	Token *string `json:"token,omitempty" cty:"token" hcl:"token,optional" sensitive:"true"`
 }
 
 func NewSlackConnection(shortName string, declRange hcl.Range) PipelingConnection {
@@ -33,6 +35,12 @@ func (c *SlackConnection) Resolve(ctx context.Context) (PipelingConnection, erro
 		return c.Pipes.Resolve(ctx, &SlackConnection{ConnectionImpl: c.ConnectionImpl})
 	}
 
+	// replace any secret references with the secrets they refer to
+	c, err := resolveSecrets(ctx, c)
+	if err != nil {
+		return nil, err
+	}
+
 	if c.Token == nil {
 		slackTokenEnvVar := os.Getenv("SLACK_TOKEN")
 
@@ -95,3 +103,42 @@ func (c *SlackConnection) GetEnv() map[string]cty.Value {
 	}
 	return env
 }
+
+var slackApiUrl = "https://slack.com/api"
+
+// Test calls the Slack auth.test method, returning the user and team as the identity
+func (c *SlackConnection) Test(ctx context.Context) *TestResult {
+	return runConnectionTest(ctx, func(ctx context.Context) (string, error) {
+		if !hasTestValue(c.Token) {
+			return "", missingAttributeError(c, "token")
+		}
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, slackApiUrl+"/auth.test", nil)
+		if err != nil {
+			return "", err
+		}
+		req.Header.Set("Authorization", "Bearer "+*c.Token)
+
+		// Slack returns errors with a 200 status
+		var res struct {
+			Ok    bool   `json:"ok"`
+			Error string `json:"error"`
+			User  string `json:"user"`
+			Team  string `json:"team"`
+		}
+		if _, err := testHttpEndpoint(req, &res); err != nil {
+			return "", err
+		}
+		if !res.Ok {
+			switch res.Error {
+			case "invalid_auth", "not_authed", "account_inactive", "token_revoked", "token_expired":
+				return "", perr.UnauthorizedWithMessage("slack auth.test failed: " + res.Error)
+			case "ratelimited":
+				return "", perr.TooManyRequestsWithMessage("slack auth.test failed: " + res.Error)
+			default:
+				return "", perr.BadRequestWithMessage("slack auth.test failed: " + res.Error)
+			}
+		}
+		return res.User + "@" + res.Team, nil
+	})
+}
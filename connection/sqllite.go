@@ -33,6 +33,12 @@ func (c *SqliteConnection) Resolve(ctx context.Context) (PipelingConnection, err
 		return c.Pipes.Resolve(ctx, &SqliteConnection{ConnectionImpl: c.ConnectionImpl})
 	}
 
+	// replace any secret references with the secrets they refer to
+	c, err := resolveSecrets(ctx, c)
+	if err != nil {
+		return nil, err
+	}
+
 	// we must have a filename string or validation would have failed
 	return c, nil
 }
@@ -88,3 +94,10 @@ func (c *SqliteConnection) getFileName() any {
 	}
 	return os.Getenv("DUCKDB_FILENAME")
 }
+
+// Test runs SELECT 1 against the database
+func (c *SqliteConnection) Test(ctx context.Context) *TestResult {
+	return runConnectionTest(ctx, func(ctx context.Context) (string, error) {
+		return testDatabase(ctx, c.GetConnectionString())
+	})
+}
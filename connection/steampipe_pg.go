@@ -2,6 +2,7 @@ package connection
 
 import (
 	"context"
+
 	"github.com/hashicorp/hcl/v2"
 	"github.com/turbot/go-kit/helpers"
 	"github.com/turbot/pipe-fittings/utils"
@@ -23,7 +24,7 @@ type SteampipePgConnection struct {
 	UserName         *string   `json:"username,omitempty" cty:"username" hcl:"username,optional"`
 	Host             *string   `json:"host,omitempty" cty:"host" hcl:"host,optional"`
 	Port             *int      `json:"port,omitempty" cty:"port" hcl:"port,optional"`
-	Password         *string   `json:"password,omitempty" cty:"password" hcl:"password,optional"`
+	Password         *string   `json:"password,omitempty" cty:"password" hcl:"password,optional" sensitive:"true"`
 	SearchPath       *[]string `json:"search_path,omitempty" cty:"search_path" hcl:"search_path,optional"`
 	SearchPathPrefix *[]string `json:"search_path_prefix,omitempty" cty:"search_path_prefix" hcl:"search_path_prefix,optional"`
 	SslMode          *string   `json:"sslmode,omitempty" cty:"sslmode" hcl:"sslmode,optional"`
@@ -45,6 +46,12 @@ func (c *SteampipePgConnection) Resolve(ctx context.Context) (PipelingConnection
 		return c.Pipes.Resolve(ctx, &SteampipePgConnection{ConnectionImpl: c.ConnectionImpl})
 	}
 
+	// replace any secret references with the secrets they refer to
+	c, err := resolveSecrets(ctx, c)
+	if err != nil {
+		return nil, err
+	}
+
 	// if pipes is nil, we must have a connection string, so there is nothing to so
 	return c, nil
 }
@@ -189,3 +196,10 @@ func (c *SteampipePgConnection) getPort() int {
 	}
 	return defaultSteampipePort
 }
+
+// Test runs SELECT 1 against the database
+func (c *SteampipePgConnection) Test(ctx context.Context) *TestResult {
+	return runConnectionTest(ctx, func(ctx context.Context) (string, error) {
+		return testDatabase(ctx, c.GetConnectionString())
+	})
+}
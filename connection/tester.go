@@ -0,0 +1,202 @@
+package connection
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"time"
+
+	awshttp "github.com/aws/aws-sdk-go-v2/aws/transport/http"
+	"github.com/turbot/pipe-fittings/backend"
+	"github.com/turbot/pipe-fittings/perr"
+)
+
+// Tester is implemented by connections which can verify their configuration with a cheap call to the service they
+// connect to. Test must be called on a resolved connection
+type Tester interface {
+	Test(ctx context.Context) *TestResult
+}
+
+// TestResult is the result of testing a connection
+type TestResult struct {
+	Ok      bool          `json:"ok"`
+	Latency time.Duration `json:"latency"`
+	// Identity is the user, account or database the connection authenticated as, if known
+	Identity string `json:"identity,omitempty"`
+	// ErrorType is the perr error type of the failure, e.g. error_unauthorized
+	ErrorType string `json:"error_type,omitempty"`
+	Error     string `json:"error,omitempty"`
+}
+
+// TestConnection resolves the connection and tests it
+func TestConnection(ctx context.Context, conn PipelingConnection) *TestResult {
+	resolved, err := conn.Resolve(ctx)
+	if err != nil {
+		return failedTestResult(0, err)
+	}
+
+	tester, ok := resolved.(Tester)
+	if !ok {
+		return failedTestResult(0, perr.BadRequestWithMessage(fmt.Sprintf("connection type %s does not support testing", conn.GetConnectionType())))
+	}
+	return tester.Test(ctx)
+}
+
+// runConnectionTest times the test function and builds the result
+func runConnectionTest(ctx context.Context, test func(context.Context) (string, error)) *TestResult {
+	start := time.Now()
+	identity, err := test(ctx)
+	latency := time.Since(start)
+
+	if err != nil {
+		return failedTestResult(latency, err)
+	}
+	return &TestResult{
+		Ok:       true,
+		Latency:  latency,
+		Identity: identity,
+	}
+}
+
+func failedTestResult(latency time.Duration, err error) *TestResult {
+	errorModel := classifyTestError(err)
+	return &TestResult{
+		Latency:   latency,
+		ErrorType: errorModel.Type,
+		Error:     errorModel.Detail,
+	}
+}
+
+// classifyTestError converts the error to a perr.ErrorModel, if it is not one already
+func classifyTestError(err error) perr.ErrorModel {
+	var errorModel perr.ErrorModel
+	if errors.As(err, &errorModel) {
+		return errorModel
+	}
+
+	var netErr net.Error
+	var responseErr *awshttp.ResponseError
+	switch {
+	case errors.As(err, &responseErr):
+		return httpStatusError(responseErr.HTTPStatusCode(), err.Error())
+	case errors.Is(err, context.DeadlineExceeded):
+		return perr.TimeoutWithMessage(err.Error())
+	case errors.As(err, &netErr):
+		if netErr.Timeout() {
+			return perr.TimeoutWithMessage(err.Error())
+		}
+		return perr.ServiceUnavailableWithMessage(err.Error())
+	default:
+		return perr.InternalWithMessage(err.Error())
+	}
+}
+
+var testHttpClient = &http.Client{Timeout: 30 * time.Second}
+
+// testHttpEndpoint sends the request, converts error statuses to perr errors, and decodes the JSON response into
+// target, if it is not nil. The response headers are returned
+func testHttpEndpoint(req *http.Request, target any) (http.Header, error) {
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := testHttpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode >= 400 {
+		return nil, httpStatusError(resp.StatusCode, fmt.Sprintf("%s returned %s", req.URL.Host, resp.Status))
+	}
+
+	if target == nil {
+		return resp.Header, nil
+	}
+	if err := json.Unmarshal(body, target); err != nil {
+		return nil, perr.InternalWithMessage(fmt.Sprintf("unable to parse response from %s: %s", req.URL.Host, err.Error()))
+	}
+	return resp.Header, nil
+}
+
+// httpStatusError converts an http error status to the equivalent perr error
+func httpStatusError(statusCode int, msg string) perr.ErrorModel {
+	switch {
+	case statusCode == http.StatusUnauthorized:
+		return perr.UnauthorizedWithMessage(msg)
+	case statusCode == http.StatusForbidden:
+		return perr.ForbiddenWithMessage(msg)
+	case statusCode == http.StatusNotFound:
+		return perr.NotFoundWithMessage(msg)
+	case statusCode == http.StatusTooManyRequests:
+		return perr.TooManyRequestsWithMessage(msg)
+	case statusCode >= 500:
+		return perr.ServiceUnavailableWithMessage(msg)
+	default:
+		return perr.BadRequestWithMessage(msg)
+	}
+}
+
+// getJson calls an endpoint which is authenticated with headers and returns the response headers, having
+// decoded the JSON response into target
+func getJson(ctx context.Context, url string, headers map[string]string, target any) (http.Header, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	return testHttpEndpoint(req, target)
+}
+
+// testGetJson tests an endpoint which is authenticated with headers, returning the identity extracted from the
+// JSON response
+func testGetJson[T any](ctx context.Context, url string, headers map[string]string, identity func(T) string) (string, error) {
+	var target T
+	if _, err := getJson(ctx, url, headers, &target); err != nil {
+		return "", err
+	}
+	if identity == nil {
+		return "", nil
+	}
+	return identity(target), nil
+}
+
+// testDatabase runs SELECT 1 against the database, returning the backend name as the identity
+func testDatabase(ctx context.Context, connectionString string) (string, error) {
+	b, err := backend.FromConnectionString(ctx, connectionString)
+	if err != nil {
+		return "", perr.BadRequestWithMessage(err.Error())
+	}
+
+	db, err := b.Connect(ctx)
+	if err != nil {
+		return "", perr.ServiceUnavailableWithMessage(err.Error())
+	}
+	defer db.Close()
+
+	var result int
+	if err := db.QueryRowContext(ctx, "SELECT 1").Scan(&result); err != nil {
+		return "", perr.ServiceUnavailableWithMessage(err.Error())
+	}
+	return b.Name(), nil
+}
+
+// hasTestValue returns whether an attribute required to test a connection is set - resolving a connection may set
+// attributes to the empty value of an unset environment variable
+func hasTestValue(value *string) bool {
+	return value != nil && *value != ""
+}
+
+// missingAttributeError is returned when testing a connection which does not have the attributes required
+func missingAttributeError(conn PipelingConnection, attribute string) error {
+	return perr.BadRequestWithMessage(fmt.Sprintf("connection %s has no %s", conn.Name(), attribute))
+}
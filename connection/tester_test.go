@@ -0,0 +1,154 @@
+package connection
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/hashicorp/hcl/v2"
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/assert"
+	"github.com/turbot/pipe-fittings/constants"
+	"github.com/turbot/pipe-fittings/perr"
+	"github.com/turbot/pipe-fittings/utils"
+)
+
+func TestSqliteConnectionTest(t *testing.T) {
+	if db, err := sql.Open("sqlite3", ":memory:"); err == nil {
+		err = db.Ping()
+		db.Close()
+		if err != nil {
+			// the sqlite3 driver requires cgo
+			t.Skipf("sqlite3 driver is unavailable: %s", err)
+		}
+	}
+
+	assert := assert.New(t)
+
+	conn := NewSqliteConnection("default", hcl.Range{}).(*SqliteConnection)
+	conn.FileName = utils.ToPointer(filepath.Join(t.TempDir(), "test.db"))
+
+	result := TestConnection(context.TODO(), conn)
+	assert.True(result.Ok, result.Error)
+	assert.Equal(constants.SQLiteBackendName, result.Identity)
+	assert.Equal("", result.ErrorType)
+}
+
+func TestDuckDbConnectionTestWithoutDriver(t *testing.T) {
+	assert := assert.New(t)
+
+	// the duckdb driver is not registered in this package's tests, so the test fails with a classified error
+	conn := NewDuckDbConnection("default", hcl.Range{}).(*DuckDbConnection)
+	conn.FileName = utils.ToPointer(filepath.Join(t.TempDir(), "test.duckdb"))
+
+	result := TestConnection(context.TODO(), conn)
+	assert.False(result.Ok)
+	assert.Equal(perr.ErrorCodeServiceUnavailable, result.ErrorType)
+	assert.NotEqual("", result.Error)
+}
+
+func TestGithubConnectionTest(t *testing.T) {
+	assert := assert.New(t)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/user" || r.Header.Get("Authorization") != "Bearer ghp_valid" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		_, _ = w.Write([]byte(`{"login":"octocat"}`))
+	}))
+	defer server.Close()
+
+	defaultUrl := githubApiUrl
+	githubApiUrl = server.URL
+	defer func() { githubApiUrl = defaultUrl }()
+
+	conn := NewGithubConnection("default", hcl.Range{}).(*GithubConnection)
+	conn.Token = utils.ToPointer("ghp_valid")
+
+	result := TestConnection(context.TODO(), conn)
+	assert.True(result.Ok, result.Error)
+	assert.Equal("octocat", result.Identity)
+
+	conn.Token = utils.ToPointer("ghp_invalid")
+	result = TestConnection(context.TODO(), conn)
+	assert.False(result.Ok)
+	assert.Equal(perr.ErrorCodeUnauthorized, result.ErrorType)
+}
+
+func TestSlackConnectionTest(t *testing.T) {
+	assert := assert.New(t)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer xoxb-valid" {
+			_, _ = w.Write([]byte(`{"ok":false,"error":"invalid_auth"}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"ok":true,"user":"flowpipe","team":"turbot"}`))
+	}))
+	defer server.Close()
+
+	defaultUrl := slackApiUrl
+	slackApiUrl = server.URL
+	defer func() { slackApiUrl = defaultUrl }()
+
+	conn := NewSlackConnection("default", hcl.Range{}).(*SlackConnection)
+	conn.Token = utils.ToPointer("xoxb-valid")
+
+	result := TestConnection(context.TODO(), conn)
+	assert.True(result.Ok, result.Error)
+	assert.Equal("flowpipe@turbot", result.Identity)
+
+	conn.Token = utils.ToPointer("xoxb-invalid")
+	result = TestConnection(context.TODO(), conn)
+	assert.False(result.Ok)
+	assert.Equal(perr.ErrorCodeUnauthorized, result.ErrorType)
+}
+
+func TestConnectionTestErrors(t *testing.T) {
+	assert := assert.New(t)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/users/me":
+			w.WriteHeader(http.StatusTooManyRequests)
+		default:
+			w.WriteHeader(http.StatusBadGateway)
+		}
+	}))
+	defer server.Close()
+
+	// missing attributes
+	conn := NewGithubConnection("default", hcl.Range{}).(*GithubConnection)
+	t.Setenv("GITHUB_TOKEN", "")
+	result := TestConnection(context.TODO(), conn)
+	assert.False(result.Ok)
+	assert.Equal(perr.ErrorCodeBadRequest, result.ErrorType)
+
+	// http statuses
+	okta := NewOktaConnection("default", hcl.Range{}).(*OktaConnection)
+	okta.Domain = utils.ToPointer(server.URL)
+	okta.Token = utils.ToPointer("token")
+	result = TestConnection(context.TODO(), okta)
+	assert.False(result.Ok)
+	assert.Equal(perr.ErrorCodeServiceUnavailable, result.ErrorType)
+
+	defaultUrl := trelloApiUrl
+	trelloApiUrl = server.URL
+	defer func() { trelloApiUrl = defaultUrl }()
+
+	trello := NewTrelloConnection("default", hcl.Range{}).(*TrelloConnection)
+	trello.APIKey = utils.ToPointer("key")
+	trello.Token = utils.ToPointer("token")
+	result = TestConnection(context.TODO(), trello)
+	assert.False(result.Ok)
+	assert.Equal(perr.ErrorCodeServiceUnavailable, result.ErrorType)
+
+	// unsupported connection types
+	result = TestConnection(context.TODO(), newFakeConnection("default", 60))
+	assert.False(result.Ok)
+	assert.Equal(perr.ErrorCodeBadRequest, result.ErrorType)
+}
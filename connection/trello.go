@@ -2,6 +2,7 @@ package connection
 
 import (
 	"context"
+	"net/url"
 	"os"
 
 	"github.com/hashicorp/hcl/v2"
@@ -15,8 +16,8 @@ const TrelloConnectionType = "trello"
 type TrelloConnection struct {
 	ConnectionImpl
 
-	APIKey *string `json:"api_key,omitempty" cty:"api_key" hcl:"api_key,optional"`
-	Token  *string `json:"token,omitempty" cty:"token" hcl:"token,optional"`
+	APIKey *string `json:"api_key,omitempty" cty:"api_key" hcl:"api_key,optional" sensitive:"true"`
+	Token  *string `json:"token,omitempty" cty:"token" hcl:"token,optional" sensitive:"true"`
 }
 
 func NewTrelloConnection(shortName string, declRange hcl.Range) PipelingConnection {
@@ -34,6 +35,12 @@ func (c *TrelloConnection) Resolve(ctx context.Context) (PipelingConnection, err
 		return c.Pipes.Resolve(ctx, &TrelloConnection{ConnectionImpl: c.ConnectionImpl})
 	}
 
+	// replace any secret references with the secrets they refer to
+	c, err := resolveSecrets(ctx, c)
+	if err != nil {
+		return nil, err
+	}
+
 	if c.APIKey == nil && c.Token == nil {
 		apiKeyEnvVar := os.Getenv("TRELLO_API_KEY")
 		tokenEnvVar := os.Getenv("TRELLO_TOKEN")
@@ -98,3 +105,22 @@ func (c *TrelloConnection) CtyValue() (cty.Value, error) {
 func (c *TrelloConnection) GetEnv() map[string]cty.Value {
 	return nil
 }
+
+var trelloApiUrl = "https://api.trello.com/1"
+
+// Test calls the Trello /members/me endpoint, returning the username of the member as the identity
+func (c *TrelloConnection) Test(ctx context.Context) *TestResult {
+	return runConnectionTest(ctx, func(ctx context.Context) (string, error) {
+		if !hasTestValue(c.APIKey) || !hasTestValue(c.Token) {
+			return "", missingAttributeError(c, "api_key and token")
+		}
+
+		query := url.Values{"key": {*c.APIKey}, "token": {*c.Token}}
+		return testGetJson(ctx, trelloApiUrl+"/members/me?"+query.Encode(), nil,
+			func(member struct {
+				Username string `json:"username"`
+			}) string {
+				return member.Username
+			})
+	})
+}
@@ -3,6 +3,8 @@ package connection
 import (
 	"context"
 	"os"
+	"strings"
+	"time"
 
 	"github.com/hashicorp/hcl/v2"
 	"github.com/turbot/go-kit/helpers"
@@ -16,7 +18,7 @@ type VaultConnection struct {
 	ConnectionImpl
 
 	Address *string `json:"address,omitempty" cty:"address" hcl:"address,optional"`
-	Token   *string `json:"token,omitempty" cty:"token" hcl:"token,optional"`
+	Token   *string `json:"token,omitempty" cty:"token" hcl:"token,optional" sensitive:"true"`
 }
 
 func NewVaultConnection(shortName string, declRange hcl.Range) PipelingConnection {
@@ -34,6 +36,12 @@ func (c *VaultConnection) Resolve(ctx context.Context) (PipelingConnection, erro
 		return c.Pipes.Resolve(ctx, &VaultConnection{ConnectionImpl: c.ConnectionImpl})
 	}
 
+	// replace any secret references with the secrets they refer to
+	c, err := resolveSecrets(ctx, c)
+	if err != nil {
+		return nil, err
+	}
+
 	if c.Token == nil && c.Address == nil {
 		tokenEnvVar := os.Getenv("VAULT_TOKEN")
 		addressEnvVar := os.Getenv("VAULT_ADDR")
@@ -106,3 +114,45 @@ func (c *VaultConnection) GetEnv() map[string]cty.Value {
 	}
 	return env
 }
+
+// Test looks up the Vault token, returning its display name as the identity
+func (c *VaultConnection) Test(ctx context.Context) *TestResult {
+	return runConnectionTest(ctx, func(ctx context.Context) (string, error) {
+		if !hasTestValue(c.Address) {
+			return "", missingAttributeError(c, "address")
+		}
+		if !hasTestValue(c.Token) {
+			return "", missingAttributeError(c, "token")
+		}
+
+		return testGetJson(ctx, strings.TrimSuffix(*c.Address, "/")+"/v1/auth/token/lookup-self", map[string]string{"X-Vault-Token": *c.Token},
+			func(res struct {
+				Data struct {
+					DisplayName string `json:"display_name"`
+				} `json:"data"`
+			}) string {
+				return res.Data.DisplayName
+			})
+	})
+}
+
+// GetExpiry returns the expiry of the token - root tokens and tokens without a ttl do not expire
+func (c *VaultConnection) GetExpiry(ctx context.Context) (*time.Time, error) {
+	if !hasTestValue(c.Address) {
+		return nil, missingAttributeError(c, "address")
+	}
+	if !hasTestValue(c.Token) {
+		return nil, missingAttributeError(c, "token")
+	}
+
+	var res struct {
+		Data struct {
+			ExpireTime *time.Time `json:"expire_time"`
+		} `json:"data"`
+	}
+	_, err := getJson(ctx, strings.TrimSuffix(*c.Address, "/")+"/v1/auth/token/lookup-self", map[string]string{"X-Vault-Token": *c.Token}, &res)
+	if err != nil {
+		return nil, err
+	}
+	return res.Data.ExpireTime, nil
+}
@@ -2,6 +2,8 @@ package connection
 
 import (
 	"context"
+	"encoding/base64"
+	"fmt"
 	"os"
 
 	"github.com/hashicorp/hcl/v2"
@@ -17,7 +19,7 @@ type ZendeskConnection struct {
 
 	Email     *string `json:"email,omitempty" cty:"email" hcl:"email,optional"`
 	Subdomain *string `json:"subdomain,omitempty" cty:"subdomain" hcl:"subdomain,optional"`
-	Token     *string `json:"token,omitempty" cty:"token" hcl:"token,optional"`
+	Token     *string `json:"token,omitempty" cty:"token" hcl:"token,optional" sensitive:"true"`
 }
 
 func NewZendeskConnection(shortName string, declRange hcl.Range) PipelingConnection {
@@ -35,6 +37,12 @@ func (c *ZendeskConnection) Resolve(ctx context.Context) (PipelingConnection, er
 		return c.Pipes.Resolve(ctx, &ZendeskConnection{ConnectionImpl: c.ConnectionImpl})
 	}
 
+	// replace any secret references with the secrets they refer to
+	c, err := resolveSecrets(ctx, c)
+	if err != nil {
+		return nil, err
+	}
+
 	if c.Subdomain == nil && c.Email == nil && c.Token == nil {
 		subdomainEnvVar := os.Getenv("ZENDESK_SUBDOMAIN")
 		emailEnvVar := os.Getenv("ZENDESK_EMAIL")
@@ -106,3 +114,27 @@ func (c *ZendeskConnection) CtyValue() (cty.Value, error) {
 func (c *ZendeskConnection) GetEnv() map[string]cty.Value {
 	return nil
 }
+
+var zendeskApiUrl = "https://%s.zendesk.com/api/v2"
+
+// Test calls the Zendesk /users/me endpoint, returning the email address of the user as the identity
+func (c *ZendeskConnection) Test(ctx context.Context) *TestResult {
+	return runConnectionTest(ctx, func(ctx context.Context) (string, error) {
+		if !hasTestValue(c.Subdomain) {
+			return "", missingAttributeError(c, "subdomain")
+		}
+		if !hasTestValue(c.Email) || !hasTestValue(c.Token) {
+			return "", missingAttributeError(c, "email and token")
+		}
+
+		auth := base64.StdEncoding.EncodeToString([]byte(*c.Email + "/token:" + *c.Token))
+		return testGetJson(ctx, fmt.Sprintf(zendeskApiUrl, *c.Subdomain)+"/users/me.json", map[string]string{"Authorization": "Basic " + auth},
+			func(res struct {
+				User struct {
+					Email string `json:"email"`
+				} `json:"user"`
+			}) string {
+				return res.User.Email
+			})
+	})
+}
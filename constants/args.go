@@ -54,10 +54,13 @@ const (
 	ArgMaxParallel             = "max-parallel"
 	ArgMemoryMaxMb             = "memory-max-mb"
 	ArgMemoryMaxMbPlugin       = "memory-max-mb-plugin"
+	ArgModHosts                = "mod-hosts"
 	ArgModInstall              = "mod-install"
 	ArgModLocation             = "mod-location"
+	ArgModMirror               = "mod-mirror"
 	ArgMultiLine               = "multi-line"
 	ArgOff                     = "off"
+	ArgOffline                 = "offline"
 	ArgOn                      = "on"
 	ArgOutput                  = "output"
 	ArgPipesHost               = "pipes-host"
@@ -2,8 +2,10 @@ package constants
 
 const (
 	DuckDBBackendName    = "DuckDB"
+	MSSQLBackendName     = "MSSQL"
 	MySQLBackendName     = "MySQL"
 	PostgresBackendName  = "PostgreSQL"
+	SnowflakeBackendName = "Snowflake"
 	SQLiteBackendName    = "SQLite"
 	SteampipeBackendName = "Steampipe"
 )
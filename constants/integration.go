@@ -0,0 +1,36 @@
+package constants
+
+// PagerDuty event severities
+const (
+	PagerDutySeverityCritical = "critical"
+	PagerDutySeverityError    = "error"
+	PagerDutySeverityWarning  = "warning"
+	PagerDutySeverityInfo     = "info"
+)
+
+func IsValidPagerDutySeverity(s string) bool {
+	switch s {
+	case PagerDutySeverityCritical, PagerDutySeverityError, PagerDutySeverityWarning, PagerDutySeverityInfo:
+		return true
+	default:
+		return false
+	}
+}
+
+// Opsgenie alert priorities
+const (
+	OpsgeniePriorityP1 = "P1"
+	OpsgeniePriorityP2 = "P2"
+	OpsgeniePriorityP3 = "P3"
+	OpsgeniePriorityP4 = "P4"
+	OpsgeniePriorityP5 = "P5"
+)
+
+func IsValidOpsgeniePriority(s string) bool {
+	switch s {
+	case OpsgeniePriorityP1, OpsgeniePriorityP2, OpsgeniePriorityP3, OpsgeniePriorityP4, OpsgeniePriorityP5:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,28 @@
+package constants
+
+// Message template severities, used to pick the accent colour of a rendered message
+const (
+	MessageSeverityInfo     = "info"
+	MessageSeveritySuccess  = "success"
+	MessageSeverityWarning  = "warning"
+	MessageSeverityError    = "error"
+	MessageSeverityCritical = "critical"
+)
+
+func IsValidMessageSeverity(s string) bool {
+	switch s {
+	case MessageSeverityInfo, MessageSeveritySuccess, MessageSeverityWarning, MessageSeverityError, MessageSeverityCritical:
+		return true
+	default:
+		return false
+	}
+}
+
+// MessageSeverityColors maps each message template severity to its default colour
+var MessageSeverityColors = map[string]string{
+	MessageSeverityInfo:     "#439FE0",
+	MessageSeveritySuccess:  "#2EB67D",
+	MessageSeverityWarning:  "#ECB22E",
+	MessageSeverityError:    "#E01E5A",
+	MessageSeverityCritical: "#8B0000",
+}
@@ -14,8 +14,8 @@ import (
 type AlicloudCredential struct {
 	CredentialImpl
 
-	AccessKey *string `json:"access_key,omitempty" cty:"access_key" hcl:"access_key,optional"`
-	SecretKey *string `json:"secret_key,omitempty" cty:"secret_key" hcl:"secret_key,optional"`
+	AccessKey *string `json:"access_key,omitempty" cty:"access_key" hcl:"access_key,optional" sensitive:"true"`
+	SecretKey *string `json:"secret_key,omitempty" cty:"secret_key" hcl:"secret_key,optional" sensitive:"true"`
 }
 
 func (c *AlicloudCredential) Validate() hcl.Diagnostics {
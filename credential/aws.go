@@ -2,12 +2,13 @@ package credential
 
 import (
 	"context"
+	"fmt"
 
-	"github.com/aws/aws-sdk-go-v2/aws"
-	"github.com/aws/aws-sdk-go-v2/config"
 	"github.com/hashicorp/hcl/v2"
 	"github.com/turbot/go-kit/helpers"
+	"github.com/turbot/pipe-fittings/connection"
 	"github.com/turbot/pipe-fittings/modconfig"
+	"github.com/turbot/pipe-fittings/perr"
 	"github.com/turbot/pipe-fittings/utils"
 	"github.com/zclconf/go-cty/cty"
 )
@@ -15,11 +16,23 @@ import (
 type AwsCredential struct {
 	CredentialImpl
 
-	AccessKey    *string `json:"access_key,omitempty" cty:"access_key" hcl:"access_key,optional"`
-	SecretKey    *string `json:"secret_key,omitempty" cty:"secret_key" hcl:"secret_key,optional"`
-	SessionToken *string `json:"session_token,omitempty" cty:"session_token" hcl:"session_token,optional"`
+	AccessKey    *string `json:"access_key,omitempty" cty:"access_key" hcl:"access_key,optional" sensitive:"true"`
+	SecretKey    *string `json:"secret_key,omitempty" cty:"secret_key" hcl:"secret_key,optional" sensitive:"true"`
+	SessionToken *string `json:"session_token,omitempty" cty:"session_token" hcl:"session_token,optional" sensitive:"true"`
 	Ttl          *int    `json:"ttl,omitempty" cty:"ttl" hcl:"ttl,optional"`
 	Profile      *string `json:"profile,omitempty" cty:"profile" hcl:"profile,optional"`
+	Region       *string `json:"region,omitempty" cty:"region" hcl:"region,optional"`
+
+	// role assumption
+	RoleArn              *string `json:"role_arn,omitempty" cty:"role_arn" hcl:"role_arn,optional"`
+	ExternalId           *string `json:"external_id,omitempty" cty:"external_id" hcl:"external_id,optional"`
+	SessionName          *string `json:"session_name,omitempty" cty:"session_name" hcl:"session_name,optional"`
+	Duration             *int    `json:"duration,omitempty" cty:"duration" hcl:"duration,optional"` // in seconds
+	WebIdentityTokenFile *string `json:"web_identity_token_file,omitempty" cty:"web_identity_token_file" hcl:"web_identity_token_file,optional"`
+
+	// SourceConnection is the name of the aws credential whose credentials are used to assume the role
+	SourceConnection *string `json:"source_connection,omitempty" cty:"source_connection" hcl:"source_connection,optional"`
+	sourceCredential *AwsCredential
 }
 
 func (c *AwsCredential) Validate() hcl.Diagnostics {
@@ -44,35 +57,68 @@ func (c *AwsCredential) Validate() hcl.Diagnostics {
 		}
 	}
 
-	return hcl.Diagnostics{}
+	return connection.ValidateAwsCredentialOptions(c.credentialOptions(), c.SourceConnection != nil, &c.DeclRange)
+}
+
+func (c *AwsCredential) credentialOptions() connection.AwsCredentialOptions {
+	return connection.AwsCredentialOptions{
+		AccessKey:            c.AccessKey,
+		SecretKey:            c.SecretKey,
+		SessionToken:         c.SessionToken,
+		Profile:              c.Profile,
+		Region:               c.Region,
+		RoleArn:              c.RoleArn,
+		ExternalId:           c.ExternalId,
+		SessionName:          c.SessionName,
+		Duration:             c.Duration,
+		WebIdentityTokenFile: c.WebIdentityTokenFile,
+	}
+}
+
+// SetSourceCredential sets the credential whose credentials are used to assume the role
+func (c *AwsCredential) SetSourceCredential(source Credential) error {
+	awsSource, ok := source.(*AwsCredential)
+	if !ok {
+		return perr.BadRequestWithMessage(fmt.Sprintf("source connection %s of credential %s must be an aws credential", source.Name(), c.Name()))
+	}
+	c.sourceCredential = awsSource
+	return nil
+}
+
+// GetSourceCredential returns the credential whose credentials are used to assume the role, if it has been set
+func (c *AwsCredential) GetSourceCredential() *AwsCredential {
+	return c.sourceCredential
 }
 
 func (c *AwsCredential) Resolve(ctx context.Context) (Credential, error) {
 
-	// if access key and secret key are provided, just return it
-	if c.AccessKey != nil && c.SecretKey != nil {
+	// if access key and secret key are provided and there is no role to assume, just return it
+	if c.AccessKey != nil && c.SecretKey != nil && c.RoleArn == nil {
 		return c, nil
 	}
 
-	var cfg aws.Config
-	var err error
+	opts := c.credentialOptions()
 
-	// Load the AWS configuration from the shared credentials file
-	if c.Profile != nil {
-		cfg, err = config.LoadDefaultConfig(ctx, config.WithSharedConfigProfile(*c.Profile), config.WithRegion("us-east-1"))
-		if err != nil {
-			return nil, err
+	// chained role assumption - use the credentials of the source credential
+	if c.SourceConnection != nil {
+		if c.sourceCredential == nil {
+			return nil, perr.BadRequestWithMessage(fmt.Sprintf("source connection %s of credential %s not found", *c.SourceConnection, c.Name()))
 		}
-	} else {
-		cfg, err = config.LoadDefaultConfig(context.TODO(), config.WithRegion("us-east-1"))
+
+		resolvedSource, err := c.sourceCredential.Resolve(ctx)
 		if err != nil {
 			return nil, err
 		}
-
+		source := resolvedSource.(*AwsCredential)
+		opts.AccessKey = source.AccessKey
+		opts.SecretKey = source.SecretKey
+		opts.SessionToken = source.SessionToken
+		if opts.Region == nil {
+			opts.Region = source.Region
+		}
 	}
 
-	// Access the credentials from the configuration
-	creds, err := cfg.Credentials.Retrieve(context.TODO())
+	creds, err := connection.RetrieveAwsCredentials(ctx, opts)
 	if err != nil {
 		return nil, err
 	}
@@ -83,12 +129,18 @@ func (c *AwsCredential) Resolve(ctx context.Context) (Credential, error) {
 		Ttl:            c.Ttl,
 		AccessKey:      &creds.AccessKeyID,
 		SecretKey:      &creds.SecretAccessKey,
+		Region:         c.Region,
 	}
 
 	if creds.SessionToken != "" {
 		newCreds.SessionToken = &creds.SessionToken
 	}
 
+	// temporary credentials must be refreshed before they expire
+	if ttl := connection.AwsCredentialTtl(creds, c.GetTtl()); ttl != c.GetTtl() {
+		newCreds.Ttl = &ttl
+	}
+
 	return newCreds, nil
 }
 
@@ -111,6 +163,10 @@ func (c *AwsCredential) getEnv() map[string]cty.Value {
 	if c.SessionToken != nil {
 		env["AWS_SESSION_TOKEN"] = cty.StringVal(*c.SessionToken)
 	}
+	if c.Region != nil {
+		env["AWS_REGION"] = cty.StringVal(*c.Region)
+		env["AWS_DEFAULT_REGION"] = cty.StringVal(*c.Region)
+	}
 	return env
 }
 
@@ -149,7 +205,13 @@ func (c *AwsCredential) Equals(otherCredential Credential) bool {
 		return false
 	}
 
-	return true
+	return utils.PtrEqual(c.Region, other.Region) &&
+		utils.PtrEqual(c.RoleArn, other.RoleArn) &&
+		utils.PtrEqual(c.ExternalId, other.ExternalId) &&
+		utils.PtrEqual(c.SessionName, other.SessionName) &&
+		utils.PtrEqual(c.Duration, other.Duration) &&
+		utils.PtrEqual(c.WebIdentityTokenFile, other.WebIdentityTokenFile) &&
+		utils.PtrEqual(c.SourceConnection, other.SourceConnection)
 }
 
 // TODO: should we merge AwsConnectionConfig with AwsCredential? They have distinct usage but the data model is very similar
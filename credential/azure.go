@@ -15,7 +15,7 @@ type AzureCredential struct {
 	CredentialImpl
 
 	ClientID     *string `json:"client_id,omitempty" cty:"client_id" hcl:"client_id,optional"`
-	ClientSecret *string `json:"client_secret,omitempty" cty:"client_secret" hcl:"client_secret,optional"`
+	ClientSecret *string `json:"client_secret,omitempty" cty:"client_secret" hcl:"client_secret,optional" sensitive:"true"`
 	TenantID     *string `json:"tenant_id,omitempty" cty:"tenant_id" hcl:"tenant_id,optional"`
 	Environment  *string `json:"environment,omitempty" cty:"environment" hcl:"environment,optional"`
 }
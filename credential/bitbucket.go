@@ -16,7 +16,7 @@ type BitbucketCredential struct {
 
 	BaseURL  *string `json:"base_url,omitempty" cty:"base_url" hcl:"base_url,optional"`
 	Username *string `json:"username,omitempty" cty:"username" hcl:"username,optional"`
-	Password *string `json:"password,omitempty" cty:"password" hcl:"password,optional"`
+	Password *string `json:"password,omitempty" cty:"password" hcl:"password,optional" sensitive:"true"`
 }
 
 func (c *BitbucketCredential) getEnv() map[string]cty.Value {
@@ -14,8 +14,8 @@ import (
 type DatadogCredential struct {
 	CredentialImpl
 
-	APIKey *string `json:"api_key,omitempty" cty:"api_key" hcl:"api_key,optional"`
-	AppKey *string `json:"app_key,omitempty" cty:"app_key" hcl:"app_key,optional"`
+	APIKey *string `json:"api_key,omitempty" cty:"api_key" hcl:"api_key,optional" sensitive:"true"`
+	AppKey *string `json:"app_key,omitempty" cty:"app_key" hcl:"app_key,optional" sensitive:"true"`
 	APIUrl *string `json:"api_url,omitempty" cty:"api_url" hcl:"api_url,optional"`
 }
 
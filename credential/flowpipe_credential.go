@@ -15,6 +15,7 @@ import (
 	"github.com/turbot/pipe-fittings/hclhelpers"
 	"github.com/turbot/pipe-fittings/modconfig"
 	"github.com/turbot/pipe-fittings/perr"
+	"github.com/turbot/pipe-fittings/sanitize"
 	"github.com/zclconf/go-cty/cty"
 )
 
@@ -54,6 +55,13 @@ var credentialTypeRegistry = map[string]reflect.Type{
 	"zendesk":       reflect.TypeOf((*ZendeskCredential)(nil)).Elem(),
 }
 
+func init() {
+	// redact the fields of all credential types which are tagged as sensitive
+	for _, t := range credentialTypeRegistry {
+		sanitize.Instance.RegisterSensitiveFields(reflect.New(t).Interface())
+	}
+}
+
 func instantiateCredential(key string, hclResourceImpl modconfig.HclResourceImpl) (Credential, error) {
 	t, exists := credentialTypeRegistry[key]
 	if !exists {
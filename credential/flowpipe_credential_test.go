@@ -29,6 +29,44 @@ func TestAwsCredential(t *testing.T) {
 	assert.Nil(newAwsCreds.SessionToken)
 }
 
+func TestAwsCredentialAssumeRoleValidate(t *testing.T) {
+
+	assert := assert.New(t)
+
+	roleArn := "arn:aws:iam::123456789012:role/remediation"
+	sourceConnection := "aws.base"
+	duration := 3600
+
+	awsCred := &AwsCredential{
+		RoleArn:          &roleArn,
+		SourceConnection: &sourceConnection,
+		Duration:         &duration,
+	}
+	assert.Len(awsCred.Validate(), 0)
+
+	other := &AwsCredential{
+		RoleArn:          &roleArn,
+		SourceConnection: &sourceConnection,
+		Duration:         &duration,
+	}
+	assert.True(awsCred.Equals(other))
+
+	otherRoleArn := "arn:aws:iam::210987654321:role/remediation"
+	other.RoleArn = &otherRoleArn
+	assert.False(awsCred.Equals(other))
+
+	// source connection without a role to assume
+	awsCred.RoleArn = nil
+	diags := awsCred.Validate()
+	assert.Len(diags, 2)
+	assert.Equal("duration defined without role_arn", diags[0].Summary)
+	assert.Equal("source_connection defined without role_arn", diags[1].Summary)
+
+	// a source credential must be an aws credential
+	assert.NotNil(awsCred.SetSourceCredential(&SlackCredential{}))
+	assert.Nil(awsCred.SetSourceCredential(&AwsCredential{}))
+}
+
 func TestAlicloudCredential(t *testing.T) {
 
 	assert := assert.New(t)
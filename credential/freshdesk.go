@@ -14,7 +14,7 @@ import (
 type FreshdeskCredential struct {
 	CredentialImpl
 
-	APIKey    *string `json:"api_key,omitempty" cty:"api_key" hcl:"api_key,optional"`
+	APIKey    *string `json:"api_key,omitempty" cty:"api_key" hcl:"api_key,optional" sensitive:"true"`
 	Subdomain *string `json:"subdomain,omitempty" cty:"subdomain" hcl:"subdomain,optional"`
 }
 
@@ -21,7 +21,7 @@ type GcpCredential struct {
 	Credentials *string `json:"credentials,omitempty" cty:"credentials" hcl:"credentials,optional"`
 	Ttl         *int    `json:"ttl,omitempty" cty:"ttl" hcl:"ttl,optional"`
 
-	AccessToken *string `json:"access_token,omitempty" cty:"access_token" hcl:"access_token,optional"`
+	AccessToken *string `json:"access_token,omitempty" cty:"access_token" hcl:"access_token,optional" sensitive:"true"`
 }
 
 func (c *GcpCredential) getEnv() map[string]cty.Value {
@@ -14,7 +14,7 @@ import (
 type GitLabCredential struct {
 	CredentialImpl
 
-	Token *string `json:"token,omitempty" cty:"token" hcl:"token,optional"`
+	Token *string `json:"token,omitempty" cty:"token" hcl:"token,optional" sensitive:"true"`
 }
 
 func (c *GitLabCredential) getEnv() map[string]cty.Value {
@@ -14,7 +14,7 @@ import (
 type IPstackCredential struct {
 	CredentialImpl
 
-	AccessKey *string `json:"access_key,omitempty" cty:"access_key" hcl:"access_key,optional"`
+	AccessKey *string `json:"access_key,omitempty" cty:"access_key" hcl:"access_key,optional" sensitive:"true"`
 }
 
 func (c *IPstackCredential) getEnv() map[string]cty.Value {
@@ -14,7 +14,7 @@ import (
 type JiraCredential struct {
 	CredentialImpl
 
-	APIToken *string `json:"api_token,omitempty" cty:"api_token" hcl:"api_token,optional"`
+	APIToken *string `json:"api_token,omitempty" cty:"api_token" hcl:"api_token,optional" sensitive:"true"`
 	BaseURL  *string `json:"base_url,omitempty" cty:"base_url" hcl:"base_url,optional"`
 	Username *string `json:"username,omitempty" cty:"username" hcl:"username,optional"`
 }
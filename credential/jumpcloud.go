@@ -14,7 +14,7 @@ import (
 type JumpCloudCredential struct {
 	CredentialImpl
 
-	APIKey *string `json:"api_key,omitempty" cty:"api_key" hcl:"api_key,optional"`
+	APIKey *string `json:"api_key,omitempty" cty:"api_key" hcl:"api_key,optional" sensitive:"true"`
 }
 
 func (c *JumpCloudCredential) getEnv() map[string]cty.Value {
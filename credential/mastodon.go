@@ -13,7 +13,7 @@ import (
 type MastodonCredential struct {
 	CredentialImpl
 
-	AccessToken *string `json:"access_token,omitempty" cty:"access_token" hcl:"access_token,optional"`
+	AccessToken *string `json:"access_token,omitempty" cty:"access_token" hcl:"access_token,optional" sensitive:"true"`
 	Server      *string `json:"server,omitempty" cty:"server" hcl:"server,optional"`
 }
 
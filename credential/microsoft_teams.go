@@ -14,7 +14,7 @@ import (
 type MicrosoftTeamsCredential struct {
 	CredentialImpl
 
-	AccessToken *string `json:"access_token,omitempty" cty:"access_token" hcl:"access_token,optional"`
+	AccessToken *string `json:"access_token,omitempty" cty:"access_token" hcl:"access_token,optional" sensitive:"true"`
 }
 
 func (c *MicrosoftTeamsCredential) getEnv() map[string]cty.Value {
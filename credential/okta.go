@@ -15,7 +15,7 @@ type OktaCredential struct {
 	CredentialImpl
 
 	Domain *string `json:"domain,omitempty" cty:"domain" hcl:"domain,optional"`
-	Token  *string `json:"token,omitempty" cty:"token" hcl:"token,optional"`
+	Token  *string `json:"token,omitempty" cty:"token" hcl:"token,optional" sensitive:"true"`
 }
 
 func (c *OktaCredential) getEnv() map[string]cty.Value {
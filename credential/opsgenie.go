@@ -14,8 +14,8 @@ import (
 type OpsgenieCredential struct {
 	CredentialImpl
 
-	AlertAPIKey    *string `json:"alert_api_key,omitempty" cty:"alert_api_key" hcl:"alert_api_key,optional"`
-	IncidentAPIKey *string `json:"incident_api_key,omitempty" cty:"incident_api_key" hcl:"incident_api_key,optional"`
+	AlertAPIKey    *string `json:"alert_api_key,omitempty" cty:"alert_api_key" hcl:"alert_api_key,optional" sensitive:"true"`
+	IncidentAPIKey *string `json:"incident_api_key,omitempty" cty:"incident_api_key" hcl:"incident_api_key,optional" sensitive:"true"`
 }
 
 func (c *OpsgenieCredential) getEnv() map[string]cty.Value {
@@ -14,7 +14,7 @@ import (
 type PagerDutyCredential struct {
 	CredentialImpl
 
-	Token *string `json:"token,omitempty" cty:"token" hcl:"token,optional"`
+	Token *string `json:"token,omitempty" cty:"token" hcl:"token,optional" sensitive:"true"`
 }
 
 func (c *PagerDutyCredential) getEnv() map[string]cty.Value {
@@ -16,7 +16,7 @@ type ServiceNowCredential struct {
 
 	InstanceURL *string `json:"instance_url,omitempty" cty:"instance_url" hcl:"instance_url,optional"`
 	Username    *string `json:"username,omitempty" cty:"username" hcl:"username,optional"`
-	Password    *string `json:"password,omitempty" cty:"password" hcl:"password,optional"`
+	Password    *string `json:"password,omitempty" cty:"password" hcl:"password,optional" sensitive:"true"`
 }
 
 func (c *ServiceNowCredential) getEnv() map[string]cty.Value {
@@ -14,7 +14,7 @@ import (
 type SlackCredential struct {
 	CredentialImpl
 
-	Token *string `json:"token,omitempty" cty:"token" hcl:"token,optional"`
+	Token *string `json:"token,omitempty" cty:"token" hcl:"token,optional" sensitive:"true"`
 }
 
 func (c *SlackCredential) getEnv() map[string]cty.Value {
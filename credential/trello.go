@@ -14,8 +14,8 @@ import (
 type TrelloCredential struct {
 	CredentialImpl
 
-	APIKey *string `json:"api_key,omitempty" cty:"api_key" hcl:"api_key,optional"`
-	Token  *string `json:"token,omitempty" cty:"token" hcl:"token,optional"`
+	APIKey *string `json:"api_key,omitempty" cty:"api_key" hcl:"api_key,optional" sensitive:"true"`
+	Token  *string `json:"token,omitempty" cty:"token" hcl:"token,optional" sensitive:"true"`
 }
 
 func (c *TrelloCredential) getEnv() map[string]cty.Value {
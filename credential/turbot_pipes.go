@@ -14,7 +14,7 @@ import (
 type PipesCredential struct {
 	CredentialImpl
 
-	Token *string `json:"token,omitempty" cty:"token" hcl:"token,optional"`
+	Token *string `json:"token,omitempty" cty:"token" hcl:"token,optional" sensitive:"true"`
 }
 
 func (c *PipesCredential) getEnv() map[string]cty.Value {
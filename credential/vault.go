@@ -15,7 +15,7 @@ type VaultCredential struct {
 	CredentialImpl
 
 	Address *string `json:"address,omitempty" cty:"address" hcl:"address,optional"`
-	Token   *string `json:"token,omitempty" cty:"token" hcl:"token,optional"`
+	Token   *string `json:"token,omitempty" cty:"token" hcl:"token,optional" sensitive:"true"`
 }
 
 func (c *VaultCredential) getEnv() map[string]cty.Value {
@@ -16,7 +16,7 @@ type ZendeskCredential struct {
 
 	Email     *string `json:"email,omitempty" cty:"email" hcl:"email,optional"`
 	Subdomain *string `json:"subdomain,omitempty" cty:"subdomain" hcl:"subdomain,optional"`
-	Token     *string `json:"token,omitempty" cty:"token" hcl:"token,optional"`
+	Token     *string `json:"token,omitempty" cty:"token" hcl:"token,optional" sensitive:"true"`
 }
 
 func (c *ZendeskCredential) getEnv() map[string]cty.Value {
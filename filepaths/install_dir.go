@@ -63,6 +63,11 @@ func EnsureInternalDir() string {
 	return ensureInstallSubDir("internal")
 }
 
+// EnsureDownloadCacheDir returns the path to the directory holding partial image downloads (creates if missing)
+func EnsureDownloadCacheDir() string {
+	return ensureInstallSubDir(filepath.Join("internal", "downloads"))
+}
+
 // EnsureBackupsDir returns the path to the backups directory (creates if missing)
 func EnsureBackupsDir() string {
 	return ensureInstallSubDir("backups")
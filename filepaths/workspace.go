@@ -13,6 +13,7 @@ import (
 const (
 	WorkspaceModDir             = "mods"
 	WorkspaceModShadowDirPrefix = ".mods."
+	WorkspaceModMirrorDir       = "mirror"
 	WorkspaceConfigFileName     = "workspace.spc"
 	WorkspaceLockFileName       = ".mod.cache.json"
 )
@@ -25,6 +26,11 @@ func WorkspaceModShadowPath(workspacePath string) string {
 	return path.Join(workspacePath, app_specific.WorkspaceDataDir, fmt.Sprintf("%s%s", WorkspaceModShadowDirPrefix, runtime.ExecutionID))
 }
 
+// WorkspaceModMirrorPath returns the default mod mirror directory, which mods are vendored to for offline installation
+func WorkspaceModMirrorPath(workspacePath string) string {
+	return path.Join(workspacePath, app_specific.WorkspaceDataDir, WorkspaceModMirrorDir)
+}
+
 func IsModInstallShadowPath(dirName string) bool {
 	return strings.HasPrefix(dirName, WorkspaceModShadowDirPrefix)
 }
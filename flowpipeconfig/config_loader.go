@@ -92,6 +92,13 @@ func LoadFlowpipeConfig(configPaths []string) (config *FlowpipeConfig, errorsAnd
 		return nil, error_helpers.NewErrorsAndWarning(err)
 	}
 
+	// link connections to the connections they derive their credentials from
+	err = config.linkSourceConnections()
+	if err != nil {
+		slog.Error("failed to link source connections", "error", err)
+		return nil, error_helpers.NewErrorsAndWarning(err)
+	}
+
 	return config, errorsAndWarnings
 }
 
@@ -142,21 +149,35 @@ func (f *FlowpipeConfig) importConnections() error {
 		if connectionImport.Source == nil {
 			continue
 		}
-		// import credentials then convert to connections
-		importedCredentials, err := importCredential(source, connectionNames, prefix)
-		if err != nil {
-			return err
-		}
-		for _, cred := range importedCredentials {
-			// Return error if the flowpipe already has a connection with same type and name
-			if f.PipelingConnections[cred.Name()] != nil || connections[cred.Name()] != nil {
-				return perr.BadRequestWithMessage(fmt.Sprintf("Credential with name '%s' already exists", cred.Name()))
-			}
 
-			conn, err := credential.CredentialToConnection(cred)
+		var imports []connection.PipelingConnection
+		if connectionImport.GetType() == modconfig.ConnectionImportTypeSteampipe {
+			// import credentials then convert to connections
+			importedCredentials, err := importCredential(source, connectionNames, prefix)
+			if err != nil {
+				return err
+			}
+			for _, cred := range importedCredentials {
+				conn, err := credential.CredentialToConnection(cred)
+				if err != nil {
+					return err
+				}
+				imports = append(imports, conn)
+			}
+		} else {
+			var err error
+			imports, err = importExternalConnections(connectionImport.GetType(), source, connectionNames, prefix)
 			if err != nil {
 				return err
 			}
+		}
+
+		for _, conn := range imports {
+			// Return error if the flowpipe already has a connection with same type and name - an imported connection
+			// may replace a default connection
+			if existing := f.PipelingConnections[conn.Name()]; (existing != nil && !isDefaultConnection(existing)) || connections[conn.Name()] != nil {
+				return perr.BadRequestWithMessage(fmt.Sprintf("Connection with name '%s' already exists", conn.Name()))
+			}
 			connections[conn.Name()] = conn
 		}
 	}
@@ -187,6 +208,85 @@ func (f *FlowpipeConfig) credentialsToConnection() error {
 	return nil
 }
 
+// linkSourceConnections sets the source of every connection (and aws credential) which specifies a
+// source_connection, and verifies there are no cycles
+func (f *FlowpipeConfig) linkSourceConnections() error {
+	for _, conn := range f.PipelingConnections {
+		consumer, ok := conn.(connection.SourceConnectionConsumer)
+		if !ok || consumer.GetSourceConnectionName() == nil {
+			continue
+		}
+
+		sourceName := sourceConnectionFullName(conn.GetConnectionType(), *consumer.GetSourceConnectionName())
+		source, ok := f.PipelingConnections[sourceName]
+		if !ok {
+			return perr.BadRequestWithMessage(fmt.Sprintf("source connection %s of connection %s not found", sourceName, conn.Name()))
+		}
+		if err := consumer.SetSourceConnection(source); err != nil {
+			return err
+		}
+	}
+
+	// now check for cycles
+	for _, conn := range f.PipelingConnections {
+		visited := map[string]struct{}{}
+		for current := conn; current != nil; {
+			if _, ok := visited[current.Name()]; ok {
+				return perr.BadRequestWithMessage(fmt.Sprintf("connection %s has a cyclic source_connection chain", conn.Name()))
+			}
+			visited[current.Name()] = struct{}{}
+
+			consumer, ok := current.(connection.SourceConnectionConsumer)
+			if !ok {
+				break
+			}
+			current = consumer.GetSourceConnection()
+		}
+	}
+
+	for _, cred := range f.Credentials {
+		awsCred, ok := cred.(*credential.AwsCredential)
+		if !ok || awsCred.SourceConnection == nil {
+			continue
+		}
+
+		sourceName := sourceConnectionFullName(awsCred.GetCredentialType(), *awsCred.SourceConnection)
+		source, ok := f.Credentials[sourceName]
+		if !ok {
+			return perr.BadRequestWithMessage(fmt.Sprintf("source connection %s of credential %s not found", sourceName, cred.Name()))
+		}
+		if err := awsCred.SetSourceCredential(source); err != nil {
+			return err
+		}
+	}
+
+	for _, cred := range f.Credentials {
+		awsCred, ok := cred.(*credential.AwsCredential)
+		if !ok {
+			continue
+		}
+		visited := map[string]struct{}{}
+		for current := awsCred; current != nil; current = current.GetSourceCredential() {
+			if _, ok := visited[current.Name()]; ok {
+				return perr.BadRequestWithMessage(fmt.Sprintf("credential %s has a cyclic source_connection chain", cred.Name()))
+			}
+			visited[current.Name()] = struct{}{}
+		}
+	}
+
+	return nil
+}
+
+// sourceConnectionFullName returns the full name of a source connection, which may be specified as either
+// <name>, <type>.<name> or connection.<type>.<name>
+func sourceConnectionFullName(connectionType, name string) string {
+	name = strings.TrimPrefix(name, schema.BlockTypeConnection+".")
+	if !strings.Contains(name, ".") {
+		name = connectionType + "." + name
+	}
+	return name
+}
+
 func importCredential(source *string, connectionNames []string, prefix *string) ([]credential.Credential, error) {
 	// This can't be encapsulated in CredentialImports due to crucial function the `parse` package
 	// it will result in circular dependency
@@ -387,7 +487,7 @@ func (f *FlowpipeConfig) loadFlowpipeConfigBlocks(configPath string, opts *loadC
 				continue
 			}
 
-			notifier, moreDiags := parse.DecodeNotifier(configPath, block, evalContext)
+			notifier, moreDiags := parse.DecodeNotifier(configPath, block, evalContext, fileData)
 			if len(moreDiags) > 0 {
 				diags = append(diags, moreDiags...)
 				slog.Debug("failed to decode notifier block")
@@ -433,6 +533,9 @@ func buildEvalContextWithIntegrationsOnly(configPath string, integrations map[st
 	email := make(map[string]cty.Value)
 	http := make(map[string]cty.Value)
 	teams := make(map[string]cty.Value)
+	pagerDuty := make(map[string]cty.Value)
+	opsgenie := make(map[string]cty.Value)
+	discord := make(map[string]cty.Value)
 
 	for k, v := range integrations {
 		parts := strings.Split(k, ".")
@@ -457,11 +560,17 @@ func buildEvalContextWithIntegrationsOnly(configPath string, integrations map[st
 			vars = http
 		case schema.IntegrationTypeMsTeams:
 			vars = teams
+		case schema.IntegrationTypePagerDuty:
+			vars = pagerDuty
+		case schema.IntegrationTypeOpsgenie:
+			vars = opsgenie
+		case schema.IntegrationTypeDiscord:
+			vars = discord
 		default:
 			diags = append(diags, &hcl.Diagnostic{
 				Severity: hcl.DiagError,
 				Summary:  "invalid integration type",
-				Detail:   "integration type must be one of slack, email, msteams or http",
+				Detail:   "integration type must be one of slack, email, msteams, pagerduty, opsgenie, discord or http",
 				Subject:  v.GetDeclRange(),
 			})
 			continue
@@ -495,6 +604,15 @@ func buildEvalContextWithIntegrationsOnly(configPath string, integrations map[st
 	if len(teams) > 0 {
 		integrationVariables[schema.IntegrationTypeMsTeams] = cty.ObjectVal(teams)
 	}
+	if len(pagerDuty) > 0 {
+		integrationVariables[schema.IntegrationTypePagerDuty] = cty.ObjectVal(pagerDuty)
+	}
+	if len(opsgenie) > 0 {
+		integrationVariables[schema.IntegrationTypeOpsgenie] = cty.ObjectVal(opsgenie)
+	}
+	if len(discord) > 0 {
+		integrationVariables[schema.IntegrationTypeDiscord] = cty.ObjectVal(discord)
+	}
 
 	variables["integration"] = cty.ObjectVal(integrationVariables)
 
@@ -0,0 +1,88 @@
+package flowpipeconfig
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"maps"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/turbot/pipe-fittings/connection"
+	"github.com/turbot/pipe-fittings/error_helpers"
+	"golang.org/x/sync/errgroup"
+)
+
+// maxConcurrentExpiryChecks limits the number of connections whose expiry is checked at once, as checking the
+// expiry may call the service which issued the credentials
+const maxConcurrentExpiryChecks = 10
+
+// CheckConnectionExpiry determines when the credentials of each connection expire, recording the expiry on the
+// connection so it is available in the connection's cty value. A warning is returned for each connection which has
+// expired or expires within warnWithin. Connections whose expiry cannot be determined are skipped
+func (f *FlowpipeConfig) CheckConnectionExpiry(ctx context.Context, warnWithin time.Duration) error_helpers.ErrorAndWarnings {
+	res := error_helpers.NewErrorsAndWarning(nil)
+
+	// the file watcher may replace the connections while they are checked, so check a snapshot of them
+	f.loadLock.Lock()
+	connections := maps.Clone(f.PipelingConnections)
+	f.loadLock.Unlock()
+
+	// the checks run concurrently, so the expiries are collected and recorded on the connections once all are done
+	expiries := make(map[string]time.Time)
+	var mut sync.Mutex
+
+	g, ctx := errgroup.WithContext(ctx)
+	g.SetLimit(maxConcurrentExpiryChecks)
+	for name, conn := range connections {
+		// the default connections are not configured, so their credentials are not checked
+		if isDefaultConnection(conn) {
+			continue
+		}
+
+		g.Go(func() error {
+			expiry, err := connection.ConnectionExpiry(ctx, conn)
+			if err != nil {
+				slog.Debug("unable to determine connection expiry", "connection", conn.Name(), "error", err)
+				return nil
+			}
+			if expiry == nil {
+				return nil
+			}
+
+			mut.Lock()
+			expiries[name] = *expiry
+			mut.Unlock()
+			return nil
+		})
+	}
+	// the checks never return an error
+	_ = g.Wait()
+
+	type expiringConnection struct {
+		name   string
+		expiry time.Time
+	}
+	var expiring []expiringConnection
+	for name, expiry := range expiries {
+		conn := connections[name]
+		conn.GetConnectionImpl().Expiry = &expiry
+		if time.Until(expiry) < warnWithin {
+			expiring = append(expiring, expiringConnection{name: conn.Name(), expiry: expiry})
+		}
+	}
+
+	sort.Slice(expiring, func(i, j int) bool {
+		return expiring[i].name < expiring[j].name
+	})
+	for _, e := range expiring {
+		expiry := e.expiry.UTC().Format(time.RFC3339)
+		if time.Now().After(e.expiry) {
+			res.AddWarning(fmt.Sprintf("connection %s credentials expired at %s", e.name, expiry))
+			continue
+		}
+		res.AddWarning(fmt.Sprintf("connection %s credentials expire in %d days, at %s", e.name, int(time.Until(e.expiry).Hours()/24), expiry))
+	}
+	return res
+}
@@ -0,0 +1,28 @@
+package flowpipeconfig
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestCheckConnectionExpiry_ConcurrentReload(t *testing.T) {
+	f := NewFlowpipeConfig(nil)
+
+	// the file watcher replaces the connections while they are checked - run with -race to detect unguarded access
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 100; i++ {
+			f.updateResources(NewFlowpipeConfig(nil))
+		}
+	}()
+	for i := 0; i < 100; i++ {
+		if res := f.CheckConnectionExpiry(context.Background(), time.Hour); res.Error != nil {
+			t.Fatalf("CheckConnectionExpiry() error = %v", res.Error)
+		}
+	}
+	wg.Wait()
+}
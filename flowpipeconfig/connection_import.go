@@ -0,0 +1,409 @@
+package flowpipeconfig
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclparse"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/turbot/pipe-fittings/connection"
+	"github.com/turbot/pipe-fittings/error_helpers"
+	"github.com/turbot/pipe-fittings/modconfig"
+	"github.com/turbot/pipe-fittings/parse"
+	"github.com/turbot/pipe-fittings/perr"
+	"github.com/turbot/pipe-fittings/schema"
+	"github.com/zclconf/go-cty/cty"
+	"gopkg.in/ini.v1"
+)
+
+// importedConnection is a connection read from an aws config, gcloud or terraform import source, before it is
+// decoded into a PipelingConnection
+type importedConnection struct {
+	connectionType string
+	name           string
+	attributes     map[string]cty.Value
+	declRange      hcl.Range
+}
+
+var invalidConnectionNameChars = regexp.MustCompile(`[^a-zA-Z0-9_-]`)
+
+// importExternalConnections reads the connections defined in the files of an aws config, gcloud or terraform
+// import source
+func importExternalConnections(importType string, source *string, connectionNames []string, prefix *string) ([]connection.PipelingConnection, error) {
+	filePaths, err := parse.ResolveConnectionImportSource(source)
+	if err != nil {
+		return nil, err
+	}
+
+	var imports []*importedConnection
+	for _, filePath := range filePaths {
+		var fileImports []*importedConnection
+		switch importType {
+		case modconfig.ConnectionImportTypeAwsConfig:
+			fileImports, err = readAwsConfigConnections(filePath)
+		case modconfig.ConnectionImportTypeGcloud:
+			fileImports, err = readGcloudConnections(filePath)
+		case modconfig.ConnectionImportTypeTerraform:
+			fileImports, err = readTerraformConnections(filePath)
+		default:
+			return nil, perr.BadRequestWithMessage(fmt.Sprintf("unsupported connection_import type '%s'", importType))
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		// a connection may be defined in more than one file of the source, e.g. a profile in both ~/.aws/config
+		// and ~/.aws/credentials - merge the attributes, the first file to set an attribute wins
+		for _, i := range fileImports {
+			idx := slices.IndexFunc(imports, func(existing *importedConnection) bool {
+				return existing.connectionType == i.connectionType && existing.name == i.name
+			})
+			if idx == -1 {
+				imports = append(imports, i)
+				continue
+			}
+			for k, v := range i.attributes {
+				if _, ok := imports[idx].attributes[k]; !ok {
+					imports[idx].attributes[k] = v
+				}
+			}
+		}
+	}
+
+	var conns []connection.PipelingConnection
+	for _, i := range imports {
+		if len(connectionNames) > 0 && !isRequiredConnection(i.name, connectionNames) {
+			continue
+		}
+
+		connectionName := invalidConnectionNameChars.ReplaceAllString(i.name, "_")
+		if prefix != nil && *prefix != "" {
+			connectionName = fmt.Sprintf("%s%s", *prefix, connectionName)
+		}
+
+		conn, err := i.toConnection(connectionName)
+		if err != nil {
+			return nil, err
+		}
+		conns = append(conns, conn)
+	}
+	return conns, nil
+}
+
+// toConnection writes the imported attributes as a connection block and decodes it, so imported connections are
+// decoded and validated exactly as if they had been defined in a config file
+func (i *importedConnection) toConnection(connectionName string) (connection.PipelingConnection, error) {
+	file := hclwrite.NewEmptyFile()
+	block := file.Body().AppendNewBlock(schema.BlockTypeConnection, []string{i.connectionType, connectionName})
+	names := make([]string, 0, len(i.attributes))
+	for k := range i.attributes {
+		names = append(names, k)
+	}
+	slices.Sort(names)
+	for _, k := range names {
+		block.Body().SetAttributeValue(k, i.attributes[k])
+	}
+
+	syntaxFile, diags := hclsyntax.ParseConfig(file.Bytes(), i.declRange.Filename, i.declRange.Start)
+	if diags.HasErrors() {
+		return nil, error_helpers.HclDiagsToError("Flowpipe Config", diags)
+	}
+	content, diags := syntaxFile.Body.Content(&hcl.BodySchema{
+		Blocks: []hcl.BlockHeaderSchema{{Type: schema.BlockTypeConnection, LabelNames: []string{"type", "name"}}},
+	})
+	if diags.HasErrors() {
+		return nil, error_helpers.HclDiagsToError("Flowpipe Config", diags)
+	}
+
+	conn, diags := parse.DecodePipelingConnection(filepath.Dir(i.declRange.Filename), content.Blocks[0])
+	if diags.HasErrors() {
+		return nil, error_helpers.HclDiagsToError("Flowpipe Config", diags)
+	}
+	return conn, nil
+}
+
+// isDefaultConnection returns whether the connection is one of the default connections created for every type
+func isDefaultConnection(conn connection.PipelingConnection) bool {
+	impl := conn.GetConnectionImpl()
+	return impl.ShortName == "default" && impl.DeclRange.Filename == ""
+}
+
+// readAwsConfigConnections returns an aws connection for each profile in an aws config or shared credentials file
+func readAwsConfigConnections(filePath string) ([]*importedConnection, error) {
+	cfg, err := ini.Load(filePath)
+	if err != nil {
+		return nil, perr.BadRequestWithMessage(fmt.Sprintf("failed to load aws config file %s: %s", filePath, err))
+	}
+
+	var res []*importedConnection
+	for _, section := range cfg.Sections() {
+		// profiles are named [profile <name>] in the config file and [<name>] in the credentials file, other
+		// sections such as [sso-session <name>] are not profiles
+		profile := section.Name()
+		if profile == ini.DefaultSection {
+			continue
+		}
+		if strings.HasPrefix(profile, "profile ") {
+			profile = strings.TrimSpace(strings.TrimPrefix(profile, "profile "))
+		} else if strings.Contains(profile, " ") {
+			continue
+		}
+
+		attributes := map[string]cty.Value{
+			"profile": cty.StringVal(profile),
+		}
+		if section.HasKey("region") {
+			attributes["region"] = cty.StringVal(section.Key("region").String())
+		}
+
+		res = append(res, &importedConnection{
+			connectionType: connection.AwsConnectionType,
+			name:           profile,
+			attributes:     attributes,
+			declRange:      hcl.Range{Filename: filePath, Start: hcl.InitialPos, End: hcl.InitialPos},
+		})
+	}
+	return res, nil
+}
+
+// readGcloudConnections returns a gcp connection for a gcloud configuration file - gcloud stores each named
+// configuration in <config dir>/configurations/config_<name>
+func readGcloudConnections(filePath string) ([]*importedConnection, error) {
+	configName, ok := strings.CutPrefix(filepath.Base(filePath), "config_")
+	if !ok {
+		return nil, nil
+	}
+
+	cfg, err := ini.Load(filePath)
+	if err != nil {
+		return nil, perr.BadRequestWithMessage(fmt.Sprintf("failed to load gcloud configuration %s: %s", filePath, err))
+	}
+
+	attributes := map[string]cty.Value{}
+	// use the application default credentials gcloud stores for the configuration account, if there are any -
+	// otherwise the connection falls back to the application default credentials
+	if account := cfg.Section("core").Key("account").String(); account != "" {
+		credentialsPath := filepath.Join(filepath.Dir(filepath.Dir(filePath)), "legacy_credentials", account, "adc.json")
+		if _, err := os.Stat(credentialsPath); err == nil {
+			attributes["credentials"] = cty.StringVal(credentialsPath)
+		}
+	}
+
+	return []*importedConnection{
+		{
+			connectionType: connection.GcpConnectionType,
+			name:           configName,
+			attributes:     attributes,
+			declRange:      hcl.Range{Filename: filePath, Start: hcl.InitialPos, End: hcl.InitialPos},
+		},
+	}, nil
+}
+
+// terraformProvider describes how the configuration of a terraform provider maps to a connection
+type terraformProvider struct {
+	connectionType string
+	// attributes maps the provider attribute names to the connection attribute names
+	attributes map[string]string
+	// blocks maps the nested block types to the mapping of their attribute names
+	blocks map[string]map[string]string
+	// transform, if set, modifies the connection attributes once they have been mapped
+	transform func(attributes map[string]cty.Value)
+}
+
+var terraformProviders = map[string]terraformProvider{
+	"aws": {
+		connectionType: connection.AwsConnectionType,
+		attributes: map[string]string{
+			"access_key": "access_key",
+			"secret_key": "secret_key",
+			"token":      "session_token",
+			"profile":    "profile",
+			"region":     "region",
+		},
+		blocks: map[string]map[string]string{
+			"assume_role": {
+				"role_arn":     "role_arn",
+				"external_id":  "external_id",
+				"session_name": "session_name",
+			},
+		},
+	},
+	"google": {
+		connectionType: connection.GcpConnectionType,
+		attributes: map[string]string{
+			"credentials":  "credentials",
+			"access_token": "access_token",
+		},
+	},
+	"azurerm": {
+		connectionType: connection.AzureConnectionType,
+		attributes: map[string]string{
+			"client_id":     "client_id",
+			"client_secret": "client_secret",
+			"tenant_id":     "tenant_id",
+			"environment":   "environment",
+		},
+		transform: func(attributes map[string]cty.Value) {
+			// the provider uses short names for the azure environments
+			environments := map[string]string{
+				"public":       "AZUREPUBLICCLOUD",
+				"usgovernment": "AZUREUSGOVERNMENTCLOUD",
+				"china":        "AZURECHINACLOUD",
+				"german":       "AZUREGERMANCLOUD",
+			}
+			if env, ok := attributes["environment"]; ok && env.Type() == cty.String {
+				if environment, ok := environments[strings.ToLower(env.AsString())]; ok {
+					attributes["environment"] = cty.StringVal(environment)
+				}
+			}
+		},
+	},
+	"github": {
+		connectionType: connection.GithubConnectionType,
+		attributes:     map[string]string{"token": "token"},
+	},
+	"gitlab": {
+		connectionType: connection.GitLabConnectionType,
+		attributes:     map[string]string{"token": "token"},
+	},
+	"vault": {
+		connectionType: connection.VaultConnectionType,
+		attributes: map[string]string{
+			"address": "address",
+			"token":   "token",
+		},
+	},
+	"datadog": {
+		connectionType: connection.DatadogConnectionType,
+		attributes: map[string]string{
+			"api_key": "api_key",
+			"app_key": "app_key",
+			"api_url": "api_url",
+		},
+	},
+	"okta": {
+		connectionType: connection.OktaConnectionType,
+		attributes: map[string]string{
+			"api_token": "token",
+			"org_name":  "org_name",
+			"base_url":  "base_url",
+		},
+		transform: func(attributes map[string]cty.Value) {
+			// the provider configures the org name and base url separately
+			orgName, ok := attributes["org_name"]
+			delete(attributes, "org_name")
+			baseUrl := cty.StringVal("okta.com")
+			if v, ok := attributes["base_url"]; ok {
+				baseUrl = v
+				delete(attributes, "base_url")
+			}
+			if ok && orgName.Type() == cty.String && baseUrl.Type() == cty.String {
+				attributes["domain"] = cty.StringVal(fmt.Sprintf("https://%s.%s", orgName.AsString(), baseUrl.AsString()))
+			}
+		},
+	},
+}
+
+// readTerraformConnections returns a connection for each provider block in a terraform (or OpenTofu) file whose
+// provider maps to a connection type. Providers are named by their alias, or 'default' if they have no alias
+func readTerraformConnections(filePath string) ([]*importedConnection, error) {
+	parser := hclparse.NewParser()
+	var file *hcl.File
+	var diags hcl.Diagnostics
+	switch {
+	case strings.HasSuffix(filePath, ".tf.json"):
+		file, diags = parser.ParseJSONFile(filePath)
+	case strings.HasSuffix(filePath, ".tf"):
+		file, diags = parser.ParseHCLFile(filePath)
+	default:
+		return nil, nil
+	}
+	if diags.HasErrors() {
+		return nil, error_helpers.HclDiagsToError("Terraform Config", diags)
+	}
+
+	content, _, diags := file.Body.PartialContent(&hcl.BodySchema{
+		Blocks: []hcl.BlockHeaderSchema{{Type: "provider", LabelNames: []string{"name"}}},
+	})
+	if diags.HasErrors() {
+		return nil, error_helpers.HclDiagsToError("Terraform Config", diags)
+	}
+
+	var res []*importedConnection
+	for _, block := range content.Blocks {
+		provider, ok := terraformProviders[block.Labels[0]]
+		if !ok {
+			slog.Debug("skipping terraform provider with no matching connection type", "provider", block.Labels[0], "file", filePath)
+			continue
+		}
+
+		bodySchema := &hcl.BodySchema{
+			Attributes: []hcl.AttributeSchema{{Name: "alias"}},
+		}
+		for name := range provider.attributes {
+			bodySchema.Attributes = append(bodySchema.Attributes, hcl.AttributeSchema{Name: name})
+		}
+		for blockType := range provider.blocks {
+			bodySchema.Blocks = append(bodySchema.Blocks, hcl.BlockHeaderSchema{Type: blockType})
+		}
+		providerContent, _, diags := block.Body.PartialContent(bodySchema)
+		if diags.HasErrors() {
+			return nil, error_helpers.HclDiagsToError("Terraform Config", diags)
+		}
+
+		name := "default"
+		if alias, ok := providerContent.Attributes["alias"]; ok {
+			if v, ok := terraformAttributeValue(alias); ok && v.Type() == cty.String {
+				name = v.AsString()
+			}
+		}
+
+		attributes := map[string]cty.Value{}
+		addTerraformAttributes(providerContent.Attributes, provider.attributes, attributes)
+		for _, childBlock := range providerContent.Blocks {
+			childAttributes, _ := childBlock.Body.JustAttributes()
+			addTerraformAttributes(childAttributes, provider.blocks[childBlock.Type], attributes)
+		}
+		if provider.transform != nil {
+			provider.transform(attributes)
+		}
+
+		res = append(res, &importedConnection{
+			connectionType: provider.connectionType,
+			name:           name,
+			attributes:     attributes,
+			declRange:      block.DefRange,
+		})
+	}
+	return res, nil
+}
+
+func addTerraformAttributes(attrs hcl.Attributes, names map[string]string, target map[string]cty.Value) {
+	for name, attr := range attrs {
+		connectionAttribute, ok := names[name]
+		if !ok {
+			continue
+		}
+		if v, ok := terraformAttributeValue(attr); ok {
+			target[connectionAttribute] = v
+		}
+	}
+}
+
+// terraformAttributeValue returns the value of a provider attribute - attributes which refer to terraform
+// variables, locals or resources cannot be evaluated and are skipped
+func terraformAttributeValue(attr *hcl.Attribute) (cty.Value, bool) {
+	v, diags := attr.Expr.Value(nil)
+	if diags.HasErrors() || !v.IsWhollyKnown() || v.IsNull() {
+		slog.Warn("skipping terraform provider attribute which cannot be evaluated", "attribute", attr.Name, "range", attr.Range.String())
+		return cty.NilVal, false
+	}
+	return v, true
+}
@@ -2,6 +2,7 @@ package flowpipeconfig
 
 import (
 	"context"
+	"fmt"
 	"log/slog"
 	"sync"
 
@@ -13,6 +14,7 @@ import (
 	"github.com/turbot/pipe-fittings/connection"
 	"github.com/turbot/pipe-fittings/credential"
 	"github.com/turbot/pipe-fittings/modconfig"
+	"github.com/turbot/pipe-fittings/perr"
 )
 
 type FlowpipeConfig struct {
@@ -25,6 +27,9 @@ type FlowpipeConfig struct {
 	ConnectionImports   map[string]modconfig.ConnectionImport
 	PipelingConnections map[string]connection.PipelingConnection
 
+	// ConnectionResolver caches resolved connections - it is invalidated when the config is reloaded
+	ConnectionResolver *connection.ConnectionResolver
+
 	watcher                 *filewatcher.FileWatcher
 	fileWatcherErrorHandler func(context.Context, error)
 
@@ -48,6 +53,19 @@ func (f *FlowpipeConfig) updateResources(other *FlowpipeConfig) {
 
 }
 
+// ResolveConnection resolves the named connection, using the cached result if it has not expired
+func (f *FlowpipeConfig) ResolveConnection(ctx context.Context, name string) (connection.PipelingConnection, error) {
+	f.loadLock.Lock()
+	conn, ok := f.PipelingConnections[name]
+	f.loadLock.Unlock()
+
+	if !ok {
+		return nil, perr.NotFoundWithMessage(fmt.Sprintf("connection %s not found", name))
+	}
+
+	return f.ConnectionResolver.Resolve(ctx, conn)
+}
+
 func (f *FlowpipeConfig) Equals(other *FlowpipeConfig) bool {
 	if len(f.Credentials) != len(other.Credentials) {
 		return false
@@ -181,6 +199,7 @@ func (f *FlowpipeConfig) handleFileWatcherEvent(ctx context.Context) {
 
 	if !newFpConfig.Equals(f) {
 		f.updateResources(newFpConfig)
+		f.ConnectionResolver.InvalidateAll()
 
 		// call hook
 		if f.OnFileWatcherEvent != nil {
@@ -223,6 +242,7 @@ func NewFlowpipeConfig(configPaths []string) *FlowpipeConfig {
 		ConfigPaths:         configPaths,
 		PipelingConnections: defaultPipelingConnections,
 		ConnectionImports:   make(map[string]modconfig.ConnectionImport),
+		ConnectionResolver:  connection.NewConnectionResolver(),
 		loadLock:            &sync.Mutex{},
 	}
 
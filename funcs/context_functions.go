@@ -133,7 +133,17 @@ func ContextFunctions(baseDir string) map[string]function.Function {
 		"is_error":         IsErrorFunc,
 		"error_message":    ErrorMessageFunc,
 		"env":              EnvFunc,
+		"secret":           SecretFunc,
 	}
 
 	return ctxFuncs
 }
+
+// ConnectionFunctions returns the functions used when evaluating connection and credential blocks. These are the
+// context functions, except that sensitive registers the value with the sanitizer - connection and credential
+// attributes are decoded into go values, which cannot carry the sensitive mark
+func ConnectionFunctions(baseDir string) map[string]function.Function {
+	ctxFuncs := ContextFunctions(baseDir)
+	ctxFuncs["sensitive"] = ConnectionSensitiveFunc
+	return ctxFuncs
+}
@@ -0,0 +1,28 @@
+package funcs
+
+import (
+	"github.com/turbot/pipe-fittings/connection"
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/function"
+)
+
+// secret: Given a secret reference such as vault://kv/path#field, secret returns a placeholder which is replaced
+// with the secret when the connection using it is resolved
+var SecretFunc = function.New(&function.Spec{
+	Description: `Reference a secret held in a secret manager`,
+	Params: []function.Parameter{
+		{
+			Name: "ref",
+			Type: cty.String,
+		},
+	},
+	Type: function.StaticReturnType(cty.String),
+	Impl: func(args []cty.Value, retType cty.Type) (cty.Value, error) {
+		value, err := connection.NewSecretReferenceValue(args[0].AsString())
+		if err != nil {
+			return cty.NilVal, function.NewArgError(0, err)
+		}
+
+		return cty.StringVal(value), nil
+	},
+})
@@ -0,0 +1,32 @@
+package funcs
+
+import (
+	"github.com/turbot/pipe-fittings/sanitize"
+	"github.com/turbot/terraform-components/lang/marks"
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/function"
+)
+
+// sensitive, in a connection or credential block: the strings within the value are registered with the sanitizer, so
+// they are redacted wherever they appear, and the value is returned unmarked so it can be decoded
+var ConnectionSensitiveFunc = function.New(&function.Spec{
+	Description: `Treats the given value as sensitive, so it is redacted from all output`,
+	Params: []function.Parameter{
+		{
+			Name:             "value",
+			Type:             cty.DynamicPseudoType,
+			AllowUnknown:     true,
+			AllowNull:        true,
+			AllowMarked:      true,
+			AllowDynamicType: true,
+		},
+	},
+	Type: func(args []cty.Value) (cty.Type, error) {
+		return args[0].Type(), nil
+	},
+	Impl: func(args []cty.Value, retType cty.Type) (cty.Value, error) {
+		value, _ := args[0].UnmarkDeep()
+		sanitize.Instance.AddSensitiveValues(value.Mark(marks.Sensitive))
+		return value, nil
+	},
+})
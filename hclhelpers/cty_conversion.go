@@ -710,6 +710,11 @@ func CtyToGoMapBool(v cty.Value) (map[string]bool, error) {
 }
 
 func CtyToGo(v cty.Value) (val interface{}, err error) {
+	// the marks, e.g. from the sensitive() function, must be removed to convert the value
+	if v.ContainsMarked() {
+		v, _ = v.UnmarkDeep()
+	}
+
 	if v.IsNull() {
 		return nil, nil
 	}
@@ -1,6 +1,8 @@
 package hclhelpers
 
 import (
+	"fmt"
+
 	"github.com/hashicorp/hcl/v2"
 	"github.com/hashicorp/hcl/v2/hclsyntax"
 )
@@ -16,3 +18,14 @@ func ExprIsNativeQuotedString(expr hcl.Expression) bool {
 	_, ok := expr.(*hclsyntax.TemplateExpr)
 	return ok
 }
+
+// ExpressionSource returns the source text of the given expression, given the source of the file it was parsed from.
+// This allows an expression which can only be evaluated at runtime to be serialised and re-parsed later.
+func ExpressionSource(expr hcl.Expression, src []byte) (string, error) {
+	rng := expr.Range()
+	if rng.Start.Byte < 0 || rng.End.Byte > len(src) || rng.Start.Byte > rng.End.Byte {
+		return "", fmt.Errorf("expression range %s is outside the bounds of the source file", rng.String())
+	}
+
+	return string(rng.SliceBytes(src)), nil
+}
@@ -9,6 +9,7 @@ import (
 	"github.com/turbot/pipe-fittings/cty_helpers"
 	"github.com/turbot/pipe-fittings/hclhelpers"
 	"github.com/turbot/pipe-fittings/perr"
+	"github.com/turbot/pipe-fittings/sanitize"
 	"github.com/turbot/pipe-fittings/schema"
 	"github.com/turbot/pipe-fittings/utils"
 	"github.com/zclconf/go-cty/cty"
@@ -56,6 +57,22 @@ func (i *IntegrationImpl) GetIntegrationImpl() *IntegrationImpl {
 	return i
 }
 
+func init() {
+	// redact the fields of all integration types which are tagged as sensitive
+	sanitize.Instance.RegisterSensitiveFields(
+		&SlackIntegration{},
+		&EmailIntegration{},
+		&HttpIntegration{},
+		&MsTeamsIntegration{},
+		&PagerDutyIntegration{},
+		&OpsgenieIntegration{},
+		&DiscordIntegration{},
+	)
+}
+
+// DefaultIntegrations returns the integrations which are available without being declared. Only integration types
+// which need no configuration have a default - slack, email, msteams, pagerduty, opsgenie and discord integrations
+// all require credentials (a token, routing key, API key or webhook URL) so must be declared explicitly.
 func DefaultIntegrations() (map[string]Integration, error) {
 	integrations := make(map[string]Integration)
 
@@ -83,8 +100,8 @@ type SlackIntegration struct {
 	Type string `json:"type" cty:"type" hcl:"type,label"`
 
 	// slack
-	Token         *string `json:"token,omitempty" cty:"token" hcl:"token,optional"`
-	SigningSecret *string `json:"signing_secret,omitempty" cty:"signing_secret" hcl:"signing_secret,optional"`
+	Token         *string `json:"token,omitempty" cty:"token" hcl:"token,optional" sensitive:"true"`
+	SigningSecret *string `json:"signing_secret,omitempty" cty:"signing_secret" hcl:"signing_secret,optional" sensitive:"true"`
 	WebhookUrl    *string `json:"webhook_url,omitempty" cty:"webhook_url" hcl:"webhook_url,optional"`
 	Channel       *string `json:"channel,omitempty" cty:"channel" hcl:"channel,optional"`
 }
@@ -275,7 +292,7 @@ type EmailIntegration struct {
 	SmtpPort     *int    `json:"smtp_port,omitempty" cty:"smtp_port" hcl:"smtp_port,optional"`
 	SmtpsPort    *int    `json:"smtps_port,omitempty" cty:"smtps_port" hcl:"smtps_port,optional"`
 	SmtpUsername *string `json:"smtp_username,omitempty" cty:"smtp_username" hcl:"smtp_username,optional"`
-	SmtpPassword *string `json:"smtp_password,omitempty" cty:"smtp_password" hcl:"smtp_password,optional"`
+	SmtpPassword *string `json:"smtp_password,omitempty" cty:"smtp_password" hcl:"smtp_password,optional" sensitive:"true"`
 
 	From    *string  `json:"from,omitempty" cty:"from" hcl:"from"`
 	To      []string `json:"to,omitempty" cty:"to" hcl:"to,optional"`
@@ -604,6 +621,12 @@ func integrationFromCtyValue(val cty.Value) (Integration, error) {
 		return HttpIntegrationFromCtyValue(val)
 	case schema.IntegrationTypeMsTeams:
 		return MsTeamsIntegrationFromCtyValue(val)
+	case schema.IntegrationTypePagerDuty:
+		return PagerDutyIntegrationFromCtyValue(val)
+	case schema.IntegrationTypeOpsgenie:
+		return OpsgenieIntegrationFromCtyValue(val)
+	case schema.IntegrationTypeDiscord:
+		return DiscordIntegrationFromCtyValue(val)
 	}
 	return nil, perr.BadRequestWithMessage(fmt.Sprintf("Unsupported integration type: %s", integrationType))
 }
@@ -892,6 +915,21 @@ func NewIntegrationFromBlock(block *hcl.Block) Integration {
 			Type:            integrationType,
 			IntegrationName: integrationFullName,
 		}
+	case schema.IntegrationTypePagerDuty:
+		return &PagerDutyIntegration{
+			HclResourceImpl: hclResourceImpl,
+			Type:            integrationType,
+		}
+	case schema.IntegrationTypeOpsgenie:
+		return &OpsgenieIntegration{
+			HclResourceImpl: hclResourceImpl,
+			Type:            integrationType,
+		}
+	case schema.IntegrationTypeDiscord:
+		return &DiscordIntegration{
+			HclResourceImpl: hclResourceImpl,
+			Type:            integrationType,
+		}
 	}
 
 	return nil
@@ -1108,3 +1146,576 @@ func (i *MsTeamsIntegration) Validate() hcl.Diagnostics {
 
 	return diags
 }
+
+type PagerDutyIntegration struct {
+	HclResourceImpl          `json:"-"`
+	ResourceWithMetadataImpl `json:"-"`
+	IntegrationImpl          `json:"-"`
+
+	Type string `json:"type" cty:"type" hcl:"type,label"`
+
+	// pagerduty
+	RoutingKey *string `json:"routing_key,omitempty" cty:"routing_key" hcl:"routing_key,optional" sensitive:"true"`
+	Severity   *string `json:"severity,omitempty" cty:"severity" hcl:"severity,optional"`
+}
+
+func (i *PagerDutyIntegration) Equals(other Integration) bool {
+	if i == nil && helpers.IsNil(other) {
+		return true
+	}
+
+	if i == nil && !helpers.IsNil(other) || i != nil && helpers.IsNil(other) {
+		return false
+	}
+
+	otherPagerDuty, ok := other.(*PagerDutyIntegration)
+	if !ok {
+		return false
+	}
+
+	return i.FileName == otherPagerDuty.FileName &&
+		i.StartLineNumber == otherPagerDuty.StartLineNumber &&
+		i.EndLineNumber == otherPagerDuty.EndLineNumber &&
+		utils.PtrEqual(i.RoutingKey, otherPagerDuty.RoutingKey) &&
+		utils.PtrEqual(i.Severity, otherPagerDuty.Severity)
+}
+
+func (i *PagerDutyIntegration) CtyValue() (cty.Value, error) {
+	iCty, err := cty_helpers.GetCtyValue(i)
+	if err != nil {
+		return cty.NilVal, err
+	}
+
+	valueMap := iCty.AsValueMap()
+	valueMap["full_name"] = cty.StringVal(i.FullName)
+	valueMap["short_name"] = cty.StringVal(i.ShortName)
+	valueMap["unqualified_name"] = cty.StringVal(i.UnqualifiedName)
+
+	if i.Title != nil {
+		valueMap["title"] = cty.StringVal(*i.Title)
+	}
+
+	if i.Description != nil {
+		valueMap["description"] = cty.StringVal(*i.Description)
+	}
+
+	return cty.ObjectVal(valueMap), nil
+}
+
+func (i *PagerDutyIntegration) MapInterface() (map[string]interface{}, error) {
+	res := make(map[string]interface{})
+	res["type"] = i.Type
+	if i.RoutingKey != nil {
+		res["routing_key"] = *i.RoutingKey
+	}
+	if i.Severity != nil {
+		res["severity"] = *i.Severity
+	}
+
+	res["full_name"] = i.FullName
+	res["short_name"] = i.ShortName
+	res["unqualified_name"] = i.UnqualifiedName
+
+	if i.Title != nil {
+		res["title"] = *i.Title
+	}
+	if i.Description != nil {
+		res["description"] = *i.Description
+	}
+
+	return res, nil
+}
+
+func (i *PagerDutyIntegration) GetIntegrationType() string {
+	return i.Type
+}
+
+func (i *PagerDutyIntegration) Validate() hcl.Diagnostics {
+	diags := hcl.Diagnostics{}
+
+	if i.RoutingKey == nil || *i.RoutingKey == "" {
+		diags = append(diags, &hcl.Diagnostic{
+			Severity: hcl.DiagError,
+			Summary:  "Attribute " + schema.AttributeTypeRoutingKey + " must be defined: " + i.Name(),
+			Subject:  &i.DeclRange,
+		})
+	}
+
+	if i.Severity != nil && !constants.IsValidPagerDutySeverity(*i.Severity) {
+		diags = append(diags, &hcl.Diagnostic{
+			Severity: hcl.DiagError,
+			Summary:  "Attribute " + schema.AttributeTypeSeverity + " specified with invalid value " + *i.Severity + ": " + i.Name(),
+			Subject:  &i.DeclRange,
+		})
+	}
+
+	return diags
+}
+
+func (i *PagerDutyIntegration) SetAttributes(hclAttributes hcl.Attributes, evalContext *hcl.EvalContext) hcl.Diagnostics {
+	var diags hcl.Diagnostics
+
+	for name, attr := range hclAttributes {
+		switch name {
+		case schema.AttributeTypeRoutingKey:
+			routingKey, moreDiags := hclhelpers.AttributeToString(attr, evalContext, true)
+			if len(moreDiags) > 0 {
+				diags = append(diags, moreDiags...)
+				continue
+			}
+			i.RoutingKey = routingKey
+		case schema.AttributeTypeSeverity:
+			severity, moreDiags := hclhelpers.AttributeToString(attr, evalContext, false)
+			if len(moreDiags) > 0 {
+				diags = append(diags, moreDiags...)
+				continue
+			}
+			i.Severity = severity
+		default:
+			diags = append(diags, &hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "Unsupported attribute for PagerDuty Integration: " + attr.Name,
+				Subject:  &attr.Range,
+			})
+		}
+	}
+
+	return diags
+}
+
+func PagerDutyIntegrationFromCtyValue(val cty.Value) (*PagerDutyIntegration, error) {
+	hclResourceImpl := hclResourceImplFromVal(val)
+	i := &PagerDutyIntegration{
+		HclResourceImpl: hclResourceImpl,
+	}
+	i.Type = val.GetAttr("type").AsString()
+
+	valMap := val.AsValueMap()
+
+	routingKey := valMap["routing_key"]
+	severity := valMap["severity"]
+
+	if !routingKey.IsNull() {
+		routingKeyStr := routingKey.AsString()
+		i.RoutingKey = &routingKeyStr
+	}
+
+	if !severity.IsNull() {
+		severityStr := severity.AsString()
+		i.Severity = &severityStr
+	}
+
+	return i, nil
+}
+
+type OpsgenieIntegration struct {
+	HclResourceImpl          `json:"-"`
+	ResourceWithMetadataImpl `json:"-"`
+	IntegrationImpl          `json:"-"`
+
+	Type string `json:"type" cty:"type" hcl:"type,label"`
+
+	// opsgenie
+	AlertApiKey *string `json:"alert_api_key,omitempty" cty:"alert_api_key" hcl:"alert_api_key,optional" sensitive:"true"`
+	ApiUrl      *string `json:"api_url,omitempty" cty:"api_url" hcl:"api_url,optional"`
+	Priority    *string `json:"priority,omitempty" cty:"priority" hcl:"priority,optional"`
+	// default responders (team or user names)
+	To []string `json:"to,omitempty" cty:"to" hcl:"to,optional"`
+}
+
+func (i *OpsgenieIntegration) Equals(other Integration) bool {
+	if i == nil && helpers.IsNil(other) {
+		return true
+	}
+
+	if i == nil && !helpers.IsNil(other) || i != nil && helpers.IsNil(other) {
+		return false
+	}
+
+	otherOpsgenie, ok := other.(*OpsgenieIntegration)
+	if !ok {
+		return false
+	}
+
+	return i.FileName == otherOpsgenie.FileName &&
+		i.StartLineNumber == otherOpsgenie.StartLineNumber &&
+		i.EndLineNumber == otherOpsgenie.EndLineNumber &&
+		utils.PtrEqual(i.AlertApiKey, otherOpsgenie.AlertApiKey) &&
+		utils.PtrEqual(i.ApiUrl, otherOpsgenie.ApiUrl) &&
+		utils.PtrEqual(i.Priority, otherOpsgenie.Priority) &&
+		helpers.StringSliceEqualIgnoreOrder(i.To, otherOpsgenie.To)
+}
+
+func (i *OpsgenieIntegration) CtyValue() (cty.Value, error) {
+	iCty, err := cty_helpers.GetCtyValue(i)
+	if err != nil {
+		return cty.NilVal, err
+	}
+
+	valueMap := iCty.AsValueMap()
+	valueMap["full_name"] = cty.StringVal(i.FullName)
+	valueMap["short_name"] = cty.StringVal(i.ShortName)
+	valueMap["unqualified_name"] = cty.StringVal(i.UnqualifiedName)
+
+	if i.Title != nil {
+		valueMap["title"] = cty.StringVal(*i.Title)
+	}
+
+	if i.Description != nil {
+		valueMap["description"] = cty.StringVal(*i.Description)
+	}
+
+	return cty.ObjectVal(valueMap), nil
+}
+
+func (i *OpsgenieIntegration) MapInterface() (map[string]interface{}, error) {
+	res := make(map[string]interface{})
+	res["type"] = i.Type
+	if i.AlertApiKey != nil {
+		res["alert_api_key"] = *i.AlertApiKey
+	}
+	if i.ApiUrl != nil {
+		res["api_url"] = *i.ApiUrl
+	}
+	if i.Priority != nil {
+		res["priority"] = *i.Priority
+	}
+	if len(i.To) > 0 {
+		res["to"] = i.To
+	}
+
+	res["full_name"] = i.FullName
+	res["short_name"] = i.ShortName
+	res["unqualified_name"] = i.UnqualifiedName
+
+	if i.Title != nil {
+		res["title"] = *i.Title
+	}
+	if i.Description != nil {
+		res["description"] = *i.Description
+	}
+
+	return res, nil
+}
+
+func (i *OpsgenieIntegration) GetIntegrationType() string {
+	return i.Type
+}
+
+func (i *OpsgenieIntegration) Validate() hcl.Diagnostics {
+	diags := hcl.Diagnostics{}
+
+	if i.AlertApiKey == nil || *i.AlertApiKey == "" {
+		diags = append(diags, &hcl.Diagnostic{
+			Severity: hcl.DiagError,
+			Summary:  "Attribute " + schema.AttributeTypeAlertApiKey + " must be defined: " + i.Name(),
+			Subject:  &i.DeclRange,
+		})
+	}
+
+	if i.Priority != nil && !constants.IsValidOpsgeniePriority(*i.Priority) {
+		diags = append(diags, &hcl.Diagnostic{
+			Severity: hcl.DiagError,
+			Summary:  "Attribute " + schema.AttributeTypePriority + " specified with invalid value " + *i.Priority + ": " + i.Name(),
+			Subject:  &i.DeclRange,
+		})
+	}
+
+	return diags
+}
+
+func (i *OpsgenieIntegration) SetAttributes(hclAttributes hcl.Attributes, evalContext *hcl.EvalContext) hcl.Diagnostics {
+	var diags hcl.Diagnostics
+
+	for name, attr := range hclAttributes {
+		switch name {
+		case schema.AttributeTypeAlertApiKey:
+			alertApiKey, moreDiags := hclhelpers.AttributeToString(attr, evalContext, true)
+			if len(moreDiags) > 0 {
+				diags = append(diags, moreDiags...)
+				continue
+			}
+			i.AlertApiKey = alertApiKey
+		case schema.AttributeTypeApiUrl:
+			apiUrl, moreDiags := hclhelpers.AttributeToString(attr, evalContext, false)
+			if len(moreDiags) > 0 {
+				diags = append(diags, moreDiags...)
+				continue
+			}
+			i.ApiUrl = apiUrl
+		case schema.AttributeTypePriority:
+			priority, moreDiags := hclhelpers.AttributeToString(attr, evalContext, false)
+			if len(moreDiags) > 0 {
+				diags = append(diags, moreDiags...)
+				continue
+			}
+			i.Priority = priority
+		case schema.AttributeTypeTo:
+			ctyVal, moreDiags := attr.Expr.Value(evalContext)
+			if len(moreDiags) > 0 {
+				diags = append(diags, moreDiags...)
+				continue
+			}
+
+			var err error
+			i.To, err = hclhelpers.CtyToGoStringSlice(ctyVal, ctyVal.Type())
+			if err != nil {
+				diags = append(diags, &hcl.Diagnostic{
+					Severity: hcl.DiagError,
+					Summary:  "Unable to parse " + attr.Name + " attribute as string slice",
+					Detail:   err.Error(),
+					Subject:  &attr.Range,
+				})
+				continue
+			}
+		default:
+			diags = append(diags, &hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "Unsupported attribute for Opsgenie Integration: " + attr.Name,
+				Subject:  &attr.Range,
+			})
+		}
+	}
+
+	return diags
+}
+
+func OpsgenieIntegrationFromCtyValue(val cty.Value) (*OpsgenieIntegration, error) {
+	hclResourceImpl := hclResourceImplFromVal(val)
+	i := &OpsgenieIntegration{
+		HclResourceImpl: hclResourceImpl,
+	}
+	i.Type = val.GetAttr("type").AsString()
+
+	valMap := val.AsValueMap()
+
+	alertApiKey := valMap["alert_api_key"]
+	apiUrl := valMap["api_url"]
+	priority := valMap["priority"]
+	to := valMap["to"]
+
+	if !alertApiKey.IsNull() {
+		alertApiKeyStr := alertApiKey.AsString()
+		i.AlertApiKey = &alertApiKeyStr
+	}
+
+	if !apiUrl.IsNull() {
+		apiUrlStr := apiUrl.AsString()
+		i.ApiUrl = &apiUrlStr
+	}
+
+	if !priority.IsNull() {
+		priorityStr := priority.AsString()
+		i.Priority = &priorityStr
+	}
+
+	if !to.IsNull() {
+		var err error
+		i.To, err = hclhelpers.CtyToGoStringSlice(to, to.Type())
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return i, nil
+}
+
+type DiscordIntegration struct {
+	HclResourceImpl          `json:"-"`
+	ResourceWithMetadataImpl `json:"-"`
+	IntegrationImpl          `json:"-"`
+
+	Type string `json:"type" cty:"type" hcl:"type,label"`
+
+	// discord
+	Token      *string `json:"token,omitempty" cty:"token" hcl:"token,optional" sensitive:"true"`
+	WebhookUrl *string `json:"webhook_url,omitempty" cty:"webhook_url" hcl:"webhook_url,optional"`
+	Channel    *string `json:"channel,omitempty" cty:"channel" hcl:"channel,optional"`
+}
+
+func (i *DiscordIntegration) Equals(other Integration) bool {
+	if i == nil && helpers.IsNil(other) {
+		return true
+	}
+
+	if i == nil && !helpers.IsNil(other) || i != nil && helpers.IsNil(other) {
+		return false
+	}
+
+	otherDiscord, ok := other.(*DiscordIntegration)
+	if !ok {
+		return false
+	}
+
+	return i.FileName == otherDiscord.FileName &&
+		i.StartLineNumber == otherDiscord.StartLineNumber &&
+		i.EndLineNumber == otherDiscord.EndLineNumber &&
+		utils.PtrEqual(i.Token, otherDiscord.Token) &&
+		utils.PtrEqual(i.WebhookUrl, otherDiscord.WebhookUrl) &&
+		utils.PtrEqual(i.Channel, otherDiscord.Channel)
+}
+
+func (i *DiscordIntegration) CtyValue() (cty.Value, error) {
+	iCty, err := cty_helpers.GetCtyValue(i)
+	if err != nil {
+		return cty.NilVal, err
+	}
+
+	valueMap := iCty.AsValueMap()
+	valueMap["full_name"] = cty.StringVal(i.FullName)
+	valueMap["short_name"] = cty.StringVal(i.ShortName)
+	valueMap["unqualified_name"] = cty.StringVal(i.UnqualifiedName)
+
+	if i.Title != nil {
+		valueMap["title"] = cty.StringVal(*i.Title)
+	}
+
+	if i.Description != nil {
+		valueMap["description"] = cty.StringVal(*i.Description)
+	}
+
+	return cty.ObjectVal(valueMap), nil
+}
+
+func (i *DiscordIntegration) MapInterface() (map[string]interface{}, error) {
+	res := make(map[string]interface{})
+	res["type"] = i.Type
+	if i.Token != nil {
+		res["token"] = *i.Token
+	}
+	if i.WebhookUrl != nil {
+		res["webhook_url"] = *i.WebhookUrl
+	}
+	if i.Channel != nil {
+		res["channel"] = *i.Channel
+	}
+
+	res["full_name"] = i.FullName
+	res["short_name"] = i.ShortName
+	res["unqualified_name"] = i.UnqualifiedName
+
+	if i.Title != nil {
+		res["title"] = *i.Title
+	}
+	if i.Description != nil {
+		res["description"] = *i.Description
+	}
+
+	return res, nil
+}
+
+func (i *DiscordIntegration) GetIntegrationType() string {
+	return i.Type
+}
+
+func (i *DiscordIntegration) Validate() hcl.Diagnostics {
+	diags := hcl.Diagnostics{}
+
+	var token, webhook string
+
+	if i.Token != nil {
+		token = *i.Token
+	}
+
+	if i.WebhookUrl != nil {
+		webhook = *i.WebhookUrl
+	}
+
+	// Return error if neither token nor webhook URL are not provided
+	if token == "" && webhook == "" {
+		diags = append(diags, &hcl.Diagnostic{
+			Severity: hcl.DiagError,
+			Summary:  i.Name() + " requires one of the following attributes set: " + schema.AttributeTypeToken + ", " + schema.AttributeTypeWebhookUrl,
+			Subject:  &i.DeclRange,
+		})
+	}
+
+	// Return error if both token and webhook URL provided
+	if token != "" && webhook != "" {
+		diags = append(diags, &hcl.Diagnostic{
+			Severity: hcl.DiagError,
+			Summary:  "Attributes " + schema.AttributeTypeToken + " and " + schema.AttributeTypeWebhookUrl + " are mutually exclusive: " + i.Name(),
+			Subject:  &i.DeclRange,
+		})
+	}
+
+	// A webhook posts to a fixed channel, so a channel only applies when posting with a bot token
+	if token == "" && i.Channel != nil {
+		diags = append(diags, &hcl.Diagnostic{
+			Severity: hcl.DiagError,
+			Summary:  "Attribute " + schema.AttributeTypeChannel + " only applies when attribute token is provided: " + i.Name(),
+			Subject:  &i.DeclRange,
+		})
+	}
+
+	return diags
+}
+
+func (i *DiscordIntegration) SetAttributes(hclAttributes hcl.Attributes, evalContext *hcl.EvalContext) hcl.Diagnostics {
+	var diags hcl.Diagnostics
+
+	for name, attr := range hclAttributes {
+		switch name {
+		case schema.AttributeTypeToken:
+			token, moreDiags := hclhelpers.AttributeToString(attr, evalContext, true)
+			if len(moreDiags) > 0 {
+				diags = append(diags, moreDiags...)
+				continue
+			}
+			i.Token = token
+		case schema.AttributeTypeWebhookUrl:
+			webhookUrl, moreDiags := hclhelpers.AttributeToString(attr, evalContext, false)
+			if len(moreDiags) > 0 {
+				diags = append(diags, moreDiags...)
+				continue
+			}
+			i.WebhookUrl = webhookUrl
+		case schema.AttributeTypeChannel:
+			channel, moreDiags := hclhelpers.AttributeToString(attr, evalContext, false)
+			if len(moreDiags) > 0 {
+				diags = append(diags, moreDiags...)
+				continue
+			}
+			i.Channel = channel
+		default:
+			diags = append(diags, &hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "Unsupported attribute for Discord Integration: " + attr.Name,
+				Subject:  &attr.Range,
+			})
+		}
+	}
+
+	return diags
+}
+
+func DiscordIntegrationFromCtyValue(val cty.Value) (*DiscordIntegration, error) {
+	hclResourceImpl := hclResourceImplFromVal(val)
+	i := &DiscordIntegration{
+		HclResourceImpl: hclResourceImpl,
+	}
+	i.Type = val.GetAttr("type").AsString()
+
+	valMap := val.AsValueMap()
+
+	token := valMap["token"]
+	webhookUrl := valMap["webhook_url"]
+	channel := valMap["channel"]
+
+	if !token.IsNull() {
+		tokenStr := token.AsString()
+		i.Token = &tokenStr
+	}
+
+	if !webhookUrl.IsNull() {
+		webhookUrlStr := webhookUrl.AsString()
+		i.WebhookUrl = &webhookUrlStr
+	}
+
+	if !channel.IsNull() {
+		channelStr := channel.AsString()
+		i.Channel = &channelStr
+	}
+
+	return i, nil
+}
@@ -0,0 +1,59 @@
+package modconfig
+
+import (
+	"testing"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/turbot/pipe-fittings/sanitize"
+	"github.com/zclconf/go-cty/cty"
+)
+
+func TestIntegration_CredentialExpressions(t *testing.T) {
+	evalContext := &hcl.EvalContext{
+		Variables: map[string]cty.Value{
+			"var": cty.ObjectVal(map[string]cty.Value{"key": cty.StringVal("from-var")}),
+		},
+	}
+	attributes := func(src string) hcl.Attributes {
+		file, diags := hclsyntax.ParseConfig([]byte(src), "integration.fpc", hcl.InitialPos)
+		if diags.HasErrors() {
+			t.Fatal(diags.Error())
+		}
+		attrs, diags := file.Body.JustAttributes()
+		if diags.HasErrors() {
+			t.Fatal(diags.Error())
+		}
+		return attrs
+	}
+
+	pagerDuty := &PagerDutyIntegration{}
+	if diags := pagerDuty.SetAttributes(attributes(`routing_key = var.key`), evalContext); diags.HasErrors() {
+		t.Fatalf("PagerDutyIntegration.SetAttributes() error = %s", diags.Error())
+	}
+	if pagerDuty.RoutingKey == nil || *pagerDuty.RoutingKey != "from-var" {
+		t.Errorf("PagerDutyIntegration.RoutingKey = %v, want from-var", pagerDuty.RoutingKey)
+	}
+
+	opsgenie := &OpsgenieIntegration{}
+	if diags := opsgenie.SetAttributes(attributes(`alert_api_key = var.key`), evalContext); diags.HasErrors() {
+		t.Fatalf("OpsgenieIntegration.SetAttributes() error = %s", diags.Error())
+	}
+	if opsgenie.AlertApiKey == nil || *opsgenie.AlertApiKey != "from-var" {
+		t.Errorf("OpsgenieIntegration.AlertApiKey = %v, want from-var", opsgenie.AlertApiKey)
+	}
+}
+
+func TestIntegration_SensitiveFieldsRedacted(t *testing.T) {
+	for _, field := range []string{"token", "signing_secret", "smtp_password", "routing_key", "alert_api_key"} {
+		if !sanitize.Instance.FieldExcluded(field) {
+			t.Errorf("sanitize.Instance.FieldExcluded(%q) = false, want true", field)
+		}
+	}
+
+	input := `{"type":"opsgenie","alert_api_key":"abcdef","priority":"P1"}`
+	want := `{"type":"opsgenie","alert_api_key":"` + sanitize.RedactedStr + `","priority":"P1"}`
+	if got := sanitize.Instance.SanitizeString(input); got != want {
+		t.Errorf("sanitize.Instance.SanitizeString() = %s, want %s", got, want)
+	}
+}
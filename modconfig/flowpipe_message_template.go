@@ -0,0 +1,405 @@
+package modconfig
+
+import (
+	"net/url"
+	"regexp"
+	"slices"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/turbot/pipe-fittings/constants"
+	"github.com/turbot/pipe-fittings/hclhelpers"
+	"github.com/turbot/pipe-fittings/schema"
+	"github.com/turbot/pipe-fittings/utils"
+	"github.com/zclconf/go-cty/cty"
+)
+
+var messageTemplateColorRegex = regexp.MustCompile(`^#[0-9A-Fa-f]{6}$`)
+
+var validMessageTemplateLinkSchemes = []string{"http", "https", "mailto"}
+
+// messageTemplateElement holds the state shared by a message template and its fields and links: the step it belongs
+// to, and the attributes which can only be resolved when the step runs
+type messageTemplateElement struct {
+	// circular link to its "parent"
+	PipelineStepBase *PipelineStepBase `json:"-"`
+
+	UnresolvedAttributes map[string]hcl.Expression `json:"-"`
+}
+
+func newMessageTemplateElement(p *PipelineStepBase) messageTemplateElement {
+	return messageTemplateElement{
+		PipelineStepBase:     p,
+		UnresolvedAttributes: make(map[string]hcl.Expression),
+	}
+}
+
+func (e *messageTemplateElement) AppendDependsOn(dependsOn ...string) {
+	e.PipelineStepBase.AppendDependsOn(dependsOn...)
+}
+
+func (e *messageTemplateElement) AppendCredentialDependsOn(credentialDependsOn ...string) {
+	e.PipelineStepBase.AppendCredentialDependsOn(credentialDependsOn...)
+}
+
+func (e *messageTemplateElement) AppendConnectionDependsOn(connectionDependsOn ...string) {
+	e.PipelineStepBase.AppendConnectionDependsOn(connectionDependsOn...)
+}
+
+func (e *messageTemplateElement) GetPipeline() *Pipeline {
+	return e.PipelineStepBase.GetPipeline()
+}
+
+func (e *messageTemplateElement) AddUnresolvedAttribute(name string, expr hcl.Expression) {
+	e.UnresolvedAttributes[name] = expr
+}
+
+func (e *messageTemplateElement) unresolvedAttributesEqual(other *messageTemplateElement) bool {
+	if len(e.UnresolvedAttributes) != len(other.UnresolvedAttributes) {
+		return false
+	}
+
+	for key, expr := range e.UnresolvedAttributes {
+		otherExpr, ok := other.UnresolvedAttributes[key]
+		if !ok || !hclhelpers.ExpressionsEqual(expr, otherExpr) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// resolveString returns the static value of a string attribute, or evaluates it if it could not be resolved at parse time
+func (e *messageTemplateElement) resolveString(name string, value *string, evalContext *hcl.EvalContext) (*string, hcl.Diagnostics) {
+	if value != nil {
+		return utils.ToPointer(*value), nil
+	}
+
+	expr := e.UnresolvedAttributes[name]
+	if expr == nil {
+		return nil, nil
+	}
+
+	val, diags := expr.Value(evalContext)
+	if diags.HasErrors() {
+		return nil, diags
+	}
+
+	if val.IsNull() {
+		return nil, nil
+	}
+
+	valString, err := hclhelpers.CtyToString(val)
+	if err != nil {
+		return nil, hcl.Diagnostics{&hcl.Diagnostic{
+			Severity: hcl.DiagError,
+			Summary:  "Unable to parse " + name + " attribute to string",
+			Subject:  expr.Range().Ptr(),
+		}}
+	}
+
+	return &valString, nil
+}
+
+// MessageTemplate is a structured message for the message and input steps. It is rendered natively by each
+// integration type, e.g. as Slack Block Kit blocks or a Teams Adaptive Card
+type MessageTemplate struct {
+	messageTemplateElement
+
+	Title    *string                `json:"title,omitempty"`
+	Body     *string                `json:"body,omitempty"`
+	Severity *string                `json:"severity,omitempty"`
+	Color    *string                `json:"color,omitempty"`
+	Fields   []MessageTemplateField `json:"fields,omitempty"`
+	Links    []MessageTemplateLink  `json:"links,omitempty"`
+}
+
+// MessageTemplateField is a labelled value displayed in the message, e.g. as a Slack section field or a Teams fact
+type MessageTemplateField struct {
+	messageTemplateElement
+
+	Name  string  `json:"name"`
+	Value *string `json:"value,omitempty"`
+	// Short fields may be displayed side by side
+	Short *bool `json:"short,omitempty"`
+}
+
+// MessageTemplateLink is a labelled URL displayed in the message, e.g. as a Slack button or a Teams action
+type MessageTemplateLink struct {
+	messageTemplateElement
+
+	Name string  `json:"name"`
+	Url  *string `json:"url,omitempty"`
+}
+
+func (t *MessageTemplate) Equals(other *MessageTemplate) bool {
+	if t == nil && other == nil {
+		return true
+	}
+
+	if t == nil && other != nil || t != nil && other == nil {
+		return false
+	}
+
+	if len(t.Fields) != len(other.Fields) || len(t.Links) != len(other.Links) {
+		return false
+	}
+
+	for i := range t.Fields {
+		if !t.Fields[i].Equals(&other.Fields[i]) {
+			return false
+		}
+	}
+
+	for i := range t.Links {
+		if !t.Links[i].Equals(&other.Links[i]) {
+			return false
+		}
+	}
+
+	return t.unresolvedAttributesEqual(&other.messageTemplateElement) &&
+		utils.PtrEqual(t.Title, other.Title) &&
+		utils.PtrEqual(t.Body, other.Body) &&
+		utils.PtrEqual(t.Severity, other.Severity) &&
+		utils.PtrEqual(t.Color, other.Color)
+}
+
+func (f *MessageTemplateField) Equals(other *MessageTemplateField) bool {
+	if f == nil && other == nil {
+		return true
+	}
+
+	if f == nil && other != nil || f != nil && other == nil {
+		return false
+	}
+
+	return f.unresolvedAttributesEqual(&other.messageTemplateElement) &&
+		f.Name == other.Name &&
+		utils.PtrEqual(f.Value, other.Value) &&
+		utils.BoolPtrEqual(f.Short, other.Short)
+}
+
+func (l *MessageTemplateLink) Equals(other *MessageTemplateLink) bool {
+	if l == nil && other == nil {
+		return true
+	}
+
+	if l == nil && other != nil || l != nil && other == nil {
+		return false
+	}
+
+	return l.unresolvedAttributesEqual(&other.messageTemplateElement) &&
+		l.Name == other.Name &&
+		utils.PtrEqual(l.Url, other.Url)
+}
+
+// decodeMessageTemplate decodes a template block. Attributes which refer to values only available when the step runs
+// are recorded as unresolved attributes of the template, field or link they belong to
+func decodeMessageTemplate(block *hcl.Block, p *PipelineStepBase, evalContext *hcl.EvalContext) (*MessageTemplate, hcl.Diagnostics) {
+	content, diags := block.Body.Content(MessageTemplateBlockSchema)
+	if diags.HasErrors() {
+		return nil, diags
+	}
+
+	template := &MessageTemplate{
+		messageTemplateElement: newMessageTemplateElement(p),
+	}
+
+	for name, attr := range content.Attributes {
+		switch name {
+		case schema.AttributeTypeTitle, schema.AttributeTypeBody, schema.AttributeTypeSeverity, schema.AttributeTypeColor:
+			structFieldName := utils.CapitalizeFirst(name)
+			moreDiags := setStringAttribute(attr, evalContext, template, structFieldName, true)
+			diags = append(diags, moreDiags...)
+		}
+	}
+
+	for _, b := range content.Blocks {
+		switch b.Type {
+		case schema.BlockTypeField:
+			fieldContent, moreDiags := b.Body.Content(MessageTemplateFieldBlockSchema)
+			if moreDiags.HasErrors() {
+				diags = append(diags, moreDiags...)
+				continue
+			}
+
+			field := MessageTemplateField{
+				messageTemplateElement: newMessageTemplateElement(p),
+				Name:                   b.Labels[0],
+			}
+
+			for name, attr := range fieldContent.Attributes {
+				switch name {
+				case schema.AttributeTypeValue:
+					moreDiags = setStringAttribute(attr, evalContext, &field, "Value", true)
+					diags = append(diags, moreDiags...)
+				case schema.AttributeTypeShort:
+					moreDiags = setBoolAttribute(attr, evalContext, &field, "Short", true)
+					diags = append(diags, moreDiags...)
+				}
+			}
+
+			template.Fields = append(template.Fields, field)
+
+		case schema.BlockTypeLink:
+			linkContent, moreDiags := b.Body.Content(MessageTemplateLinkBlockSchema)
+			if moreDiags.HasErrors() {
+				diags = append(diags, moreDiags...)
+				continue
+			}
+
+			link := MessageTemplateLink{
+				messageTemplateElement: newMessageTemplateElement(p),
+				Name:                   b.Labels[0],
+			}
+
+			if attr, ok := linkContent.Attributes[schema.AttributeTypeUrl]; ok {
+				moreDiags = setStringAttribute(attr, evalContext, &link, "Url", true)
+				diags = append(diags, moreDiags...)
+			}
+
+			template.Links = append(template.Links, link)
+		}
+	}
+
+	if diags.HasErrors() {
+		return nil, diags
+	}
+
+	if len(template.Fields) == 0 && len(template.Links) == 0 && template.Title == nil && template.Body == nil &&
+		template.UnresolvedAttributes[schema.AttributeTypeTitle] == nil && template.UnresolvedAttributes[schema.AttributeTypeBody] == nil {
+		return nil, hcl.Diagnostics{&hcl.Diagnostic{
+			Severity: hcl.DiagError,
+			Summary:  "Empty " + schema.BlockTypeTemplate + " block, specify at least one of title, body, field or link",
+			Subject:  &block.DefRange,
+		}}
+	}
+
+	diags = append(diags, template.Validate()...)
+	return template, diags
+}
+
+// decodeStepMessageTemplate decodes the template block of a message or input step, if there is one
+func decodeStepMessageTemplate(blocks hcl.Blocks, p *PipelineStepBase, evalContext *hcl.EvalContext) (*MessageTemplate, hcl.Diagnostics) {
+	templateBlocks := blocks.OfType(schema.BlockTypeTemplate)
+	if len(templateBlocks) == 0 {
+		return nil, nil
+	}
+
+	if len(templateBlocks) > 1 {
+		return nil, hcl.Diagnostics{&hcl.Diagnostic{
+			Severity: hcl.DiagError,
+			Summary:  "Only one " + schema.BlockTypeTemplate + " block is allowed per step",
+			Subject:  &templateBlocks[1].DefRange,
+		}}
+	}
+
+	return decodeMessageTemplate(templateBlocks[0], p, evalContext)
+}
+
+// Resolve returns a copy of the template with all unresolved attributes evaluated
+func (t *MessageTemplate) Resolve(evalContext *hcl.EvalContext) (*MessageTemplate, hcl.Diagnostics) {
+	var diags hcl.Diagnostics
+	var moreDiags hcl.Diagnostics
+
+	resolved := &MessageTemplate{}
+
+	resolved.Title, moreDiags = t.resolveString(schema.AttributeTypeTitle, t.Title, evalContext)
+	diags = append(diags, moreDiags...)
+	resolved.Body, moreDiags = t.resolveString(schema.AttributeTypeBody, t.Body, evalContext)
+	diags = append(diags, moreDiags...)
+	resolved.Severity, moreDiags = t.resolveString(schema.AttributeTypeSeverity, t.Severity, evalContext)
+	diags = append(diags, moreDiags...)
+	resolved.Color, moreDiags = t.resolveString(schema.AttributeTypeColor, t.Color, evalContext)
+	diags = append(diags, moreDiags...)
+
+	for _, f := range t.Fields {
+		field := MessageTemplateField{Name: f.Name}
+
+		field.Value, moreDiags = f.resolveString(schema.AttributeTypeValue, f.Value, evalContext)
+		diags = append(diags, moreDiags...)
+
+		if f.Short != nil {
+			field.Short = utils.ToPointer(*f.Short)
+		} else if expr := f.UnresolvedAttributes[schema.AttributeTypeShort]; expr != nil {
+			val, moreDiags := expr.Value(evalContext)
+			diags = append(diags, moreDiags...)
+			if !moreDiags.HasErrors() && !val.IsNull() && val.Type() == cty.Bool {
+				field.Short = utils.ToPointer(val.True())
+			}
+		}
+
+		resolved.Fields = append(resolved.Fields, field)
+	}
+
+	for _, l := range t.Links {
+		link := MessageTemplateLink{Name: l.Name}
+
+		link.Url, moreDiags = l.resolveString(schema.AttributeTypeUrl, l.Url, evalContext)
+		diags = append(diags, moreDiags...)
+
+		resolved.Links = append(resolved.Links, link)
+	}
+
+	if diags.HasErrors() {
+		return nil, diags
+	}
+
+	return resolved, resolved.Validate()
+}
+
+// Validate checks the values which are known, it is called at parse time and again once the template is resolved
+func (t *MessageTemplate) Validate() hcl.Diagnostics {
+	var diags hcl.Diagnostics
+
+	if t.Severity != nil && !constants.IsValidMessageSeverity(*t.Severity) {
+		diags = append(diags, &hcl.Diagnostic{
+			Severity: hcl.DiagError,
+			Summary:  "Attribute " + schema.AttributeTypeSeverity + " specified with invalid value " + *t.Severity,
+			Subject:  t.stepRange(),
+		})
+	}
+
+	if t.Color != nil && !messageTemplateColorRegex.MatchString(*t.Color) {
+		diags = append(diags, &hcl.Diagnostic{
+			Severity: hcl.DiagError,
+			Summary:  "Attribute " + schema.AttributeTypeColor + " specified with invalid value " + *t.Color + ", specify a hex colour, e.g. #E01E5A",
+			Subject:  t.stepRange(),
+		})
+	}
+
+	for _, l := range t.Links {
+		if l.Url == nil {
+			continue
+		}
+
+		u, err := url.Parse(*l.Url)
+		if err != nil || !slices.Contains(validMessageTemplateLinkSchemes, u.Scheme) {
+			diags = append(diags, &hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "Attribute " + schema.AttributeTypeUrl + " specified with invalid value " + *l.Url + " in link " + l.Name,
+				Subject:  t.stepRange(),
+			})
+		}
+	}
+
+	return diags
+}
+
+// stepRange returns the range of the step the template belongs to, if known
+func (t *MessageTemplate) stepRange() *hcl.Range {
+	if t.PipelineStepBase == nil {
+		return nil
+	}
+	return t.PipelineStepBase.Range
+}
+
+// ResolvedColor returns the explicit colour of the template, falling back to the colour of its severity
+func (t *MessageTemplate) ResolvedColor() string {
+	if t.Color != nil {
+		return *t.Color
+	}
+	if t.Severity != nil {
+		return constants.MessageSeverityColors[*t.Severity]
+	}
+	return ""
+}
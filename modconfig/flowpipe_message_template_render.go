@@ -0,0 +1,502 @@
+package modconfig
+
+import (
+	"bytes"
+	"encoding/json"
+	"html"
+	"html/template"
+	"regexp"
+	"strings"
+
+	"github.com/turbot/pipe-fittings/constants"
+	"github.com/turbot/pipe-fittings/perr"
+	"github.com/turbot/pipe-fittings/schema"
+)
+
+const (
+	// slackMaxSectionFields is the maximum number of fields Slack allows in a single section block
+	slackMaxSectionFields = 10
+	// slackMaxHeaderLength is the maximum length of the text of a Slack header block
+	slackMaxHeaderLength = 150
+)
+
+// RenderedMessage is a message template rendered for a specific integration type
+type RenderedMessage struct {
+	// ContentType is the media type of Payload
+	ContentType string `json:"content_type"`
+	// Payload is the native message: Slack Block Kit JSON, a Teams Adaptive Card, an HTML email body or a JSON document
+	Payload string `json:"payload"`
+	// Text is the plain text version of the message, used for notification previews and as the plain text email part
+	Text string `json:"text"`
+}
+
+// Render renders the template for the given integration type. Options are the options of an input step, they are
+// rendered as buttons where the integration supports it. Integration types without a native rendering receive the
+// plain text version of the message.
+func (t *MessageTemplate) Render(integrationType string, options []PipelineStepInputOption) (*RenderedMessage, error) {
+	text := t.PlainText(options)
+
+	var payload any
+
+	switch integrationType {
+	case schema.IntegrationTypeSlack:
+		payload = t.slackPayload(text, options)
+	case schema.IntegrationTypeMsTeams:
+		payload = t.teamsPayload(options)
+	case schema.IntegrationTypeHttp:
+		payload = t.httpPayload(text, options)
+	case schema.IntegrationTypeEmail:
+		body, err := t.EmailHTML(options)
+		if err != nil {
+			return nil, err
+		}
+		return &RenderedMessage{ContentType: "text/html", Payload: body, Text: text}, nil
+	default:
+		return &RenderedMessage{ContentType: "text/plain", Payload: text, Text: text}, nil
+	}
+
+	res, err := marshalMessagePayload(payload)
+	if err != nil {
+		return nil, perr.InternalWithMessage("unable to render message template for " + integrationType + ": " + err.Error())
+	}
+
+	return &RenderedMessage{ContentType: "application/json", Payload: res, Text: text}, nil
+}
+
+// PlainText renders the template as plain text, keeping the markdown of the body as is
+func (t *MessageTemplate) PlainText(options []PipelineStepInputOption) string {
+	var sections []string
+
+	if t.Title != nil {
+		sections = append(sections, *t.Title)
+	}
+
+	if t.Body != nil {
+		sections = append(sections, *t.Body)
+	}
+
+	if len(t.Fields) > 0 {
+		lines := make([]string, 0, len(t.Fields))
+		for _, f := range t.Fields {
+			lines = append(lines, f.Name+": "+f.value())
+		}
+		sections = append(sections, strings.Join(lines, "\n"))
+	}
+
+	if len(t.Links) > 0 {
+		lines := make([]string, 0, len(t.Links))
+		for _, l := range t.Links {
+			lines = append(lines, l.Name+": "+l.url())
+		}
+		sections = append(sections, strings.Join(lines, "\n"))
+	}
+
+	if len(options) > 0 {
+		lines := make([]string, 0, len(options))
+		for _, o := range options {
+			lines = append(lines, "- "+optionLabel(o))
+		}
+		sections = append(sections, strings.Join(lines, "\n"))
+	}
+
+	return strings.Join(sections, "\n\n")
+}
+
+func (t *MessageTemplate) slackPayload(text string, options []PipelineStepInputOption) map[string]any {
+	var blocks []any
+
+	if t.Title != nil {
+		blocks = append(blocks, map[string]any{
+			"type": "header",
+			"text": map[string]any{"type": "plain_text", "text": truncate(*t.Title, slackMaxHeaderLength), "emoji": true},
+		})
+	}
+
+	if t.Body != nil {
+		blocks = append(blocks, map[string]any{
+			"type": "section",
+			"text": map[string]any{"type": "mrkdwn", "text": markdownToSlack(*t.Body)},
+		})
+	}
+
+	// short fields are displayed side by side in section fields, long fields in a section of their own
+	var shortFields []any
+	flushShortFields := func() {
+		for len(shortFields) > 0 {
+			n := min(len(shortFields), slackMaxSectionFields)
+			blocks = append(blocks, map[string]any{"type": "section", "fields": shortFields[:n]})
+			shortFields = shortFields[n:]
+		}
+	}
+	for _, f := range t.Fields {
+		fieldText := map[string]any{"type": "mrkdwn", "text": "*" + slackEscaper.Replace(f.Name) + "*\n" + markdownToSlack(f.value())}
+		if f.isShort() {
+			shortFields = append(shortFields, fieldText)
+			continue
+		}
+		flushShortFields()
+		blocks = append(blocks, map[string]any{"type": "section", "text": fieldText})
+	}
+	flushShortFields()
+
+	var elements []any
+	for _, l := range t.Links {
+		elements = append(elements, map[string]any{
+			"type": "button",
+			"text": map[string]any{"type": "plain_text", "text": l.Name, "emoji": true},
+			"url":  l.url(),
+		})
+	}
+	for _, o := range options {
+		button := map[string]any{
+			"type":  "button",
+			"text":  map[string]any{"type": "plain_text", "text": optionLabel(o), "emoji": true},
+			"value": optionValue(o),
+		}
+		if style := slackButtonStyle(o); style != "" {
+			button["style"] = style
+		}
+		elements = append(elements, button)
+	}
+	if len(elements) > 0 {
+		blocks = append(blocks, map[string]any{"type": "actions", "elements": elements})
+	}
+
+	// the top level text is used for notifications
+	text = slackEscaper.Replace(text)
+
+	color := t.ResolvedColor()
+	if color == "" {
+		return map[string]any{"text": text, "blocks": blocks}
+	}
+
+	// the colour bar is only available on attachments
+	return map[string]any{
+		"text":        text,
+		"attachments": []any{map[string]any{"color": color, "blocks": blocks}},
+	}
+}
+
+func (t *MessageTemplate) teamsPayload(options []PipelineStepInputOption) map[string]any {
+	var body []any
+
+	if t.Title != nil {
+		title := map[string]any{"type": "TextBlock", "text": *t.Title, "weight": "Bolder", "size": "Large", "wrap": true}
+		if color := teamsColor(t.Severity); color != "" {
+			title["color"] = color
+		}
+		body = append(body, title)
+	}
+
+	if t.Body != nil {
+		body = append(body, map[string]any{"type": "TextBlock", "text": *t.Body, "wrap": true})
+	}
+
+	if len(t.Fields) > 0 {
+		facts := make([]any, 0, len(t.Fields))
+		for _, f := range t.Fields {
+			facts = append(facts, map[string]any{"title": f.Name, "value": f.value()})
+		}
+		body = append(body, map[string]any{"type": "FactSet", "facts": facts})
+	}
+
+	var actions []any
+	for _, l := range t.Links {
+		actions = append(actions, map[string]any{"type": "Action.OpenUrl", "title": l.Name, "url": l.url()})
+	}
+	for _, o := range options {
+		action := map[string]any{"type": "Action.Submit", "title": optionLabel(o), "data": map[string]any{"value": optionValue(o)}}
+		if style := teamsActionStyle(o); style != "" {
+			action["style"] = style
+		}
+		actions = append(actions, action)
+	}
+
+	card := map[string]any{
+		"$schema": "http://adaptivecards.io/schemas/adaptive-card.json",
+		"type":    "AdaptiveCard",
+		"version": "1.4",
+		"body":    body,
+	}
+	if len(actions) > 0 {
+		card["actions"] = actions
+	}
+
+	return map[string]any{
+		"type": "message",
+		"attachments": []any{map[string]any{
+			"contentType": "application/vnd.microsoft.card.adaptive",
+			"content":     card,
+		}},
+	}
+}
+
+func (t *MessageTemplate) httpPayload(text string, options []PipelineStepInputOption) map[string]any {
+	res := map[string]any{"text": text}
+
+	if t.Title != nil {
+		res[schema.AttributeTypeTitle] = *t.Title
+	}
+	if t.Body != nil {
+		res[schema.AttributeTypeBody] = *t.Body
+	}
+	if t.Severity != nil {
+		res[schema.AttributeTypeSeverity] = *t.Severity
+	}
+	if color := t.ResolvedColor(); color != "" {
+		res[schema.AttributeTypeColor] = color
+	}
+
+	if len(t.Fields) > 0 {
+		fields := make([]any, 0, len(t.Fields))
+		for _, f := range t.Fields {
+			fields = append(fields, map[string]any{"name": f.Name, "value": f.value(), "short": f.isShort()})
+		}
+		res["fields"] = fields
+	}
+
+	if len(t.Links) > 0 {
+		links := make([]any, 0, len(t.Links))
+		for _, l := range t.Links {
+			links = append(links, map[string]any{"name": l.Name, "url": l.url()})
+		}
+		res["links"] = links
+	}
+
+	if len(options) > 0 {
+		opts := make([]any, 0, len(options))
+		for _, o := range options {
+			opts = append(opts, map[string]any{"label": optionLabel(o), "value": optionValue(o)})
+		}
+		res[schema.AttributeTypeOptions] = opts
+	}
+
+	return res
+}
+
+var emailTemplate = template.Must(template.New("email").Parse(`<!DOCTYPE html>
+<html>
+<body style="font-family: -apple-system, BlinkMacSystemFont, 'Segoe UI', Helvetica, Arial, sans-serif; font-size: 14px; color: #1D1C1D;">
+<div style="border-left: 4px solid {{if .Color}}{{.Color}}{{else}}#DDDDDD{{end}}; padding: 8px 16px;">
+{{- if .Title}}
+<h2 style="margin: 0 0 12px 0;">{{.Title}}</h2>
+{{- end}}
+{{- if .Body}}
+{{.Body}}
+{{- end}}
+{{- if .Fields}}
+<table style="border-collapse: collapse; margin: 12px 0;">
+{{- range .Fields}}
+<tr><th style="text-align: left; padding: 4px 12px 4px 0; vertical-align: top;">{{.Name}}</th><td style="padding: 4px 0;">{{.Value}}</td></tr>
+{{- end}}
+</table>
+{{- end}}
+{{- if .Links}}
+<p>
+{{- range $i, $l := .Links}}{{if $i}} | {{end}}<a href="{{$l.Url}}">{{$l.Name}}</a>{{end}}
+</p>
+{{- end}}
+{{- if .Options}}
+<ul>
+{{- range .Options}}
+<li>{{.}}</li>
+{{- end}}
+</ul>
+{{- end}}
+</div>
+</body>
+</html>
+`))
+
+type emailTemplateField struct {
+	Name  string
+	Value template.HTML
+}
+
+type emailTemplateLink struct {
+	Name string
+	Url  string
+}
+
+// EmailHTML renders the template as an HTML email body, converting the markdown of the body and field values to HTML
+func (t *MessageTemplate) EmailHTML(options []PipelineStepInputOption) (string, error) {
+	data := struct {
+		Title   string
+		Body    template.HTML
+		Color   string
+		Fields  []emailTemplateField
+		Links   []emailTemplateLink
+		Options []string
+	}{
+		Color: t.ResolvedColor(),
+	}
+
+	if t.Title != nil {
+		data.Title = *t.Title
+	}
+	if t.Body != nil {
+		data.Body = markdownToHTML(*t.Body) //nolint:gosec // the markdown is escaped before it is converted
+	}
+	for _, f := range t.Fields {
+		data.Fields = append(data.Fields, emailTemplateField{Name: f.Name, Value: markdownInlineToHTML(f.value())}) //nolint:gosec // escaped before conversion
+	}
+	for _, l := range t.Links {
+		data.Links = append(data.Links, emailTemplateLink{Name: l.Name, Url: l.url()})
+	}
+	for _, o := range options {
+		data.Options = append(data.Options, optionLabel(o))
+	}
+
+	var buf bytes.Buffer
+	if err := emailTemplate.Execute(&buf, data); err != nil {
+		return "", perr.InternalWithMessage("unable to render email message template: " + err.Error())
+	}
+	return buf.String(), nil
+}
+
+func (f *MessageTemplateField) value() string {
+	if f.Value == nil {
+		return ""
+	}
+	return *f.Value
+}
+
+func (f *MessageTemplateField) isShort() bool {
+	return f.Short != nil && *f.Short
+}
+
+func (l *MessageTemplateLink) url() string {
+	if l.Url == nil {
+		return ""
+	}
+	return *l.Url
+}
+
+func optionLabel(o PipelineStepInputOption) string {
+	if o.Label != nil {
+		return *o.Label
+	}
+	return optionValue(o)
+}
+
+func optionValue(o PipelineStepInputOption) string {
+	if o.Value != nil {
+		return *o.Value
+	}
+	if o.OptionLabel != nil {
+		return *o.OptionLabel
+	}
+	return ""
+}
+
+func slackButtonStyle(o PipelineStepInputOption) string {
+	if o.Style == nil {
+		return ""
+	}
+	switch *o.Style {
+	case constants.InputStyleOk:
+		return "primary"
+	case constants.InputStyleAlert:
+		return "danger"
+	default:
+		return ""
+	}
+}
+
+func teamsActionStyle(o PipelineStepInputOption) string {
+	if o.Style == nil {
+		return ""
+	}
+	switch *o.Style {
+	case constants.InputStyleOk:
+		return "positive"
+	case constants.InputStyleAlert:
+		return "destructive"
+	default:
+		return ""
+	}
+}
+
+// teamsColor maps a severity to one of the named colours supported by Adaptive Cards, which do not support hex colours
+func teamsColor(severity *string) string {
+	if severity == nil {
+		return ""
+	}
+	switch *severity {
+	case constants.MessageSeverityInfo:
+		return "Accent"
+	case constants.MessageSeveritySuccess:
+		return "Good"
+	case constants.MessageSeverityWarning:
+		return "Warning"
+	case constants.MessageSeverityError, constants.MessageSeverityCritical:
+		return "Attention"
+	default:
+		return ""
+	}
+}
+
+func marshalMessagePayload(payload any) (string, error) {
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	// Slack links use angle brackets, which must not be escaped
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(payload); err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(buf.String(), "\n"), nil
+}
+
+func truncate(s string, maxLength int) string {
+	runes := []rune(s)
+	if len(runes) <= maxLength {
+		return s
+	}
+	return string(runes[:maxLength-1]) + "…"
+}
+
+var (
+	markdownLinkRegex   = regexp.MustCompile(`\[([^\]]+)\]\(((?:https?|mailto):[^)\s]+)\)`)
+	markdownBoldRegex   = regexp.MustCompile(`\*\*([^*]+)\*\*`)
+	markdownItalicRegex = regexp.MustCompile(`(^|[^*])\*([^*]+)\*`)
+	markdownCodeRegex   = regexp.MustCompile("`([^`]+)`")
+)
+
+// slackEscaper escapes the characters Slack uses for its own markup
+var slackEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+
+// markdownToSlack converts the markdown subset supported by templates (bold, italic, code and links) to Slack mrkdwn
+func markdownToSlack(s string) string {
+	s = slackEscaper.Replace(s)
+	s = markdownLinkRegex.ReplaceAllString(s, "<$2|$1>")
+	s = markdownItalicRegex.ReplaceAllString(s, "${1}_${2}_")
+	s = markdownBoldRegex.ReplaceAllString(s, "*$1*")
+	return s
+}
+
+// markdownToHTML converts markdown to HTML, paragraphs are separated by blank lines
+func markdownToHTML(s string) template.HTML {
+	var paragraphs []string
+	for _, p := range strings.Split(strings.ReplaceAll(s, "\r\n", "\n"), "\n\n") {
+		p = strings.TrimSpace(p)
+		if p == "" {
+			continue
+		}
+		lines := strings.Split(p, "\n")
+		for i, line := range lines {
+			lines[i] = string(markdownInlineToHTML(line))
+		}
+		paragraphs = append(paragraphs, "<p>"+strings.Join(lines, "<br>\n")+"</p>")
+	}
+	return template.HTML(strings.Join(paragraphs, "\n")) //nolint:gosec // each line is escaped before it is converted
+}
+
+// markdownInlineToHTML escapes a line of text, then converts its bold, italic, code and link markdown to HTML
+func markdownInlineToHTML(s string) template.HTML {
+	s = html.EscapeString(s)
+	s = markdownCodeRegex.ReplaceAllString(s, "<code>$1</code>")
+	s = markdownLinkRegex.ReplaceAllString(s, `<a href="$2">$1</a>`)
+	s = markdownBoldRegex.ReplaceAllString(s, "<strong>$1</strong>")
+	s = markdownItalicRegex.ReplaceAllString(s, "$1<em>$2</em>")
+	return template.HTML(s) //nolint:gosec // the text is escaped before it is converted
+}
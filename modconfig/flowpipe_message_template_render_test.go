@@ -0,0 +1,171 @@
+package modconfig
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/turbot/pipe-fittings/schema"
+	"github.com/turbot/pipe-fittings/utils"
+	"github.com/zclconf/go-cty/cty"
+)
+
+var updateGolden = flag.Bool("update", false, "update the golden files of the message template rendering tests")
+
+func deploymentMessageTemplate() *MessageTemplate {
+	return &MessageTemplate{
+		Title:    utils.ToPointer("Deployment failed"),
+		Body:     utils.ToPointer("The deployment to **production** failed at step `migrate`.\n\nSee the [run log](https://example.com/runs/123) for *details*."),
+		Severity: utils.ToPointer("error"),
+		Fields: []MessageTemplateField{
+			{Name: "Environment", Value: utils.ToPointer("production"), Short: utils.ToPointer(true)},
+			{Name: "Version", Value: utils.ToPointer("v1.2.3"), Short: utils.ToPointer(true)},
+			{Name: "Error", Value: utils.ToPointer("relation \"users\" already exists <42P07>")},
+		},
+		Links: []MessageTemplateLink{
+			{Name: "View run", Url: utils.ToPointer("https://example.com/runs/123")},
+			{Name: "Rollback", Url: utils.ToPointer("https://example.com/runs/123/rollback?env=production&confirm=true")},
+		},
+	}
+}
+
+func approvalMessageTemplate() *MessageTemplate {
+	return &MessageTemplate{
+		Title: utils.ToPointer("Approve deployment?"),
+		Body:  utils.ToPointer("Version v1.2.3 is ready to deploy to production."),
+		Color: utils.ToPointer("#36A64F"),
+	}
+}
+
+func approvalOptions() []PipelineStepInputOption {
+	return []PipelineStepInputOption{
+		{OptionLabel: utils.ToPointer("approve"), Label: utils.ToPointer("Approve"), Style: utils.ToPointer("ok")},
+		{OptionLabel: utils.ToPointer("deny"), Label: utils.ToPointer("Deny"), Style: utils.ToPointer("alert")},
+		{Value: utils.ToPointer("later")},
+	}
+}
+
+func TestMessageTemplate_Render(t *testing.T) {
+	tests := []struct {
+		name            string
+		template        *MessageTemplate
+		options         []PipelineStepInputOption
+		integrationType string
+		contentType     string
+	}{
+		{name: "deployment", template: deploymentMessageTemplate(), integrationType: schema.IntegrationTypeSlack, contentType: "application/json"},
+		{name: "deployment", template: deploymentMessageTemplate(), integrationType: schema.IntegrationTypeMsTeams, contentType: "application/json"},
+		{name: "deployment", template: deploymentMessageTemplate(), integrationType: schema.IntegrationTypeEmail, contentType: "text/html"},
+		{name: "deployment", template: deploymentMessageTemplate(), integrationType: schema.IntegrationTypeHttp, contentType: "application/json"},
+		{name: "deployment", template: deploymentMessageTemplate(), integrationType: schema.IntegrationTypeDiscord, contentType: "text/plain"},
+		{name: "approval", template: approvalMessageTemplate(), options: approvalOptions(), integrationType: schema.IntegrationTypeSlack, contentType: "application/json"},
+		{name: "approval", template: approvalMessageTemplate(), options: approvalOptions(), integrationType: schema.IntegrationTypeMsTeams, contentType: "application/json"},
+		{name: "approval", template: approvalMessageTemplate(), options: approvalOptions(), integrationType: schema.IntegrationTypeEmail, contentType: "text/html"},
+		{name: "approval", template: approvalMessageTemplate(), options: approvalOptions(), integrationType: schema.IntegrationTypeHttp, contentType: "application/json"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name+"_"+tt.integrationType, func(t *testing.T) {
+			rendered, err := tt.template.Render(tt.integrationType, tt.options)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if rendered.ContentType != tt.contentType {
+				t.Errorf("Render() content type = %s, want %s", rendered.ContentType, tt.contentType)
+			}
+
+			payload := rendered.Payload
+			if rendered.ContentType == "application/json" {
+				var buf bytes.Buffer
+				if err := json.Indent(&buf, []byte(payload), "", "  "); err != nil {
+					t.Fatalf("Render() returned invalid JSON: %s", err)
+				}
+				payload = buf.String()
+			}
+
+			compareGolden(t, tt.name+"."+tt.integrationType+".golden", payload)
+			compareGolden(t, tt.name+".txt.golden", rendered.Text)
+		})
+	}
+}
+
+func compareGolden(t *testing.T, name, got string) {
+	t.Helper()
+
+	path := filepath.Join("testdata", "message_templates", name)
+	if *updateGolden {
+		if err := os.WriteFile(path, []byte(got+"\n"), 0644); err != nil { //nolint:gosec // test data
+			t.Fatal(err)
+		}
+		return
+	}
+
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("unable to read golden file, run the tests with -update to create it: %s", err)
+	}
+
+	if string(want) != got+"\n" {
+		t.Errorf("rendered message does not match %s, run the tests with -update to update it\ngot:\n%s\nwant:\n%s", path, got, want)
+	}
+}
+
+func TestMessageTemplate_Resolve(t *testing.T) {
+	expr, diags := hclsyntax.ParseExpression([]byte(`"Deployed ${param.version}"`), "test.fp", hcl.InitialPos)
+	if diags.HasErrors() {
+		t.Fatal(diags)
+	}
+
+	template := &MessageTemplate{
+		messageTemplateElement: messageTemplateElement{
+			UnresolvedAttributes: map[string]hcl.Expression{schema.AttributeTypeTitle: expr},
+		},
+		Severity: utils.ToPointer("success"),
+		Fields: []MessageTemplateField{
+			{
+				messageTemplateElement: messageTemplateElement{
+					UnresolvedAttributes: map[string]hcl.Expression{schema.AttributeTypeValue: &hclsyntax.ScopeTraversalExpr{
+						Traversal: hcl.Traversal{hcl.TraverseRoot{Name: "param"}, hcl.TraverseAttr{Name: "version"}},
+					}},
+				},
+				Name: "Version",
+			},
+		},
+	}
+
+	evalContext := &hcl.EvalContext{
+		Variables: map[string]cty.Value{
+			"param": cty.ObjectVal(map[string]cty.Value{"version": cty.StringVal("v1.2.3")}),
+		},
+	}
+
+	resolved, diags := template.Resolve(evalContext)
+	if diags.HasErrors() {
+		t.Fatal(diags)
+	}
+
+	if *resolved.Title != "Deployed v1.2.3" {
+		t.Errorf("Resolve() title = %s, want Deployed v1.2.3", *resolved.Title)
+	}
+	if *resolved.Fields[0].Value != "v1.2.3" {
+		t.Errorf("Resolve() field value = %s, want v1.2.3", *resolved.Fields[0].Value)
+	}
+	if resolved.ResolvedColor() != "#2EB67D" {
+		t.Errorf("ResolvedColor() = %s, want #2EB67D", resolved.ResolvedColor())
+	}
+
+	// values which are only known at runtime are validated once resolved
+	template.Severity = nil
+	template.UnresolvedAttributes[schema.AttributeTypeSeverity] = &hclsyntax.TemplateExpr{
+		Parts: []hclsyntax.Expression{&hclsyntax.LiteralValueExpr{Val: cty.StringVal("urgent")}},
+	}
+	if _, diags := template.Resolve(evalContext); !diags.HasErrors() {
+		t.Error("Resolve() with an invalid severity should fail")
+	}
+}
@@ -3,8 +3,12 @@ package modconfig
 import (
 	"encoding/json"
 	"fmt"
+	"slices"
+	"strings"
+	"time"
 
 	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/gohcl"
 	"github.com/turbot/go-kit/helpers"
 	"github.com/turbot/pipe-fittings/hclhelpers"
 	"github.com/turbot/pipe-fittings/perr"
@@ -31,6 +35,8 @@ type NotifierImpl struct {
 
 	NotifierName string   `json:"notifier_name" cty:"notifier_name" hcl:"notifier_name"`
 	Notifies     []Notify `json:"notifies" cty:"notifies" hcl:"notifies"`
+	// Routing controls whether every matching notify is sent, or only the first match in each escalation tier
+	Routing *string `json:"routing,omitempty" cty:"routing" hcl:"routing,optional"`
 
 	// required to allow partial decoding
 	Remain hcl.Body `hcl:",remain" json:"-"`
@@ -62,7 +68,8 @@ func (n *NotifierImpl) Equals(other Notifier) bool {
 
 	return n.FileName == other.GetNotifierImpl().FileName &&
 		n.StartLineNumber == other.GetNotifierImpl().StartLineNumber &&
-		n.EndLineNumber == other.GetNotifierImpl().EndLineNumber
+		n.EndLineNumber == other.GetNotifierImpl().EndLineNumber &&
+		utils.PtrEqual(n.Routing, other.GetNotifierImpl().Routing)
 }
 
 func (n *NotifierImpl) SetFileReference(fileName string, startLineNumber int, endLineNumber int) {
@@ -128,6 +135,9 @@ func (n *NotifierImpl) CtyValue() (cty.Value, error) {
 	}
 	notifierMap["resource_type"] = "notifier"
 	notifierMap["notifier_name"] = n.NotifierName
+	if n.Routing != nil {
+		notifierMap["routing"] = *n.Routing
+	}
 
 	notifierCtyVal, err := hclhelpers.ConvertInterfaceToCtyValue(notifierMap)
 	return notifierCtyVal, err
@@ -143,6 +153,31 @@ func (n *NotifierImpl) Validate() hcl.Diagnostics {
 			Summary:  schema.BlockTypeNotifier + " must have at least one " + schema.BlockTypeNotify + " block to send the request to: " + n.Name(),
 		})
 	}
+
+	if n.Routing != nil && !slices.Contains(ValidNotifierRoutings, *n.Routing) {
+		diags = append(diags, &hcl.Diagnostic{
+			Severity: hcl.DiagError,
+			Summary:  "Attribute " + schema.AttributeTypeRouting + " specified with invalid value " + *n.Routing + ", valid values are " + strings.Join(ValidNotifierRoutings, ", ") + ": " + n.Name(),
+		})
+	}
+	return diags
+}
+
+func (n *NotifierImpl) SetAttributes(hclAttributes hcl.Attributes, evalContext *hcl.EvalContext) hcl.Diagnostics {
+	var diags hcl.Diagnostics
+
+	for name, attr := range hclAttributes {
+		switch name {
+		case schema.AttributeTypeRouting:
+			routing, moreDiags := hclhelpers.AttributeToString(attr, evalContext, false)
+			if len(moreDiags) > 0 {
+				diags = append(diags, moreDiags...)
+				continue
+			}
+			n.Routing = routing
+		}
+	}
+
 	return diags
 }
 
@@ -163,6 +198,15 @@ type Notify struct {
 	Subject     *string  `json:"subject,omitempty" cty:"subject" hcl:"subject,optional"`
 	Title       *string  `json:"title,omitempty" cty:"title" hcl:"title,optional"`
 	To          []string `json:"to,omitempty" cty:"to" hcl:"to,optional"`
+
+	// When is the source of an expression over the message severity and tags, evaluated at runtime to decide
+	// whether this notify applies. It is kept as source text so it survives serialisation.
+	When     *string        `json:"when,omitempty" cty:"when"`
+	WhenExpr hcl.Expression `json:"-"`
+	// EscalateAfter delays this notify until an input step has gone unanswered for the given duration
+	EscalateAfter *time.Duration `json:"escalate_after,omitempty" cty:"escalate_after"`
+	// Fallback is sent instead of this notify if delivery through its integration fails
+	Fallback *Notify `json:"fallback,omitempty" cty:"fallback"`
 }
 
 func (n *Notify) Equals(other *Notify) bool {
@@ -182,6 +226,9 @@ func (n *Notify) Equals(other *Notify) bool {
 		utils.PtrEqual(n.Description, other.Description) &&
 		utils.PtrEqual(n.Subject, other.Subject) &&
 		utils.PtrEqual(n.Title, other.Title) &&
+		utils.PtrEqual(n.When, other.When) &&
+		utils.PtrEqual(n.EscalateAfter, other.EscalateAfter) &&
+		n.Fallback.Equals(other.Fallback) &&
 		n.Integration.Equals(other.Integration)
 }
 
@@ -236,6 +283,24 @@ func (n *Notify) UnmarshalJSON(data []byte) error {
 			return err
 		}
 		n.Integration = &teamsIntegration
+	case schema.IntegrationTypePagerDuty:
+		var pagerDutyIntegration PagerDutyIntegration
+		if err := json.Unmarshal(temp.Integration, &pagerDutyIntegration); err != nil {
+			return err
+		}
+		n.Integration = &pagerDutyIntegration
+	case schema.IntegrationTypeOpsgenie:
+		var opsgenieIntegration OpsgenieIntegration
+		if err := json.Unmarshal(temp.Integration, &opsgenieIntegration); err != nil {
+			return err
+		}
+		n.Integration = &opsgenieIntegration
+	case schema.IntegrationTypeDiscord:
+		var discordIntegration DiscordIntegration
+		if err := json.Unmarshal(temp.Integration, &discordIntegration); err != nil {
+			return err
+		}
+		n.Integration = &discordIntegration
 	default:
 		return perr.InternalWithMessage(fmt.Sprintf("unknown integration type: %s", typeIndicator.Type))
 	}
@@ -245,6 +310,8 @@ func (n *Notify) UnmarshalJSON(data []byte) error {
 func (n *Notify) MapInterface() (map[string]interface{}, error) {
 	notifyMap := make(map[string]interface{})
 
+	var err error
+
 	if n.Cc != nil {
 		notifyMap[schema.AttributeTypeCc] = n.Cc
 	}
@@ -273,49 +340,31 @@ func (n *Notify) MapInterface() (map[string]interface{}, error) {
 		notifyMap["to"] = n.To
 	}
 
-	var err error
-	notifyMap["integration"], err = n.Integration.MapInterface()
-	if err != nil {
-		return nil, err
+	if n.When != nil {
+		notifyMap["when"] = *n.When
 	}
 
-	return notifyMap, nil
-}
-
-func (n *Notify) CtyValue() (cty.Value, error) {
-	notifyMap := make(map[string]interface{})
-
-	var err error
-
-	if n.Cc != nil {
-		notifyMap[schema.AttributeTypeCc] = n.Cc
-	}
-
-	if n.Bcc != nil {
-		notifyMap["bcc"] = n.Bcc
-	}
-
-	if n.Channel != nil {
-		notifyMap["channel"] = *n.Channel
+	if n.EscalateAfter != nil {
+		notifyMap["escalate_after"] = n.EscalateAfter.String()
 	}
 
-	if n.Description != nil {
-		notifyMap["description"] = *n.Description
-	}
-
-	if n.Subject != nil {
-		notifyMap["subject"] = *n.Subject
+	if n.Fallback != nil {
+		notifyMap["fallback"], err = n.Fallback.MapInterface()
+		if err != nil {
+			return nil, err
+		}
 	}
 
-	if n.Title != nil {
-		notifyMap["title"] = *n.Title
+	notifyMap["integration"], err = n.Integration.MapInterface()
+	if err != nil {
+		return nil, err
 	}
 
-	if n.To != nil {
-		notifyMap["to"] = n.To
-	}
+	return notifyMap, nil
+}
 
-	notifyMap["integration"], err = n.Integration.MapInterface()
+func (n *Notify) CtyValue() (cty.Value, error) {
+	notifyMap, err := n.MapInterface()
 	if err != nil {
 		return cty.NilVal, err
 	}
@@ -328,6 +377,13 @@ func (n *Notify) CtyValue() (cty.Value, error) {
 	return ctyVal, nil
 }
 
+// the integration types which support the per-notify overrides
+var (
+	notifyToIntegrationTypes      = []string{schema.IntegrationTypeEmail, schema.IntegrationTypeOpsgenie}
+	notifySubjectIntegrationTypes = []string{schema.IntegrationTypeEmail, schema.IntegrationTypePagerDuty, schema.IntegrationTypeOpsgenie}
+	notifyChannelIntegrationTypes = []string{schema.IntegrationTypeSlack, schema.IntegrationTypeDiscord}
+)
+
 func (n *Notify) Validate() hcl.Diagnostics {
 	diags := hcl.Diagnostics{}
 
@@ -348,21 +404,21 @@ func (n *Notify) Validate() hcl.Diagnostics {
 			})
 		}
 
-		if integrationType != schema.IntegrationTypeEmail && len(n.To) > 0 {
+		if !slices.Contains(notifyToIntegrationTypes, integrationType) && len(n.To) > 0 {
 			diags = append(diags, &hcl.Diagnostic{
 				Severity: hcl.DiagError,
 				Summary:  "Attribute '" + schema.AttributeTypeTo + "' is not a valid attribute for " + integrationType + " type integration",
 			})
 		}
 
-		if integrationType != schema.IntegrationTypeEmail && n.Subject != nil {
+		if !slices.Contains(notifySubjectIntegrationTypes, integrationType) && n.Subject != nil {
 			diags = append(diags, &hcl.Diagnostic{
 				Severity: hcl.DiagError,
 				Summary:  "Attribute '" + schema.AttributeTypeSubject + "' is not a valid attribute for " + integrationType + " type integration",
 			})
 		}
 
-		if integrationType != schema.IntegrationTypeSlack && n.Channel != nil {
+		if !slices.Contains(notifyChannelIntegrationTypes, integrationType) && n.Channel != nil {
 			diags = append(diags, &hcl.Diagnostic{
 				Severity: hcl.DiagError,
 				Summary:  "Attribute '" + schema.AttributeTypeChannel + "' is not a valid attribute for " + integrationType + " type integration",
@@ -370,15 +426,46 @@ func (n *Notify) Validate() hcl.Diagnostics {
 		}
 	}
 
+	if n.Fallback != nil {
+		diags = append(diags, n.Fallback.Validate()...)
+	}
+
 	return diags
 }
 
-func (n *Notify) SetAttributes(body hcl.Body, evalCtx *hcl.EvalContext) hcl.Diagnostics {
-	attribs, diags := body.JustAttributes()
+var notifyBlockSchema = &hcl.BodySchema{
+	Attributes: []hcl.AttributeSchema{
+		{Name: schema.AttributeTypeIntegration},
+		{Name: schema.AttributeTypeCc},
+		{Name: schema.AttributeTypeBcc},
+		{Name: schema.AttributeTypeChannel},
+		{Name: schema.AttributeTypeDescription},
+		{Name: schema.AttributeTypeSubject},
+		{Name: schema.AttributeTypeTitle},
+		{Name: schema.AttributeTypeTo},
+		{Name: schema.AttributeTypeWhen},
+		{Name: schema.AttributeTypeEscalateAfter},
+	},
+	Blocks: []hcl.BlockHeaderSchema{
+		{
+			Type: schema.BlockTypeFallback,
+		},
+	},
+}
+
+// SetAttributes decodes the attributes of the notify block - src is the source of the file the block was parsed from
+func (n *Notify) SetAttributes(body hcl.Body, evalCtx *hcl.EvalContext, src []byte) hcl.Diagnostics {
+	return n.setAttributes(body, evalCtx, src, false)
+}
+
+func (n *Notify) setAttributes(body hcl.Body, evalCtx *hcl.EvalContext, src []byte, isFallback bool) hcl.Diagnostics {
+	content, diags := body.Content(notifyBlockSchema)
 	if diags.HasErrors() {
 		return diags
 	}
 
+	attribs := content.Attributes
+
 	attr := attribs[schema.AttributeTypeIntegration]
 	if attr == nil {
 		return hcl.Diagnostics{
@@ -408,5 +495,101 @@ func (n *Notify) SetAttributes(body hcl.Body, evalCtx *hcl.EvalContext) hcl.Diag
 	}
 
 	n.Integration = integration
+
+	// routing and escalation are decided by the notify being fallen back from
+	for _, name := range []string{schema.AttributeTypeWhen, schema.AttributeTypeEscalateAfter} {
+		if attr, ok := attribs[name]; ok && isFallback {
+			diags = append(diags, &hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "Attribute '" + name + "' is not valid in a " + schema.BlockTypeFallback + " block",
+				Subject:  &attr.Range,
+			})
+		}
+	}
+	if diags.HasErrors() {
+		return diags
+	}
+
+	if attr, ok := attribs[schema.AttributeTypeWhen]; ok {
+		moreDiags := n.setWhen(attr, src)
+		diags = append(diags, moreDiags...)
+	}
+
+	if attr, ok := attribs[schema.AttributeTypeEscalateAfter]; ok {
+		escalateAfter, moreDiags := hclhelpers.AttributeToString(attr, evalCtx, false)
+		if len(moreDiags) > 0 {
+			diags = append(diags, moreDiags...)
+		} else {
+			duration, err := time.ParseDuration(*escalateAfter)
+			if err != nil || duration < 0 {
+				diags = append(diags, &hcl.Diagnostic{
+					Severity: hcl.DiagError,
+					Summary:  "Attribute '" + schema.AttributeTypeEscalateAfter + "' specified with invalid duration " + *escalateAfter,
+					Subject:  &attr.Range,
+				})
+			} else {
+				n.EscalateAfter = &duration
+			}
+		}
+	}
+
+	fallbackBlocks := content.Blocks.OfType(schema.BlockTypeFallback)
+	if len(fallbackBlocks) > 1 {
+		diags = append(diags, &hcl.Diagnostic{
+			Severity: hcl.DiagError,
+			Summary:  "Only one " + schema.BlockTypeFallback + " block is allowed, chain further fallbacks by nesting them",
+			Subject:  &fallbackBlocks[1].DefRange,
+		})
+		return diags
+	}
+
+	for _, block := range fallbackBlocks {
+		fallback := &Notify{}
+		moreDiags := gohcl.DecodeBody(block.Body, evalCtx, fallback)
+		if len(moreDiags) > 0 {
+			diags = append(diags, moreDiags...)
+			continue
+		}
+
+		moreDiags = fallback.setAttributes(block.Body, evalCtx, src, true)
+		if len(moreDiags) > 0 {
+			diags = append(diags, moreDiags...)
+			continue
+		}
+		n.Fallback = fallback
+	}
+
+	return diags
+}
+
+// setWhen validates the when expression, which may only refer to the message severity and tags, and records its source
+func (n *Notify) setWhen(attr *hcl.Attribute, src []byte) hcl.Diagnostics {
+	var diags hcl.Diagnostics
+
+	for _, traversal := range attr.Expr.Variables() {
+		if !slices.Contains(notifyWhenVariables, traversal.RootName()) {
+			diags = append(diags, &hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "Invalid reference in '" + schema.AttributeTypeWhen + "' expression: " + hclhelpers.TraversalAsString(traversal) + ", only " + strings.Join(notifyWhenVariables, ", ") + " may be referenced",
+				Subject:  traversal.SourceRange().Ptr(),
+			})
+		}
+	}
+	if diags.HasErrors() {
+		return diags
+	}
+
+	when, err := hclhelpers.ExpressionSource(attr.Expr, src)
+	if err != nil {
+		return hcl.Diagnostics{&hcl.Diagnostic{
+			Severity: hcl.DiagError,
+			Summary:  "Unable to read the source of the '" + schema.AttributeTypeWhen + "' expression",
+			Detail:   err.Error(),
+			Subject:  &attr.Range,
+		}}
+	}
+
+	n.When = &when
+	n.WhenExpr = attr.Expr
 	return diags
 }
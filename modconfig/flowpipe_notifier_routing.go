@@ -0,0 +1,161 @@
+package modconfig
+
+import (
+	"cmp"
+	"slices"
+	"time"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/turbot/pipe-fittings/error_helpers"
+	"github.com/turbot/pipe-fittings/funcs"
+	"github.com/turbot/pipe-fittings/perr"
+	"github.com/zclconf/go-cty/cty"
+)
+
+const (
+	// NotifierRoutingAll sends every notify whose when expression matches (default)
+	NotifierRoutingAll = "all"
+	// NotifierRoutingFirst sends only the first matching notify in each escalation tier
+	NotifierRoutingFirst = "first"
+)
+
+var ValidNotifierRoutings = []string{NotifierRoutingAll, NotifierRoutingFirst}
+
+// the variables available to a notify when expression
+const (
+	notifyWhenSeverity = "severity"
+	notifyWhenTags     = "tags"
+)
+
+var notifyWhenVariables = []string{notifyWhenSeverity, notifyWhenTags}
+
+// NotifyTier is a group of notifies sent together, once an input step has gone unanswered for EscalateAfter
+type NotifyTier struct {
+	EscalateAfter time.Duration
+	Notifies      []Notify
+}
+
+// Matches evaluates the when expression of the notify against the message severity and tags.
+// A notify without a when expression always matches.
+func (n *Notify) Matches(severity string, tags map[string]string) (bool, error) {
+	if n.When == nil {
+		return true, nil
+	}
+
+	expr := n.WhenExpr
+	if expr == nil {
+		// the notify has been deserialised, re-parse the expression from its source
+		var diags hcl.Diagnostics
+		expr, diags = hclsyntax.ParseExpression([]byte(*n.When), "when", hcl.InitialPos)
+		if diags.HasErrors() {
+			return false, error_helpers.HclDiagsToError("when", diags)
+		}
+		n.WhenExpr = expr
+	}
+
+	tagValues := make(map[string]cty.Value, len(tags))
+	for k, v := range tags {
+		tagValues[k] = cty.StringVal(v)
+	}
+	// a tag referenced by the expression but not set on the message is null, so the expression does not match
+	// rather than failing to evaluate
+	for _, k := range referencedTags(expr) {
+		if _, ok := tagValues[k]; !ok {
+			tagValues[k] = cty.NullVal(cty.String)
+		}
+	}
+	tagsVal := cty.MapValEmpty(cty.String)
+	if len(tagValues) > 0 {
+		tagsVal = cty.MapVal(tagValues)
+	}
+
+	evalContext := &hcl.EvalContext{
+		Functions: funcs.ContextFunctions(""),
+		Variables: map[string]cty.Value{
+			notifyWhenSeverity: cty.StringVal(severity),
+			notifyWhenTags:     tagsVal,
+		},
+	}
+
+	val, diags := expr.Value(evalContext)
+	if diags.HasErrors() {
+		return false, error_helpers.HclDiagsToError("when", diags)
+	}
+
+	if !val.IsKnown() || val.IsNull() {
+		return false, nil
+	}
+
+	if val.Type() != cty.Bool {
+		return false, perr.BadRequestWithMessage("when expression must evaluate to a bool: " + *n.When)
+	}
+
+	return val.True(), nil
+}
+
+// referencedTags returns the tag keys the expression references directly, e.g. tags.env or tags["env"]
+func referencedTags(expr hcl.Expression) []string {
+	var res []string
+	for _, traversal := range expr.Variables() {
+		if traversal.RootName() != notifyWhenTags || len(traversal) < 2 {
+			continue
+		}
+		switch step := traversal[1].(type) {
+		case hcl.TraverseAttr:
+			res = append(res, step.Name)
+		case hcl.TraverseIndex:
+			if step.Key.Type() == cty.String && step.Key.IsKnown() && !step.Key.IsNull() {
+				res = append(res, step.Key.AsString())
+			}
+		}
+	}
+	return res
+}
+
+// Route returns the notifies which apply to a message with the given severity and tags, grouped into
+// escalation tiers in the order they should be sent. Notifies without escalate_after are in the first tier.
+func (n *NotifierImpl) Route(severity string, tags map[string]string) ([]NotifyTier, error) {
+	var tiers []NotifyTier
+
+	for _, notify := range n.Notifies {
+		matches, err := notify.Matches(severity, tags)
+		if err != nil {
+			return nil, err
+		}
+		if !matches {
+			continue
+		}
+
+		var escalateAfter time.Duration
+		if notify.EscalateAfter != nil {
+			escalateAfter = *notify.EscalateAfter
+		}
+
+		idx := slices.IndexFunc(tiers, func(t NotifyTier) bool { return t.EscalateAfter == escalateAfter })
+		if idx == -1 {
+			tiers = append(tiers, NotifyTier{EscalateAfter: escalateAfter})
+			idx = len(tiers) - 1
+		}
+
+		if n.Routing != nil && *n.Routing == NotifierRoutingFirst && len(tiers[idx].Notifies) > 0 {
+			continue
+		}
+		tiers[idx].Notifies = append(tiers[idx].Notifies, notify)
+	}
+
+	slices.SortStableFunc(tiers, func(a, b NotifyTier) int {
+		return cmp.Compare(a.EscalateAfter, b.EscalateAfter)
+	})
+
+	return tiers, nil
+}
+
+// FallbackChain returns the notify followed by each of its fallbacks, in the order they should be attempted
+func (n *Notify) FallbackChain() []Notify {
+	var chain []Notify
+	for current := n; current != nil; current = current.Fallback {
+		chain = append(chain, *current)
+	}
+	return chain
+}
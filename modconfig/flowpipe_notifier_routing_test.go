@@ -0,0 +1,202 @@
+package modconfig
+
+import (
+	"testing"
+	"time"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+)
+
+func strPtr(s string) *string {
+	return &s
+}
+
+func TestNotify_Matches(t *testing.T) {
+	tests := []struct {
+		name     string
+		when     *string
+		severity string
+		tags     map[string]string
+		want     bool
+		wantErr  bool
+	}{
+		{
+			name: "no when",
+			want: true,
+		},
+		{
+			name:     "severity match",
+			when:     strPtr(`severity == "critical"`),
+			severity: "critical",
+			want:     true,
+		},
+		{
+			name:     "severity mismatch",
+			when:     strPtr(`severity == "critical"`),
+			severity: "info",
+			want:     false,
+		},
+		{
+			name:     "tag match",
+			when:     strPtr(`contains(keys(tags), "security")`),
+			severity: "info",
+			tags:     map[string]string{"security": "true"},
+			want:     true,
+		},
+		{
+			name:     "no tags",
+			when:     strPtr(`contains(keys(tags), "security")`),
+			severity: "info",
+			want:     false,
+		},
+		{
+			name:     "tag attribute match",
+			when:     strPtr(`tags.env == "prod"`),
+			severity: "info",
+			tags:     map[string]string{"env": "prod"},
+			want:     true,
+		},
+		{
+			name:     "missing tag attribute",
+			when:     strPtr(`tags.env == "prod"`),
+			severity: "info",
+			tags:     map[string]string{"team": "security"},
+			want:     false,
+		},
+		{
+			name:     "missing tag index",
+			when:     strPtr(`severity == "critical" || tags["env"] == "prod"`),
+			severity: "info",
+			want:     false,
+		},
+		{
+			name:    "not a bool",
+			when:    strPtr(`severity`),
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			notify := &Notify{When: tt.when}
+			got, err := notify.Matches(tt.severity, tt.tags)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Matches() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("Matches() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNotify_SetWhen(t *testing.T) {
+	// the config is parsed from memory, there is no file to read the expression source from
+	src := []byte("when = severity == \"critical\" && tags.env == \"prod\"\n")
+	file, diags := hclsyntax.ParseConfig(src, "notifier.fpc", hcl.InitialPos)
+	if diags.HasErrors() {
+		t.Fatal(diags)
+	}
+	attrs, diags := file.Body.JustAttributes()
+	if diags.HasErrors() {
+		t.Fatal(diags)
+	}
+
+	notify := &Notify{}
+	if diags := notify.setWhen(attrs["when"], src); diags.HasErrors() {
+		t.Fatalf("setWhen() error = %v", diags)
+	}
+	if want := `severity == "critical" && tags.env == "prod"`; notify.When == nil || *notify.When != want {
+		t.Fatalf("setWhen() when = %v, want %s", notify.When, want)
+	}
+
+	// a deserialised notify re-parses the expression from its source
+	deserialised := &Notify{When: notify.When}
+	if got, err := deserialised.Matches("critical", map[string]string{"env": "prod"}); err != nil || !got {
+		t.Errorf("Matches() = %v, %v, want true", got, err)
+	}
+
+	// the source no longer matches the parsed expression
+	if diags := (&Notify{}).setWhen(attrs["when"], src[:10]); !diags.HasErrors() {
+		t.Error("setWhen() with truncated source expected error")
+	}
+}
+
+func TestNotifierImpl_Route(t *testing.T) {
+	critical := Notify{Title: strPtr("critical"), When: strPtr(`severity == "critical"`)}
+	always := Notify{Title: strPtr("always")}
+	escalation := Notify{Title: strPtr("escalation"), EscalateAfter: durationPtr(15 * time.Minute)}
+	early := Notify{Title: strPtr("early"), EscalateAfter: durationPtr(5 * time.Minute)}
+
+	notifies := []Notify{escalation, critical, always, early}
+
+	tests := []struct {
+		name     string
+		routing  *string
+		severity string
+		want     [][]string
+	}{
+		{
+			name:     "all",
+			severity: "critical",
+			want:     [][]string{{"critical", "always"}, {"early"}, {"escalation"}},
+		},
+		{
+			name:     "all without match",
+			severity: "info",
+			want:     [][]string{{"always"}, {"early"}, {"escalation"}},
+		},
+		{
+			name:     "first",
+			routing:  strPtr(NotifierRoutingFirst),
+			severity: "critical",
+			want:     [][]string{{"critical"}, {"early"}, {"escalation"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			notifier := &NotifierImpl{Notifies: notifies, Routing: tt.routing}
+			tiers, err := notifier.Route(tt.severity, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if len(tiers) != len(tt.want) {
+				t.Fatalf("Route() returned %d tiers, want %d", len(tiers), len(tt.want))
+			}
+			for i, want := range tt.want {
+				if len(tiers[i].Notifies) != len(want) {
+					t.Fatalf("Route() tier %d has %d notifies, want %d", i, len(tiers[i].Notifies), len(want))
+				}
+				for j, title := range want {
+					if *tiers[i].Notifies[j].Title != title {
+						t.Errorf("Route() tier %d notify %d = %s, want %s", i, j, *tiers[i].Notifies[j].Title, title)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestNotify_FallbackChain(t *testing.T) {
+	notify := &Notify{
+		Title: strPtr("primary"),
+		Fallback: &Notify{
+			Title:    strPtr("secondary"),
+			Fallback: &Notify{Title: strPtr("tertiary")},
+		},
+	}
+
+	chain := notify.FallbackChain()
+	want := []string{"primary", "secondary", "tertiary"}
+	if len(chain) != len(want) {
+		t.Fatalf("FallbackChain() returned %d notifies, want %d", len(chain), len(want))
+	}
+	for i, title := range want {
+		if *chain[i].Title != title {
+			t.Errorf("FallbackChain()[%d] = %s, want %s", i, *chain[i].Title, title)
+		}
+	}
+}
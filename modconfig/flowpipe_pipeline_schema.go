@@ -108,6 +108,81 @@ var IntegrationTeamsBlockSchema = &hcl.BodySchema{
 	},
 }
 
+var IntegrationPagerDutyBlockSchema = &hcl.BodySchema{
+	Attributes: []hcl.AttributeSchema{
+		{
+			Name:     schema.AttributeTypeDescription,
+			Required: false,
+		},
+		{
+			Name:     schema.AttributeTypeTitle,
+			Required: false,
+		},
+		{
+			Name:     schema.AttributeTypeRoutingKey,
+			Required: true,
+		},
+		{
+			Name:     schema.AttributeTypeSeverity,
+			Required: false,
+		},
+	},
+}
+
+var IntegrationOpsgenieBlockSchema = &hcl.BodySchema{
+	Attributes: []hcl.AttributeSchema{
+		{
+			Name:     schema.AttributeTypeDescription,
+			Required: false,
+		},
+		{
+			Name:     schema.AttributeTypeTitle,
+			Required: false,
+		},
+		{
+			Name:     schema.AttributeTypeAlertApiKey,
+			Required: true,
+		},
+		{
+			Name:     schema.AttributeTypeApiUrl,
+			Required: false,
+		},
+		{
+			Name:     schema.AttributeTypePriority,
+			Required: false,
+		},
+		{
+			Name:     schema.AttributeTypeTo,
+			Required: false,
+		},
+	},
+}
+
+var IntegrationDiscordBlockSchema = &hcl.BodySchema{
+	Attributes: []hcl.AttributeSchema{
+		{
+			Name:     schema.AttributeTypeDescription,
+			Required: false,
+		},
+		{
+			Name:     schema.AttributeTypeTitle,
+			Required: false,
+		},
+		{
+			Name:     schema.AttributeTypeToken,
+			Required: false,
+		},
+		{
+			Name:     schema.AttributeTypeWebhookUrl,
+			Required: false,
+		},
+		{
+			Name:     schema.AttributeTypeChannel,
+			Required: false,
+		},
+	},
+}
+
 var TriggerScheduleBlockSchema = &hcl.BodySchema{
 	Attributes: []hcl.AttributeSchema{
 		{
@@ -134,6 +209,15 @@ var TriggerScheduleBlockSchema = &hcl.BodySchema{
 			Name:     schema.AttributeTypePipeline,
 			Required: true,
 		},
+		{
+			Name: schema.AttributeTypeTimezone,
+		},
+		{
+			Name: schema.AttributeTypeJitter,
+		},
+		{
+			Name: schema.AttributeTypeCatchUp,
+		},
 		{
 			Name: schema.AttributeTypeArgs,
 		},
@@ -146,6 +230,9 @@ var TriggerScheduleBlockSchema = &hcl.BodySchema{
 			Type:       schema.BlockTypeParam,
 			LabelNames: []string{schema.LabelName},
 		},
+		{
+			Type: schema.BlockTypeBlackout,
+		},
 	},
 }
 
@@ -396,6 +483,9 @@ var PipelineStepHttpBlockSchema = &hcl.BodySchema{
 		{
 			Name: schema.AttributeTypeRequestHeaders,
 		},
+		{
+			Name: schema.AttributeTypeConnection,
+		},
 		{
 			Name: schema.AttributeTypeMaxConcurrency,
 		},
@@ -932,6 +1022,9 @@ var PipelineStepInputBlockSchema = &hcl.BodySchema{
 		{
 			Type: schema.BlockTypeLoop,
 		},
+		{
+			Type: schema.BlockTypeTemplate,
+		},
 	},
 }
 
@@ -957,8 +1050,7 @@ var PipelineStepMessageBlockSchema = &hcl.BodySchema{
 			Required: true,
 		},
 		{
-			Name:     schema.AttributeTypeText,
-			Required: true,
+			Name: schema.AttributeTypeText,
 		},
 		{
 			Name:     schema.AttributeTypeTo,
@@ -998,6 +1090,9 @@ var PipelineStepMessageBlockSchema = &hcl.BodySchema{
 		{
 			Type: schema.BlockTypeLoop,
 		},
+		{
+			Type: schema.BlockTypeTemplate,
+		},
 	},
 }
 
@@ -1011,3 +1106,68 @@ var PipelineStepInputNotifyBlockSchema = &hcl.BodySchema{
 		},
 	},
 }
+
+var MessageTemplateBlockSchema = &hcl.BodySchema{
+	Attributes: []hcl.AttributeSchema{
+		{
+			Name: schema.AttributeTypeTitle,
+		},
+		{
+			Name: schema.AttributeTypeBody,
+		},
+		{
+			Name: schema.AttributeTypeSeverity,
+		},
+		{
+			Name: schema.AttributeTypeColor,
+		},
+	},
+	Blocks: []hcl.BlockHeaderSchema{
+		{
+			Type:       schema.BlockTypeField,
+			LabelNames: []string{schema.LabelName},
+		},
+		{
+			Type:       schema.BlockTypeLink,
+			LabelNames: []string{schema.LabelName},
+		},
+	},
+}
+
+var MessageTemplateFieldBlockSchema = &hcl.BodySchema{
+	Attributes: []hcl.AttributeSchema{
+		{
+			Name:     schema.AttributeTypeValue,
+			Required: true,
+		},
+		{
+			Name: schema.AttributeTypeShort,
+		},
+	},
+}
+
+var MessageTemplateLinkBlockSchema = &hcl.BodySchema{
+	Attributes: []hcl.AttributeSchema{
+		{
+			Name:     schema.AttributeTypeUrl,
+			Required: true,
+		},
+	},
+}
+
+var TriggerScheduleBlackoutBlockSchema = &hcl.BodySchema{
+	Attributes: []hcl.AttributeSchema{
+		{
+			Name: schema.AttributeTypeSchedule,
+		},
+		{
+			Name: schema.AttributeTypeDuration,
+		},
+		{
+			Name: schema.AttributeTypeStart,
+		},
+		{
+			Name: schema.AttributeTypeEnd,
+		},
+	},
+}
@@ -1,6 +1,7 @@
 package modconfig
 
 import (
+	"fmt"
 	"reflect"
 	"strings"
 
@@ -8,8 +9,11 @@ import (
 	"github.com/hashicorp/hcl/v2/gohcl"
 	"github.com/turbot/go-kit/helpers"
 	"github.com/turbot/go-kit/types"
+	"github.com/turbot/pipe-fittings/app_specific_connection"
+	"github.com/turbot/pipe-fittings/connection"
 	"github.com/turbot/pipe-fittings/error_helpers"
 	"github.com/turbot/pipe-fittings/hclhelpers"
+	"github.com/turbot/pipe-fittings/perr"
 	"github.com/turbot/pipe-fittings/schema"
 	"github.com/turbot/pipe-fittings/utils"
 	"github.com/zclconf/go-cty/cty"
@@ -149,11 +153,70 @@ func (p *PipelineStepHttp) GetInputs2(evalContext *hcl.EvalContext) (map[string]
 		basicAuthMap["Password"] = basicAuth.Password
 		results[schema.BlockTypePipelineBasicAuth] = basicAuthMap
 	}
+
+	// connection
+	if connectionExpression, ok := p.UnresolvedAttributes[schema.AttributeTypeConnection]; ok {
+		connectionDependencies, err := p.applyConnection(connectionExpression, evalContext, results)
+		if err != nil {
+			return nil, nil, err
+		}
+		allConnectionDependencies = append(allConnectionDependencies, connectionDependencies...)
+	}
 	results[schema.AttributeTypeStepName] = p.Name
 
 	return results, allConnectionDependencies, nil
 }
 
+// applyConnection applies the base url, headers and auth of the http connection referenced by the connection
+// attribute to the url and request headers inputs
+func (p *PipelineStepHttp) applyConnection(expr hcl.Expression, evalContext *hcl.EvalContext, results map[string]interface{}) ([]ConnectionDependency, error) {
+	var connValue cty.Value
+	diags := gohcl.DecodeExpression(expr, evalContext, &connValue)
+	if diags.HasErrors() {
+		// the connection will be resolved at runtime
+		if IsConnectionError(diags) {
+			return FindConnectionFromDiags(diags), nil
+		}
+		return nil, error_helpers.BetterHclDiagsToError(p.Name, diags)
+	}
+	if connValue.IsNull() {
+		return nil, nil
+	}
+	if !connValue.Type().IsObjectType() {
+		return nil, perr.BadRequestWithMessage(p.Name + ": connection attribute must reference an http connection")
+	}
+
+	c, err := app_specific_connection.CtyValueToConnection(connValue)
+	if err != nil {
+		return nil, perr.BadRequestWithMessage(p.Name + ": unable to resolve connection attribute: " + err.Error())
+	}
+	httpConnection, ok := c.(*connection.HttpConnection)
+	if !ok {
+		return nil, perr.BadRequestWithMessage(fmt.Sprintf("%s: invalid connection reference '%s' - only http connections are supported", p.Name, c.Name()))
+	}
+
+	requestUrl, _ := results[schema.AttributeTypeUrl].(string)
+	requestHeaders := make(map[string]string)
+	if headers, ok := results[schema.AttributeTypeRequestHeaders].(map[string]interface{}); ok {
+		for name, value := range headers {
+			requestHeaders[name] = fmt.Sprint(value)
+		}
+	}
+
+	requestUrl, headers, err := httpConnection.PrepareRequest(requestUrl, requestHeaders)
+	if err != nil {
+		return nil, perr.BadRequestWithMessage(p.Name + ": " + err.Error())
+	}
+
+	results[schema.AttributeTypeUrl] = requestUrl
+	headersValue := make(map[string]interface{}, len(headers))
+	for name, value := range headers {
+		headersValue[name] = value
+	}
+	results[schema.AttributeTypeRequestHeaders] = headersValue
+	return nil, nil
+}
+
 func (p *PipelineStepHttp) SetAttributes(hclAttributes hcl.Attributes, evalContext *hcl.EvalContext) hcl.Diagnostics {
 	diags := p.SetBaseAttributes(hclAttributes, evalContext)
 
@@ -241,6 +304,22 @@ func (p *PipelineStepHttp) SetAttributes(hclAttributes hcl.Attributes, evalConte
 					continue
 				}
 			}
+		case schema.AttributeTypeConnection:
+			val, stepDiags := dependsOnFromExpressions(attr, evalContext, p)
+			if stepDiags.HasErrors() {
+				diags = append(diags, stepDiags...)
+				continue
+			}
+
+			// connections are resolved at runtime, so the value should not be known yet
+			if val != cty.NilVal {
+				diags = append(diags, &hcl.Diagnostic{
+					Severity: hcl.DiagError,
+					Summary:  "connection attribute must reference an http connection",
+					Subject:  &attr.Range,
+				})
+				continue
+			}
 		default:
 			if !p.IsBaseAttribute(name) {
 				diags = append(diags, &hcl.Diagnostic{
@@ -20,6 +20,9 @@ type PipelineStepInput struct {
 	Prompt     *string `json:"prompt" cty:"prompt"`
 	OptionList []PipelineStepInputOption
 
+	// Template is a structured alternative to Prompt, rendered natively by each integration
+	Template *MessageTemplate `json:"template,omitempty" cty:"-"`
+
 	// Notifier cty.Value `json:"-" cty:"notify"`
 	Notifier NotifierImpl `json:"notify" cty:"-"`
 
@@ -70,6 +73,7 @@ func (p *PipelineStepInput) Equals(other PipelineStep) bool {
 		utils.PtrEqual(p.Subject, pOther.Subject) &&
 		utils.PtrEqual(p.Title, pOther.Title) &&
 		helpers.StringSliceEqualIgnoreOrder(p.To, pOther.To) &&
+		p.Template.Equals(pOther.Template) &&
 		p.Notifier.Equals(&pOther.Notifier)
 
 }
@@ -161,6 +165,15 @@ func (p *PipelineStepInput) GetInputs2(evalContext *hcl.EvalContext) (map[string
 
 	results[schema.AttributeTypeOptions] = resolvedOpts
 
+	// template
+	if p.Template != nil {
+		template, diags := p.Template.Resolve(evalContext)
+		if diags.HasErrors() {
+			return nil, nil, error_helpers.BetterHclDiagsToError(p.Name, diags)
+		}
+		results[schema.BlockTypeTemplate] = template
+	}
+
 	// notifier
 	if attr, ok := p.UnresolvedAttributes[schema.AttributeTypeNotifier]; !ok {
 		results[schema.AttributeTypeNotifier] = p.Notifier
@@ -312,6 +325,10 @@ func (p *PipelineStepInput) SetBlockConfig(blocks hcl.Blocks, evalContext *hcl.E
 		}
 	}
 
+	template, moreDiags := decodeStepMessageTemplate(blocks, &p.PipelineStepBase, evalContext)
+	diags = append(diags, moreDiags...)
+	p.Template = template
+
 	return diags
 }
 
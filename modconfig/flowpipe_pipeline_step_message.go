@@ -15,6 +15,9 @@ type PipelineStepMessage struct {
 
 	Text string `json:"text" hcl:"text" cty:"text"`
 
+	// Template is a structured alternative to Text, rendered natively by each integration
+	Template *MessageTemplate `json:"template,omitempty" cty:"-"`
+
 	// Notifier cty.Value `json:"-" cty:"notify"`
 	Notifier NotifierImpl `json:"notify" cty:"-"`
 
@@ -50,6 +53,7 @@ func (p *PipelineStepMessage) Equals(iOther PipelineStep) bool {
 		helpers.StringSliceEqualIgnoreOrder(p.Bcc, other.Bcc) &&
 		utils.PtrEqual(p.Channel, other.Channel) &&
 		helpers.StringSliceEqualIgnoreOrder(p.To, other.To) &&
+		p.Template.Equals(other.Template) &&
 		p.Notifier.Equals(&other.Notifier)
 }
 
@@ -65,7 +69,7 @@ func (p *PipelineStepMessage) GetInputs2(evalContext *hcl.EvalContext) (map[stri
 
 	results := map[string]interface{}{}
 
-	// text is a mandatory attribute
+	// text is mandatory unless a template is provided
 	textValue, connectionDependencies, diags := decodeStepAttribute(p.UnresolvedAttributes, evalContext, p.Name, schema.AttributeTypeText, p.Text)
 	if len(diags) > 0 {
 		return nil, nil, error_helpers.BetterHclDiagsToError(p.Name, diags)
@@ -113,6 +117,15 @@ func (p *PipelineStepMessage) GetInputs2(evalContext *hcl.EvalContext) (map[stri
 	results[schema.AttributeTypeBcc] = bccValue
 	allConnectionDependencies = append(allConnectionDependencies, connectionDependencies...)
 
+	// template
+	if p.Template != nil {
+		template, diags := p.Template.Resolve(evalContext)
+		if diags.HasErrors() {
+			return nil, nil, error_helpers.BetterHclDiagsToError(p.Name, diags)
+		}
+		results[schema.BlockTypeTemplate] = template
+	}
+
 	// notifier
 	if attr, ok := p.UnresolvedAttributes[schema.AttributeTypeNotifier]; !ok {
 		results[schema.AttributeTypeNotifier] = p.Notifier
@@ -197,3 +210,27 @@ func (p *PipelineStepMessage) SetAttributes(hclAttributes hcl.Attributes, evalCo
 
 	return diags
 }
+
+func (p *PipelineStepMessage) SetBlockConfig(blocks hcl.Blocks, evalContext *hcl.EvalContext) hcl.Diagnostics {
+	diags := p.PipelineStepBase.SetBlockConfig(blocks, evalContext)
+
+	template, moreDiags := decodeStepMessageTemplate(blocks, &p.PipelineStepBase, evalContext)
+	diags = append(diags, moreDiags...)
+	p.Template = template
+
+	return diags
+}
+
+func (p *PipelineStepMessage) Validate() hcl.Diagnostics {
+	diags := hcl.Diagnostics{}
+
+	if p.Text == "" && p.UnresolvedAttributes[schema.AttributeTypeText] == nil && p.Template == nil {
+		diags = append(diags, &hcl.Diagnostic{
+			Severity: hcl.DiagError,
+			Summary:  "Message step requires either the " + schema.AttributeTypeText + " attribute or a " + schema.BlockTypeTemplate + " block: " + p.GetFullyQualifiedName(),
+			Subject:  p.Range,
+		})
+	}
+
+	return diags
+}
@@ -5,6 +5,7 @@ import (
 	"reflect"
 	"slices"
 	"strings"
+	"time"
 
 	"github.com/hashicorp/hcl/v2"
 	"github.com/hashicorp/hcl/v2/gohcl"
@@ -274,9 +275,13 @@ type TriggerConfig interface {
 }
 
 type TriggerSchedule struct {
-	Schedule             string                    `json:"schedule"`
-	UnresolvedAttributes map[string]hcl.Expression `json:"-"`
-	ConnectionDependsOn  []string                  `json:"connection_depends_on,omitempty"`
+	Schedule             string                     `json:"schedule"`
+	Timezone             string                     `json:"timezone,omitempty"`
+	Jitter               *time.Duration             `json:"jitter,omitempty"`
+	CatchUp              string                     `json:"catch_up,omitempty"`
+	Blackouts            []*TriggerScheduleBlackout `json:"blackouts,omitempty"`
+	UnresolvedAttributes map[string]hcl.Expression  `json:"-"`
+	ConnectionDependsOn  []string                   `json:"connection_depends_on,omitempty"`
 }
 
 func (t *TriggerSchedule) GetConfig(evalContext *hcl.EvalContext, mod *Mod) (TriggerConfig, error) {
@@ -351,7 +356,17 @@ func (t *TriggerSchedule) Equals(other TriggerConfig) bool {
 		}
 	}
 
-	return t.Schedule == otherTrigger.Schedule
+	if t.Schedule != otherTrigger.Schedule || t.Timezone != otherTrigger.Timezone || t.CatchUp != otherTrigger.CatchUp {
+		return false
+	}
+
+	if !utils.PtrEqual(t.Jitter, otherTrigger.Jitter) {
+		return false
+	}
+
+	return slices.EqualFunc(t.Blackouts, otherTrigger.Blackouts, func(a, b *TriggerScheduleBlackout) bool {
+		return a.Equals(b)
+	})
 }
 
 func (t *TriggerSchedule) SetAttributes(mod *Mod, trigger *Trigger, hclAttributes hcl.Attributes, evalContext *hcl.EvalContext) hcl.Diagnostics {
@@ -396,6 +411,56 @@ func (t *TriggerSchedule) SetAttributes(mod *Mod, trigger *Trigger, hclAttribute
 					Subject:  &attr.Range,
 				})
 			}
+		case schema.AttributeTypeTimezone:
+			timezone, moreDiags := scheduleStringAttribute(attr, evalContext)
+			if len(moreDiags) > 0 {
+				diags = append(diags, moreDiags...)
+				continue
+			}
+
+			if _, err := time.LoadLocation(timezone); err != nil {
+				diags = append(diags, &hcl.Diagnostic{
+					Severity: hcl.DiagError,
+					Summary:  "Invalid timezone: " + timezone + ". Specify a valid IANA time zone name, e.g. Europe/London",
+					Detail:   err.Error(),
+					Subject:  &attr.Range,
+				})
+				continue
+			}
+			t.Timezone = timezone
+		case schema.AttributeTypeJitter:
+			jitter, moreDiags := scheduleStringAttribute(attr, evalContext)
+			if len(moreDiags) > 0 {
+				diags = append(diags, moreDiags...)
+				continue
+			}
+
+			duration, err := time.ParseDuration(jitter)
+			if err != nil || duration < 0 {
+				diags = append(diags, &hcl.Diagnostic{
+					Severity: hcl.DiagError,
+					Summary:  "Invalid jitter: " + jitter + ". Specify a positive duration, e.g. 30s or 5m",
+					Subject:  &attr.Range,
+				})
+				continue
+			}
+			t.Jitter = &duration
+		case schema.AttributeTypeCatchUp:
+			catchUp, moreDiags := scheduleStringAttribute(attr, evalContext)
+			if len(moreDiags) > 0 {
+				diags = append(diags, moreDiags...)
+				continue
+			}
+
+			if !slices.Contains(ValidCatchUpPolicies, catchUp) {
+				diags = append(diags, &hcl.Diagnostic{
+					Severity: hcl.DiagError,
+					Summary:  "Invalid catch_up policy: " + catchUp + ". Valid values are: " + strings.Join(ValidCatchUpPolicies, ", "),
+					Subject:  &attr.Range,
+				})
+				continue
+			}
+			t.CatchUp = catchUp
 		default:
 			if !trigger.IsBaseAttribute(name) {
 				diags = append(diags, &hcl.Diagnostic{
@@ -411,9 +476,49 @@ func (t *TriggerSchedule) SetAttributes(mod *Mod, trigger *Trigger, hclAttribute
 
 func (t *TriggerSchedule) SetBlocks(mod *Mod, trigger *Trigger, hclBlocks hcl.Blocks, evalContext *hcl.EvalContext) hcl.Diagnostics {
 	diags := hcl.Diagnostics{}
+
+	// blackout dates without an explicit offset are interpreted in the trigger's timezone
+	location, err := t.Location()
+	if err != nil {
+		// the timezone has already been validated in SetAttributes
+		location = time.UTC
+	}
+
+	for _, block := range hclBlocks {
+		if block.Type != schema.BlockTypeBlackout {
+			continue
+		}
+
+		blackout, moreDiags := decodeTriggerScheduleBlackout(block, location, evalContext)
+		if len(moreDiags) > 0 {
+			diags = append(diags, moreDiags...)
+			continue
+		}
+		t.Blackouts = append(t.Blackouts, blackout)
+	}
+
 	return diags
 }
 
+// scheduleStringAttribute resolves a schedule trigger attribute which must be a fully resolved string
+func scheduleStringAttribute(attr *hcl.Attribute, evalContext *hcl.EvalContext) (string, hcl.Diagnostics) {
+	val, diags := attr.Expr.Value(evalContext)
+	if len(diags) > 0 {
+		return "", diags
+	}
+
+	if val.IsNull() || val.Type() != cty.String {
+		return "", hcl.Diagnostics{&hcl.Diagnostic{
+			Severity: hcl.DiagError,
+			Summary:  "The given " + attr.Name + " is not a string",
+			Detail:   "The given " + attr.Name + " is not a string",
+			Subject:  &attr.Range,
+		}}
+	}
+
+	return val.AsString(), diags
+}
+
 var validIntervals = []string{"hourly", "daily", "weekly", "5m", "10m", "15m", "30m", "60m", "1h", "2h", "4h", "6h", "12h", "24h"}
 
 type TriggerQuery struct {
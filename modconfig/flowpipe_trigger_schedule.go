@@ -0,0 +1,387 @@
+package modconfig
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"slices"
+	"time"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/robfig/cron/v3"
+	"github.com/turbot/pipe-fittings/perr"
+	"github.com/turbot/pipe-fittings/schema"
+	"github.com/turbot/pipe-fittings/utils"
+)
+
+// Catch up policies for schedule triggers, controlling what happens to runs missed while the scheduler was down
+const (
+	// CatchUpPolicyNone skips all missed runs (default)
+	CatchUpPolicyNone = "none"
+	// CatchUpPolicyLatest runs only the most recent missed run
+	CatchUpPolicyLatest = "latest"
+	// CatchUpPolicyAll runs every missed run, oldest first
+	CatchUpPolicyAll = "all"
+)
+
+var ValidCatchUpPolicies = []string{CatchUpPolicyNone, CatchUpPolicyLatest, CatchUpPolicyAll}
+
+// maxScheduleCatchUpRuns caps the number of missed runs returned for the "all" catch up policy
+const maxScheduleCatchUpRuns = 1000
+
+// maxScheduleCatchUpWindow is the widest window before now searched for missed runs before searching from the last
+// run - it keeps the window within the range of a time.Duration
+const maxScheduleCatchUpWindow = 100 * 365 * 24 * time.Hour
+
+// maxScheduleBlackoutSkips guards against schedules which are permanently blacked out
+const maxScheduleBlackoutSkips = 10000
+
+// map of the interval shorthands supported by the schedule attribute to their cron equivalent
+var intervalCronExpressions = map[string]string{
+	"hourly": "@hourly",
+	"daily":  "@daily",
+	"weekly": "@weekly",
+}
+
+// TriggerScheduleBlackout is a window during which scheduled runs are skipped.
+//
+// It is either recurring (a cron Schedule marking the start of each window, plus the window Duration)
+// or a fixed date range ([Start, End)).
+type TriggerScheduleBlackout struct {
+	Schedule string         `json:"schedule,omitempty"`
+	Duration *time.Duration `json:"duration,omitempty"`
+	Start    *time.Time     `json:"start,omitempty"`
+	End      *time.Time     `json:"end,omitempty"`
+}
+
+func (b *TriggerScheduleBlackout) Equals(other *TriggerScheduleBlackout) bool {
+	if b == nil && other == nil {
+		return true
+	}
+
+	if b == nil && other != nil || b != nil && other == nil {
+		return false
+	}
+
+	return b.Schedule == other.Schedule &&
+		utils.PtrEqual(b.Duration, other.Duration) &&
+		timePtrEqual(b.Start, other.Start) &&
+		timePtrEqual(b.End, other.End)
+}
+
+// window returns the end of the blackout window containing t, and whether t is in a blackout window at all
+func (b *TriggerScheduleBlackout) window(t time.Time) (time.Time, bool) {
+	if b.Start != nil && b.End != nil {
+		if !t.Before(*b.Start) && t.Before(*b.End) {
+			return *b.End, true
+		}
+		return time.Time{}, false
+	}
+
+	if b.Schedule == "" || b.Duration == nil {
+		return time.Time{}, false
+	}
+
+	sched, err := cron.ParseStandard(b.Schedule)
+	if err != nil {
+		return time.Time{}, false
+	}
+
+	// any window containing t must have started in the interval (t - duration, t]
+	start := sched.Next(t.Add(-*b.Duration))
+	if start.After(t) {
+		return time.Time{}, false
+	}
+	return start.Add(*b.Duration), true
+}
+
+func decodeTriggerScheduleBlackout(block *hcl.Block, location *time.Location, evalContext *hcl.EvalContext) (*TriggerScheduleBlackout, hcl.Diagnostics) {
+	content, diags := block.Body.Content(TriggerScheduleBlackoutBlockSchema)
+	if diags.HasErrors() {
+		return nil, diags
+	}
+
+	attrs := content.Attributes
+	blackout := &TriggerScheduleBlackout{}
+
+	_, hasSchedule := attrs[schema.AttributeTypeSchedule]
+	_, hasDuration := attrs[schema.AttributeTypeDuration]
+	_, hasStart := attrs[schema.AttributeTypeStart]
+	_, hasEnd := attrs[schema.AttributeTypeEnd]
+
+	switch {
+	case (hasSchedule || hasDuration) && (hasStart || hasEnd):
+		return nil, hcl.Diagnostics{&hcl.Diagnostic{
+			Severity: hcl.DiagError,
+			Summary:  "Invalid blackout block",
+			Detail:   "A blackout must specify either 'schedule' and 'duration', or 'start' and 'end', but not both",
+			Subject:  &block.DefRange,
+		}}
+
+	case hasSchedule && hasDuration:
+		scheduleAttr := attrs[schema.AttributeTypeSchedule]
+		schedule, moreDiags := scheduleStringAttribute(scheduleAttr, evalContext)
+		if len(moreDiags) > 0 {
+			return nil, moreDiags
+		}
+		if _, err := cron.ParseStandard(schedule); err != nil {
+			return nil, hcl.Diagnostics{&hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "Invalid blackout cron expression: " + schedule,
+				Detail:   err.Error(),
+				Subject:  &scheduleAttr.Range,
+			}}
+		}
+		blackout.Schedule = schedule
+
+		durationAttr := attrs[schema.AttributeTypeDuration]
+		durationString, moreDiags := scheduleStringAttribute(durationAttr, evalContext)
+		if len(moreDiags) > 0 {
+			return nil, moreDiags
+		}
+		duration, err := time.ParseDuration(durationString)
+		if err != nil || duration <= 0 {
+			return nil, hcl.Diagnostics{&hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "Invalid blackout duration: " + durationString + ". Specify a positive duration, e.g. 2h",
+				Subject:  &durationAttr.Range,
+			}}
+		}
+		blackout.Duration = &duration
+
+	case hasStart && hasEnd:
+		startAttr := attrs[schema.AttributeTypeStart]
+		start, moreDiags := blackoutTimeAttribute(startAttr, location, false, evalContext)
+		if len(moreDiags) > 0 {
+			return nil, moreDiags
+		}
+
+		endAttr := attrs[schema.AttributeTypeEnd]
+		end, moreDiags := blackoutTimeAttribute(endAttr, location, true, evalContext)
+		if len(moreDiags) > 0 {
+			return nil, moreDiags
+		}
+
+		if !end.After(start) {
+			return nil, hcl.Diagnostics{&hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "Invalid blackout block",
+				Detail:   "The blackout 'end' must be after 'start'",
+				Subject:  &endAttr.Range,
+			}}
+		}
+		blackout.Start = &start
+		blackout.End = &end
+
+	default:
+		return nil, hcl.Diagnostics{&hcl.Diagnostic{
+			Severity: hcl.DiagError,
+			Summary:  "Invalid blackout block",
+			Detail:   "A blackout must specify either 'schedule' and 'duration', or 'start' and 'end'",
+			Subject:  &block.DefRange,
+		}}
+	}
+
+	return blackout, diags
+}
+
+// blackoutTimeAttribute parses an RFC3339 timestamp, a local date time (2006-01-02T15:04:05) or a date (2006-01-02).
+// Timestamps without an offset are interpreted in the given location. A date used as the end of a range is inclusive,
+// i.e. the blackout runs until the end of that day
+func blackoutTimeAttribute(attr *hcl.Attribute, location *time.Location, isEnd bool, evalContext *hcl.EvalContext) (time.Time, hcl.Diagnostics) {
+	value, diags := scheduleStringAttribute(attr, evalContext)
+	if len(diags) > 0 {
+		return time.Time{}, diags
+	}
+
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, diags
+	}
+
+	if t, err := time.ParseInLocation("2006-01-02T15:04:05", value, location); err == nil {
+		return t, diags
+	}
+
+	if t, err := time.ParseInLocation(time.DateOnly, value, location); err == nil {
+		if isEnd {
+			t = t.AddDate(0, 0, 1)
+		}
+		return t, diags
+	}
+
+	return time.Time{}, hcl.Diagnostics{&hcl.Diagnostic{
+		Severity: hcl.DiagError,
+		Summary:  "Invalid blackout " + attr.Name + ": " + value + ". Specify a date (2006-01-02) or timestamp (2006-01-02T15:04:05Z07:00)",
+		Subject:  &attr.Range,
+	}}
+}
+
+// Location returns the time zone the schedule is evaluated in, defaulting to UTC
+func (t *TriggerSchedule) Location() (*time.Location, error) {
+	if t.Timezone == "" {
+		return time.UTC, nil
+	}
+	return time.LoadLocation(t.Timezone)
+}
+
+// CronSchedule returns the parsed schedule, with interval shorthands (hourly, 5m, ...) converted to their cron equivalent
+func (t *TriggerSchedule) CronSchedule() (cron.Schedule, error) {
+	spec := t.Schedule
+	if expr, ok := intervalCronExpressions[spec]; ok {
+		spec = expr
+	} else if slices.Contains(validIntervals, spec) {
+		spec = "@every " + spec
+	}
+
+	sched, err := cron.ParseStandard(spec)
+	if err != nil {
+		return nil, perr.BadRequestWithMessage("invalid schedule " + t.Schedule + ": " + err.Error())
+	}
+	return sched, nil
+}
+
+// IsBlackedOut returns whether the given time falls inside any of the trigger's blackout windows
+func (t *TriggerSchedule) IsBlackedOut(at time.Time) bool {
+	_, ok := t.blackoutEnd(at)
+	return ok
+}
+
+// blackoutEnd returns the latest end of the blackout windows containing the given time - the windows are evaluated
+// in the trigger's timezone, whatever the location of the given time
+func (t *TriggerSchedule) blackoutEnd(at time.Time) (time.Time, bool) {
+	if location, err := t.Location(); err == nil {
+		at = at.In(location)
+	}
+	var end time.Time
+	found := false
+	for _, b := range t.Blackouts {
+		if windowEnd, ok := b.window(at); ok {
+			found = true
+			if windowEnd.After(end) {
+				end = windowEnd
+			}
+		}
+	}
+	return end, found
+}
+
+// NextFireTimes returns the next n times after from at which the trigger is scheduled to fire, in the trigger's
+// timezone. Runs falling inside a blackout window are skipped. Jitter is not applied, the times returned are nominal.
+func (t *TriggerSchedule) NextFireTimes(from time.Time, n int) ([]time.Time, error) {
+	location, err := t.Location()
+	if err != nil {
+		return nil, perr.BadRequestWithMessage("invalid timezone " + t.Timezone + ": " + err.Error())
+	}
+
+	sched, err := t.CronSchedule()
+	if err != nil {
+		return nil, err
+	}
+
+	var res []time.Time
+	next := from.In(location)
+	skips := 0
+	for len(res) < n {
+		next = sched.Next(next)
+		if next.IsZero() {
+			// the schedule will never fire again
+			break
+		}
+
+		if end, ok := t.blackoutEnd(next); ok {
+			skips++
+			if skips > maxScheduleBlackoutSkips {
+				return nil, perr.BadRequestWithMessage(fmt.Sprintf("schedule %s does not fire outside its blackout windows", t.Schedule))
+			}
+			// resume from just before the end of the blackout, so a run scheduled exactly at the end is not skipped
+			next = end.Add(-time.Nanosecond).In(location)
+			continue
+		}
+
+		res = append(res, next)
+	}
+
+	return res, nil
+}
+
+// MissedFireTimes returns the runs which should be executed to catch up on runs missed between lastRun and now,
+// according to the trigger's catch up policy
+func (t *TriggerSchedule) MissedFireTimes(lastRun, now time.Time) ([]time.Time, error) {
+	if t.CatchUp == "" || t.CatchUp == CatchUpPolicyNone || !now.After(lastRun) {
+		return nil, nil
+	}
+
+	location, err := t.Location()
+	if err != nil {
+		return nil, perr.BadRequestWithMessage("invalid timezone " + t.Timezone + ": " + err.Error())
+	}
+
+	sched, err := t.CronSchedule()
+	if err != nil {
+		return nil, err
+	}
+
+	n := maxScheduleCatchUpRuns
+	if t.CatchUp == CatchUpPolicyLatest {
+		n = 1
+	}
+
+	// rather than visiting every run since lastRun, which may be years ago, collect the runs in a window before now
+	// which is widened until it holds enough runs or reaches lastRun - the window starts at n intervals of the schedule
+	window := time.Duration(0)
+	if first := sched.Next(now.In(location)); !first.IsZero() {
+		if second := sched.Next(first); !second.IsZero() && second.Sub(first) < maxScheduleCatchUpWindow/time.Duration(n) {
+			window = second.Sub(first) * time.Duration(n)
+		}
+	}
+	for {
+		start := lastRun
+		if window > 0 && now.Add(-window).After(lastRun) {
+			start = now.Add(-window)
+		}
+		missed := t.lastFireTimes(sched, location, start, now, n)
+		if len(missed) >= n || !start.After(lastRun) {
+			return missed, nil
+		}
+		if window > maxScheduleCatchUpWindow/2 {
+			window = 0
+		} else {
+			window *= 2
+		}
+	}
+}
+
+// lastFireTimes returns at most the last n runs in (from, to], excluding runs in blackout windows
+func (t *TriggerSchedule) lastFireTimes(sched cron.Schedule, location *time.Location, from, to time.Time, n int) []time.Time {
+	var res []time.Time
+	for next := sched.Next(from.In(location)); !next.IsZero() && !next.After(to); next = sched.Next(next) {
+		if end, ok := t.blackoutEnd(next); ok {
+			next = end.Add(-time.Nanosecond).In(location)
+			continue
+		}
+
+		res = append(res, next)
+		if len(res) > n {
+			// only keep the most recent runs
+			res = res[1:]
+		}
+	}
+	return res
+}
+
+// JitterDelay returns a random delay in the range [0, jitter) to apply to a run
+func (t *TriggerSchedule) JitterDelay() time.Duration {
+	if t.Jitter == nil || *t.Jitter <= 0 {
+		return 0
+	}
+	return rand.N(*t.Jitter) //nolint:gosec // jitter does not need a cryptographically secure source
+}
+
+func timePtrEqual(a, b *time.Time) bool {
+	if a == nil && b == nil {
+		return true
+	}
+	if a == nil || b == nil {
+		return false
+	}
+	return a.Equal(*b)
+}
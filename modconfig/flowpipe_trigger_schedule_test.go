@@ -0,0 +1,253 @@
+package modconfig
+
+import (
+	"testing"
+	"time"
+)
+
+func mustTime(t *testing.T, value string) time.Time {
+	res, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return res
+}
+
+func durationPtr(d time.Duration) *time.Duration {
+	return &d
+}
+
+func timePtr(t time.Time) *time.Time {
+	return &t
+}
+
+func TestTriggerSchedule_NextFireTimes(t *testing.T) {
+	tests := []struct {
+		name    string
+		trigger *TriggerSchedule
+		from    string
+		n       int
+		want    []string
+		wantErr bool
+	}{
+		{
+			name:    "cron in UTC",
+			trigger: &TriggerSchedule{Schedule: "0 9 * * *"},
+			from:    "2024-03-01T10:00:00Z",
+			n:       2,
+			want:    []string{"2024-03-02T09:00:00Z", "2024-03-03T09:00:00Z"},
+		},
+		{
+			name:    "cron in timezone",
+			trigger: &TriggerSchedule{Schedule: "0 9 * * *", Timezone: "America/New_York"},
+			from:    "2024-03-01T10:00:00Z",
+			n:       1,
+			want:    []string{"2024-03-01T09:00:00-05:00"},
+		},
+		{
+			name:    "interval shorthand",
+			trigger: &TriggerSchedule{Schedule: "daily"},
+			from:    "2024-03-01T10:00:00Z",
+			n:       2,
+			want:    []string{"2024-03-02T00:00:00Z", "2024-03-03T00:00:00Z"},
+		},
+		{
+			name:    "duration interval",
+			trigger: &TriggerSchedule{Schedule: "15m"},
+			from:    "2024-03-01T10:00:00Z",
+			n:       2,
+			want:    []string{"2024-03-01T10:15:00Z", "2024-03-01T10:30:00Z"},
+		},
+		{
+			name: "recurring blackout",
+			trigger: &TriggerSchedule{
+				Schedule: "0 * * * *",
+				Blackouts: []*TriggerScheduleBlackout{
+					{Schedule: "0 12 * * *", Duration: durationPtr(2 * time.Hour)},
+				},
+			},
+			from: "2024-03-01T10:30:00Z",
+			n:    3,
+			want: []string{"2024-03-01T11:00:00Z", "2024-03-01T14:00:00Z", "2024-03-01T15:00:00Z"},
+		},
+		{
+			name: "date range blackout",
+			trigger: &TriggerSchedule{
+				Schedule: "0 0 * * *",
+				Blackouts: []*TriggerScheduleBlackout{
+					{Start: timePtr(mustTime(t, "2024-12-24T00:00:00Z")), End: timePtr(mustTime(t, "2024-12-27T00:00:00Z"))},
+				},
+			},
+			from: "2024-12-22T12:00:00Z",
+			n:    3,
+			want: []string{"2024-12-23T00:00:00Z", "2024-12-27T00:00:00Z", "2024-12-28T00:00:00Z"},
+		},
+		{
+			name: "permanently blacked out",
+			trigger: &TriggerSchedule{
+				Schedule: "0 * * * *",
+				Blackouts: []*TriggerScheduleBlackout{
+					{Schedule: "0 0 * * *", Duration: durationPtr(24 * time.Hour)},
+				},
+			},
+			from:    "2024-03-01T10:30:00Z",
+			n:       1,
+			wantErr: true,
+		},
+		{
+			name:    "invalid schedule",
+			trigger: &TriggerSchedule{Schedule: "bad cron format"},
+			from:    "2024-03-01T10:30:00Z",
+			n:       1,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.trigger.NextFireTimes(mustTime(t, tt.from), tt.n)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("NextFireTimes() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+
+			if len(got) != len(tt.want) {
+				t.Fatalf("NextFireTimes() returned %d times, want %d", len(got), len(tt.want))
+			}
+			for i, want := range tt.want {
+				if got[i].Format(time.RFC3339) != want {
+					t.Errorf("NextFireTimes()[%d] = %s, want %s", i, got[i].Format(time.RFC3339), want)
+				}
+			}
+		})
+	}
+}
+
+func TestTriggerSchedule_MissedFireTimes(t *testing.T) {
+	lastRun := mustTime(t, "2024-03-01T10:00:00Z")
+	now := mustTime(t, "2024-03-01T13:30:00Z")
+
+	tests := []struct {
+		name    string
+		catchUp string
+		want    []string
+	}{
+		{
+			name: "default",
+		},
+		{
+			name:    "none",
+			catchUp: CatchUpPolicyNone,
+		},
+		{
+			name:    "latest",
+			catchUp: CatchUpPolicyLatest,
+			want:    []string{"2024-03-01T13:00:00Z"},
+		},
+		{
+			name:    "all",
+			catchUp: CatchUpPolicyAll,
+			want:    []string{"2024-03-01T11:00:00Z", "2024-03-01T12:00:00Z", "2024-03-01T13:00:00Z"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			trigger := &TriggerSchedule{Schedule: "0 * * * *", CatchUp: tt.catchUp}
+			got, err := trigger.MissedFireTimes(lastRun, now)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if len(got) != len(tt.want) {
+				t.Fatalf("MissedFireTimes() returned %d times, want %d", len(got), len(tt.want))
+			}
+			for i, want := range tt.want {
+				if got[i].Format(time.RFC3339) != want {
+					t.Errorf("MissedFireTimes()[%d] = %s, want %s", i, got[i].Format(time.RFC3339), want)
+				}
+			}
+		})
+	}
+}
+
+func TestTriggerSchedule_MissedFireTimes_LongDowntime(t *testing.T) {
+	// a year of missed per minute runs - only the most recent are visited and returned
+	lastRun := mustTime(t, "2023-03-01T10:00:00Z")
+	now := mustTime(t, "2024-03-01T13:30:30Z")
+
+	trigger := &TriggerSchedule{Schedule: "* * * * *", CatchUp: CatchUpPolicyAll}
+	got, err := trigger.MissedFireTimes(lastRun, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != maxScheduleCatchUpRuns {
+		t.Fatalf("MissedFireTimes() returned %d times, want %d", len(got), maxScheduleCatchUpRuns)
+	}
+	if first, last := got[0].Format(time.RFC3339), got[len(got)-1].Format(time.RFC3339); first != "2024-02-29T20:51:00Z" || last != "2024-03-01T13:30:00Z" {
+		t.Errorf("MissedFireTimes() = %s ... %s, want 2024-02-29T20:51:00Z ... 2024-03-01T13:30:00Z", first, last)
+	}
+
+	trigger.CatchUp = CatchUpPolicyLatest
+	got, err = trigger.MissedFireTimes(lastRun, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 1 || got[0].Format(time.RFC3339) != "2024-03-01T13:30:00Z" {
+		t.Errorf("MissedFireTimes() with the latest policy = %v, want [2024-03-01T13:30:00Z]", got)
+	}
+
+	// runs are found when the schedule is sparser near now than before it, e.g. weekdays only
+	trigger = &TriggerSchedule{Schedule: "0 9 * * 1-5", CatchUp: CatchUpPolicyAll}
+	got, err = trigger.MissedFireTimes(mustTime(t, "2024-02-26T00:00:00Z"), mustTime(t, "2024-03-02T12:00:00Z"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 5 || got[0].Format(time.RFC3339) != "2024-02-26T09:00:00Z" {
+		t.Errorf("MissedFireTimes() for weekdays = %v, want the 5 runs from 2024-02-26", got)
+	}
+}
+
+func TestTriggerSchedule_JitterDelay(t *testing.T) {
+	trigger := &TriggerSchedule{Jitter: durationPtr(time.Minute)}
+	for i := 0; i < 100; i++ {
+		d := trigger.JitterDelay()
+		if d < 0 || d >= time.Minute {
+			t.Fatalf("JitterDelay() = %s, want [0, 1m)", d)
+		}
+	}
+
+	if d := (&TriggerSchedule{}).JitterDelay(); d != 0 {
+		t.Fatalf("JitterDelay() without jitter = %s, want 0", d)
+	}
+}
+
+func TestTriggerSchedule_IsBlackedOut(t *testing.T) {
+	// a blackout from 22:00 to 06:00 New York time
+	trigger := &TriggerSchedule{
+		Schedule: "0 * * * *",
+		Timezone: "America/New_York",
+		Blackouts: []*TriggerScheduleBlackout{
+			{Schedule: "0 22 * * *", Duration: durationPtr(8 * time.Hour)},
+		},
+	}
+	tests := []struct {
+		at   string
+		want bool
+	}{
+		// 23:00 New York time
+		{at: "2024-03-02T04:00:00Z", want: true},
+		{at: "2024-03-01T23:00:00-05:00", want: true},
+		// 22:00 UTC is 17:00 New York time
+		{at: "2024-03-01T22:00:00Z", want: false},
+		// 12:00 New York time
+		{at: "2024-03-01T17:00:00Z", want: false},
+	}
+	for _, tt := range tests {
+		if got := trigger.IsBlackedOut(mustTime(t, tt.at)); got != tt.want {
+			t.Errorf("IsBlackedOut(%s) = %v, want %v", tt.at, got, tt.want)
+		}
+	}
+}
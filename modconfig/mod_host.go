@@ -0,0 +1,106 @@
+package modconfig
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/turbot/go-kit/helpers"
+)
+
+const (
+	ModHostTypeGitHub    = "github"
+	ModHostTypeGitLab    = "gitlab"
+	ModHostTypeBitbucket = "bitbucket"
+	ModHostTypeGit       = "git"
+)
+
+const (
+	ModHostUrlModeHTTPS = "https"
+	ModHostUrlModeSSH   = "ssh"
+)
+
+// ModHost is the configuration for a git host which mods are installed from, as specified in a mod_host block of a
+// workspace profile, e.g.
+//
+//	mod_host "gitlab.example.com" {
+//	  type       = "gitlab"
+//	  url_mode   = "ssh"
+//	  connection = "gitlab.default"
+//	}
+type ModHost struct {
+	// the host name, as used in mod names, e.g. gitlab.example.com or gitlab.example.com:8443
+	Host string `cty:"host" hcl:"host,label"`
+	// the type of git server - "github", "gitlab", "bitbucket" or "git" - used to determine how tokens are passed
+	Type *string `cty:"type" hcl:"type,optional"`
+	// the protocol used to clone mods - "https" or "ssh". If not set, https is tried, falling back to ssh
+	UrlMode *string `cty:"url_mode" hcl:"url_mode,optional"`
+	// the url used in place of the host when cloning, e.g. ssh://git@gitlab.example.com:2222 or a mirror
+	BaseURL *string `cty:"base_url" hcl:"base_url,optional"`
+
+	// https credentials - a token (with an optional username, e.g. for GitLab deploy tokens), a username and
+	// password, or the name of a github, gitlab or bitbucket connection to take credentials from
+	Token      *string `cty:"token" hcl:"token,optional" sensitive:"true"`
+	Username   *string `cty:"username" hcl:"username,optional"`
+	Password   *string `cty:"password" hcl:"password,optional" sensitive:"true"`
+	Connection *string `cty:"connection" hcl:"connection,optional"`
+	// if set, https credentials are retrieved from the configured git credential helpers
+	CredentialHelper *bool `cty:"credential_helper" hcl:"credential_helper,optional"`
+
+	// ssh credentials - if no key file is set, the ssh agent is used
+	SSHUser          *string `cty:"ssh_user" hcl:"ssh_user,optional"`
+	SSHKeyFile       *string `cty:"ssh_key_file" hcl:"ssh_key_file,optional"`
+	SSHKeyPassphrase *string `cty:"ssh_key_passphrase" hcl:"ssh_key_passphrase,optional" sensitive:"true"`
+
+	DeclRange hcl.Range
+}
+
+func (h *ModHost) Validate() hcl.Diagnostics {
+	var diags hcl.Diagnostics
+	addError := func(summary string) {
+		diags = append(diags, &hcl.Diagnostic{
+			Severity: hcl.DiagError,
+			Summary:  summary,
+			Subject:  &h.DeclRange,
+		})
+	}
+
+	if h.Host == "" || strings.Contains(h.Host, "/") {
+		addError(fmt.Sprintf("invalid mod_host '%s' - must be a host name, e.g. gitlab.example.com", h.Host))
+	}
+	if h.Type != nil {
+		validTypes := []string{ModHostTypeGitHub, ModHostTypeGitLab, ModHostTypeBitbucket, ModHostTypeGit}
+		if !helpers.StringSliceContains(validTypes, *h.Type) {
+			addError(fmt.Sprintf("invalid type '%s' for mod_host %s - must be one of: %s", *h.Type, h.Host, strings.Join(validTypes, ", ")))
+		}
+	}
+	if h.UrlMode != nil && *h.UrlMode != ModHostUrlModeHTTPS && *h.UrlMode != ModHostUrlModeSSH {
+		addError(fmt.Sprintf("invalid url_mode '%s' for mod_host %s - must be one of: %s, %s", *h.UrlMode, h.Host, ModHostUrlModeHTTPS, ModHostUrlModeSSH))
+	}
+	if h.Token != nil && h.Password != nil {
+		addError(fmt.Sprintf("mod_host %s may specify a token or a password, not both", h.Host))
+	}
+	if h.Connection != nil && (h.Token != nil || h.Username != nil || h.Password != nil) {
+		addError(fmt.Sprintf("mod_host %s may specify a connection or credentials, not both", h.Host))
+	}
+	if h.SSHKeyPassphrase != nil && h.SSHKeyFile == nil {
+		addError(fmt.Sprintf("mod_host %s specifies ssh_key_passphrase without ssh_key_file", h.Host))
+	}
+	return diags
+}
+
+// GetType returns the type of the host, inferring it from well known host names if not set
+func (h *ModHost) GetType() string {
+	if h.Type != nil {
+		return *h.Type
+	}
+	switch h.Host {
+	case "github.com":
+		return ModHostTypeGitHub
+	case "gitlab.com":
+		return ModHostTypeGitLab
+	case "bitbucket.org":
+		return ModHostTypeBitbucket
+	}
+	return ModHostTypeGit
+}
@@ -8,17 +8,59 @@ import (
 	"github.com/Masterminds/semver/v3"
 )
 
+// OciModPrefix is the prefix of the name of a mod which is installed from an OCI registry,
+// e.g. oci://ghcr.io/turbot/steampipe-mod-m2
+const OciModPrefix = "oci://"
+
+// ociModDependencyDir is the directory, relative to the mods directory, which mods installed from an OCI registry
+// are installed under - the name prefix is not used as it is not a valid directory name
+const ociModDependencyDir = "oci/"
+
+// IsOciModName returns whether the mod name refers to a mod installed from an OCI registry
+func IsOciModName(name string) bool {
+	return strings.HasPrefix(name, OciModPrefix)
+}
+
+// OciRepositoryRef returns the registry repository ref of an OCI mod name,
+// e.g. oci://ghcr.io/turbot/steampipe-mod-m2 => ghcr.io/turbot/steampipe-mod-m2
+func OciRepositoryRef(name string) string {
+	return strings.TrimPrefix(name, OciModPrefix)
+}
+
+// splitOciModTag splits an OCI mod reference of form oci://ghcr.io/turbot/steampipe-mod-m2:1.0.0 into the mod name
+// and tag (which is empty if the reference has no tag)
+func splitOciModTag(ref string) (name string, tag string) {
+	idx := strings.LastIndex(ref, ":")
+	// a colon before the last slash is the scheme or a registry port, not a tag
+	if idx <= strings.LastIndex(ref, "/") {
+		return ref, ""
+	}
+	return ref[:idx], ref[idx+1:]
+}
+
+// ModDependencyDir returns the path, relative to the mods directory, which the installed versions of the given mod are
+// named from, e.g. github.com/turbot/mod1 for github.com/turbot/mod1@v1.0.0 - this is the mod name, except for mods
+// installed from an OCI registry, which are installed under the oci directory
+func ModDependencyDir(dependencyName string) string {
+	if IsOciModName(dependencyName) {
+		return ociModDependencyDir + OciRepositoryRef(dependencyName)
+	}
+	return dependencyName
+}
+
 // BuildModDependencyPath converts a mod dependency name of form github.com/turbot/steampipe-mod-m2
 // and a DependencyVersion into a dependency path of form:
 // - github.com/turbot/steampipe-mod-m2@v1.0.0
 // - github.com/turbot/steampipe-mod-m2#branch
 // - github.com/turbot/steampipe-mod-m2:filepath
+// - oci/ghcr.io/turbot/steampipe-mod-m2@v1.0.0 (for oci://ghcr.io/turbot/steampipe-mod-m2)
 // This represents the relative path the dependency will be installed at underneath the mods directory
 func BuildModDependencyPath(dependencyName string, version *DependencyVersion) string {
 	if version == nil {
 		// not expected
 		return dependencyName
 	}
+	dependencyName = ModDependencyDir(dependencyName)
 
 	switch {
 	case version.Tag != "":
@@ -38,6 +80,10 @@ func BuildModDependencyPath(dependencyName string, version *DependencyVersion) s
 // ParseModDependencyPath converts a mod depdency path of form github.com/turbot/steampipe-mod-m2@v1.0.0
 // into the dependency name (github.com/turbot/steampipe-mod-m2) and version
 func ParseModDependencyPath(fullName string) (string, *DependencyVersion, error) {
+	// mods installed from an OCI registry are installed under the oci directory
+	if strings.HasPrefix(fullName, ociModDependencyDir) {
+		fullName = OciModPrefix + strings.TrimPrefix(fullName, ociModDependencyDir)
+	}
 	switch {
 	// is this a version constraint
 	case strings.Contains(fullName, "@"):
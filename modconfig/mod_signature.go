@@ -0,0 +1,50 @@
+package modconfig
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+)
+
+const (
+	ModSignatureTypeSSH      = "ssh"
+	ModSignatureTypeMinisign = "minisign"
+)
+
+// ModSignature is a struct to represent the signature verification required for a mod, as specified in the
+// signature block of a mod require block
+type ModSignature struct {
+	// the format of the git tag signature - "ssh" or "minisign"
+	Type string `cty:"type" hcl:"type"`
+	// the public keys trusted to sign the tag - in authorized_keys format for ssh signatures,
+	// or minisign public keys
+	PublicKeys []string `cty:"public_keys" hcl:"public_keys"`
+}
+
+func (s *ModSignature) validate(m *ModVersionConstraint) hcl.Diagnostics {
+	var diags hcl.Diagnostics
+	if s.Type != ModSignatureTypeSSH && s.Type != ModSignatureTypeMinisign {
+		diags = append(diags, &hcl.Diagnostic{
+			Severity: hcl.DiagError,
+			Summary:  fmt.Sprintf("invalid signature type '%s' for mod %s - must be one of: %s", s.Type, m.Name, strings.Join([]string{ModSignatureTypeSSH, ModSignatureTypeMinisign}, ", ")),
+			Subject:  &m.DefRange,
+		})
+	}
+	if len(s.PublicKeys) == 0 {
+		diags = append(diags, &hcl.Diagnostic{
+			Severity: hcl.DiagError,
+			Summary:  fmt.Sprintf("signature for mod %s must specify at least one public key", m.Name),
+			Subject:  &m.DefRange,
+		})
+	}
+	// only git tags are signed
+	if m.BranchName != "" || m.FilePath != "" || IsOciModName(m.Name) {
+		diags = append(diags, &hcl.Diagnostic{
+			Severity: hcl.DiagError,
+			Summary:  fmt.Sprintf("signature verification is only supported for mods installed from a git tag - mod %s must specify a version or tag", m.Name),
+			Subject:  &m.DefRange,
+		})
+	}
+	return diags
+}
@@ -35,9 +35,13 @@ type ModVersionConstraint struct {
 	// the (non-version) tag to use
 	// populated only if a tag which is not a semver is used
 	Tag string `cty:"tag" hcl:"tag,optional"`
+	// optionally, the signature the git tag must be signed with
+	Signature *ModSignature `cty:"signature" hcl:"signature,block"`
 
 	// only one of VersionConstraint, Branch and FilePath will be set
 	versionConstraint *versionhelpers.Constraints
+	// for OCI mods, whether the version was specified as an image tag in the name
+	versionInName bool
 
 	// contains the range of the definition of the mod block
 	DefRange hcl.Range
@@ -62,6 +66,9 @@ func NewModVersionConstraint(modFullName string) (*ModVersionConstraint, error)
 		Args: make(map[string]cty.Value),
 	}
 	switch {
+	case IsOciModName(modFullName) && !strings.ContainsAny(modFullName, "@#"):
+		// OCI mods may specify a version as an image tag, e.g. oci://ghcr.io/turbot/mod:1.0.0
+		m.Name, m.VersionString = splitOciModTag(modFullName)
 	case strings.Contains(modFullName, "@"):
 		// try to extract version from name
 		segments := strings.Split(modFullName, "@")
@@ -102,6 +109,18 @@ func (m *ModVersionConstraint) Initialise(block *hcl.Block) hcl.Diagnostics {
 		}
 	}
 
+	if IsOciModName(m.Name) {
+		if diags := m.initialiseOci(); diags.HasErrors() {
+			return diags
+		}
+	}
+
+	if m.Signature != nil {
+		if diags := m.Signature.validate(m); diags.HasErrors() {
+			return diags
+		}
+	}
+
 	// only 1 of version, branch, file path or tag should be set
 	// Ensure that only one of version, branch, file path, or tag is set.
 	fields := []string{m.VersionString, m.BranchName, m.FilePath, m.Tag}
@@ -137,6 +156,15 @@ func (m *ModVersionConstraint) Initialise(block *hcl.Block) hcl.Diagnostics {
 		return nil
 	}
 
+	// OCI mods are resolved from the image tags which are semver versions, so must have a version constraint
+	if IsOciModName(m.Name) {
+		return hcl.Diagnostics{&hcl.Diagnostic{
+			Severity: hcl.DiagError,
+			Summary:  fmt.Sprintf("invalid version '%s' for mod %s - OCI mods must specify a version constraint", m.VersionString, m.Name),
+			Subject:  &m.DefRange,
+		}}
+	}
+
 	// if we get here we failed to parse the version string as a semver - treat it as a git tag instead - we will verify it later
 	m.Tag = m.VersionString
 	// NOTE: clear the version string
@@ -144,6 +172,32 @@ func (m *ModVersionConstraint) Initialise(block *hcl.Block) hcl.Diagnostics {
 	return nil
 }
 
+// initialiseOci extracts the version from the name of an OCI mod, if the name includes an image tag, and validates
+// that only a version is specified
+func (m *ModVersionConstraint) initialiseOci() hcl.Diagnostics {
+	name, tag := splitOciModTag(m.Name)
+	if tag != "" {
+		if m.VersionString != "" {
+			return hcl.Diagnostics{&hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  fmt.Sprintf("mod %s specifies a version in both the name and the 'version' attribute", m.Name),
+				Subject:  &m.DefRange,
+			}}
+		}
+		m.Name = name
+		m.VersionString = tag
+		m.versionInName = true
+	}
+	if m.BranchName != "" || m.FilePath != "" || m.Tag != "" {
+		return hcl.Diagnostics{&hcl.Diagnostic{
+			Severity: hcl.DiagError,
+			Summary:  fmt.Sprintf("mod %s is installed from an OCI registry - only 'version' may be set", m.Name),
+			Subject:  &m.DefRange,
+		}}
+	}
+	return nil
+}
+
 func (m *ModVersionConstraint) setRanges(block *hcl.Block) hcl.Diagnostics {
 	// record all the ranges in the source file
 	m.DefRange = block.DefRange
@@ -157,6 +211,9 @@ func (m *ModVersionConstraint) setRanges(block *hcl.Block) hcl.Diagnostics {
 		m.VersionRange = pathAttribute.SrcRange
 	} else if tagAttribute, ok := block.Body.(*hclsyntax.Body).Attributes["tag"]; ok {
 		m.VersionRange = tagAttribute.SrcRange
+	} else if _, tag := splitOciModTag(m.Name); IsOciModName(m.Name) && tag != "" {
+		// the version of an OCI mod may be specified as an image tag in the name
+		m.VersionRange = block.LabelRanges[0]
 	} else {
 		// one of these must be present
 		return hcl.Diagnostics{&hcl.Diagnostic{
@@ -202,6 +259,12 @@ func (m *ModVersionConstraint) IsPrerelease() bool {
 	return m.versionConstraint != nil && m.versionConstraint.IsPrerelease()
 }
 
+// VersionInName returns whether the version of an OCI mod was specified as an image tag in the name,
+// e.g. oci://ghcr.io/turbot/mod1:1.0.0 - if so, VersionRange is the range of the name label
+func (m *ModVersionConstraint) VersionInName() bool {
+	return m.versionInName
+}
+
 func (m *ModVersionConstraint) VersionConstraint() *versionhelpers.Constraints {
 	return m.versionConstraint
 }
@@ -0,0 +1,133 @@
+package modconfig
+
+import (
+	"testing"
+
+	"github.com/Masterminds/semver/v3"
+)
+
+func TestNewModVersionConstraint_Oci(t *testing.T) {
+	tests := []struct {
+		name        string
+		arg         string
+		wantName    string
+		wantVersion string
+		wantErr     bool
+	}{
+		{
+			name:        "image tag",
+			arg:         "oci://ghcr.io/turbot/mod1:1.2.3",
+			wantName:    "oci://ghcr.io/turbot/mod1",
+			wantVersion: "1.2.3",
+		},
+		{
+			name:        "registry port",
+			arg:         "oci://localhost:5000/turbot/mod1:1.2.3",
+			wantName:    "oci://localhost:5000/turbot/mod1",
+			wantVersion: "1.2.3",
+		},
+		{
+			name:        "no tag",
+			arg:         "oci://localhost:5000/turbot/mod1",
+			wantName:    "oci://localhost:5000/turbot/mod1",
+			wantVersion: "*",
+		},
+		{
+			name:        "version constraint",
+			arg:         "oci://ghcr.io/turbot/mod1@^1.2",
+			wantName:    "oci://ghcr.io/turbot/mod1",
+			wantVersion: "^1.2",
+		},
+		{
+			name:    "non version tag",
+			arg:     "oci://ghcr.io/turbot/mod1:stable",
+			wantErr: true,
+		},
+		{
+			name:    "branch",
+			arg:     "oci://ghcr.io/turbot/mod1#main",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NewModVersionConstraint(tt.arg)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("NewModVersionConstraint() expected error, got %v", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("NewModVersionConstraint() error = %v", err)
+			}
+			if got.Name != tt.wantName || got.VersionString != tt.wantVersion || got.VersionConstraint() == nil {
+				t.Errorf("NewModVersionConstraint() = %s %s, want %s %s", got.Name, got.VersionString, tt.wantName, tt.wantVersion)
+			}
+		})
+	}
+}
+
+func TestOciModDependencyPath(t *testing.T) {
+	version := &DependencyVersion{Version: semver.MustParse("1.2.3")}
+	dependencyPath := BuildModDependencyPath("oci://localhost:5000/turbot/mod1", version)
+	if dependencyPath != "oci/localhost:5000/turbot/mod1@v1.2.3" {
+		t.Errorf("BuildModDependencyPath() = %s", dependencyPath)
+	}
+
+	name, parsedVersion, err := ParseModDependencyPath(dependencyPath)
+	if err != nil {
+		t.Fatalf("ParseModDependencyPath() error = %v", err)
+	}
+	if name != "oci://localhost:5000/turbot/mod1" || !parsedVersion.Equal(version) {
+		t.Errorf("ParseModDependencyPath() = %s %v", name, parsedVersion)
+	}
+}
+
+func TestModVersionConstraint_InitialiseSignature(t *testing.T) {
+	sshSignature := func() *ModSignature {
+		return &ModSignature{Type: ModSignatureTypeSSH, PublicKeys: []string{"ssh-ed25519 AAAA"}}
+	}
+	tests := []struct {
+		name       string
+		constraint *ModVersionConstraint
+		wantErr    bool
+	}{
+		{
+			name:       "version",
+			constraint: &ModVersionConstraint{Name: "github.com/turbot/mod1", VersionString: "^1.0", Signature: sshSignature()},
+		},
+		{
+			name:       "tag",
+			constraint: &ModVersionConstraint{Name: "github.com/turbot/mod1", Tag: "release", Signature: sshSignature()},
+		},
+		{
+			name:       "branch",
+			constraint: &ModVersionConstraint{Name: "github.com/turbot/mod1", BranchName: "main", Signature: sshSignature()},
+			wantErr:    true,
+		},
+		{
+			name:       "oci",
+			constraint: &ModVersionConstraint{Name: "oci://ghcr.io/turbot/mod1", VersionString: "^1.0", Signature: sshSignature()},
+			wantErr:    true,
+		},
+		{
+			name:       "invalid type",
+			constraint: &ModVersionConstraint{Name: "github.com/turbot/mod1", VersionString: "^1.0", Signature: &ModSignature{Type: "gpg", PublicKeys: []string{"key"}}},
+			wantErr:    true,
+		},
+		{
+			name:       "no keys",
+			constraint: &ModVersionConstraint{Name: "github.com/turbot/mod1", VersionString: "^1.0", Signature: &ModSignature{Type: ModSignatureTypeMinisign}},
+			wantErr:    true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			diags := tt.constraint.Initialise(nil)
+			if diags.HasErrors() != tt.wantErr {
+				t.Errorf("Initialise() diags = %v, wantErr %v", diags, tt.wantErr)
+			}
+		})
+	}
+}
@@ -6,6 +6,21 @@ import (
 	"github.com/turbot/pipe-fittings/utils"
 )
 
+// The supported connection_import source types
+const (
+	ConnectionImportTypeSteampipe = "steampipe"
+	ConnectionImportTypeAwsConfig = "aws_config"
+	ConnectionImportTypeGcloud    = "gcloud"
+	ConnectionImportTypeTerraform = "terraform"
+)
+
+var ValidConnectionImportTypes = []string{
+	ConnectionImportTypeSteampipe,
+	ConnectionImportTypeAwsConfig,
+	ConnectionImportTypeGcloud,
+	ConnectionImportTypeTerraform,
+}
+
 // The definition of a single ConnectionImport
 type ConnectionImport struct {
 	HclResourceImpl
@@ -18,13 +33,16 @@ type ConnectionImport struct {
 	Source      *string  `json:"source" cty:"source" hcl:"source"`
 	Connections []string `json:"connections" cty:"connections" hcl:"connections,optional"`
 	Prefix      *string  `json:"prefix" cty:"prefix" hcl:"prefix,optional"`
+	// Type is the type of the import source, defaulting to steampipe
+	Type *string `json:"type,omitempty" cty:"type" hcl:"type,optional"`
 }
 
 func (c ConnectionImport) Equals(other ConnectionImport) bool {
 
 	return utils.PtrEqual(c.Source, other.Source) &&
 		gokit.StringSliceEqualIgnoreOrder(c.Connections, other.Connections) &&
-		utils.PtrEqual(c.Prefix, other.Prefix)
+		utils.PtrEqual(c.Prefix, other.Prefix) &&
+		c.GetType() == other.GetType()
 }
 
 func (c *ConnectionImport) SetFileReference(fileName string, startLineNumber int, endLineNumber int) {
@@ -41,6 +59,14 @@ func (c *ConnectionImport) GetPrefix() *string {
 	return c.Prefix
 }
 
+// GetType returns the type of the import source - if no type is set, the source is a steampipe config
+func (c *ConnectionImport) GetType() string {
+	if c.Type == nil {
+		return ConnectionImportTypeSteampipe
+	}
+	return *c.Type
+}
+
 func (c *ConnectionImport) GetConnections() []string {
 	return c.Connections
 }
@@ -0,0 +1,355 @@
+package modfile
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/turbot/pipe-fittings/schema"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// BlockRef identifies a block in a mod file by its type and labels - if Labels is nil, the first block of the type
+// is matched, whatever its labels
+type BlockRef struct {
+	Type   string
+	Labels []string
+}
+
+// ModBlock refers to the mod block of a mod file
+var ModBlock = BlockRef{Type: schema.BlockTypeMod}
+
+// Editor edits a mod file, preserving its comments and formatting.
+//
+// Blocks are addressed by a path of BlockRefs from the root of the file, e.g. the title of the mod is the 'title'
+// attribute at path [ModBlock], and the default of a variable is the 'default' attribute at path
+// [{Type: "variable", Labels: ["region"]}].
+type Editor struct {
+	// the path the file was loaded from - empty if the editor was created from bytes
+	path string
+	file *hclwrite.File
+	// the file contents as loaded
+	original []byte
+	// the file contents as written by hclwrite before any modification - this may differ in spacing from original
+	unmodified []byte
+}
+
+// Load creates an editor for the mod file at path
+func Load(path string) (*Editor, error) {
+	src, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	e, err := NewEditor(src, path)
+	if err != nil {
+		return nil, err
+	}
+	e.path = path
+	return e, nil
+}
+
+// NewEditor creates an editor for the given mod file contents - filename is used in error messages
+func NewEditor(src []byte, filename string) (*Editor, error) {
+	file, diags := hclwrite.ParseConfig(src, filename, hcl.InitialPos)
+	if diags.HasErrors() {
+		return nil, fmt.Errorf("failed to parse %s: %s", filename, diags.Error())
+	}
+	return &Editor{file: file, original: src, unmodified: fileBytes(file)}, nil
+}
+
+// Changed returns whether the file has been modified
+func (e *Editor) Changed() bool {
+	return !bytes.Equal(fileBytes(e.file), e.unmodified)
+}
+
+// Bytes returns the contents of the file - only content added by the editor is formatted, the rest of the file is
+// left as it was
+func (e *Editor) Bytes() []byte {
+	if !e.Changed() {
+		return e.original
+	}
+	return fileBytes(e.file)
+}
+
+// Save writes the file back to the path it was loaded from, if it has been modified
+func (e *Editor) Save() error {
+	if e.path == "" {
+		return fmt.Errorf("cannot save a mod file which was not loaded from a file")
+	}
+	if !e.Changed() {
+		return nil
+	}
+	return os.WriteFile(e.path, e.Bytes(), 0644) //nolint:gosec // mod files are not sensitive
+}
+
+// GetBlock returns the block at the given path, or nil if it does not exist
+func (e *Editor) GetBlock(path ...BlockRef) *hclwrite.Block {
+	body := e.file.Body()
+	var block *hclwrite.Block
+	for _, ref := range path {
+		block = findBlock(body, ref)
+		if block == nil {
+			return nil
+		}
+		body = block.Body()
+	}
+	return block
+}
+
+// EnsureBlock returns the body of the block at the given path, appending any blocks in the path which do not exist
+func (e *Editor) EnsureBlock(path ...BlockRef) (*hclwrite.Body, error) {
+	body := e.file.Body()
+	var parent *hclwrite.Block
+	for depth, ref := range path {
+		block := findBlock(body, ref)
+		if block == nil {
+			if ref.Labels == nil && ref.Type == schema.BlockTypeMod {
+				return nil, fmt.Errorf("mod file has no mod block")
+			}
+			openBlock(parent, depth-1)
+			block = appendNewBlock(body, depth, ref.Type, ref.Labels)
+		}
+		parent = block
+		body = block.Body()
+	}
+	openBlock(parent, len(path)-1)
+	return body, nil
+}
+
+// RemoveBlock removes the block at the given path - it returns false if the block does not exist
+func (e *Editor) RemoveBlock(path ...BlockRef) bool {
+	if len(path) == 0 {
+		return false
+	}
+	block := e.GetBlock(path...)
+	if block == nil {
+		return false
+	}
+	return e.parentBody(path).RemoveBlock(block)
+}
+
+// GetAttributeValue returns the value of the attribute at the given path, if it is a literal value - it returns
+// false if the attribute does not exist or is an expression, e.g. a reference to a variable
+func (e *Editor) GetAttributeValue(name string, path ...BlockRef) (cty.Value, bool) {
+	body := e.file.Body()
+	if len(path) > 0 {
+		block := e.GetBlock(path...)
+		if block == nil {
+			return cty.NilVal, false
+		}
+		body = block.Body()
+	}
+	attr := body.GetAttribute(name)
+	if attr == nil {
+		return cty.NilVal, false
+	}
+	expr, diags := hclsyntax.ParseExpression(attr.Expr().BuildTokens(nil).Bytes(), "", hcl.InitialPos)
+	if diags.HasErrors() {
+		return cty.NilVal, false
+	}
+	val, diags := expr.Value(nil)
+	if diags.HasErrors() {
+		return cty.NilVal, false
+	}
+	return val, true
+}
+
+// SetAttributeValue sets the attribute with the given name to a literal value, creating the blocks in the path if
+// they do not exist
+func (e *Editor) SetAttributeValue(name string, value cty.Value, path ...BlockRef) error {
+	body, err := e.EnsureBlock(path...)
+	if err != nil {
+		return err
+	}
+	setAttributeValue(body, len(path), name, value)
+	return nil
+}
+
+// SetAttributeExpression sets the attribute with the given name to an HCL expression, e.g. var.region or
+// "${var.prefix}-name", creating the blocks in the path if they do not exist
+func (e *Editor) SetAttributeExpression(name string, expression string, path ...BlockRef) error {
+	tokens, err := expressionTokens(expression)
+	if err != nil {
+		return err
+	}
+	body, err := e.EnsureBlock(path...)
+	if err != nil {
+		return err
+	}
+	setAttributeRaw(body, len(path), name, tokens)
+	return nil
+}
+
+// RemoveAttribute removes the attribute with the given name - it returns false if the attribute does not exist
+func (e *Editor) RemoveAttribute(name string, path ...BlockRef) bool {
+	body := e.file.Body()
+	if len(path) > 0 {
+		block := e.GetBlock(path...)
+		if block == nil {
+			return false
+		}
+		body = block.Body()
+	}
+	return body.RemoveAttribute(name) != nil
+}
+
+// SetModAttribute sets an attribute of the mod block, e.g. title
+func (e *Editor) SetModAttribute(name string, value cty.Value) error {
+	return e.SetAttributeValue(name, value, ModBlock)
+}
+
+// RemoveModAttribute removes an attribute of the mod block - it returns false if the attribute does not exist
+func (e *Editor) RemoveModAttribute(name string) bool {
+	return e.RemoveAttribute(name, ModBlock)
+}
+
+// SetVariableDefault sets the default value of a variable, adding the variable if it does not exist
+func (e *Editor) SetVariableDefault(name string, value cty.Value) error {
+	return e.SetAttributeValue("default", value, BlockRef{Type: schema.BlockTypeVariable, Labels: []string{name}})
+}
+
+// parentBody returns the body containing the last block in the path - which must exist
+func (e *Editor) parentBody(path []BlockRef) *hclwrite.Body {
+	if len(path) == 1 {
+		return e.file.Body()
+	}
+	return e.GetBlock(path[:len(path)-1]...).Body()
+}
+
+// fileBytes returns the contents of a file - unlike hclwrite.File.Bytes, this does not format the file
+func fileBytes(file *hclwrite.File) []byte {
+	return file.BuildTokens(nil).Bytes()
+}
+
+func findBlock(body *hclwrite.Body, ref BlockRef) *hclwrite.Block {
+	for _, block := range body.Blocks() {
+		if block.Type() != ref.Type {
+			continue
+		}
+		if ref.Labels == nil || strings.Join(block.Labels(), "\x00") == strings.Join(ref.Labels, "\x00") {
+			return block
+		}
+	}
+	return nil
+}
+
+// expressionTokens returns the tokens of an HCL expression, validating its syntax
+func expressionTokens(expression string) (hclwrite.Tokens, error) {
+	if _, diags := hclsyntax.ParseExpression([]byte(expression), "", hcl.InitialPos); diags.HasErrors() {
+		return nil, fmt.Errorf("invalid expression '%s': %s", expression, diags.Error())
+	}
+	file, diags := hclwrite.ParseConfig([]byte("value = "+expression+"\n"), "", hcl.InitialPos)
+	if diags.HasErrors() {
+		return nil, fmt.Errorf("invalid expression '%s': %s", expression, diags.Error())
+	}
+	return file.Body().GetAttribute("value").Expr().BuildTokens(nil), nil
+}
+
+// openBlock adds a newline to the body of an empty single line block, e.g. mod "m1" {} - otherwise content added to
+// the body would be on the same line as the opening brace, which is invalid. depth is the nesting depth of the
+// block, used to indent its closing brace
+func openBlock(block *hclwrite.Block, depth int) {
+	if block == nil {
+		return
+	}
+	tokens := block.BuildTokens(nil)
+	for i := len(tokens) - 1; i > 0; i-- {
+		if tokens[i].Type == hclsyntax.TokenCBrace {
+			if tokens[i-1].Type == hclsyntax.TokenOBrace {
+				block.Body().AppendNewline()
+				tokens[i].SpacesBefore = depth * 2
+			}
+			return
+		}
+	}
+}
+
+// setLabels sets the labels of a block - hclwrite does not space the new labels from the block type, so they are
+// spaced here
+func setLabels(block *hclwrite.Block, labels []string) {
+	block.SetLabels(labels)
+	tokens := block.BuildTokens(nil)
+	for i, token := range tokens {
+		if token.Type == hclsyntax.TokenIdent && i+1 < len(tokens) {
+			tokens[i+1].SpacesBefore = 1
+			return
+		}
+	}
+}
+
+// appendNewBlock appends a block to a body at the given nesting depth, formatting the block
+func appendNewBlock(body *hclwrite.Body, depth int, typeName string, labels []string) *hclwrite.Block {
+	block := body.AppendNewBlock(typeName, labels)
+	formatTokens(block.BuildTokens(nil), depth, false)
+	return block
+}
+
+// setAttributeValue sets an attribute of a body at the given nesting depth to a literal value, formatting the
+// generated tokens - the name of an existing attribute is left as it was
+func setAttributeValue(body *hclwrite.Body, depth int, name string, value cty.Value) {
+	exists := body.GetAttribute(name) != nil
+	body.SetAttributeValue(name, value)
+	formatAttribute(body.GetAttribute(name), depth, exists)
+}
+
+// setAttributeRaw sets an attribute of a body at the given nesting depth to the given expression tokens,
+// formatting the generated tokens - the name of an existing attribute is left as it was
+func setAttributeRaw(body *hclwrite.Body, depth int, name string, tokens hclwrite.Tokens) {
+	exists := body.GetAttribute(name) != nil
+	body.SetAttributeRaw(name, tokens)
+	formatAttribute(body.GetAttribute(name), depth, exists)
+}
+
+// formatAttribute formats an attribute which has just been set - hclwrite does not return new attributes from
+// SetAttributeValue, so the attribute is looked up again by the caller
+func formatAttribute(attr *hclwrite.Attribute, depth int, exists bool) {
+	if exists {
+		formatTokens(attr.Expr().BuildTokens(nil), depth, true)
+		return
+	}
+	formatTokens(attr.BuildTokens(nil), depth, false)
+}
+
+// formatTokens sets the spacing of tokens added to the file as hclwrite.Format would, leaving the rest of the
+// file untouched. The tokens are formatted in isolation, wrapped in depth blocks so they are indented, and as the
+// value of an attribute if they are an expression.
+func formatTokens(tokens hclwrite.Tokens, depth int, expression bool) {
+	var src bytes.Buffer
+	src.WriteString(strings.Repeat("x {\n", depth))
+	// the tokens of the wrapping blocks: x, { and a newline for each block, and x and = for an attribute
+	skip := depth * 3
+	if expression {
+		src.WriteString("x =")
+		skip += 2
+	}
+	src.Write(tokens.Bytes())
+	src.WriteString("\n" + strings.Repeat("}\n", depth))
+
+	file, diags := hclwrite.ParseConfig(hclwrite.Format(src.Bytes()), "", hcl.InitialPos)
+	if diags.HasErrors() {
+		return
+	}
+	formatted := file.BuildTokens(nil)
+	if len(formatted) < skip+len(tokens) {
+		return
+	}
+	formatted = formatted[skip : skip+len(tokens)]
+	// formatting only changes spacing, so the tokens should correspond one to one - if not, leave them as they are
+	for i, token := range tokens {
+		if formatted[i].Type != token.Type {
+			return
+		}
+	}
+	for i, token := range tokens {
+		token.SpacesBefore = formatted[i].SpacesBefore
+	}
+}
+
+// isEmptyBody returns whether a body has no attributes or blocks
+func isEmptyBody(body *hclwrite.Body) bool {
+	return len(body.Attributes()) == 0 && len(body.Blocks()) == 0
+}
@@ -0,0 +1,318 @@
+package modfile
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/turbot/pipe-fittings/modconfig"
+	"github.com/zclconf/go-cty/cty"
+)
+
+const testModFile = `// the local mod
+mod "local" {
+  # the title shown in the UI
+  title = "Local"
+  description = "A local mod" // trailing comment
+
+  require {
+    mod "github.com/turbot/m1" {
+      version = "^1.0"
+      args = {
+        region = "us-east-1"
+      }
+    }
+  }
+}
+
+variable "region" {
+  type = string
+}
+`
+
+func TestEditorAttributes(t *testing.T) {
+	e, err := NewEditor([]byte(testModFile), "mod.pp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e.Changed() || string(e.Bytes()) != testModFile {
+		t.Fatalf("unmodified editor changed the file:\n%s", e.Bytes())
+	}
+
+	if err := e.SetModAttribute("title", cty.StringVal("New title")); err != nil {
+		t.Fatal(err)
+	}
+	if !e.RemoveModAttribute("description") {
+		t.Error("RemoveModAttribute() = false, want true")
+	}
+	if e.RemoveModAttribute("documentation") {
+		t.Error("RemoveModAttribute() for a missing attribute = true, want false")
+	}
+	if err := e.SetModAttribute("tags", cty.MapVal(map[string]cty.Value{"service": cty.StringVal("aws")})); err != nil {
+		t.Fatal(err)
+	}
+	if err := e.SetAttributeValue("title", cty.StringVal("Local mod"), ModBlock, BlockRef{Type: "opengraph"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := e.SetVariableDefault("region", cty.StringVal("us-east-1")); err != nil {
+		t.Fatal(err)
+	}
+	if err := e.SetAttributeExpression("default", "var.region", BlockRef{Type: "variable", Labels: []string{"home_region"}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := e.SetAttributeExpression("default", "var.", BlockRef{Type: "variable", Labels: []string{"invalid"}}); err == nil {
+		t.Error("SetAttributeExpression() with an invalid expression expected error")
+	}
+
+	want := `// the local mod
+mod "local" {
+  # the title shown in the UI
+  title = "New title"
+
+  require {
+    mod "github.com/turbot/m1" {
+      version = "^1.0"
+      args = {
+        region = "us-east-1"
+      }
+    }
+  }
+  tags = {
+    service = "aws"
+  }
+  opengraph {
+    title = "Local mod"
+  }
+}
+
+variable "region" {
+  type = string
+  default = "us-east-1"
+}
+variable "home_region" {
+  default = var.region
+}
+`
+	if got := string(e.Bytes()); got != want {
+		t.Errorf("Bytes() =\n%s\nwant\n%s", got, want)
+	}
+
+	if got, ok := e.GetAttributeValue("title", ModBlock, BlockRef{Type: "opengraph"}); !ok || got.AsString() != "Local mod" {
+		t.Errorf("GetAttributeValue() = %v, %v", got, ok)
+	}
+	if _, ok := e.GetAttributeValue("default", BlockRef{Type: "variable", Labels: []string{"home_region"}}); ok {
+		t.Error("GetAttributeValue() for an expression = true, want false")
+	}
+	if !e.RemoveBlock(ModBlock, BlockRef{Type: "opengraph"}) || e.GetBlock(ModBlock, BlockRef{Type: "opengraph"}) != nil {
+		t.Error("RemoveBlock() did not remove the block")
+	}
+}
+
+func TestEditorRequiredMods(t *testing.T) {
+	newModVersion := func(name string) *modconfig.ModVersionConstraint {
+		modVersion, err := modconfig.NewModVersionConstraint(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return modVersion
+	}
+
+	e, err := NewEditor([]byte(testModFile), "mod.pp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// updating a requirement preserves its args
+	if err := e.SetRequiredMod(newModVersion("github.com/turbot/m1#main")); err != nil {
+		t.Fatal(err)
+	}
+	if err := e.SetRequiredMod(newModVersion("github.com/turbot/m2@^2.0")); err != nil {
+		t.Fatal(err)
+	}
+	want := `// the local mod
+mod "local" {
+  # the title shown in the UI
+  title = "Local"
+  description = "A local mod" // trailing comment
+
+  require {
+    mod "github.com/turbot/m1" {
+      args = {
+        region = "us-east-1"
+      }
+      branch = "main"
+    }
+    mod "github.com/turbot/m2" {
+      version = "^2.0"
+    }
+  }
+}
+
+variable "region" {
+  type = string
+}
+`
+	if got := string(e.Bytes()); got != want {
+		t.Errorf("Bytes() =\n%s\nwant\n%s", got, want)
+	}
+
+	// removing every requirement removes the require block
+	if !e.RemoveRequiredMod("github.com/turbot/m1") || !e.RemoveRequiredMod("github.com/turbot/m2") {
+		t.Fatal("RemoveRequiredMod() = false, want true")
+	}
+	if e.RemoveRequiredMod("github.com/turbot/m3") {
+		t.Error("RemoveRequiredMod() for a mod which is not required = true, want false")
+	}
+	if e.GetBlock(ModBlock, RequireBlock) != nil {
+		t.Errorf("require block was not removed:\n%s", e.Bytes())
+	}
+
+	// a require block is added to a mod with none
+	e, err = NewEditor([]byte(`mod "local" {}`), "mod.pp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := e.SetRequiredMod(newModVersion("github.com/turbot/m1@^1.0")); err != nil {
+		t.Fatal(err)
+	}
+	want = `mod "local" {
+  require {
+    mod "github.com/turbot/m1" {
+      version = "^1.0"
+    }
+  }
+}`
+	if got := string(e.Bytes()); got != want {
+		t.Errorf("Bytes() =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestEditorRequiredMods_OciTag(t *testing.T) {
+	modFile := `mod "local" {
+  require {
+    mod "oci://ghcr.io/turbot/m1:1.0.0" {}
+  }
+}
+`
+	tests := []struct {
+		version string
+		want    string
+	}{
+		{
+			version: "oci://ghcr.io/turbot/m1:1.1.0",
+			want: `mod "local" {
+  require {
+    mod "oci://ghcr.io/turbot/m1:1.1.0" {}
+  }
+}
+`,
+		},
+		{
+			version: "oci://ghcr.io/turbot/m1@^2.0",
+			want: `mod "local" {
+  require {
+    mod "oci://ghcr.io/turbot/m1" {
+      version = "^2.0"
+    }
+  }
+}
+`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.version, func(t *testing.T) {
+			e, err := NewEditor([]byte(modFile), "mod.pp")
+			if err != nil {
+				t.Fatal(err)
+			}
+			modVersion, err := modconfig.NewModVersionConstraint(tt.version)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := e.SetRequiredMod(modVersion); err != nil {
+				t.Fatal(err)
+			}
+			if got := string(e.Bytes()); got != tt.want {
+				t.Errorf("Bytes() =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEditorPreservesFormatting(t *testing.T) {
+	modFile := `mod "local" {
+  title    =   "Local"
+  opengraph {}
+}
+
+variable "region" {
+  type=string
+}
+`
+	e, err := NewEditor([]byte(modFile), "mod.pp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := e.SetModAttribute("description", cty.StringVal("A local mod")); err != nil {
+		t.Fatal(err)
+	}
+	if err := e.SetAttributeValue("title", cty.StringVal("Local mod"), ModBlock, BlockRef{Type: "opengraph"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := e.SetAttributeValue("tags", cty.MapVal(map[string]cty.Value{"service": cty.StringVal("aws")}), ModBlock, BlockRef{Type: "opengraph"}); err != nil {
+		t.Fatal(err)
+	}
+	// only the content added by the editor is formatted
+	want := `mod "local" {
+  title    =   "Local"
+  opengraph {
+    title = "Local mod"
+    tags = {
+      service = "aws"
+    }
+  }
+  description = "A local mod"
+}
+
+variable "region" {
+  type=string
+}
+`
+	if got := string(e.Bytes()); got != want {
+		t.Errorf("Bytes() =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestEditorSave(t *testing.T) {
+	modFilePath := filepath.Join(t.TempDir(), "mod.pp")
+	if err := os.WriteFile(modFilePath, []byte(testModFile), 0644); err != nil {
+		t.Fatal(err)
+	}
+	e, err := Load(modFilePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := e.SetModAttribute("title", cty.StringVal("Saved")); err != nil {
+		t.Fatal(err)
+	}
+	if err := e.Save(); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	saved, err := Load(modFilePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, ok := saved.GetAttributeValue("title", ModBlock); !ok || got.AsString() != "Saved" {
+		t.Errorf("saved title = %v, %v", got, ok)
+	}
+
+	if _, err := NewEditor([]byte(`mod "local" {`), "mod.pp"); err == nil {
+		t.Error("NewEditor() for an invalid file expected error")
+	}
+	noMod, err := NewEditor([]byte(`variable "region" {}`), "mod.pp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := noMod.SetModAttribute("title", cty.StringVal("title")); err == nil {
+		t.Error("SetModAttribute() for a file with no mod block expected error")
+	}
+}
@@ -0,0 +1,112 @@
+package modfile
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/turbot/pipe-fittings/modconfig"
+	"github.com/turbot/pipe-fittings/schema"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// the attributes of a mod requirement which specify the version - only one may be set
+var requireVersionAttributes = []string{"version", "branch", "path", "tag"}
+
+// RequireBlock refers to the require block of the mod block
+var RequireBlock = BlockRef{Type: schema.BlockTypeRequire}
+
+// the nesting depth of the mod blocks in the require block
+const requireDepth = 2
+
+// SetRequiredMod adds or updates the requirement for a mod in the require block, adding the require block if
+// needed. Any other attributes of an existing requirement, e.g. args, are preserved.
+func (e *Editor) SetRequiredMod(modVersion *modconfig.ModVersionConstraint) error {
+	requireBody, err := e.EnsureBlock(ModBlock, RequireBlock)
+	if err != nil {
+		return err
+	}
+
+	name, value := requireVersionAttribute(modVersion)
+	block := findRequiredModBlock(requireBody, modVersion.Name)
+	switch {
+	case block == nil:
+		block = appendNewBlock(requireBody, requireDepth, schema.BlockTypeMod, []string{modVersion.Name})
+	case block.Labels()[0] != modVersion.Name:
+		// the version of an OCI mod is an image tag in the name - keep it there if the new version is also a version
+		if _, err := semver.StrictNewVersion(modVersion.VersionString); err == nil && name == "version" {
+			setLabels(block, []string{modVersion.Name + ":" + modVersion.VersionString})
+			removeAttributes(block.Body(), requireVersionAttributes...)
+			return nil
+		}
+		// otherwise move it to an attribute
+		setLabels(block, []string{modVersion.Name})
+	}
+
+	removeAttributes(block.Body(), slices.DeleteFunc(slices.Clone(requireVersionAttributes), func(attr string) bool {
+		return attr == name
+	})...)
+	if name != "" {
+		openBlock(block, requireDepth)
+		setAttributeValue(block.Body(), requireDepth+1, name, cty.StringVal(value))
+	}
+	return nil
+}
+
+// RemoveRequiredMod removes the requirement for a mod from the require block, removing the require block if it
+// is then empty - it returns false if the mod is not required
+func (e *Editor) RemoveRequiredMod(name string) bool {
+	requireBlock := e.GetBlock(ModBlock, RequireBlock)
+	if requireBlock == nil {
+		return false
+	}
+	block := findRequiredModBlock(requireBlock.Body(), name)
+	if block == nil {
+		return false
+	}
+	requireBlock.Body().RemoveBlock(block)
+	if isEmptyBody(requireBlock.Body()) {
+		e.RemoveBlock(ModBlock, RequireBlock)
+	}
+	return true
+}
+
+// findRequiredModBlock returns the block requiring the given mod - for OCI mods the block label may include an
+// image tag, e.g. oci://ghcr.io/turbot/mod1:1.0.0
+func findRequiredModBlock(requireBody *hclwrite.Body, name string) *hclwrite.Block {
+	for _, block := range requireBody.Blocks() {
+		if block.Type() != schema.BlockTypeMod || len(block.Labels()) != 1 {
+			continue
+		}
+		label := block.Labels()[0]
+		if label == name {
+			return block
+		}
+		if modconfig.IsOciModName(name) && strings.HasPrefix(label, name+":") && !strings.Contains(strings.TrimPrefix(label, name+":"), "/") {
+			return block
+		}
+	}
+	return nil
+}
+
+// requireVersionAttribute returns the attribute and value specifying the version of a requirement
+func requireVersionAttribute(modVersion *modconfig.ModVersionConstraint) (string, string) {
+	switch {
+	case modVersion.BranchName != "":
+		return "branch", modVersion.BranchName
+	case modVersion.FilePath != "":
+		return "path", modVersion.FilePath
+	case modVersion.Tag != "":
+		return "tag", modVersion.Tag
+	case modVersion.VersionString != "":
+		return "version", modVersion.VersionString
+	}
+	return "", ""
+}
+
+func removeAttributes(body *hclwrite.Body, names ...string) {
+	for _, name := range names {
+		body.RemoveAttribute(name)
+	}
+}
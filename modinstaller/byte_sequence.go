@@ -4,6 +4,9 @@ import (
 	"sort"
 )
 
+// ChangeOperation is the operation of a Change
+//
+// Deprecated: mod files are edited with modfile.Editor, which preserves their comments and formatting.
 type ChangeOperation int
 
 const (
@@ -12,6 +15,9 @@ const (
 	Replace
 )
 
+// Change is an edit of a byte range of a file
+//
+// Deprecated: mod files are edited with modfile.Editor, which preserves their comments and formatting.
 type Change struct {
 	Content     []byte
 	Operation   ChangeOperation
@@ -19,11 +25,19 @@ type Change struct {
 	OffsetEnd   int
 }
 
+// ChangeSet is a set of changes applied to a ByteSequence
+//
+// Deprecated: mod files are edited with modfile.Editor, which preserves their comments and formatting.
 type ChangeSet []*Change
 
+// EmptyChangeSet creates a ChangeSet with no changes
+//
+// Deprecated: mod files are edited with modfile.Editor, which preserves their comments and formatting.
 func EmptyChangeSet() ChangeSet { return ChangeSet{} }
 
 // MergeChangeSet creates a ChangeSet by merging the given ChangeSets in order
+//
+// Deprecated: mod files are edited with modfile.Editor, which preserves their comments and formatting.
 func MergeChangeSet(changeSets ...ChangeSet) ChangeSet {
 	changeSet := ChangeSet{}
 	for _, cs := range changeSets {
@@ -33,6 +47,8 @@ func MergeChangeSet(changeSets ...ChangeSet) ChangeSet {
 }
 
 // NewChangeSet creates a ChangeSet from the given changes
+//
+// Deprecated: mod files are edited with modfile.Editor, which preserves their comments and formatting.
 func NewChangeSet(changes ...*Change) ChangeSet {
 	return ChangeSet(changes)
 }
@@ -47,13 +63,22 @@ func (c ChangeSet) SortByOffset() {
 	})
 }
 
+// OperatorFunc applies a Change to a byte sequence
+//
+// Deprecated: mod files are edited with modfile.Editor, which preserves their comments and formatting.
 type OperatorFunc func(*Change, []byte) []byte
 
+// ByteSequence is a byte sequence which changes are applied to
+//
+// Deprecated: mod files are edited with modfile.Editor, which preserves their comments and formatting.
 type ByteSequence struct {
 	operators   map[ChangeOperation]OperatorFunc
 	_underlying []byte
 }
 
+// NewByteSequence creates a ByteSequence from a copy of b
+//
+// Deprecated: mod files are edited with modfile.Editor, which preserves their comments and formatting.
 func NewByteSequence(b []byte) *ByteSequence {
 	byteSequence := new(ByteSequence)
 	byteSequence._underlying = make([]byte, len(b))
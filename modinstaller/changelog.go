@@ -0,0 +1,53 @@
+package modinstaller
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/Masterminds/semver/v3"
+)
+
+// the name of the changelog file of a mod
+const changelogFileName = "CHANGELOG.md"
+
+// matches a changelog heading for a version, e.g. "## v0.12.0 [2023-10-20]" or "# [1.2.0] - 2024-01-01"
+var changelogHeadingRegex = regexp.MustCompile(`^(#{1,3})\s*\[?v?(\d+\.\d+\.\d+[0-9A-Za-z.+-]*)\]?`)
+
+// getChangelogBetween returns the sections of the changelog for versions greater than fromVersion, up to and
+// including toVersion - returns an empty string if the changelog has no such sections
+func getChangelogBetween(changelog string, fromVersion, toVersion *semver.Version) string {
+	var res []string
+	// the heading level of version sections - a less nested heading ends the version sections
+	var headingLevel string
+	var inSection bool
+	for _, line := range strings.Split(strings.ReplaceAll(changelog, "\r\n", "\n"), "\n") {
+		if !strings.HasPrefix(line, "#") {
+			if inSection {
+				res = append(res, line)
+			}
+			continue
+		}
+
+		match := changelogHeadingRegex.FindStringSubmatch(line)
+		if match == nil {
+			// a nested heading within a version section is part of the section
+			if inSection && len(line)-len(strings.TrimLeft(line, "#")) > len(headingLevel) {
+				res = append(res, line)
+			} else {
+				inSection = false
+			}
+			continue
+		}
+		version, err := semver.NewVersion(match[2])
+		if err != nil {
+			inSection = false
+			continue
+		}
+		headingLevel = match[1]
+		inSection = version.GreaterThan(fromVersion) && !version.GreaterThan(toVersion)
+		if inSection {
+			res = append(res, line)
+		}
+	}
+	return strings.TrimSpace(strings.Join(res, "\n"))
+}
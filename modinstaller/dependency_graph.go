@@ -0,0 +1,260 @@
+package modinstaller
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/turbot/go-kit/helpers"
+	"github.com/turbot/pipe-fittings/modconfig"
+	"github.com/turbot/pipe-fittings/utils"
+	"github.com/turbot/pipe-fittings/versionmap"
+)
+
+// DependencyGraphNode is a mod in the dependency graph - the workspace mod or a resolved dependency version
+type DependencyGraphNode struct {
+	// the install cache key of the mod - the dependency path for dependencies, or the name of a requirement which
+	// has no locked version
+	ID      string `json:"id"`
+	Name    string `json:"name"`
+	Version string `json:"version,omitempty"`
+	Root    bool   `json:"root,omitempty"`
+	// the version is locked but not installed, or the mod is required but has no locked version
+	Missing bool `json:"missing,omitempty"`
+	// more than one version of this mod is in the graph
+	Duplicate bool `json:"duplicate,omitempty"`
+}
+
+// DependencyGraphEdge is a requirement of one mod on another
+type DependencyGraphEdge struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+	// the version constraint, branch or tag in the require block of the parent
+	Constraint string `json:"constraint,omitempty"`
+	Alias      string `json:"alias,omitempty"`
+}
+
+// DependencyGraph is the graph of the workspace mod dependencies, as resolved in the lock file
+type DependencyGraph struct {
+	Root  string                 `json:"root"`
+	Nodes []*DependencyGraphNode `json:"nodes"`
+	Edges []*DependencyGraphEdge `json:"edges"`
+}
+
+// GraphWorkspaceDependencies builds the dependency graph of the workspace from the lock file and the require blocks
+// of the workspace mod and installed dependencies
+func GraphWorkspaceDependencies(ctx context.Context, opts *InstallOpts) (_ *DependencyGraph, err error) {
+	utils.LogTime("cmd.GraphWorkspaceDependencies")
+	defer func() {
+		utils.LogTime("cmd.GraphWorkspaceDependencies end")
+		if r := recover(); r != nil {
+			err = helpers.ToError(r)
+		}
+	}()
+
+	installer, err := NewModInstaller(opts)
+	if err != nil {
+		return nil, err
+	}
+	return installer.buildDependencyGraph(ctx), nil
+}
+
+func (i *ModInstaller) buildDependencyGraph(ctx context.Context) *DependencyGraph {
+	lock := i.installData.Lock
+	root := i.workspaceMod.GetInstallCacheKey()
+	nodes := map[string]*DependencyGraphNode{
+		root: {ID: root, Name: root, Root: true},
+	}
+	g := &DependencyGraph{Root: root}
+
+	// locked versions which are not installed have been moved to MissingVersions
+	lockedDependencies := make(map[string]map[string]*versionmap.InstalledModVersion)
+	missingPaths := make(map[string]bool)
+	for _, lockedVersions := range []versionmap.InstalledDependencyVersionsMap{lock.InstallCache, lock.MissingVersions} {
+		for parent, deps := range lockedVersions {
+			if lockedDependencies[parent] == nil {
+				lockedDependencies[parent] = make(map[string]*versionmap.InstalledModVersion)
+			}
+			for name, dep := range deps {
+				lockedDependencies[parent][name] = dep
+			}
+		}
+	}
+	for dependencyPath := range lock.MissingVersions.FlatMap() {
+		missingPaths[dependencyPath] = true
+	}
+
+	// mods with no locked dependencies have no lock entries, but may have requirements with no locked version
+	parents := []string{root}
+	for dependencyPath := range lock.InstallCache.FlatMap() {
+		parents = append(parents, dependencyPath)
+	}
+	for _, parent := range parents {
+		if _, ok := lockedDependencies[parent]; !ok {
+			lockedDependencies[parent] = nil
+		}
+	}
+	for parent, deps := range lockedDependencies {
+		if _, ok := nodes[parent]; !ok {
+			nodes[parent] = newDependencyGraphNodeForPath(parent)
+		}
+		require := i.getParentRequire(ctx, parent)
+
+		for name, dep := range deps {
+			dependencyPath := dep.DependencyPath()
+			if _, ok := nodes[dependencyPath]; !ok {
+				nodes[dependencyPath] = &DependencyGraphNode{
+					ID:      dependencyPath,
+					Name:    dep.Name,
+					Version: strings.TrimSpace(dep.DependencyVersion.String()),
+					Missing: missingPaths[dependencyPath],
+				}
+			}
+			edge := &DependencyGraphEdge{From: parent, To: dependencyPath, Alias: dep.Alias}
+			if require != nil {
+				if requiredVersion := require.GetModDependency(name); requiredVersion != nil {
+					edge.Constraint = getConstraintString(requiredVersion)
+				}
+			}
+			g.Edges = append(g.Edges, edge)
+		}
+
+		// requirements with no locked version
+		if require == nil {
+			continue
+		}
+		for _, requiredVersion := range require.Mods {
+			if _, ok := deps[requiredVersion.Name]; ok {
+				continue
+			}
+			if _, ok := nodes[requiredVersion.Name]; !ok {
+				nodes[requiredVersion.Name] = &DependencyGraphNode{ID: requiredVersion.Name, Name: requiredVersion.Name, Missing: true}
+			}
+			g.Edges = append(g.Edges, &DependencyGraphEdge{From: parent, To: requiredVersion.Name, Constraint: getConstraintString(requiredVersion)})
+		}
+	}
+
+	// flag mods with more than one version in the graph
+	versionCounts := make(map[string]int)
+	for _, n := range nodes {
+		if !n.Root && n.Version != "" {
+			versionCounts[n.Name]++
+		}
+	}
+	for _, n := range nodes {
+		n.Duplicate = versionCounts[n.Name] > 1 && n.Version != ""
+		g.Nodes = append(g.Nodes, n)
+	}
+
+	sort.Slice(g.Nodes, func(a, b int) bool {
+		// the root is always first
+		if g.Nodes[a].Root != g.Nodes[b].Root {
+			return g.Nodes[a].Root
+		}
+		return g.Nodes[a].ID < g.Nodes[b].ID
+	})
+	sort.Slice(g.Edges, func(a, b int) bool {
+		if g.Edges[a].From != g.Edges[b].From {
+			return g.Edges[a].From < g.Edges[b].From
+		}
+		return g.Edges[a].To < g.Edges[b].To
+	})
+	return g
+}
+
+// newDependencyGraphNodeForPath creates a node for a parent mod which is only known by its dependency path
+func newDependencyGraphNodeForPath(dependencyPath string) *DependencyGraphNode {
+	name, version, err := modconfig.ParseModDependencyPath(dependencyPath)
+	if err != nil || version == nil {
+		return &DependencyGraphNode{ID: dependencyPath, Name: dependencyPath}
+	}
+	return &DependencyGraphNode{ID: dependencyPath, Name: name, Version: strings.TrimSpace(version.String())}
+}
+
+func (n *DependencyGraphNode) label() string {
+	if n.Version == "" {
+		return n.Name
+	}
+	return n.Name + "\n" + n.Version
+}
+
+// JSON returns the graph as indented JSON
+func (g *DependencyGraph) JSON() ([]byte, error) {
+	return json.MarshalIndent(g, "", "  ")
+}
+
+// Dot returns the graph in Graphviz DOT format - missing mods are dashed red and duplicated mods are orange
+func (g *DependencyGraph) Dot() string {
+	quote := func(s string) string {
+		return `"` + strings.ReplaceAll(strings.ReplaceAll(s, `\`, `\\`), `"`, `\"`) + `"`
+	}
+
+	var b strings.Builder
+	b.WriteString("digraph dependencies {\n")
+	b.WriteString("  rankdir=LR;\n")
+	b.WriteString("  node [shape=box];\n")
+	for _, n := range g.Nodes {
+		attrs := []string{"label=" + strings.ReplaceAll(quote(n.label()), "\n", `\n`)}
+		switch {
+		case n.Root:
+			attrs = append(attrs, "style=bold")
+		case n.Missing:
+			attrs = append(attrs, "style=dashed", "color=red")
+		case n.Duplicate:
+			attrs = append(attrs, "color=orange")
+		}
+		fmt.Fprintf(&b, "  %s [%s];\n", quote(n.ID), strings.Join(attrs, ", "))
+	}
+	for _, e := range g.Edges {
+		if e.Constraint != "" {
+			fmt.Fprintf(&b, "  %s -> %s [label=%s];\n", quote(e.From), quote(e.To), quote(e.Constraint))
+		} else {
+			fmt.Fprintf(&b, "  %s -> %s;\n", quote(e.From), quote(e.To))
+		}
+	}
+	b.WriteString("}\n")
+	return b.String()
+}
+
+// Mermaid returns the graph as a Mermaid flowchart - missing mods are dashed red and duplicated mods are orange
+func (g *DependencyGraph) Mermaid() string {
+	// mermaid node ids may not contain most punctuation, so nodes are numbered
+	ids := make(map[string]string, len(g.Nodes))
+	escape := func(s string) string {
+		return strings.ReplaceAll(strings.ReplaceAll(s, `"`, "#quot;"), "\n", "<br/>")
+	}
+
+	var b strings.Builder
+	b.WriteString("graph LR\n")
+	var missing, duplicate []string
+	for idx, n := range g.Nodes {
+		id := fmt.Sprintf("n%d", idx)
+		ids[n.ID] = id
+		fmt.Fprintf(&b, "  %s[\"%s\"]\n", id, escape(n.label()))
+		switch {
+		case n.Root:
+		case n.Missing:
+			missing = append(missing, id)
+		case n.Duplicate:
+			duplicate = append(duplicate, id)
+		}
+	}
+	for _, e := range g.Edges {
+		if e.Constraint != "" {
+			fmt.Fprintf(&b, "  %s -->|\"%s\"| %s\n", ids[e.From], escape(e.Constraint), ids[e.To])
+		} else {
+			fmt.Fprintf(&b, "  %s --> %s\n", ids[e.From], ids[e.To])
+		}
+	}
+	if len(missing) > 0 {
+		b.WriteString("  classDef missing stroke:#d00,stroke-dasharray:5 5\n")
+		fmt.Fprintf(&b, "  class %s missing\n", strings.Join(missing, ","))
+	}
+	if len(duplicate) > 0 {
+		b.WriteString("  classDef duplicate stroke:#f90\n")
+		fmt.Fprintf(&b, "  class %s duplicate\n", strings.Join(duplicate, ","))
+	}
+	return b.String()
+}
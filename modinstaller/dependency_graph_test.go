@@ -0,0 +1,132 @@
+package modinstaller
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/turbot/pipe-fittings/app_specific"
+	"github.com/turbot/pipe-fittings/modconfig"
+	"github.com/turbot/pipe-fittings/versionmap"
+)
+
+func TestBuildDependencyGraph(t *testing.T) {
+	defer func(original []string) { app_specific.ModDataExtensions = original }(app_specific.ModDataExtensions)
+	app_specific.ModDataExtensions = []string{".pp"}
+
+	modsPath := t.TempDir()
+	m1, _ := installedTestMod(t, modsPath, "github.com/turbot/m1", "1.0.0")
+	m2, m2Path := installedTestMod(t, modsPath, "github.com/turbot/m2", "1.0.0")
+	// m2 requires a different version of m1, which is not installed, and a mod with no locked version
+	m1v2, m1v2Path := installedTestMod(t, modsPath, "github.com/turbot/m1", "2.0.0")
+	if err := os.RemoveAll(m1v2Path); err != nil {
+		t.Fatal(err)
+	}
+	m2ModFile := `mod "m2" {
+  require {
+    mod "github.com/turbot/m1" {
+      version = "^2.0"
+    }
+    mod "github.com/turbot/m3" {
+      version = "^1.0"
+    }
+  }
+}`
+	if err := os.WriteFile(filepath.Join(m2Path, "mod.pp"), []byte(m2ModFile), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	require := modconfig.NewRequire()
+	requiredVersions := make(map[string]*modconfig.ModVersionConstraint)
+	for _, name := range []string{"github.com/turbot/m1@^1.0", "github.com/turbot/m2@^1.0", "github.com/turbot/m4@^1.0"} {
+		requiredVersion, err := modconfig.NewModVersionConstraint(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		requiredVersions[requiredVersion.Name] = requiredVersion
+	}
+	require.AddModDependencies(requiredVersions)
+	workspaceMod := modconfig.NewMod("root", t.TempDir(), hcl.Range{})
+	workspaceMod.Require = require
+
+	i := &ModInstaller{
+		modsPath:     modsPath,
+		workspaceMod: workspaceMod,
+		installData: &InstallData{Lock: &versionmap.WorkspaceLock{
+			InstallCache: versionmap.InstalledDependencyVersionsMap{
+				"root": {m1.Name: m1, m2.Name: m2},
+			},
+			MissingVersions: versionmap.InstalledDependencyVersionsMap{
+				m2.DependencyPath(): {m1v2.Name: m1v2},
+			},
+		}},
+	}
+	g := i.buildDependencyGraph(context.Background())
+
+	var nodes []string
+	for _, n := range g.Nodes {
+		nodes = append(nodes, fmt.Sprintf("%s root=%v missing=%v duplicate=%v", n.ID, n.Root, n.Missing, n.Duplicate))
+	}
+	wantNodes := []string{
+		"root root=true missing=false duplicate=false",
+		"github.com/turbot/m1@v1.0.0 root=false missing=false duplicate=true",
+		"github.com/turbot/m1@v2.0.0 root=false missing=true duplicate=true",
+		"github.com/turbot/m2@v1.0.0 root=false missing=false duplicate=false",
+		"github.com/turbot/m3 root=false missing=true duplicate=false",
+		"github.com/turbot/m4 root=false missing=true duplicate=false",
+	}
+	if strings.Join(nodes, "\n") != strings.Join(wantNodes, "\n") {
+		t.Errorf("nodes =\n%s\nwant\n%s", strings.Join(nodes, "\n"), strings.Join(wantNodes, "\n"))
+	}
+
+	var edges []string
+	for _, e := range g.Edges {
+		edges = append(edges, fmt.Sprintf("%s -> %s %s", e.From, e.To, e.Constraint))
+	}
+	wantEdges := []string{
+		"github.com/turbot/m2@v1.0.0 -> github.com/turbot/m1@v2.0.0 ^2.0",
+		"github.com/turbot/m2@v1.0.0 -> github.com/turbot/m3 ^1.0",
+		"root -> github.com/turbot/m1@v1.0.0 ^1.0",
+		"root -> github.com/turbot/m2@v1.0.0 ^1.0",
+		"root -> github.com/turbot/m4 ^1.0",
+	}
+	if strings.Join(edges, "\n") != strings.Join(wantEdges, "\n") {
+		t.Errorf("edges =\n%s\nwant\n%s", strings.Join(edges, "\n"), strings.Join(wantEdges, "\n"))
+	}
+
+	dot := g.Dot()
+	for _, want := range []string{
+		`"github.com/turbot/m1@v2.0.0" [label="github.com/turbot/m1\n2.0.0", style=dashed, color=red];`,
+		`"root" -> "github.com/turbot/m1@v1.0.0" [label="^1.0"];`,
+	} {
+		if !strings.Contains(dot, want) {
+			t.Errorf("Dot() does not contain %s:\n%s", want, dot)
+		}
+	}
+
+	mermaid := g.Mermaid()
+	for _, want := range []string{
+		`n1["github.com/turbot/m1<br/>1.0.0"]`,
+		`n0 -->|"^1.0"| n1`,
+		"class n2,n4,n5 missing",
+		"class n1 duplicate",
+	} {
+		if !strings.Contains(mermaid, want) {
+			t.Errorf("Mermaid() does not contain %s:\n%s", want, mermaid)
+		}
+	}
+
+	data, err := g.JSON()
+	if err != nil {
+		t.Fatalf("JSON() error = %v", err)
+	}
+	var decoded DependencyGraph
+	if err := json.Unmarshal(data, &decoded); err != nil || len(decoded.Nodes) != len(g.Nodes) || len(decoded.Edges) != len(g.Edges) {
+		t.Errorf("JSON() did not round trip: %v", err)
+	}
+}
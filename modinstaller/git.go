@@ -1,6 +1,8 @@
 package modinstaller
 
 import (
+	"context"
+	"fmt"
 	"log/slog"
 	"os"
 	"sort"
@@ -24,13 +26,13 @@ type GitUrlMode int
 const (
 	GitUrlModeHTTPS GitUrlMode = iota
 	GitUrlModeSSH
+	// a local repository url, e.g. file:///repos/mod1 - no credentials are required
+	gitUrlModeLocal
 )
 
-func getGitUrl(modName string, urlMode GitUrlMode) string {
-	return transformToGitURL(modName, urlMode)
-}
-
-func transformToGitURL(input string, urlMode GitUrlMode) string {
+// transformToGitURL returns the url of the given mod for the url mode - an https url, or an scp-style ssh url
+// (e.g. git@github.com:turbot/mod1.git). If the host specifies a port, an ssh:// url is returned.
+func transformToGitURL(input string, urlMode GitUrlMode, sshUser string) string {
 	if urlMode == GitUrlModeHTTPS {
 		if !strings.HasPrefix(input, "https://") {
 			input = "https://" + input
@@ -38,45 +40,26 @@ func transformToGitURL(input string, urlMode GitUrlMode) string {
 		return input
 	}
 
-	if !strings.HasPrefix(input, "github.com") {
-		return input
-	}
-
-	if !strings.HasPrefix(input, "git@") {
-		input = "git@" + input
+	host, repoPath, _ := strings.Cut(input, "/")
+	if !strings.HasSuffix(repoPath, ".git") {
+		repoPath += ".git"
 	}
-
-	if !strings.HasSuffix(input, ".git") {
-		input += ".git"
-	}
-
-	// Add a colon after the "git@github.com" part, so it replaces the first / with :
-	if !strings.Contains(input, ":") {
-		index := strings.Index(input, "/")
-		input = input[:index] + ":" + input[index+1:]
+	// scp-style urls cannot specify a port
+	if strings.Contains(host, ":") {
+		return fmt.Sprintf("ssh://%s@%s/%s", sshUser, host, repoPath)
 	}
-
-	return input
+	return fmt.Sprintf("%s@%s:%s", sshUser, host, repoPath)
 }
 
-func getRefs(repo string) ([]*plumbing.Reference, error) {
-	gitHubToken := getGitToken()
-
+func getRefs(ctx context.Context, remote *gitRemote) ([]*plumbing.Reference, error) {
 	// Create the remote with repository URL
 	rem := git.NewRemote(memory.NewStorage(), &config.RemoteConfig{
 		Name: "origin",
-		URLs: []string{repo},
+		URLs: []string{remote.url},
 	})
 
-	var listOption git.ListOptions
-	// if a token was provided, use it
-	if gitHubToken != "" {
-		listOption = git.ListOptions{
-			Auth: getGitAuthForToken(gitHubToken),
-		}
-	}
 	// load remote references
-	refs, err := rem.List(&listOption)
+	refs, err := rem.ListContext(ctx, &git.ListOptions{Auth: remote.auth})
 	if err != nil {
 		return nil, err
 	}
@@ -107,9 +90,9 @@ func getGitToken() string {
 	return os.Getenv(app_specific.EnvGitToken)
 }
 
-func getTagVersionsFromGit(modName string, includePrerelease bool) ([]*versionmap.ResolvedVersionConstraint, error) {
+func getTagVersionsFromGit(ctx context.Context, hosts *gitHosts, modName string, includePrerelease bool) ([]*versionmap.ResolvedVersionConstraint, error) {
 	// get and cache all references for the mod
-	refs, err := getRefsFromGit(modName)
+	refs, err := getRefsFromGit(ctx, hosts, modName)
 	if err != nil {
 		return nil, perr.BadRequestWithMessage("could not retrieve version data from Git URL " + modName + " - " + err.Error())
 	}
@@ -156,9 +139,9 @@ func getTagVersionsFromGit(modName string, includePrerelease bool) ([]*versionma
 	return versions, nil
 }
 
-func getTagFromGit(modName string, tag string) (*versionmap.ResolvedVersionConstraint, error) {
+func getTagFromGit(ctx context.Context, hosts *gitHosts, modName string, tag string) (*versionmap.ResolvedVersionConstraint, error) {
 	// get and cache all references for the mod
-	refs, err := getRefsFromGit(modName)
+	refs, err := getRefsFromGit(ctx, hosts, modName)
 	if err != nil {
 		return nil, perr.BadRequestWithMessage("could not retrieve tag data from Git URL " + modName + " - " + err.Error())
 	}
@@ -180,24 +163,25 @@ func getTagFromGit(modName string, tag string) (*versionmap.ResolvedVersionConst
 	return nil, nil
 }
 
-func getRefsFromGit(modName string) ([]*plumbing.Reference, error) {
+func getRefsFromGit(ctx context.Context, hosts *gitHosts, modName string) ([]*plumbing.Reference, error) {
 	slog.Debug("getTagVersionsFromGit - retrieving tags from Git", "mod", modName)
-	// first try https
-	repo := getGitUrl(modName, GitUrlModeHTTPS)
-
-	slog.Debug("trying HTTPS", "url", repo)
-	refs, err := getRefs(repo)
+	remotes, err := hosts.remotes(ctx, modName)
 	if err != nil {
-		slog.Debug("HTTPS failed", "error", err)
-		// if that fails try ssh
-		repo = getGitUrl(modName, GitUrlModeSSH)
+		return nil, err
+	}
 
-		slog.Debug("trying SSH", "url", repo)
-		refs, err = getRefs(repo)
-		if err != nil {
-			slog.Debug("SSH failed", "error", err)
-			return nil, err
+	// try each remote in turn - by default https, then ssh
+	var refs []*plumbing.Reference
+	for _, remote := range remotes {
+		slog.Debug("trying remote", "url", remote.url)
+		refs, err = getRefs(ctx, remote)
+		if err == nil {
+			break
 		}
+		slog.Debug("remote failed", "url", remote.url, "error", err)
+	}
+	if err != nil {
+		return nil, err
 	}
 
 	slog.Debug("retrieved tags from Git")
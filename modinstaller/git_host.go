@@ -0,0 +1,302 @@
+package modinstaller
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	"github.com/go-git/go-git/v5/plumbing/transport/http"
+	gitssh "github.com/go-git/go-git/v5/plumbing/transport/ssh"
+	"github.com/hashicorp/hcl/v2"
+	"github.com/turbot/pipe-fittings/connection"
+	"github.com/turbot/pipe-fittings/modconfig"
+)
+
+// the user used for ssh urls, if the mod host does not specify one
+const defaultSSHUser = "git"
+
+// gitRemote is a url a mod may be retrieved from, with the credentials to use
+type gitRemote struct {
+	url  string
+	auth transport.AuthMethod
+}
+
+// gitHosts determines the urls and credentials used to retrieve mods from each git host, using the mod_host
+// configuration of the workspace profile. Hosts with no configuration use https, falling back to ssh.
+type gitHosts struct {
+	hosts       map[string]*modconfig.ModHost
+	connections map[string]connection.PipelingConnection
+	// if set, installing offline - mods are only retrieved from the repositories of the mod mirror
+	mirror *modMirror
+
+	// the credentials for each host and url mode, resolved on first use
+	authLock sync.Mutex
+	auth     map[string]transport.AuthMethod
+}
+
+func newGitHosts(modHosts []*modconfig.ModHost, connections map[string]connection.PipelingConnection) *gitHosts {
+	g := &gitHosts{
+		hosts:       make(map[string]*modconfig.ModHost, len(modHosts)),
+		connections: connections,
+		auth:        make(map[string]transport.AuthMethod),
+	}
+	for _, h := range modHosts {
+		g.hosts[h.Host] = h
+	}
+	return g
+}
+
+// getHost returns the configuration for the host of the given mod, and the path of the mod on the host
+func (g *gitHosts) getHost(modName string) (*modconfig.ModHost, string) {
+	host, repoPath, _ := strings.Cut(modName, "/")
+	if g != nil {
+		if h, ok := g.hosts[host]; ok {
+			return h, repoPath
+		}
+	}
+	return &modconfig.ModHost{Host: host}, repoPath
+}
+
+// remotes returns the remotes to try, in order, to retrieve the given mod
+func (g *gitHosts) remotes(ctx context.Context, modName string) ([]*gitRemote, error) {
+	if g != nil && g.mirror != nil {
+		url, ok := g.mirror.repoURL(modName)
+		if !ok {
+			return nil, fmt.Errorf("mod '%s' is not available in the mod mirror %s", modName, g.mirror.path)
+		}
+		return []*gitRemote{{url: url}}, nil
+	}
+
+	host, repoPath := g.getHost(modName)
+
+	// if a base url is configured, it replaces the host
+	if host.BaseURL != nil {
+		url := strings.TrimSuffix(*host.BaseURL, "/") + "/" + repoPath
+		auth, err := g.getAuth(ctx, host, repoPath, urlModeForURL(url))
+		if err != nil {
+			return nil, err
+		}
+		return []*gitRemote{{url: url, auth: auth}}, nil
+	}
+
+	urlModes := []GitUrlMode{GitUrlModeHTTPS, GitUrlModeSSH}
+	if host.UrlMode != nil {
+		switch *host.UrlMode {
+		case modconfig.ModHostUrlModeHTTPS:
+			urlModes = []GitUrlMode{GitUrlModeHTTPS}
+		case modconfig.ModHostUrlModeSSH:
+			urlModes = []GitUrlMode{GitUrlModeSSH}
+		}
+	}
+
+	var res []*gitRemote
+	for _, urlMode := range urlModes {
+		auth, err := g.getAuth(ctx, host, repoPath, urlMode)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, &gitRemote{url: transformToGitURL(modName, urlMode, sshUser(host)), auth: auth})
+	}
+	return res, nil
+}
+
+// authForURL returns the credentials to use for the given url of the given mod, e.g. the origin url of a cloned mod
+func (g *gitHosts) authForURL(ctx context.Context, modName, url string) (transport.AuthMethod, error) {
+	host, repoPath := g.getHost(modName)
+	return g.getAuth(ctx, host, repoPath, urlModeForURL(url))
+}
+
+func (g *gitHosts) getAuth(ctx context.Context, host *modconfig.ModHost, repoPath string, urlMode GitUrlMode) (transport.AuthMethod, error) {
+	if urlMode == gitUrlModeLocal {
+		return nil, nil
+	}
+	if g == nil {
+		return resolveGitAuth(ctx, host, repoPath, urlMode, nil)
+	}
+
+	key := fmt.Sprintf("%s|%d", host.Host, urlMode)
+	g.authLock.Lock()
+	defer g.authLock.Unlock()
+	if auth, ok := g.auth[key]; ok {
+		return auth, nil
+	}
+	auth, err := resolveGitAuth(ctx, host, repoPath, urlMode, g.connections)
+	if err != nil {
+		return nil, err
+	}
+	g.auth[key] = auth
+	return auth, nil
+}
+
+func resolveGitAuth(ctx context.Context, host *modconfig.ModHost, repoPath string, urlMode GitUrlMode, connections map[string]connection.PipelingConnection) (transport.AuthMethod, error) {
+	if urlMode == GitUrlModeSSH {
+		return getSSHAuth(host)
+	}
+
+	hostType := host.GetType()
+	switch {
+	case host.Token != nil:
+		return getAuthForHostToken(hostType, host.Username, *host.Token), nil
+	case host.Password != nil:
+		return &http.BasicAuth{Username: stringValue(host.Username), Password: *host.Password}, nil
+	case host.Connection != nil:
+		conn, ok := connections[*host.Connection]
+		if !ok {
+			return nil, fmt.Errorf("connection '%s' for mod_host %s not found", *host.Connection, host.Host)
+		}
+		return getAuthForConnection(ctx, conn)
+	case host.CredentialHelper != nil && *host.CredentialHelper:
+		return getAuthFromCredentialHelper(ctx, host.Host, repoPath)
+	}
+
+	// no credentials are configured - the default credentials are only used for the public hosts, a self-hosted
+	// server must configure its credentials explicitly, even if its type is set, so they are never sent elsewhere
+	switch host.Host {
+	case "gitlab.com":
+		return getDefaultConnectionAuth(ctx, modconfig.ModHostTypeGitLab, connections, connection.NewGitLabConnection)
+	case "bitbucket.org":
+		return getDefaultConnectionAuth(ctx, modconfig.ModHostTypeBitbucket, connections, connection.NewBitbucketConnection)
+	case "github.com":
+		return getGitAuthForToken(getGitToken()), nil
+	default:
+		return nil, nil
+	}
+}
+
+// getAuthForHostToken returns the https credentials for a token, in the form expected by the type of host
+func getAuthForHostToken(hostType string, username *string, token string) transport.AuthMethod {
+	if token == "" {
+		return nil
+	}
+	if username != nil {
+		return &http.BasicAuth{Username: *username, Password: token}
+	}
+	switch hostType {
+	case modconfig.ModHostTypeGitHub:
+		return getGitAuthForToken(token)
+	case modconfig.ModHostTypeGitLab:
+		// GitLab accepts personal, project and group access tokens with any username
+		return &http.BasicAuth{Username: "oauth2", Password: token}
+	case modconfig.ModHostTypeBitbucket:
+		// Bitbucket repository and workspace access tokens use the x-token-auth username
+		return &http.BasicAuth{Username: "x-token-auth", Password: token}
+	default:
+		return &http.TokenAuth{Token: token}
+	}
+}
+
+// getAuthForConnection returns the https credentials from a github, gitlab or bitbucket connection
+func getAuthForConnection(ctx context.Context, conn connection.PipelingConnection) (transport.AuthMethod, error) {
+	resolved, err := conn.Resolve(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve connection '%s': %w", conn.Name(), err)
+	}
+	switch c := resolved.(type) {
+	case *connection.GithubConnection:
+		return getAuthForHostToken(modconfig.ModHostTypeGitHub, nil, stringValue(c.Token)), nil
+	case *connection.GitLabConnection:
+		return getAuthForHostToken(modconfig.ModHostTypeGitLab, nil, stringValue(c.Token)), nil
+	case *connection.BitbucketConnection:
+		if stringValue(c.Password) == "" {
+			return nil, nil
+		}
+		return &http.BasicAuth{Username: stringValue(c.Username), Password: *c.Password}, nil
+	default:
+		return nil, fmt.Errorf("connection '%s' cannot be used for mod_host credentials - only github, gitlab and bitbucket connections are supported", conn.Name())
+	}
+}
+
+// getDefaultConnectionAuth returns the credentials of the default connection for the type of host - the connection
+// named '<type>.default' if one is configured, otherwise the connection resolved from the environment
+func getDefaultConnectionAuth(ctx context.Context, hostType string, connections map[string]connection.PipelingConnection, newConnection func(string, hcl.Range) connection.PipelingConnection) (transport.AuthMethod, error) {
+	conn, ok := connections[hostType+".default"]
+	if !ok {
+		conn = newConnection("default", hcl.Range{})
+	}
+	return getAuthForConnection(ctx, conn)
+}
+
+// gitCredentialFill runs 'git credential fill' with the given input, returning its output - this is a variable so
+// it can be replaced in tests
+var gitCredentialFill = func(ctx context.Context, input string) (string, error) {
+	cmd := exec.CommandContext(ctx, "git", "credential", "fill")
+	cmd.Stdin = strings.NewReader(input)
+	// never prompt - if no helper has credentials for the host, fail
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0", "GIT_ASKPASS=", "SSH_ASKPASS=")
+	output, err := cmd.Output()
+	return string(output), err
+}
+
+// getAuthFromCredentialHelper retrieves https credentials for the host from the git credential helpers
+func getAuthFromCredentialHelper(ctx context.Context, host, repoPath string) (transport.AuthMethod, error) {
+	output, err := gitCredentialFill(ctx, fmt.Sprintf("protocol=https\nhost=%s\npath=%s\n\n", host, repoPath))
+	if err != nil {
+		// the helpers have no credentials for this host - try without
+		slog.Debug("git credential helper returned no credentials", "host", host, "error", err)
+		return nil, nil
+	}
+	var username, password string
+	for _, line := range strings.Split(output, "\n") {
+		key, value, _ := strings.Cut(strings.TrimRight(line, "\r"), "=")
+		switch key {
+		case "username":
+			username = value
+		case "password":
+			password = value
+		}
+	}
+	if password == "" {
+		return nil, nil
+	}
+	return &http.BasicAuth{Username: username, Password: password}, nil
+}
+
+// getSSHAuth returns the ssh credentials for the host - the configured key file if set, otherwise the ssh agent
+func getSSHAuth(host *modconfig.ModHost) (transport.AuthMethod, error) {
+	user := sshUser(host)
+	if host.SSHKeyFile != nil {
+		auth, err := gitssh.NewPublicKeysFromFile(user, *host.SSHKeyFile, stringValue(host.SSHKeyPassphrase))
+		if err != nil {
+			return nil, fmt.Errorf("failed to load ssh key file for mod_host %s: %w", host.Host, err)
+		}
+		return auth, nil
+	}
+	auth, err := gitssh.NewSSHAgentAuth(user)
+	if err != nil {
+		// no agent is running - try without credentials
+		slog.Debug("ssh agent not available", "host", host.Host, "error", err)
+		return nil, nil
+	}
+	return auth, nil
+}
+
+func sshUser(host *modconfig.ModHost) string {
+	if host.SSHUser != nil {
+		return *host.SSHUser
+	}
+	return defaultSSHUser
+}
+
+// urlModeForURL returns the url mode of a git url
+func urlModeForURL(url string) GitUrlMode {
+	switch {
+	case strings.HasPrefix(url, "https://"), strings.HasPrefix(url, "http://"):
+		return GitUrlModeHTTPS
+	case strings.HasPrefix(url, "file://"), strings.HasPrefix(url, "/"):
+		return gitUrlModeLocal
+	default:
+		return GitUrlModeSSH
+	}
+}
+
+func stringValue(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
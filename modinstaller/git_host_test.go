@@ -0,0 +1,272 @@
+package modinstaller
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/plumbing/transport/http"
+	"github.com/hashicorp/hcl/v2"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"github.com/turbot/pipe-fittings/app_specific"
+	"github.com/turbot/pipe-fittings/connection"
+	"github.com/turbot/pipe-fittings/constants"
+	"github.com/turbot/pipe-fittings/modconfig"
+)
+
+func TestTransformToGitURL(t *testing.T) {
+	tests := []struct {
+		name    string
+		urlMode GitUrlMode
+		want    string
+	}{
+		{name: "github.com/turbot/mod1", urlMode: GitUrlModeHTTPS, want: "https://github.com/turbot/mod1"},
+		{name: "github.com/turbot/mod1", urlMode: GitUrlModeSSH, want: "git@github.com:turbot/mod1.git"},
+		{name: "gitlab.example.com/group/subgroup/mod1", urlMode: GitUrlModeSSH, want: "git@gitlab.example.com:group/subgroup/mod1.git"},
+		{name: "git.example.com:2222/turbot/mod1", urlMode: GitUrlModeSSH, want: "ssh://git@git.example.com:2222/turbot/mod1.git"},
+	}
+	for _, tt := range tests {
+		if got := transformToGitURL(tt.name, tt.urlMode, defaultSSHUser); got != tt.want {
+			t.Errorf("transformToGitURL(%s, %d) = %s, want %s", tt.name, tt.urlMode, got, tt.want)
+		}
+	}
+}
+
+func TestGitHostsRemotes(t *testing.T) {
+	ssh, https := modconfig.ModHostUrlModeSSH, modconfig.ModHostUrlModeHTTPS
+	user, baseURL := "gitlab", "https://mirror.example.com/gitlab/"
+	hosts := newGitHosts([]*modconfig.ModHost{
+		{Host: "gitlab.example.com", UrlMode: &ssh, SSHUser: &user},
+		{Host: "bitbucket.org", UrlMode: &https},
+		{Host: "git.example.com", BaseURL: &baseURL},
+	}, nil)
+
+	tests := []struct {
+		modName string
+		want    []string
+	}{
+		{modName: "github.com/turbot/mod1", want: []string{"https://github.com/turbot/mod1", "git@github.com:turbot/mod1.git"}},
+		{modName: "gitlab.example.com/group/mod1", want: []string{"gitlab@gitlab.example.com:group/mod1.git"}},
+		{modName: "bitbucket.org/turbot/mod1", want: []string{"https://bitbucket.org/turbot/mod1"}},
+		{modName: "git.example.com/turbot/mod1", want: []string{"https://mirror.example.com/gitlab/turbot/mod1"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.modName, func(t *testing.T) {
+			remotes, err := hosts.remotes(context.Background(), tt.modName)
+			if err != nil {
+				t.Fatalf("remotes() error = %v", err)
+			}
+			var urls []string
+			for _, remote := range remotes {
+				urls = append(urls, remote.url)
+			}
+			if strings.Join(urls, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("remotes() = %v, want %v", urls, tt.want)
+			}
+		})
+	}
+}
+
+func TestResolveGitAuth(t *testing.T) {
+	defer func(original func(context.Context, string) (string, error)) { gitCredentialFill = original }(gitCredentialFill)
+	var credentialInput string
+	gitCredentialFill = func(_ context.Context, input string) (string, error) {
+		credentialInput = input
+		return "protocol=https\nhost=git.example.com\nusername=helper-user\npassword=helper-password\n", nil
+	}
+
+	token, username, password, connectionName := "secret", "deploy", "pass", "gitlab.private"
+	gitLabType, enabled := modconfig.ModHostTypeGitLab, true
+	gitLabConnection := connection.NewGitLabConnection("private", hcl.Range{}).(*connection.GitLabConnection)
+	gitLabConnection.Token = &token
+	connections := map[string]connection.PipelingConnection{connectionName: gitLabConnection}
+	missingConnection := "gitlab.missing"
+
+	tests := []struct {
+		name    string
+		host    *modconfig.ModHost
+		want    *http.BasicAuth
+		wantErr bool
+	}{
+		{
+			name: "gitlab token",
+			host: &modconfig.ModHost{Host: "gitlab.com", Token: &token},
+			want: &http.BasicAuth{Username: "oauth2", Password: token},
+		},
+		{
+			name: "bitbucket token",
+			host: &modconfig.ModHost{Host: "bitbucket.org", Token: &token},
+			want: &http.BasicAuth{Username: "x-token-auth", Password: token},
+		},
+		{
+			name: "deploy token",
+			host: &modconfig.ModHost{Host: "gitlab.example.com", Type: &gitLabType, Username: &username, Token: &token},
+			want: &http.BasicAuth{Username: username, Password: token},
+		},
+		{
+			name: "username and password",
+			host: &modconfig.ModHost{Host: "git.example.com", Username: &username, Password: &password},
+			want: &http.BasicAuth{Username: username, Password: password},
+		},
+		{
+			name: "connection",
+			host: &modconfig.ModHost{Host: "gitlab.example.com", Type: &gitLabType, Connection: &connectionName},
+			want: &http.BasicAuth{Username: "oauth2", Password: token},
+		},
+		{
+			name:    "missing connection",
+			host:    &modconfig.ModHost{Host: "gitlab.example.com", Connection: &missingConnection},
+			wantErr: true,
+		},
+		{
+			name: "credential helper",
+			host: &modconfig.ModHost{Host: "git.example.com", CredentialHelper: &enabled},
+			want: &http.BasicAuth{Username: "helper-user", Password: "helper-password"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			auth, err := resolveGitAuth(context.Background(), tt.host, "turbot/mod1", GitUrlModeHTTPS, connections)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("resolveGitAuth() expected error, got %v", auth)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("resolveGitAuth() error = %v", err)
+			}
+			got, ok := auth.(*http.BasicAuth)
+			if !ok || *got != *tt.want {
+				t.Errorf("resolveGitAuth() = %v, want %v", auth, tt.want)
+			}
+		})
+	}
+
+	if !strings.Contains(credentialInput, "host=git.example.com\npath=turbot/mod1\n") {
+		t.Errorf("git credential fill input = %q", credentialInput)
+	}
+}
+
+func TestResolveGitAuth_DefaultToken(t *testing.T) {
+	defer func(original string) { app_specific.EnvGitToken = original }(app_specific.EnvGitToken)
+	app_specific.EnvGitToken = "TEST_GIT_TOKEN"
+	t.Setenv("TEST_GIT_TOKEN", "ghp_secret")
+
+	auth, err := resolveGitAuth(context.Background(), &modconfig.ModHost{Host: "github.com"}, "turbot/mod1", GitUrlModeHTTPS, nil)
+	if err != nil || auth == nil {
+		t.Errorf("resolveGitAuth() for github = %v, %v, want the github token", auth, err)
+	}
+
+	// the github token is never sent to other hosts
+	auth, err = resolveGitAuth(context.Background(), &modconfig.ModHost{Host: "git.example.com"}, "turbot/mod1", GitUrlModeHTTPS, nil)
+	if err != nil || auth != nil {
+		t.Errorf("resolveGitAuth() for another host = %v, %v, want no credentials", auth, err)
+	}
+
+	// nor to a self-hosted server of the same type, which must configure its credentials explicitly
+	for _, hostType := range []string{modconfig.ModHostTypeGitHub, modconfig.ModHostTypeGitLab, modconfig.ModHostTypeBitbucket} {
+		auth, err = resolveGitAuth(context.Background(), &modconfig.ModHost{Host: "git.example.com", Type: &hostType}, "turbot/mod1", GitUrlModeHTTPS, nil)
+		if err != nil || auth != nil {
+			t.Errorf("resolveGitAuth() for a self-hosted %s server = %v, %v, want no credentials", hostType, auth, err)
+		}
+	}
+}
+
+func TestNewInstallOpts_Connections(t *testing.T) {
+	defer viper.Reset()
+	connectionName, token := "github.private", "ghp_connection"
+	viper.Set(constants.ConfigKeyActiveCommand, &cobra.Command{Use: "install"})
+	viper.Set(constants.ArgModHosts, []*modconfig.ModHost{{Host: "github.example.com", Connection: &connectionName}})
+
+	githubConnection := connection.NewGithubConnection("private", hcl.Range{}).(*connection.GithubConnection)
+	githubConnection.Token = &token
+	opts := NewInstallOpts(&modconfig.Mod{})
+	opts.Connections = map[string]connection.PipelingConnection{connectionName: githubConnection}
+
+	hosts := newGitHosts(opts.ModHosts, opts.Connections)
+	auth, err := hosts.authForURL(context.Background(), "github.example.com/turbot/mod1", "https://github.example.com/turbot/mod1.git")
+	if err != nil {
+		t.Fatalf("authForURL() error = %v", err)
+	}
+	if want := getGitAuthForToken(token); auth == nil || auth.String() != want.String() {
+		t.Errorf("authForURL() = %v, want %v", auth, want)
+	}
+}
+
+// newLocalModRepo creates a repository containing a mod, with a commit tagged with each of the given tags
+func newLocalModRepo(t *testing.T, tags ...string) string {
+	t.Helper()
+	repoPath := t.TempDir()
+	repo, err := git.PlainInit(repoPath, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wt, err := repo.Worktree()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tag := range tags {
+		if err := os.WriteFile(filepath.Join(repoPath, "mod.pp"), []byte(`mod "mod1" { title = "`+tag+`" }`), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := wt.Add("mod.pp"); err != nil {
+			t.Fatal(err)
+		}
+		commit, err := wt.Commit(tag, &git.CommitOptions{Author: &object.Signature{Name: "test", Email: "test@example.com", When: time.Now()}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := repo.CreateTag(tag, commit, nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return repoPath
+}
+
+func TestInstallFromGit_CustomHost(t *testing.T) {
+	defer func(original []string) { app_specific.ModDataExtensions = original }(app_specific.ModDataExtensions)
+	app_specific.ModDataExtensions = []string{".pp"}
+
+	// the mod is served from a local repository, in place of the custom host
+	reposPath := t.TempDir()
+	if err := os.Rename(newLocalModRepo(t, "v1.0.0", "v1.1.0"), filepath.Join(reposPath, "mod1")); err != nil {
+		t.Fatal(err)
+	}
+	baseURL := "file://" + filepath.ToSlash(reposPath) + "/"
+	i := &ModInstaller{
+		gitHosts: newGitHosts([]*modconfig.ModHost{{Host: "git.example.com", BaseURL: &baseURL}}, nil),
+	}
+	ctx := context.Background()
+	modName := "git.example.com/mod1"
+
+	versions, err := getTagVersionsFromGit(ctx, i.gitHosts, modName, false)
+	if err != nil {
+		t.Fatalf("getTagVersionsFromGit() error = %v", err)
+	}
+	if len(versions) != 2 || versions[0].Version.String() != "1.1.0" {
+		t.Fatalf("getTagVersionsFromGit() = %v, want 1.1.0, 1.0.0", versions)
+	}
+
+	installPath := filepath.Join(t.TempDir(), versions[1].DependencyPath())
+	repo, err := i.installFromGit(ctx, modName, plumbing.ReferenceName(versions[1].GitRefStr), installPath)
+	if err != nil {
+		t.Fatalf("installFromGit() error = %v", err)
+	}
+	content, err := os.ReadFile(filepath.Join(installPath, "mod.pp"))
+	if err != nil || !strings.Contains(string(content), "v1.0.0") {
+		t.Errorf("installFromGit() installed %q, %v - want v1.0.0", content, err)
+	}
+
+	// fetching from the cloned mod uses the same host configuration
+	if _, err := i.getRemoteAuth(repo, modName); err != nil {
+		t.Errorf("getRemoteAuth() error = %v", err)
+	}
+}
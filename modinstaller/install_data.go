@@ -1,6 +1,7 @@
 package modinstaller
 
 import (
+	"context"
 	"fmt"
 	"github.com/turbot/pipe-fittings/modconfig"
 	"github.com/turbot/pipe-fittings/perr"
@@ -21,6 +22,11 @@ type InstallData struct {
 	Downgraded  [][]string
 
 	WorkspaceMod *modconfig.Mod
+
+	// the urls and credentials used to retrieve mods from each git host
+	gitHosts *gitHosts
+	// the mod mirror to retrieve versions from when installing offline
+	mirror *modMirror
 }
 
 func NewInstallData(workspaceLock *versionmap.WorkspaceLock, workspaceMod *modconfig.Mod) *InstallData {
@@ -48,18 +54,31 @@ func (d *InstallData) addExisting(existingDep *DependencyMod, parent *modconfig.
 }
 
 // retrieve all available mod versions from our cache, or from Git if not yet cached
-func (d *InstallData) getAvailableModVersions(modName string, includePrerelease bool) (versionmap.ResolvedVersionConstraintList, error) {
+func (d *InstallData) getAvailableModVersions(ctx context.Context, modName string, includePrerelease bool) (versionmap.ResolvedVersionConstraintList, error) {
 	// have we already loaded the versions for this mod
 	availableVersions, ok := d.allAvailable[modName]
 	if ok {
 		return availableVersions, nil
 	}
 
-	// so we have not cached this yet - retrieve from Git
+	// so we have not cached this yet - retrieve from the OCI registry or Git
 	var err error
-	availableVersions, err = getTagVersionsFromGit(modName, includePrerelease)
-	if err != nil {
-		return nil, perr.BadRequestWithMessage("could not retrieve version data from Git URL " + modName + " - " + err.Error())
+	switch {
+	case d.mirror != nil:
+		availableVersions, err = d.mirror.getVersions(ctx, d.gitHosts, d.Lock, modName, includePrerelease)
+		if err != nil {
+			return nil, err
+		}
+	case modconfig.IsOciModName(modName):
+		availableVersions, err = getTagVersionsFromOci(ctx, modName, includePrerelease)
+		if err != nil {
+			return nil, err
+		}
+	default:
+		availableVersions, err = getTagVersionsFromGit(ctx, d.gitHosts, modName, includePrerelease)
+		if err != nil {
+			return nil, perr.BadRequestWithMessage("could not retrieve version data from Git URL " + modName + " - " + err.Error())
+		}
 	}
 	// update our cache
 	d.allAvailable[modName] = availableVersions
@@ -3,6 +3,7 @@ package modinstaller
 import (
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
+	"github.com/turbot/pipe-fittings/connection"
 	"github.com/turbot/pipe-fittings/constants"
 	"github.com/turbot/pipe-fittings/modconfig"
 	"github.com/turbot/pipe-fittings/plugin"
@@ -17,6 +18,15 @@ type InstallOpts struct {
 	Force          bool
 	PluginVersions *plugin.PluginVersionMap
 	UpdateStrategy string
+	// the git hosts mods are installed from, as configured in the workspace profile
+	ModHosts []*modconfig.ModHost
+	// connections which may be referenced by ModHosts for credentials, keyed by connection name - these are not
+	// set by NewInstallOpts, the caller sets them from the connections it has loaded
+	Connections map[string]connection.PipelingConnection
+	// if set, mods are resolved and installed from the mod mirror only, without network access
+	Offline bool
+	// the mod mirror directory - defaults to the mirror directory of the workspace
+	MirrorPath string
 }
 
 func NewInstallOpts(workspaceMod *modconfig.Mod, modsToInstall ...string) *InstallOpts {
@@ -38,6 +48,12 @@ func NewInstallOpts(workspaceMod *modconfig.Mod, modsToInstall ...string) *Insta
 		ModArgs:        utils.TrimGitUrls(modsToInstall),
 		Command:        cmdName,
 		UpdateStrategy: viper.GetString(constants.ArgPull),
+		Offline:        viper.GetBool(constants.ArgOffline),
+		MirrorPath:     viper.GetString(constants.ArgModMirror),
+	}
+
+	if modHosts, ok := viper.Get(constants.ArgModHosts).([]*modconfig.ModHost); ok {
+		opts.ModHosts = modHosts
 	}
 
 	opts.ModArgs = utils.TrimGitUrls(opts.ModArgs)
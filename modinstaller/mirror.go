@@ -0,0 +1,247 @@
+package modinstaller
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	filehelpers "github.com/turbot/go-kit/files"
+	"github.com/turbot/pipe-fittings/modconfig"
+	"github.com/turbot/pipe-fittings/ociinstaller"
+	"github.com/turbot/pipe-fittings/utils"
+	"github.com/turbot/pipe-fittings/versionmap"
+)
+
+// the extension of mod archives in a mod mirror
+const modArchiveExtension = ".tar.gz"
+
+// modMirror is a local directory of mods, used to install mods without network access.
+//
+// Each mod may be mirrored as a git repository (usually bare) at <mirror>/<mod name>.git or <mirror>/<mod name>,
+// and/or as archives of individual versions, created by PackageMod, at <mirror>/<dependency path>.tar.gz,
+// e.g. <mirror>/github.com/turbot/mod1@v1.2.0.tar.gz
+type modMirror struct {
+	path string
+}
+
+func newModMirror(path string) *modMirror {
+	return &modMirror{path: path}
+}
+
+// repoPath returns the path of the mirrored git repository of the given mod, if there is one
+func (m *modMirror) repoPath(modName string) (string, bool) {
+	// mods from OCI registries are only mirrored as archives
+	if modconfig.IsOciModName(modName) {
+		return "", false
+	}
+	for _, p := range []string{filepath.Join(m.path, modName+".git"), filepath.Join(m.path, modName)} {
+		if filehelpers.DirectoryExists(p) {
+			return p, true
+		}
+	}
+	return "", false
+}
+
+// repoURL returns the url of the mirrored git repository of the given mod, if there is one
+func (m *modMirror) repoURL(modName string) (string, bool) {
+	repoPath, ok := m.repoPath(modName)
+	if !ok {
+		return "", false
+	}
+	return "file://" + filepath.ToSlash(repoPath), true
+}
+
+// archivePath returns the path of the archive of the given mod version in the mirror
+func (m *modMirror) archivePath(dependencyPath string) string {
+	return filepath.Join(m.path, filepath.FromSlash(dependencyPath)+modArchiveExtension)
+}
+
+func (m *modMirror) hasArchive(dependencyPath string) bool {
+	return filehelpers.FileExists(m.archivePath(dependencyPath))
+}
+
+// getArchiveVersions returns the versions and tags of the given mod which are mirrored as archives
+func (m *modMirror) getArchiveVersions(modName string) (versionmap.ResolvedVersionConstraintList, error) {
+	dependencyDir := modconfig.ModDependencyDir(modName)
+	archives, err := filepath.Glob(filepath.Join(m.path, filepath.FromSlash(dependencyDir)) + "@*" + modArchiveExtension)
+	if err != nil {
+		return nil, err
+	}
+
+	var res versionmap.ResolvedVersionConstraintList
+	for _, archive := range archives {
+		rel, err := filepath.Rel(m.path, archive)
+		if err != nil {
+			return nil, err
+		}
+		dependencyPath := strings.TrimSuffix(filepath.ToSlash(rel), modArchiveExtension)
+		_, version, err := modconfig.ParseModDependencyPath(dependencyPath)
+		if err != nil || version == nil {
+			// not a mod archive
+			continue
+		}
+		res = append(res, &versionmap.ResolvedVersionConstraint{
+			DependencyVersion: *version,
+			Name:              modName,
+			StructVersion:     versionmap.WorkspaceLockStructVersion,
+		})
+	}
+	return res, nil
+}
+
+// getVersions returns all mirrored versions of the given mod, in reverse order - the tags of the mirrored
+// repository, and the versions mirrored as archives
+func (m *modMirror) getVersions(ctx context.Context, hosts *gitHosts, lock *versionmap.WorkspaceLock, modName string, includePrerelease bool) (versionmap.ResolvedVersionConstraintList, error) {
+	versions := make(map[string]*versionmap.ResolvedVersionConstraint)
+	if _, ok := m.repoPath(modName); ok {
+		repoVersions, err := getTagVersionsFromGit(ctx, hosts, modName, includePrerelease)
+		if err != nil {
+			return nil, err
+		}
+		for _, v := range repoVersions {
+			versions[v.DependencyPath()] = v
+		}
+	}
+
+	archiveVersions, err := m.getArchiveVersions(modName)
+	if err != nil {
+		return nil, err
+	}
+	for _, v := range archiveVersions {
+		if v.Version == nil || (!includePrerelease && (v.Version.Prerelease() != "" || v.Version.Metadata() != "")) {
+			continue
+		}
+		if _, ok := versions[v.DependencyPath()]; ok {
+			continue
+		}
+		versions[v.DependencyPath()] = m.withLockedRefs(lock, v)
+	}
+
+	if len(versions) == 0 {
+		return nil, fmt.Errorf("mod '%s' is not available in the mod mirror %s", modName, m.path)
+	}
+	res := make(versionmap.ResolvedVersionConstraintList, 0, len(versions))
+	for _, v := range versions {
+		res = append(res, v)
+	}
+	sort.Sort(sort.Reverse(res))
+	return res, nil
+}
+
+// getTag returns the given tag of the mod from the mirror, or nil if it is not mirrored
+func (m *modMirror) getTag(ctx context.Context, hosts *gitHosts, lock *versionmap.WorkspaceLock, modName string, tag string) (*versionmap.ResolvedVersionConstraint, error) {
+	if _, ok := m.repoPath(modName); ok {
+		return getTagFromGit(ctx, hosts, modName, tag)
+	}
+	version := &versionmap.ResolvedVersionConstraint{
+		DependencyVersion: modconfig.DependencyVersion{Tag: tag},
+		Name:              modName,
+		StructVersion:     versionmap.WorkspaceLockStructVersion,
+	}
+	if !m.hasArchive(version.DependencyPath()) {
+		return nil, nil
+	}
+	return m.withLockedRefs(lock, version), nil
+}
+
+// withLockedRefs sets the git refs and OCI digest of a version mirrored as an archive from the lock file, so installing
+// from the mirror does not change the lock
+func (m *modMirror) withLockedRefs(lock *versionmap.WorkspaceLock, version *versionmap.ResolvedVersionConstraint) *versionmap.ResolvedVersionConstraint {
+	if locked := lock.GetLockedModVersionForPath(version.DependencyPath()); locked != nil {
+		version.Commit = locked.Commit
+		version.GitRefStr = locked.GitRefStr
+		version.OciTag = locked.OciTag
+		version.Digest = locked.Digest
+	}
+	return version
+}
+
+// contains returns whether the given locked mod version is available in the mirror
+func (m *modMirror) contains(version *versionmap.ResolvedVersionConstraint) bool {
+	if m.hasArchive(version.DependencyPath()) {
+		return true
+	}
+	repoPath, ok := m.repoPath(version.Name)
+	if !ok {
+		return false
+	}
+	repo, err := git.PlainOpen(repoPath)
+	if err != nil {
+		return false
+	}
+	// the locked commit must be in the repository
+	if version.Commit != "" {
+		_, err = repo.CommitObject(plumbing.NewHash(version.Commit))
+		return err == nil
+	}
+	_, err = repo.Reference(plumbing.ReferenceName(version.GitRefStr), true)
+	return err == nil
+}
+
+// install extracts the archive of the given mod version from the mirror to installPath - it returns false if the
+// version is not mirrored as an archive
+func (m *modMirror) install(dependencyPath string, installPath string) (bool, error) {
+	if !m.hasArchive(dependencyPath) {
+		return false, nil
+	}
+	if err := ociinstaller.ExtractModArchive(m.archivePath(dependencyPath), installPath); err != nil {
+		return false, fmt.Errorf("failed to extract mod '%s' from the mod mirror: %w", dependencyPath, err)
+	}
+	return true, nil
+}
+
+// MissingDependenciesError is returned by an offline installation when locked dependencies are not installed and are
+// not available in the mod mirror
+type MissingDependenciesError struct {
+	MirrorPath string
+	// the dependency paths of the missing mods
+	Missing []string
+}
+
+func (e *MissingDependenciesError) Error() string {
+	return fmt.Sprintf("%d locked %s not installed and not available in the mod mirror %s:\n  %s",
+		len(e.Missing),
+		utils.Pluralize("dependency is", len(e.Missing)),
+		e.MirrorPath,
+		strings.Join(e.Missing, "\n  "))
+}
+
+// validateMirror verifies that every locked dependency which is not installed is available in the mirror, returning a
+// MissingDependenciesError listing those which are not
+func (i *ModInstaller) validateMirror() error {
+	lock := i.installData.Lock
+	missing := make(map[string]struct{})
+	check := func(dependencyPath string, version *versionmap.InstalledModVersion) {
+		if version.FilePath != "" {
+			return
+		}
+		if _, err := os.Stat(i.getDependencyDestPath(dependencyPath)); err == nil {
+			return
+		}
+		if !i.mirror.contains(version.ResolvedVersionConstraint) {
+			missing[dependencyPath] = struct{}{}
+		}
+	}
+	for dependencyPath, version := range lock.InstallCache.FlatMap() {
+		check(dependencyPath, version)
+	}
+	// locked versions which are not installed are moved to MissingVersions when the lock is loaded
+	for dependencyPath, version := range lock.MissingVersions.FlatMap() {
+		check(dependencyPath, version)
+	}
+
+	if len(missing) == 0 {
+		return nil
+	}
+	res := &MissingDependenciesError{MirrorPath: i.mirror.path}
+	for dependencyPath := range missing {
+		res.Missing = append(res.Missing, dependencyPath)
+	}
+	sort.Strings(res.Missing)
+	return res
+}
@@ -0,0 +1,163 @@
+package modinstaller
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/turbot/pipe-fittings/app_specific"
+	"github.com/turbot/pipe-fittings/modconfig"
+	"github.com/turbot/pipe-fittings/versionmap"
+)
+
+func TestVendorAndInstallOffline(t *testing.T) {
+	defer func(original []string) { app_specific.ModDataExtensions = original }(app_specific.ModDataExtensions)
+	app_specific.ModDataExtensions = []string{".pp"}
+
+	ctx := context.Background()
+	locked, _ := installedTestMod(t, t.TempDir(), "github.com/turbot/m1", "1.0.0")
+	// the mod is installed in the vendoring workspace
+	vendorModsPath := t.TempDir()
+	if _, installPath := installedTestMod(t, vendorModsPath, locked.Name, "1.0.0"); installPath == "" {
+		t.Fatal("mod not installed")
+	}
+	lock := &versionmap.WorkspaceLock{
+		InstallCache: versionmap.InstalledDependencyVersionsMap{"mod.root": {locked.Name: locked}},
+	}
+
+	mirror := newModMirror(t.TempDir())
+	vendorer := &ModInstaller{modsPath: vendorModsPath, installData: &InstallData{Lock: lock}}
+	res, err := vendorer.vendorMods(ctx, mirror)
+	if err != nil {
+		t.Fatalf("vendorMods() error = %v", err)
+	}
+	if got := strings.Join(res.Added, ","); got != "github.com/turbot/m1@v1.0.0" {
+		t.Fatalf("Added = %s", got)
+	}
+	// vendoring again leaves the existing archive
+	res, err = vendorer.vendorMods(ctx, mirror)
+	if err != nil || len(res.Added) != 0 || len(res.Existing) != 1 {
+		t.Fatalf("vendorMods() = %v, %v, want 1 existing", res, err)
+	}
+
+	// install offline into an empty workspace
+	offlineLock := &versionmap.WorkspaceLock{
+		InstallCache:    versionmap.InstalledDependencyVersionsMap{},
+		MissingVersions: versionmap.InstalledDependencyVersionsMap{"mod.root": {locked.Name: locked}},
+	}
+	hosts := newGitHosts(nil, nil)
+	hosts.mirror = mirror
+	i := &ModInstaller{
+		modsPath:    t.TempDir(),
+		mirror:      mirror,
+		gitHosts:    hosts,
+		installData: &InstallData{Lock: offlineLock, gitHosts: hosts, mirror: mirror, allAvailable: make(versionmap.ResolvedVersionConstraintListMap)},
+	}
+	if err := i.validateMirror(); err != nil {
+		t.Fatalf("validateMirror() error = %v", err)
+	}
+	versions, err := i.installData.getAvailableModVersions(ctx, locked.Name, false)
+	if err != nil {
+		t.Fatalf("getAvailableModVersions() error = %v", err)
+	}
+	if len(versions) != 1 || versions[0].DependencyPath() != locked.DependencyPath() {
+		t.Fatalf("getAvailableModVersions() = %v, want %s", versions, locked.DependencyPath())
+	}
+
+	installPath := filepath.Join(t.TempDir(), locked.DependencyPath())
+	if err := i.fetchMod(ctx, versions[0], installPath); err != nil {
+		t.Fatalf("fetchMod() error = %v", err)
+	}
+	contentHash, err := versionmap.HashModDirectory(installPath)
+	if err != nil || contentHash != locked.ContentHash {
+		t.Errorf("installed content hash = %s, %v, want %s", contentHash, err, locked.ContentHash)
+	}
+}
+
+func TestInstallOffline_MirrorRepo(t *testing.T) {
+	defer func(original []string) { app_specific.ModDataExtensions = original }(app_specific.ModDataExtensions)
+	app_specific.ModDataExtensions = []string{".pp"}
+
+	ctx := context.Background()
+	mirror := newModMirror(t.TempDir())
+	// the mod is mirrored as a bare repository
+	_, err := git.PlainClone(filepath.Join(mirror.path, "git.example.com", "mod1.git"), true, &git.CloneOptions{URL: newLocalModRepo(t, "v1.0.0", "v1.1.0")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	hosts := newGitHosts(nil, nil)
+	hosts.mirror = mirror
+	i := &ModInstaller{gitHosts: hosts, mirror: mirror}
+	modName := "git.example.com/mod1"
+
+	versions, err := mirror.getVersions(ctx, hosts, &versionmap.WorkspaceLock{}, modName, false)
+	if err != nil {
+		t.Fatalf("getVersions() error = %v", err)
+	}
+	if len(versions) != 2 || versions[0].Version.String() != "1.1.0" {
+		t.Fatalf("getVersions() = %v, want 1.1.0, 1.0.0", versions)
+	}
+
+	installPath := filepath.Join(t.TempDir(), versions[1].DependencyPath())
+	if err := i.fetchMod(ctx, versions[1], installPath); err != nil {
+		t.Fatalf("fetchMod() error = %v", err)
+	}
+	content, err := os.ReadFile(filepath.Join(installPath, "mod.pp"))
+	if err != nil || !strings.Contains(string(content), "v1.0.0") {
+		t.Errorf("fetchMod() installed %q, %v - want v1.0.0", content, err)
+	}
+
+	// mods which are not mirrored are never retrieved from their host
+	if _, err := hosts.remotes(ctx, "github.com/turbot/mod2"); err == nil {
+		t.Errorf("remotes() for a mod which is not mirrored expected error")
+	}
+}
+
+func TestValidateMirror(t *testing.T) {
+	modsPath := t.TempDir()
+	installed, _ := installedTestMod(t, modsPath, "github.com/turbot/installed", "1.0.0")
+	archivedModsPath := t.TempDir()
+	archived, _ := installedTestMod(t, archivedModsPath, "github.com/turbot/archived", "1.0.0")
+	missing, _ := installedTestMod(t, t.TempDir(), "github.com/turbot/missing", "1.0.0")
+	missingDependency, _ := installedTestMod(t, t.TempDir(), "github.com/turbot/missing-dependency", "2.0.0")
+	local := &versionmap.InstalledModVersion{
+		ResolvedVersionConstraint: &versionmap.ResolvedVersionConstraint{
+			DependencyVersion: modconfig.DependencyVersion{FilePath: "../local"},
+			Name:              "local",
+		},
+	}
+
+	mirror := newModMirror(t.TempDir())
+	vendorer := &ModInstaller{modsPath: archivedModsPath, installData: &InstallData{Lock: &versionmap.WorkspaceLock{
+		InstallCache: versionmap.InstalledDependencyVersionsMap{"mod.root": {archived.Name: archived}},
+	}}}
+	if _, err := vendorer.vendorMods(context.Background(), mirror); err != nil {
+		t.Fatal(err)
+	}
+
+	i := &ModInstaller{
+		modsPath: modsPath,
+		mirror:   mirror,
+		installData: &InstallData{Lock: &versionmap.WorkspaceLock{
+			InstallCache: versionmap.InstalledDependencyVersionsMap{
+				"mod.root": {installed.Name: installed, local.Name: local},
+			},
+			MissingVersions: versionmap.InstalledDependencyVersionsMap{
+				"mod.root":                         {archived.Name: archived, missing.Name: missing},
+				"github.com/turbot/missing@v1.0.0": {missingDependency.Name: missingDependency},
+			},
+		}},
+	}
+	err := i.validateMirror()
+	var missingErr *MissingDependenciesError
+	if !errors.As(err, &missingErr) {
+		t.Fatalf("validateMirror() error = %v, want MissingDependenciesError", err)
+	}
+	if got := strings.Join(missingErr.Missing, ","); got != "github.com/turbot/missing-dependency@v2.0.0,github.com/turbot/missing@v1.0.0" {
+		t.Errorf("Missing = %s", got)
+	}
+}
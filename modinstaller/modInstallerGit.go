@@ -13,18 +13,14 @@ func (i *ModInstaller) openRepo(modPath string) (*git.Repository, error) {
 	return repo, nil
 }
 
-func (i *ModInstaller) cloneRepo(gitUrl string, gitRefName plumbing.ReferenceName, installPath string) (*git.Repository, error) {
-	gitHubToken := getGitToken()
+func (i *ModInstaller) cloneRepo(remote *gitRemote, gitRefName plumbing.ReferenceName, installPath string) (*git.Repository, error) {
 	cloneOptions := git.CloneOptions{
-		URL:           gitUrl,
+		URL:           remote.url,
+		Auth:          remote.auth,
 		ReferenceName: gitRefName,
 		Depth:         1,
 		SingleBranch:  true,
 	}
-	if gitHubToken != "" {
-		// if we have a token, use it
-		cloneOptions.Auth = getGitAuthForToken(gitHubToken)
-	}
 
 	repo, err := git.PlainClone(installPath,
 		false, &cloneOptions)
@@ -10,8 +10,8 @@ import (
 
 	"github.com/Masterminds/semver/v3"
 	"github.com/go-git/go-git/v5"
-	"github.com/go-git/go-git/v5/config"
 	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/transport"
 	"github.com/otiai10/copy"
 	"github.com/spf13/viper"
 	filehelpers "github.com/turbot/go-kit/files"
@@ -56,8 +56,17 @@ type ModInstaller struct {
 	// TODO why does powerpipe care about plugins???
 	// optional map of installed plugin versions
 	pluginVersions *plugin.PluginVersionMap
+	// the urls and credentials used to retrieve mods from each git host
+	gitHosts *gitHosts
+	// the mod mirror mods are installed from when installing offline - nil if installing online
+	mirror *modMirror
 
 	updateStrategy string
+
+	// the version of each version constrained dependency, as resolved by resolveDependencies
+	resolvedVersions map[string]*versionmap.ResolvedVersionConstraint
+	// a temporary directory mods are cloned to while resolving dependencies
+	resolverCachePath string
 }
 
 func NewModInstaller(opts *InstallOpts) (*ModInstaller, error) {
@@ -78,6 +87,7 @@ func NewModInstaller(opts *InstallOpts) (*ModInstaller, error) {
 		// TODO why does powerpipe care about plugins???
 		pluginVersions: opts.PluginVersions,
 		updateStrategy: opts.UpdateStrategy,
+		gitHosts:       newGitHosts(opts.ModHosts, opts.Connections),
 	}
 
 	if opts.WorkspaceMod.Require != nil {
@@ -96,6 +106,18 @@ func NewModInstaller(opts *InstallOpts) (*ModInstaller, error) {
 
 	// create install data
 	i.installData = NewInstallData(workspaceLock, i.workspaceMod)
+	i.installData.gitHosts = i.gitHosts
+
+	// if installing offline, resolve and install mods from the mirror only
+	if opts.Offline {
+		mirrorPath := opts.MirrorPath
+		if mirrorPath == "" {
+			mirrorPath = filepaths.WorkspaceModMirrorPath(workspacePath)
+		}
+		i.mirror = newModMirror(mirrorPath)
+		i.gitHosts.mirror = i.mirror
+		i.installData.mirror = i.mirror
+	}
 
 	// parse args to get the required mod versions
 	requiredMods, err := i.GetRequiredModVersionsFromArgs(opts.ModArgs)
@@ -189,6 +211,13 @@ func (i *ModInstaller) InstallWorkspaceDependencies(ctx context.Context) (err er
 		workspaceMod.AddModDependencies(i.targetMods)
 	}
 
+	// if installing offline, verify everything in the lock file can be installed before starting
+	if i.mirror != nil {
+		if err := i.validateMirror(); err != nil {
+			return err
+		}
+	}
+
 	if err := i.installMods(ctx, workspaceMod); err != nil {
 		return err
 	}
@@ -310,8 +339,17 @@ func (i *ModInstaller) installMods(ctx context.Context, parent *modconfig.Mod) (
 		// force remove the shadow directory - we can ignore any error here, since
 		// these directories get cleaned up before any install session
 		_ = os.RemoveAll(i.shadowDirPath)
+		if i.resolverCachePath != "" {
+			_ = os.RemoveAll(i.resolverCachePath)
+			i.resolverCachePath = ""
+		}
 	}()
 
+	// resolve a consistent set of versions for all dependencies before installing any
+	if err := i.resolveDependencies(ctx); err != nil {
+		return i.buildInstallError([]error{err})
+	}
+
 	var errors []error
 
 	for _, requiredModVersion := range i.workspaceMod.Require.Mods {
@@ -375,6 +413,9 @@ func (i *ModInstaller) installModDependenciesRecursively(ctx context.Context, re
 			return nil
 		}
 	} else {
+		if err := i.verifyExisting(requiredModVersion, dependencyMod); err != nil {
+			return err
+		}
 		// this mod is already installed - just update the install data
 		i.installData.addExisting(dependencyMod, parent)
 		slog.Debug(fmt.Sprintf("not installing %s with version constraint %s as version %s is already Installed", requiredModVersion.Name, requiredModVersion.VersionString, dependencyMod.Mod.Version))
@@ -412,30 +453,34 @@ func (i *ModInstaller) install(ctx context.Context, requiredModVersion *modconfi
 
 	switch {
 	case requiredModVersion.VersionConstraint() != nil:
-		// get available versions for this mod
-		includePrerelease := requiredModVersion.IsPrerelease()
-		availableVersions, err := i.installData.getAvailableModVersions(requiredModVersion.Name, includePrerelease)
-		if err != nil {
-			return nil, err
-		}
-		// get a resolved mod ref that satisfies the version constraints
-		resolvedRef, err = i.getModRefSatisfyingVersionConstraint(requiredModVersion, availableVersions)
-		if err != nil {
-			return nil, err
+		// use the resolved version if there is one
+		resolvedRef = i.resolvedVersion(requiredModVersion)
+		if resolvedRef == nil {
+			// get available versions for this mod
+			includePrerelease := requiredModVersion.IsPrerelease()
+			availableVersions, err := i.installData.getAvailableModVersions(ctx, requiredModVersion.Name, includePrerelease)
+			if err != nil {
+				return nil, err
+			}
+			// get a resolved mod ref that satisfies the version constraints
+			resolvedRef, err = i.getModRefSatisfyingVersionConstraint(requiredModVersion, availableVersions)
+			if err != nil {
+				return nil, err
+			}
 		}
 		// install the mod
-		modDef, err = i.installFromTag(resolvedRef)
+		modDef, err = i.installFromTag(ctx, resolvedRef)
 		if err != nil {
 			return nil, err
 		}
 	case requiredModVersion.Tag != "":
 		// get a resolved mod ref that satisfies the version constraints
-		resolvedRef, err = i.getModRefForTag(requiredModVersion)
+		resolvedRef, err = i.getModRefForTag(ctx, requiredModVersion)
 		if err != nil {
 			return nil, err
 		}
 		// install the mod
-		modDef, err = i.installFromTag(resolvedRef)
+		modDef, err = i.installFromTag(ctx, resolvedRef)
 		if err != nil {
 			return nil, err
 		}
@@ -452,6 +497,19 @@ func (i *ModInstaller) install(ctx context.Context, requiredModVersion *modconfi
 		}
 	}
 
+	// verify the signature and content of mods installed to the mods directory
+	var contentHash string
+	if requiredModVersion.FilePath == "" {
+		installPath := i.getDependencyShadowPath(resolvedRef.DependencyPath())
+		if err := i.verifyModSignature(requiredModVersion, resolvedRef, installPath); err != nil {
+			return nil, err
+		}
+		contentHash, err = i.verifyInstalledContent(resolvedRef, installPath)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	if !i.dryRun {
 		// now the mod is installed in its final location, set mod dependency path
 		if err := i.setModDependencyConfig(modDef, resolvedRef.DependencyPath()); err != nil {
@@ -463,6 +521,7 @@ func (i *ModInstaller) install(ctx context.Context, requiredModVersion *modconfi
 	installedModVersion := &versionmap.InstalledModVersion{
 		ResolvedVersionConstraint: resolvedRef,
 		Alias:                     modDef.ShortName,
+		ContentHash:               contentHash,
 	}
 
 	return &DependencyMod{
@@ -473,7 +532,7 @@ func (i *ModInstaller) install(ctx context.Context, requiredModVersion *modconfi
 }
 
 // install a mod
-func (i *ModInstaller) installFromTag(dependency *versionmap.ResolvedVersionConstraint) (*modconfig.Mod, error) {
+func (i *ModInstaller) installFromTag(ctx context.Context, dependency *versionmap.ResolvedVersionConstraint) (*modconfig.Mod, error) {
 	// get the temp location to install the mod to
 	dependencyPath := dependency.DependencyPath()
 	destPath := i.getDependencyShadowPath(dependencyPath)
@@ -490,9 +549,17 @@ func (i *ModInstaller) installFromTag(dependency *versionmap.ResolvedVersionCons
 		}
 	}
 
-	slog.Debug("installing", "dependency", dependencyPath, "in", destPath)
-	if _, err := i.installFromGit(dependency.Name, plumbing.ReferenceName(dependency.GitRefStr), destPath); err != nil {
-		return nil, err
+	// if the mod was cloned while resolving dependencies, copy the clone
+	if cachePath := i.getResolverCachePath(dependencyPath); cachePath != "" && filehelpers.DirectoryExists(cachePath) {
+		slog.Debug("installing from resolver cache", "dependency", dependencyPath, "in", destPath)
+		if err := copy.Copy(cachePath, destPath); err != nil {
+			return nil, fmt.Errorf("could not copy mod '%s': %w", dependencyPath, err)
+		}
+	} else {
+		slog.Debug("installing", "dependency", dependencyPath, "in", destPath)
+		if err := i.fetchMod(ctx, dependency, destPath); err != nil {
+			return nil, err
+		}
 	}
 
 	// now load the installed mod and return it
@@ -506,7 +573,7 @@ func (i *ModInstaller) installFromTag(dependency *versionmap.ResolvedVersionCons
 	return modDef, nil
 }
 
-func (i *ModInstaller) installFromBranch(_ context.Context, modVersion *modconfig.ModVersionConstraint) (*versionmap.ResolvedVersionConstraint, *modconfig.Mod, error) {
+func (i *ModInstaller) installFromBranch(ctx context.Context, modVersion *modconfig.ModVersionConstraint) (*versionmap.ResolvedVersionConstraint, *modconfig.Mod, error) {
 	// build a DependencyVersion
 	var dependencyVersion = &modconfig.DependencyVersion{
 		Branch: modVersion.BranchName,
@@ -530,20 +597,33 @@ func (i *ModInstaller) installFromBranch(_ context.Context, modVersion *modconfi
 	}
 
 	slog.Debug("installing", "dependency", dependencyPath, "in", destPath)
-	// build a git ref for the branch
-	gitRef := plumbing.NewBranchReferenceName(dependencyVersion.Branch)
-	repo, err := i.installFromGit(modVersion.Name, gitRef, destPath)
-	if err != nil {
-		return nil, nil, err
-	}
-	// get the commit hash
-	ref, err := repo.Reference(gitRef, true)
-	if err != nil {
-		return nil, nil, err
-	}
+	var resolvedRef *versionmap.ResolvedVersionConstraint
+	if i.mirror != nil && i.mirror.hasArchive(dependencyPath) {
+		// the branch is mirrored as an archive - the commit is taken from the lock file
+		if _, err := i.mirror.install(dependencyPath, destPath); err != nil {
+			return nil, nil, err
+		}
+		resolvedRef = i.mirror.withLockedRefs(i.installData.Lock, &versionmap.ResolvedVersionConstraint{
+			DependencyVersion: *dependencyVersion,
+			Name:              modVersion.Name,
+			StructVersion:     versionmap.WorkspaceLockStructVersion,
+		})
+	} else {
+		// build a git ref for the branch
+		gitRef := plumbing.NewBranchReferenceName(dependencyVersion.Branch)
+		repo, err := i.installFromGit(ctx, modVersion.Name, gitRef, destPath)
+		if err != nil {
+			return nil, nil, err
+		}
+		// get the commit hash
+		ref, err := repo.Reference(gitRef, true)
+		if err != nil {
+			return nil, nil, err
+		}
 
-	// build a ResolvedVersionConstraint
-	resolvedRef := versionmap.NewResolvedVersionConstraint(dependencyVersion, modVersion.Name, ref)
+		// build a ResolvedVersionConstraint
+		resolvedRef = versionmap.NewResolvedVersionConstraint(dependencyVersion, modVersion.Name, ref)
+	}
 
 	// now load the installed mod and return it
 	modDef, err := parse.LoadModfile(destPath)
@@ -557,21 +637,54 @@ func (i *ModInstaller) installFromBranch(_ context.Context, modVersion *modconfi
 	return resolvedRef, modDef, nil
 }
 
-func (i *ModInstaller) installFromGit(repoName string, gitRefName plumbing.ReferenceName, installPath string) (*git.Repository, error) {
-	// get the mod from git = first try https
-	gitUrl := getGitUrl(repoName, GitUrlModeHTTPS)
-	slog.Debug("installFromGit cloning the repo", gitUrl, gitRefName.String())
+// fetchMod retrieves the given mod version to installPath - from the OCI registry for mods installed from an OCI
+// registry, otherwise from Git
+func (i *ModInstaller) fetchMod(ctx context.Context, dependency *versionmap.ResolvedVersionConstraint, installPath string) error {
+	if i.mirror != nil {
+		installed, err := i.mirror.install(dependency.DependencyPath(), installPath)
+		if err != nil {
+			return err
+		}
+		if installed {
+			return i.verifyModFile(dependency.Name, installPath)
+		}
+		// otherwise the mod must be installed from the mirrored repository
+		if modconfig.IsOciModName(dependency.Name) {
+			return fmt.Errorf("mod '%s' is not available in the mod mirror %s", dependency.DependencyPath(), i.mirror.path)
+		}
+	}
+	if !modconfig.IsOciModName(dependency.Name) {
+		_, err := i.installFromGit(ctx, dependency.Name, plumbing.ReferenceName(dependency.GitRefStr), installPath)
+		return err
+	}
 
-	repo, err := i.cloneRepo(gitUrl, gitRefName, installPath)
+	if err := installFromOci(ctx, dependency, installPath); err != nil {
+		return err
+	}
+	// verify the image contains a valid modfile
+	return i.verifyModFile(dependency.Name, installPath)
+}
 
+func (i *ModInstaller) installFromGit(ctx context.Context, repoName string, gitRefName plumbing.ReferenceName, installPath string) (*git.Repository, error) {
+	remotes, err := i.gitHosts.remotes(ctx, repoName)
 	if err != nil {
-		// if that failed, try ssh
-		gitUrl := getGitUrl(repoName, GitUrlModeSSH)
-		slog.Debug(">>> cloning", gitUrl, gitRefName.String())
-		repo, err = i.cloneRepo(gitUrl, gitRefName, installPath)
-		if err != nil {
-			return nil, err
+		return nil, err
+	}
+
+	// try each remote in turn - by default https, then ssh
+	var repo *git.Repository
+	for _, remote := range remotes {
+		slog.Debug("installFromGit cloning the repo", "url", remote.url, "ref", gitRefName.String())
+		repo, err = i.cloneRepo(remote, gitRefName, installPath)
+		if err == nil {
+			break
 		}
+		slog.Debug("clone failed", "url", remote.url, "error", err)
+		// remove anything left by the failed clone before trying the next remote
+		_ = os.RemoveAll(installPath)
+	}
+	if err != nil {
+		return nil, err
 	}
 
 	// verify the cloned repo contains a valid modfile
@@ -635,8 +748,13 @@ func (i *ModInstaller) getModForRequirement(ctx context.Context, requiredModVers
 		}
 	}
 
+	// if a different version was resolved, install that
+	if resolved := i.resolvedVersion(requiredModVersion); resolved != nil && !resolved.Version.Equal(installedVersion.Version) {
+		return nil, nil
+	}
+
 	// can we update this
-	shouldUpdate, err := i.shouldUpdateMod(installedVersion, requiredModVersion, commandTargettingParent)
+	shouldUpdate, err := i.shouldUpdateMod(ctx, installedVersion, requiredModVersion, commandTargettingParent)
 	if err != nil {
 		return nil, err
 	}
@@ -713,9 +831,28 @@ func (i *ModInstaller) loadDependencyModFromRoot(ctx context.Context, modInstall
 }
 
 // determine if we should update this mod, and if so whether there is an update available
-func (i *ModInstaller) shouldUpdateMod(installedVersion *versionmap.InstalledModVersion, requiredModVersion *modconfig.ModVersionConstraint, commandTargettingParent bool) (bool, error) {
+func (i *ModInstaller) shouldUpdateMod(ctx context.Context, installedVersion *versionmap.InstalledModVersion, requiredModVersion *modconfig.ModVersionConstraint, commandTargettingParent bool) (bool, error) {
 	// user non-method, injecting updateChecker interface to make unit testing easier
-	return shouldUpdateMod(installedVersion, requiredModVersion, commandTargettingParent, i)
+	return shouldUpdateMod(installedVersion, requiredModVersion, commandTargettingParent, &installerUpdateChecker{ctx: ctx, installer: i})
+}
+
+// installerUpdateChecker is the updateChecker of a ModInstaller - it passes the context of the install to the
+// update checks, which may query a remote
+type installerUpdateChecker struct {
+	ctx       context.Context
+	installer *ModInstaller
+}
+
+func (c *installerUpdateChecker) newerVersionAvailable(requiredVersion *modconfig.ModVersionConstraint, currentVersion *semver.Version) (bool, error) {
+	return c.installer.newerVersionAvailable(c.ctx, requiredVersion, currentVersion)
+}
+
+func (c *installerUpdateChecker) newCommitAvailable(version *versionmap.InstalledModVersion) (bool, error) {
+	return c.installer.newCommitAvailable(c.ctx, version)
+}
+
+func (c *installerUpdateChecker) getUpdateStrategy() string {
+	return c.installer.getUpdateStrategy()
 }
 
 func (i *ModInstaller) getUpdateStrategy() string {
@@ -723,10 +860,10 @@ func (i *ModInstaller) getUpdateStrategy() string {
 }
 
 // determine whether there is a newer mod version available which satisfies the dependency version constraint
-func (i *ModInstaller) newerVersionAvailable(requiredVersion *modconfig.ModVersionConstraint, currentVersion *semver.Version) (bool, error) {
+func (i *ModInstaller) newerVersionAvailable(ctx context.Context, requiredVersion *modconfig.ModVersionConstraint, currentVersion *semver.Version) (bool, error) {
 	// get available versions for this mod
 	includePrerelease := requiredVersion.IsPrerelease()
-	availableVersions, err := i.installData.getAvailableModVersions(requiredVersion.Name, includePrerelease)
+	availableVersions, err := i.installData.getAvailableModVersions(ctx, requiredVersion.Name, includePrerelease)
 	if err != nil {
 		return false, err
 	}
@@ -741,15 +878,27 @@ func (i *ModInstaller) newerVersionAvailable(requiredVersion *modconfig.ModVersi
 	return false, nil
 }
 
-func (i *ModInstaller) newCommitAvailable(version *versionmap.InstalledModVersion) (bool, error) {
+func (i *ModInstaller) newCommitAvailable(ctx context.Context, version *versionmap.InstalledModVersion) (bool, error) {
+	// the remote cannot be checked when installing offline
+	if i.mirror != nil {
+		return false, nil
+	}
+
 	var latestCommit string
 	var err error
 
 	switch {
+	case modconfig.IsOciModName(version.Name):
+		// for mods installed from an OCI registry, check whether the image tag now refers to a different image
+		latestDigest, err := getLatestDigestForTag(ctx, version)
+		if err != nil {
+			return false, err
+		}
+		return latestDigest != version.Digest, nil
 	case version.Branch != "":
-		latestCommit, err = i.getLatestCommitForBranch(version)
+		latestCommit, err = i.getLatestCommitForBranch(ctx, version)
 	case version.Version != nil, version.Tag != "":
-		latestCommit, err = i.getLatestCommitForTag(version)
+		latestCommit, err = i.getLatestCommitForTag(ctx, version)
 	case version.FilePath != "":
 		// TODO CHECK FILE VERSIONS? OR EXPECT TO NEVER GET HERE
 		return false, nil
@@ -781,14 +930,20 @@ func (i *ModInstaller) getModRefSatisfyingVersionConstraint(modVersion *modconfi
 }
 
 // get the most recent available mod version which satisfies the version constraint
-func (i *ModInstaller) getModRefForTag(modVersion *modconfig.ModVersionConstraint) (*versionmap.ResolvedVersionConstraint, error) {
+func (i *ModInstaller) getModRefForTag(ctx context.Context, modVersion *modconfig.ModVersionConstraint) (*versionmap.ResolvedVersionConstraint, error) {
 	// mod version MUST have a version constrait to be here
 	if modVersion.Tag == "" {
 		return nil, fmt.Errorf("getModRefForTag should not be called if mod version has no tag")
 	}
 
 	// find a version which satisfies the version constraint
-	dependencyVersion, err := getTagFromGit(modVersion.Name, modVersion.Tag)
+	var dependencyVersion *versionmap.ResolvedVersionConstraint
+	var err error
+	if i.mirror != nil {
+		dependencyVersion, err = i.mirror.getTag(ctx, i.gitHosts, i.installData.Lock, modVersion.Name, modVersion.Tag)
+	} else {
+		dependencyVersion, err = getTagFromGit(ctx, i.gitHosts, modVersion.Name, modVersion.Tag)
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -799,78 +954,60 @@ func (i *ModInstaller) getModRefForTag(modVersion *modconfig.ModVersionConstrain
 	return dependencyVersion, nil
 }
 
-func (i *ModInstaller) getLatestCommitForBranch(installedVersion *versionmap.InstalledModVersion) (string, error) {
+func (i *ModInstaller) getLatestCommitForBranch(ctx context.Context, installedVersion *versionmap.InstalledModVersion) (string, error) {
 	branch := installedVersion.Branch
 	if branch == "" {
 		return "", fmt.Errorf("getLatestCommitForBranch called but Installed version has no branch")
 	}
-	// Open the local repository
-	modPath := path.Join(i.modsPath, installedVersion.DependencyPath())
-	repo, err := i.openRepo(modPath)
-	if err != nil {
-		return "", err
-	}
 
-	// Fetch the latest changes from the remote for the specific branch
-	fetchOptions := &git.FetchOptions{
-		RefSpecs: []config.RefSpec{config.RefSpec("+refs/heads/" + branch + ":refs/remotes/origin/" + branch)},
-		Force:    true,
-	}
-
-	if gitHubToken := getGitToken(); gitHubToken != "" {
-		// if we have a token, use it
-		fetchOptions.Auth = getGitAuthForToken(gitHubToken)
-	}
-	err = repo.Fetch(fetchOptions)
-	if err != nil && err != git.NoErrAlreadyUpToDate {
-		return "", fmt.Errorf("error fetching branch '%s' from remote: %w", branch, err)
-	}
-
-	// Get the latest commit on the fetched remote branch
-	ref, err := repo.Reference(plumbing.ReferenceName("refs/remotes/origin/"+branch), true)
-	if err != nil {
-		return "", fmt.Errorf("error finding reference for branch '%s': %w", branch, err)
-	}
-
-	// Return the hash of the latest commit
-	return ref.Hash().String(), nil
+	return i.getRemoteRefHash(ctx, installedVersion, plumbing.NewBranchReferenceName(branch))
 }
 
-func (i *ModInstaller) getLatestCommitForTag(installedVersion *versionmap.InstalledModVersion) (string, error) {
+func (i *ModInstaller) getLatestCommitForTag(ctx context.Context, installedVersion *versionmap.InstalledModVersion) (string, error) {
 	// a version or tag must be set to call this function
 	if installedVersion.Version == nil && installedVersion.Tag == "" {
 		return "", fmt.Errorf("getLatestCommitForTag called but Installed version has no version or tag")
 	}
 
-	// Open the local repository
+	return i.getRemoteRefHash(ctx, installedVersion, plumbing.ReferenceName(installedVersion.GitRefStr))
+}
+
+// getRemoteRefHash returns the hash of the given reference in the origin remote of an installed mod. The remote
+// references are listed, as with 'git ls-remote', so nothing is fetched into the installed repository
+func (i *ModInstaller) getRemoteRefHash(ctx context.Context, installedVersion *versionmap.InstalledModVersion, refName plumbing.ReferenceName) (string, error) {
 	modPath := path.Join(i.modsPath, installedVersion.DependencyPath())
 	repo, err := i.openRepo(modPath)
 	if err != nil {
 		return "", err
 	}
-
-	// Fetch only tag information from the remote
-	// Fetch the latest changes from the remote for the specific branch
-	fetchOptions := &git.FetchOptions{
-		RefSpecs: []config.RefSpec{"+refs/tags/*:refs/tags/*"},
+	remote, err := repo.Remote(git.DefaultRemoteName)
+	if err != nil {
+		return "", err
+	}
+	auth, err := i.getRemoteAuth(repo, installedVersion.Name)
+	if err != nil {
+		return "", err
 	}
 
-	if gitHubToken := getGitToken(); gitHubToken != "" {
-		// if we have a token, use it
-		fetchOptions.Auth = getGitAuthForToken(gitHubToken)
+	refs, err := remote.ListContext(ctx, &git.ListOptions{Auth: auth})
+	if err != nil {
+		return "", fmt.Errorf("error listing references of remote: %w", err)
 	}
-	err = repo.Fetch(fetchOptions)
-	if err != nil && err != git.NoErrAlreadyUpToDate {
-		return "", fmt.Errorf("error fetching tags from remote: %w", err)
+	for _, ref := range refs {
+		if ref.Name() == refName {
+			return ref.Hash().String(), nil
+		}
 	}
+	return "", fmt.Errorf("error finding remote reference '%s'", refName.Short())
+}
 
-	// Specify the tag you want to check
-	remoteRef, err := repo.Reference(plumbing.ReferenceName(installedVersion.GitRefStr), true)
+// getRemoteAuth returns the credentials to use to fetch from the origin remote of a cloned mod
+func (i *ModInstaller) getRemoteAuth(repo *git.Repository, modName string) (transport.AuthMethod, error) {
+	remote, err := repo.Remote(git.DefaultRemoteName)
 	if err != nil {
-		return "", fmt.Errorf("error finding remote tag: %w", err)
+		return nil, err
 	}
-
-	return remoteRef.Hash().String(), nil
+	return i.gitHosts.authForURL(context.Background(), modName, remote.Config().URLs[0])
 }
 
 // build the path of the temp location to copy this dependency to
@@ -0,0 +1,184 @@
+package modinstaller
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+
+	"github.com/Masterminds/semver/v3"
+	filehelpers "github.com/turbot/go-kit/files"
+	"github.com/turbot/pipe-fittings/modconfig"
+	"github.com/turbot/pipe-fittings/parse"
+	"github.com/turbot/pipe-fittings/versionmap"
+)
+
+// resolveDependencies resolves a single version of each version constrained dependency of the workspace mod,
+// so shared dependencies are not installed at more than one version
+func (i *ModInstaller) resolveDependencies(ctx context.Context) error {
+	if i.workspaceMod.Require == nil {
+		return nil
+	}
+	requirements := i.workspaceMod.Require.Mods
+	rootName := i.workspaceMod.GetInstallCacheKey()
+	preferred := i.preferredVersions()
+
+	// unless updating, first try to resolve using only the locked versions - if these satisfy the requirements no
+	// versions need be retrieved from Git
+	if !i.updating() {
+		resolved, err := ResolveDependencies(ctx, rootName, requirements, &lockDependencySource{installer: i}, preferred)
+		if err == nil {
+			i.resolvedVersions = resolved
+			return nil
+		}
+		slog.Debug("locked mod versions do not satisfy requirements - resolving using available versions", "error", err)
+	}
+
+	resolved, err := ResolveDependencies(ctx, rootName, requirements, &gitDependencySource{installer: i}, preferred)
+	if err != nil {
+		return err
+	}
+	i.resolvedVersions = resolved
+	return nil
+}
+
+// preferredVersions returns the locked version of each mod, which the resolver selects where possible - when
+// updating, mods targeted by the update are not included
+func (i *ModInstaller) preferredVersions() map[string]*semver.Version {
+	res := make(map[string]*semver.Version)
+	for _, deps := range i.installData.Lock.InstallCache {
+		for name, dep := range deps {
+			if dep.Version == nil {
+				continue
+			}
+			if i.updating() && (len(i.targetMods) == 0 || i.targetMods[name] != nil) {
+				continue
+			}
+			// if more than one version is locked, prefer the newest
+			if existing, ok := res[name]; !ok || dep.Version.GreaterThan(existing) {
+				res[name] = dep.Version
+			}
+		}
+	}
+	return res
+}
+
+// resolvedVersion returns the resolved version for the requirement, if it has a version constraint which was resolved
+func (i *ModInstaller) resolvedVersion(requiredModVersion *modconfig.ModVersionConstraint) *versionmap.ResolvedVersionConstraint {
+	resolved := i.resolvedVersions[requiredModVersion.Name]
+	if resolved == nil || requiredModVersion.VersionConstraint() == nil || !requiredModVersion.VersionConstraint().Check(resolved.Version) {
+		return nil
+	}
+	return resolved
+}
+
+// loadInstalledMod loads the installed mod for the given version, returning nil if it is not installed
+func (i *ModInstaller) loadInstalledMod(ctx context.Context, version *versionmap.ResolvedVersionConstraint) (*modconfig.Mod, error) {
+	if !filehelpers.DirectoryExists(i.getDependencyDestPath(version.DependencyPath())) {
+		return nil, nil
+	}
+	return i.loadDependencyModFromRoot(ctx, i.modsPath, version.DependencyPath())
+}
+
+// fetchForResolution clones (or pulls) the given mod version to the resolver cache, so its requirements can be read - if the
+// version is then installed, the clone is copied rather than cloning again
+func (i *ModInstaller) fetchForResolution(ctx context.Context, version *versionmap.ResolvedVersionConstraint) (*modconfig.Mod, error) {
+	if i.resolverCachePath == "" {
+		cachePath, err := os.MkdirTemp("", "mod_resolve")
+		if err != nil {
+			return nil, err
+		}
+		i.resolverCachePath = cachePath
+	}
+
+	destPath := i.getResolverCachePath(version.DependencyPath())
+	if !filehelpers.DirectoryExists(destPath) {
+		slog.Debug("fetching mod to resolve its requirements", "dependency", version.DependencyPath(), "in", destPath)
+		if err := i.fetchMod(ctx, version, destPath); err != nil {
+			_ = os.RemoveAll(destPath)
+			return nil, err
+		}
+	}
+
+	modDef, err := parse.LoadModfile(destPath)
+	if err != nil {
+		return nil, err
+	}
+	if modDef == nil {
+		return nil, fmt.Errorf("'%s' has no mod definition file", version.Name)
+	}
+	return modDef, nil
+}
+
+// getResolverCachePath returns the path the given dependency is cloned to by fetchForResolution, or "" if there is
+// no resolver cache
+func (i *ModInstaller) getResolverCachePath(dependencyFullName string) string {
+	if i.resolverCachePath == "" {
+		return ""
+	}
+	return filepath.Join(i.resolverCachePath, dependencyFullName)
+}
+
+func modRequirements(mod *modconfig.Mod) []*modconfig.ModVersionConstraint {
+	if mod.Require == nil {
+		return nil
+	}
+	return mod.Require.Mods
+}
+
+// lockDependencySource is a DependencySource which only provides the versions in the lock file, reading their
+// requirements from the installed mods
+type lockDependencySource struct {
+	installer *ModInstaller
+}
+
+func (s *lockDependencySource) AvailableVersions(_ context.Context, modName string) (versionmap.ResolvedVersionConstraintList, error) {
+	var res versionmap.ResolvedVersionConstraintList
+	seen := make(map[string]struct{})
+	for _, installed := range s.installer.installData.Lock.FindMod(modName) {
+		if _, ok := seen[installed.DependencyPath()]; ok {
+			continue
+		}
+		seen[installed.DependencyPath()] = struct{}{}
+		res = append(res, installed.ResolvedVersionConstraint)
+	}
+	return res, nil
+}
+
+func (s *lockDependencySource) Requirements(ctx context.Context, version *versionmap.ResolvedVersionConstraint) ([]*modconfig.ModVersionConstraint, error) {
+	modDef, err := s.installer.loadInstalledMod(ctx, version)
+	if err != nil {
+		return nil, err
+	}
+	if modDef == nil {
+		return nil, fmt.Errorf("locked mod '%s' is not installed", version.DependencyPath())
+	}
+	return modRequirements(modDef), nil
+}
+
+// gitDependencySource is a DependencySource which provides the versions tagged in Git (or, for mods installed from
+// an OCI registry, the image tags), reading their requirements from the installed mods where possible, otherwise
+// fetching them
+type gitDependencySource struct {
+	installer *ModInstaller
+}
+
+func (s *gitDependencySource) AvailableVersions(ctx context.Context, modName string) (versionmap.ResolvedVersionConstraintList, error) {
+	// include prerelease versions - they only satisfy constraints which explicitly allow them
+	return s.installer.installData.getAvailableModVersions(ctx, modName, true)
+}
+
+func (s *gitDependencySource) Requirements(ctx context.Context, version *versionmap.ResolvedVersionConstraint) ([]*modconfig.ModVersionConstraint, error) {
+	modDef, err := s.installer.loadInstalledMod(ctx, version)
+	if err != nil {
+		return nil, err
+	}
+	if modDef == nil {
+		modDef, err = s.installer.fetchForResolution(ctx, version)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return modRequirements(modDef), nil
+}
@@ -0,0 +1,100 @@
+package modinstaller
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sort"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/turbot/pipe-fittings/modconfig"
+	"github.com/turbot/pipe-fittings/ociinstaller"
+	"github.com/turbot/pipe-fittings/perr"
+	"github.com/turbot/pipe-fittings/versionmap"
+)
+
+// newOciModRepository returns the registry repository for a mod installed from an OCI registry
+// (overridden by tests to use a local store)
+var newOciModRepository = func(modName string) (ociinstaller.ModRepository, error) {
+	return ociinstaller.NewModRepository(modconfig.OciRepositoryRef(modName))
+}
+
+// getTagVersionsFromOci returns the versions of a mod installed from an OCI registry - these are the image tags
+// which parse as a semver version, sorted newest first
+func getTagVersionsFromOci(ctx context.Context, modName string, includePrerelease bool) (versionmap.ResolvedVersionConstraintList, error) {
+	repo, err := newOciModRepository(modName)
+	if err != nil {
+		return nil, perr.BadRequestWithMessage("could not connect to OCI registry for " + modName + " - " + err.Error())
+	}
+	tags, err := ociinstaller.ListModTags(ctx, repo)
+	if err != nil {
+		return nil, perr.BadRequestWithMessage("could not retrieve version data from OCI registry for " + modName + " - " + err.Error())
+	}
+
+	slog.Debug("retrieved tags from OCI registry", "repository", modName, "tags", len(tags))
+
+	var versions versionmap.ResolvedVersionConstraintList
+	for _, tag := range tags {
+		v, err := semver.NewVersion(tag)
+		if err != nil {
+			continue
+		}
+
+		if !includePrerelease && (v.Metadata() != "" || v.Prerelease() != "") {
+			continue
+		}
+		versions = append(versions, &versionmap.ResolvedVersionConstraint{
+			DependencyVersion: modconfig.DependencyVersion{
+				Version: v,
+			},
+			Name:          modName,
+			OciTag:        tag,
+			StructVersion: versionmap.WorkspaceLockStructVersion,
+		})
+	}
+
+	// sort the versions in REVERSE order
+	sort.Sort(sort.Reverse(versions))
+	return versions, nil
+}
+
+// installFromOci installs the given version of a mod from its OCI registry to installPath, pulling the locked image
+// digest if there is one, and recording the digest of the installed image
+func installFromOci(ctx context.Context, dependency *versionmap.ResolvedVersionConstraint, installPath string) error {
+	repo, err := newOciModRepository(dependency.Name)
+	if err != nil {
+		return err
+	}
+
+	reference := dependency.Digest
+	if reference == "" {
+		reference = dependency.OciTag
+	}
+	if reference == "" {
+		return fmt.Errorf("no image tag or digest set for mod %s", dependency.DependencyPath())
+	}
+
+	slog.Debug("installFromOci pulling the image", "mod", dependency.Name, "reference", reference)
+	image, err := ociinstaller.InstallMod(ctx, repo, reference, installPath)
+	if err != nil {
+		return fmt.Errorf("failed to install mod %s from OCI registry: %w", dependency.DependencyPath(), err)
+	}
+	dependency.Digest = image.OCIDescriptor.Digest.String()
+	return nil
+}
+
+// getLatestDigestForTag returns the digest of the image currently tagged with the image tag of the installed mod
+func getLatestDigestForTag(ctx context.Context, installedVersion *versionmap.InstalledModVersion) (string, error) {
+	if installedVersion.OciTag == "" {
+		return "", fmt.Errorf("getLatestDigestForTag called but Installed version has no image tag")
+	}
+	repo, err := newOciModRepository(installedVersion.Name)
+	if err != nil {
+		return "", err
+	}
+	desc, err := repo.Resolve(ctx, installedVersion.OciTag)
+	if err != nil {
+		return "", fmt.Errorf("error resolving image tag '%s': %w", installedVersion.OciTag, err)
+	}
+	return desc.Digest.String(), nil
+}
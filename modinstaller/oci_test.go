@@ -0,0 +1,126 @@
+package modinstaller
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/turbot/pipe-fittings/modconfig"
+	"github.com/turbot/pipe-fittings/ociinstaller"
+	"github.com/turbot/pipe-fittings/parse"
+	"oras.land/oras-go/v2/content/oci"
+)
+
+func TestOciModVersions(t *testing.T) {
+	ctx := context.Background()
+	// a local OCI layout stands in for the registry
+	repo, err := oci.New(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func(original func(string) (ociinstaller.ModRepository, error)) { newOciModRepository = original }(newOciModRepository)
+	newOciModRepository = func(string) (ociinstaller.ModRepository, error) { return repo, nil }
+
+	modDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(modDir, "mod.pp"), []byte(`mod "m1" {}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	for _, tag := range []string{"1.0.0", "v1.1.0", "1.2.0-rc.1", "latest"} {
+		if _, err := ociinstaller.PushMod(ctx, repo, modDir, tag, &ociinstaller.ModImageConfig{}); err != nil {
+			t.Fatalf("PushMod() error = %v", err)
+		}
+	}
+
+	modName := "oci://ghcr.io/turbot/m1"
+	versions, err := getTagVersionsFromOci(ctx, modName, false)
+	if err != nil {
+		t.Fatalf("getTagVersionsFromOci() error = %v", err)
+	}
+	if len(versions) != 2 || versions[0].OciTag != "v1.1.0" || versions[1].OciTag != "1.0.0" {
+		t.Fatalf("getTagVersionsFromOci() = %v, want v1.1.0, 1.0.0", versions)
+	}
+	if got := versions[0].DependencyPath(); got != "oci/ghcr.io/turbot/m1@v1.1.0" {
+		t.Errorf("DependencyPath() = %s", got)
+	}
+
+	withPrerelease, err := getTagVersionsFromOci(ctx, modName, true)
+	if err != nil {
+		t.Fatalf("getTagVersionsFromOci() error = %v", err)
+	}
+	if len(withPrerelease) != 3 || withPrerelease[0].OciTag != "1.2.0-rc.1" {
+		t.Errorf("getTagVersionsFromOci() with prerelease = %v", withPrerelease)
+	}
+
+	// installing records the image digest, so the locked image is installed in future
+	installPath := filepath.Join(t.TempDir(), versions[0].DependencyPath())
+	if err := installFromOci(ctx, versions[0], installPath); err != nil {
+		t.Fatalf("installFromOci() error = %v", err)
+	}
+	if versions[0].Digest == "" {
+		t.Errorf("installFromOci() did not record the image digest")
+	}
+	if _, err := os.Stat(filepath.Join(installPath, "mod.pp")); err != nil {
+		t.Errorf("installFromOci() did not install the mod: %v", err)
+	}
+}
+
+func TestUpdateModFile_OciTag(t *testing.T) {
+	modFile := `mod "local" {
+  require {
+    mod "oci://ghcr.io/turbot/m1:1.0.0" {}
+  }
+}
+`
+	tests := []struct {
+		name string
+		want string
+	}{
+		{
+			name: "version",
+			want: "oci://ghcr.io/turbot/m1:1.1.0",
+		},
+		{
+			name: "constraint",
+			want: "oci://ghcr.io/turbot/m1@^2.0",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			modFilePath := filepath.Join(t.TempDir(), "mod.pp")
+			if err := os.WriteFile(modFilePath, []byte(modFile), 0644); err != nil {
+				t.Fatal(err)
+			}
+			oldRequire, _, diags := parse.ParseModRequireAndShortName(modFilePath)
+			if diags.HasErrors() {
+				t.Fatalf("ParseModRequireAndShortName() error = %v", diags)
+			}
+			want, err := modconfig.NewModVersionConstraint(tt.want)
+			if err != nil {
+				t.Fatal(err)
+			}
+			workspaceMod := modconfig.NewMod("local", filepath.Dir(modFilePath), hcl.Range{})
+			workspaceMod.SetFilePath(modFilePath)
+			workspaceMod.Require = oldRequire.Clone()
+			workspaceMod.Require.AddModDependencies(map[string]*modconfig.ModVersionConstraint{want.Name: want})
+
+			i := &ModInstaller{oldRequire: oldRequire, workspaceMod: workspaceMod}
+			if err := i.updateModFile(); err != nil {
+				t.Fatalf("updateModFile() error = %v", err)
+			}
+			contents, err := os.ReadFile(modFilePath)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			updated, _, diags := parse.ParseModRequireAndShortName(modFilePath)
+			if diags.HasErrors() {
+				t.Fatalf("updated mod file is invalid: %v\n%s", diags, contents)
+			}
+			if got := updated.Mods[0]; got.Name != want.Name || got.VersionString != want.VersionString {
+				t.Errorf("updated requirement = %s %s, want %s %s\n%s", got.Name, got.VersionString, want.Name, want.VersionString, contents)
+			}
+		})
+	}
+}
@@ -0,0 +1,286 @@
+package modinstaller
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/Masterminds/semver/v3"
+	filehelpers "github.com/turbot/go-kit/files"
+	"github.com/turbot/go-kit/helpers"
+	"github.com/turbot/pipe-fittings/modconfig"
+	"github.com/turbot/pipe-fittings/printers"
+	"github.com/turbot/pipe-fittings/sanitize"
+	"github.com/turbot/pipe-fittings/utils"
+	"github.com/turbot/pipe-fittings/versionmap"
+)
+
+// SemverImpact classifies the change between two versions of a mod
+type SemverImpact string
+
+const (
+	SemverImpactNone  SemverImpact = "none"
+	SemverImpactPatch SemverImpact = "patch"
+	SemverImpactMinor SemverImpact = "minor"
+	SemverImpactMajor SemverImpact = "major"
+)
+
+// getSemverImpact returns the impact of updating from fromVersion to toVersion - a change in prerelease or metadata
+// only is treated as a patch
+func getSemverImpact(fromVersion, toVersion *semver.Version) SemverImpact {
+	switch {
+	case !toVersion.GreaterThan(fromVersion):
+		return SemverImpactNone
+	case toVersion.Major() != fromVersion.Major():
+		return SemverImpactMajor
+	case toVersion.Minor() != fromVersion.Minor():
+		return SemverImpactMinor
+	default:
+		return SemverImpactPatch
+	}
+}
+
+// OutdatedDependency describes the available updates for a locked mod dependency
+type OutdatedDependency struct {
+	Name  string `json:"name"`
+	Alias string `json:"alias,omitempty"`
+	// the install cache key of the mod which requires this dependency
+	Parent string `json:"parent"`
+	// the version constraint, branch or tag required by the parent
+	Constraint string `json:"constraint,omitempty"`
+	// the locked version, branch or tag
+	Current string `json:"current"`
+	// the latest version which satisfies the constraint
+	Wanted string `json:"wanted,omitempty"`
+	// the latest version available, regardless of the constraint
+	Latest string `json:"latest,omitempty"`
+	// the impact of updating to the latest version
+	Impact SemverImpact `json:"impact"`
+	// whether an update is available which satisfies the constraint - for branches and non-semver tags, whether
+	// they now refer to a different commit
+	UpdateAvailable bool `json:"update_available"`
+	// the changelog sections of the versions after the current version, up to the latest version
+	Changelog string `json:"changelog,omitempty"`
+}
+
+// String implements sanitize.SanitizedStringer
+func (d *OutdatedDependency) String(sanitizer *sanitize.Sanitizer, _ sanitize.RenderOptions) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s %s", d.Name, d.Current)
+	switch {
+	case d.Latest != "" && d.Impact != SemverImpactNone:
+		fmt.Fprintf(&b, " -> %s (%s)", d.Latest, d.Impact)
+		if d.Wanted != "" && d.Wanted != d.Latest {
+			fmt.Fprintf(&b, ", %s satisfies %s", d.Wanted, d.Constraint)
+		}
+	case d.UpdateAvailable:
+		b.WriteString(" (update available)")
+	default:
+		b.WriteString(" (up to date)")
+	}
+	b.WriteString("\n")
+	if d.Changelog != "" {
+		fmt.Fprintf(&b, "\n%s\n\n", d.Changelog)
+	}
+	return sanitizer.SanitizeString(b.String())
+}
+
+// OutdatedReport lists the available updates for every dependency in the lock file
+type OutdatedReport struct {
+	Dependencies []*OutdatedDependency
+}
+
+var _ printers.PrintableResource[*OutdatedDependency] = (*OutdatedReport)(nil)
+
+// Outdated returns the dependencies which have a newer version available
+func (r *OutdatedReport) Outdated() []*OutdatedDependency {
+	var res []*OutdatedDependency
+	for _, d := range r.Dependencies {
+		if d.UpdateAvailable || d.Impact != SemverImpactNone {
+			res = append(res, d)
+		}
+	}
+	return res
+}
+
+// GetItems implements printers.PrintableResource
+func (r *OutdatedReport) GetItems() []*OutdatedDependency {
+	return r.Dependencies
+}
+
+// GetTable implements printers.PrintableResource
+func (r *OutdatedReport) GetTable() (*printers.Table, error) {
+	var rows []printers.TableRow
+	for _, d := range r.Dependencies {
+		rows = append(rows, printers.TableRow{Cells: []any{d.Name, d.Parent, d.Constraint, d.Current, d.Wanted, d.Latest, d.Impact}})
+	}
+	return printers.NewTable().WithData(rows, []string{"MOD", "PARENT", "CONSTRAINT", "CURRENT", "WANTED", "LATEST", "IMPACT"}), nil
+}
+
+// OutdatedWorkspaceDependencies reports the available updates for the workspace dependencies, without installing
+// anything or changing the lock file
+func OutdatedWorkspaceDependencies(ctx context.Context, opts *InstallOpts) (_ *OutdatedReport, err error) {
+	utils.LogTime("cmd.OutdatedWorkspaceDependencies")
+	defer func() {
+		utils.LogTime("cmd.OutdatedWorkspaceDependencies end")
+		if r := recover(); r != nil {
+			err = helpers.ToError(r)
+		}
+	}()
+
+	installer, err := NewModInstaller(opts)
+	if err != nil {
+		return nil, err
+	}
+	return installer.getOutdatedDependencies(ctx)
+}
+
+func (i *ModInstaller) getOutdatedDependencies(ctx context.Context) (*OutdatedReport, error) {
+	lock := i.installData.Lock
+	res := &OutdatedReport{}
+	// the changelogs retrieved so far, keyed by the dependency path of the version they were read from
+	changelogs := make(map[string]string)
+
+	// locked versions which are not installed have been moved to MissingVersions
+	for _, lockedVersions := range []versionmap.InstalledDependencyVersionsMap{lock.InstallCache, lock.MissingVersions} {
+		for parent, deps := range lockedVersions {
+			require := i.getParentRequire(ctx, parent)
+			for _, dep := range deps {
+				if ctx.Err() != nil {
+					return nil, ctx.Err()
+				}
+				// file path dependencies have no versions
+				if dep.FilePath != "" {
+					continue
+				}
+				var requiredVersion *modconfig.ModVersionConstraint
+				if require != nil {
+					requiredVersion = require.GetModDependency(dep.Name)
+				}
+				outdated, err := i.getOutdatedDependency(ctx, parent, dep, requiredVersion, changelogs)
+				if err != nil {
+					return nil, err
+				}
+				res.Dependencies = append(res.Dependencies, outdated)
+			}
+		}
+	}
+
+	sort.Slice(res.Dependencies, func(a, b int) bool {
+		if res.Dependencies[a].Parent != res.Dependencies[b].Parent {
+			return res.Dependencies[a].Parent < res.Dependencies[b].Parent
+		}
+		return res.Dependencies[a].Name < res.Dependencies[b].Name
+	})
+	return res, nil
+}
+
+// getParentRequire returns the require block of the mod with the given install cache key - or nil if the mod is not
+// installed, in which case the constraints of its dependencies are not known
+func (i *ModInstaller) getParentRequire(ctx context.Context, parent string) *modconfig.Require {
+	if i.workspaceMod != nil && parent == i.workspaceMod.GetInstallCacheKey() {
+		return i.workspaceMod.Require
+	}
+	parentMod, err := i.loadDependencyModFromRoot(ctx, i.modsPath, parent)
+	if err != nil {
+		slog.Debug("could not load mod to determine dependency constraints", "mod", parent, "error", err)
+		return nil
+	}
+	return parentMod.Require
+}
+
+func (i *ModInstaller) getOutdatedDependency(ctx context.Context, parent string, dep *versionmap.InstalledModVersion, requiredVersion *modconfig.ModVersionConstraint, changelogs map[string]string) (*OutdatedDependency, error) {
+	res := &OutdatedDependency{
+		Name:    dep.Name,
+		Alias:   dep.Alias,
+		Parent:  parent,
+		Current: strings.TrimSpace(dep.DependencyVersion.String()),
+		Impact:  SemverImpactNone,
+	}
+	if requiredVersion != nil {
+		res.Constraint = getConstraintString(requiredVersion)
+	}
+
+	// branches and non-semver tags have no versions - check whether they now refer to a different commit
+	if dep.Version == nil {
+		updateAvailable, err := i.newCommitAvailable(ctx, dep)
+		if err != nil {
+			return nil, err
+		}
+		res.UpdateAvailable = updateAvailable
+		return res, nil
+	}
+
+	includePrerelease := dep.Version.Prerelease() != "" || (requiredVersion != nil && requiredVersion.IsPrerelease())
+	availableVersions, err := i.installData.getAvailableModVersions(ctx, dep.Name, includePrerelease)
+	if err != nil {
+		return nil, err
+	}
+	if len(availableVersions) == 0 {
+		return res, nil
+	}
+
+	// available versions are sorted in reverse order
+	latest := availableVersions[0]
+	res.Latest = latest.Version.String()
+	res.Impact = getSemverImpact(dep.Version, latest.Version)
+	if requiredVersion != nil && requiredVersion.VersionConstraint() != nil {
+		if wanted := getVersionSatisfyingConstraint(requiredVersion.VersionConstraint(), availableVersions); wanted != nil {
+			res.Wanted = wanted.Version.String()
+			res.UpdateAvailable = wanted.Version.GreaterThan(dep.Version)
+		}
+	}
+
+	if res.Impact != SemverImpactNone {
+		changelog, ok := changelogs[latest.DependencyPath()]
+		if !ok {
+			changelog = i.getChangelog(ctx, latest)
+			changelogs[latest.DependencyPath()] = changelog
+		}
+		res.Changelog = getChangelogBetween(changelog, dep.Version, latest.Version)
+	}
+	return res, nil
+}
+
+// getConstraintString returns the version constraint, branch or tag of a requirement
+func getConstraintString(requiredVersion *modconfig.ModVersionConstraint) string {
+	switch {
+	case requiredVersion.BranchName != "":
+		return "#" + requiredVersion.BranchName
+	case requiredVersion.Tag != "":
+		return requiredVersion.Tag
+	default:
+		return requiredVersion.VersionString
+	}
+}
+
+// getChangelog returns the changelog of the given mod version - the installed mod is used if the version is installed,
+// otherwise it is downloaded to a temporary directory. An empty string is returned if the mod has no changelog.
+func (i *ModInstaller) getChangelog(ctx context.Context, version *versionmap.ResolvedVersionConstraint) string {
+	modPath := i.getDependencyDestPath(version.DependencyPath())
+	if !filehelpers.DirectoryExists(modPath) {
+		tmpDir, err := os.MkdirTemp("", "mod-changelog")
+		if err != nil {
+			slog.Debug("could not create temp directory to retrieve changelog", "error", err)
+			return ""
+		}
+		defer os.RemoveAll(tmpDir)
+
+		modPath = filepath.Join(tmpDir, version.DependencyPath())
+		if err := i.fetchMod(ctx, version, modPath); err != nil {
+			// the changelog is informational - do not fail the report
+			slog.Debug("could not retrieve mod to read changelog", "mod", version.DependencyPath(), "error", err)
+			return ""
+		}
+	}
+
+	changelog, err := os.ReadFile(filepath.Join(modPath, changelogFileName))
+	if err != nil {
+		return ""
+	}
+	return string(changelog)
+}
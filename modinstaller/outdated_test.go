@@ -0,0 +1,250 @@
+package modinstaller
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/hashicorp/hcl/v2"
+	"github.com/turbot/pipe-fittings/modconfig"
+	"github.com/turbot/pipe-fittings/printers"
+	"github.com/turbot/pipe-fittings/versionmap"
+)
+
+const testChangelog = `# Changelog
+
+## v2.0.0 [2024-03-01]
+
+_Breaking changes_
+
+- Removed the legacy benchmark.
+
+## v1.2.0 [2024-02-01]
+
+### Enhancements
+
+- Added the s3 controls.
+
+## v1.1.0 [2024-01-01]
+
+- Added the ec2 controls.
+`
+
+func TestGetChangelogBetween(t *testing.T) {
+	tests := []struct {
+		name string
+		from string
+		to   string
+		want string
+	}{
+		{
+			name: "single version",
+			from: "1.1.0",
+			to:   "1.2.0",
+			want: "## v1.2.0 [2024-02-01]\n\n### Enhancements\n\n- Added the s3 controls.",
+		},
+		{
+			name: "multiple versions",
+			from: "1.1.0",
+			to:   "2.0.0",
+			want: "## v2.0.0 [2024-03-01]\n\n_Breaking changes_\n\n- Removed the legacy benchmark.\n\n## v1.2.0 [2024-02-01]\n\n### Enhancements\n\n- Added the s3 controls.",
+		},
+		{
+			name: "no newer versions",
+			from: "2.0.0",
+			to:   "2.0.0",
+			want: "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := getChangelogBetween(testChangelog, semver.MustParse(tt.from), semver.MustParse(tt.to)); got != tt.want {
+				t.Errorf("getChangelogBetween() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetSemverImpact(t *testing.T) {
+	tests := []struct {
+		from string
+		to   string
+		want SemverImpact
+	}{
+		{from: "1.0.0", to: "1.0.0", want: SemverImpactNone},
+		{from: "1.1.0", to: "1.0.0", want: SemverImpactNone},
+		{from: "1.0.0", to: "1.0.1", want: SemverImpactPatch},
+		{from: "1.0.0-rc.1", to: "1.0.0", want: SemverImpactPatch},
+		{from: "1.0.3", to: "1.2.0", want: SemverImpactMinor},
+		{from: "1.9.0", to: "2.0.0", want: SemverImpactMajor},
+	}
+	for _, tt := range tests {
+		if got := getSemverImpact(semver.MustParse(tt.from), semver.MustParse(tt.to)); got != tt.want {
+			t.Errorf("getSemverImpact(%s, %s) = %s, want %s", tt.from, tt.to, got, tt.want)
+		}
+	}
+}
+
+func TestGetOutdatedDependencies(t *testing.T) {
+	modsPath := t.TempDir()
+	current, _ := installedTestMod(t, modsPath, "github.com/turbot/m1", "1.1.0")
+	upToDate, _ := installedTestMod(t, modsPath, "github.com/turbot/m2", "3.0.0")
+	// the latest version is installed for another mod, so its changelog is read from the mods directory
+	_, latestPath := installedTestMod(t, modsPath, "github.com/turbot/m1", "2.0.0")
+	if err := os.WriteFile(filepath.Join(latestPath, changelogFileName), []byte(testChangelog), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	require := modconfig.NewRequire()
+	requiredVersions := make(map[string]*modconfig.ModVersionConstraint)
+	for _, name := range []string{"github.com/turbot/m1@^1.0", "github.com/turbot/m2@*"} {
+		requiredVersion, err := modconfig.NewModVersionConstraint(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		requiredVersions[requiredVersion.Name] = requiredVersion
+	}
+	require.AddModDependencies(requiredVersions)
+
+	workspaceMod := modconfig.NewMod("root", t.TempDir(), hcl.Range{})
+	workspaceMod.Require = require
+
+	availableVersions := func(name string, versions ...string) versionmap.ResolvedVersionConstraintList {
+		var res versionmap.ResolvedVersionConstraintList
+		for _, v := range versions {
+			res = append(res, &versionmap.ResolvedVersionConstraint{Name: name, DependencyVersion: modconfig.DependencyVersion{Version: semver.MustParse(v)}})
+		}
+		return res
+	}
+	i := &ModInstaller{
+		modsPath:     modsPath,
+		workspaceMod: workspaceMod,
+		installData: &InstallData{
+			Lock: &versionmap.WorkspaceLock{
+				InstallCache: versionmap.InstalledDependencyVersionsMap{
+					workspaceMod.GetInstallCacheKey(): {current.Name: current, upToDate.Name: upToDate},
+				},
+			},
+			// the available versions have already been retrieved
+			allAvailable: versionmap.ResolvedVersionConstraintListMap{
+				current.Name:  availableVersions(current.Name, "2.0.0", "1.2.0", "1.1.0"),
+				upToDate.Name: availableVersions(upToDate.Name, "3.0.0"),
+			},
+		},
+	}
+
+	report, err := i.getOutdatedDependencies(context.Background())
+	if err != nil {
+		t.Fatalf("getOutdatedDependencies() error = %v", err)
+	}
+	if len(report.Dependencies) != 2 {
+		t.Fatalf("getOutdatedDependencies() returned %d dependencies, want 2", len(report.Dependencies))
+	}
+
+	m1 := report.Dependencies[0]
+	if m1.Current != "1.1.0" || m1.Wanted != "1.2.0" || m1.Latest != "2.0.0" || m1.Impact != SemverImpactMajor || !m1.UpdateAvailable || m1.Constraint != "^1.0" {
+		t.Errorf("m1 = %+v", m1)
+	}
+	if want := getChangelogBetween(testChangelog, semver.MustParse("1.1.0"), semver.MustParse("2.0.0")); m1.Changelog != want {
+		t.Errorf("m1 changelog = %q, want %q", m1.Changelog, want)
+	}
+
+	m2 := report.Dependencies[1]
+	if m2.Impact != SemverImpactNone || m2.UpdateAvailable || m2.Changelog != "" {
+		t.Errorf("m2 = %+v", m2)
+	}
+	if outdated := report.Outdated(); len(outdated) != 1 || outdated[0] != m1 {
+		t.Errorf("Outdated() = %v, want m1 only", outdated)
+	}
+
+	table, err := report.GetTable()
+	if err != nil || len(table.Rows) != 2 || len(table.Rows[0].Cells) != len(table.Columns) {
+		t.Errorf("GetTable() = %+v, %v", table, err)
+	}
+
+	// the report is rendered by the printers
+	tablePrinter, err := printers.NewTablePrinter[*OutdatedDependency]()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out bytes.Buffer
+	if err := tablePrinter.PrintResource(context.Background(), report, &out); err != nil {
+		t.Fatalf("table PrintResource() error = %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 3 || !strings.HasPrefix(lines[0], "MOD") || !strings.Contains(lines[1], "github.com/turbot/m1") || !strings.Contains(lines[1], "major") {
+		t.Errorf("table PrintResource() =\n%s", out.String())
+	}
+
+	jsonPrinter, err := printers.NewJsonPrinter[*OutdatedDependency]()
+	if err != nil {
+		t.Fatal(err)
+	}
+	out.Reset()
+	if err := jsonPrinter.PrintResource(context.Background(), report, &out); err != nil {
+		t.Fatalf("json PrintResource() error = %v", err)
+	}
+	var printed []OutdatedDependency
+	if err := json.Unmarshal(out.Bytes(), &printed); err != nil || len(printed) != 2 || printed[0].Latest != "2.0.0" {
+		t.Errorf("json PrintResource() = %s, %v", out.String(), err)
+	}
+}
+
+func TestNewCommitAvailable_Branch(t *testing.T) {
+	origin := newLocalModRepo(t, "v1.0.0")
+	originRepo, err := git.PlainOpen(origin)
+	if err != nil {
+		t.Fatal(err)
+	}
+	head, err := originRepo.Head()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	modsPath := t.TempDir()
+	installed := &versionmap.InstalledModVersion{ResolvedVersionConstraint: &versionmap.ResolvedVersionConstraint{
+		Name:              "github.com/turbot/mod1",
+		DependencyVersion: modconfig.DependencyVersion{Branch: head.Name().Short()},
+		Commit:            head.Hash().String(),
+	}}
+	installPath := filepath.Join(modsPath, installed.DependencyPath())
+	if _, err := git.PlainClone(installPath, false, &git.CloneOptions{URL: origin}); err != nil {
+		t.Fatal(err)
+	}
+
+	i := &ModInstaller{modsPath: modsPath}
+	if available, err := i.newCommitAvailable(context.Background(), installed); err != nil || available {
+		t.Errorf("newCommitAvailable() = %v, %v, want false", available, err)
+	}
+
+	// a new commit is pushed to the branch
+	wt, err := originRepo.Worktree()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := wt.Commit("update", &git.CommitOptions{AllowEmptyCommits: true, Author: &object.Signature{Name: "test", Email: "test@example.com", When: time.Now()}}); err != nil {
+		t.Fatal(err)
+	}
+	if available, err := i.newCommitAvailable(context.Background(), installed); err != nil || !available {
+		t.Errorf("newCommitAvailable() = %v, %v, want true", available, err)
+	}
+
+	// the installed repository is not changed
+	installedRepo, err := git.PlainOpen(installPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ref, err := installedRepo.Reference(plumbing.NewRemoteReferenceName("origin", head.Name().Short()), true)
+	if err != nil || ref.Hash() != head.Hash() {
+		t.Errorf("installed remote branch = %v, %v, want %s", ref, err, head.Hash())
+	}
+}
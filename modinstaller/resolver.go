@@ -0,0 +1,519 @@
+package modinstaller
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/turbot/pipe-fittings/modconfig"
+	"github.com/turbot/pipe-fittings/versionmap"
+)
+
+// DependencySource provides the available versions of mods, and the requirements of each version, to the
+// dependency resolver
+type DependencySource interface {
+	// AvailableVersions returns the available versions of the given mod
+	AvailableVersions(ctx context.Context, modName string) (versionmap.ResolvedVersionConstraintList, error)
+	// Requirements returns the mods required by the given version of a mod
+	Requirements(ctx context.Context, version *versionmap.ResolvedVersionConstraint) ([]*modconfig.ModVersionConstraint, error)
+}
+
+// ResolutionError is returned when there is no set of mod versions which satisfies all the version constraints
+type ResolutionError struct {
+	// Explanation is a human-readable derivation of the conflict
+	Explanation string
+}
+
+func (e *ResolutionError) Error() string {
+	return fmt.Sprintf("could not resolve mod dependencies:\n%s", e.Explanation)
+}
+
+// ResolveDependencies finds a single version of each mod required, directly or indirectly, by the given requirements
+// which satisfies all the version constraints on that mod. Where there is a choice, the preferred version of a mod
+// (e.g. the version in the lock file) is selected if possible, otherwise the newest version.
+// Only version constraints are resolved - branch, tag and file path requirements, and the requirements of those mods,
+// are ignored.
+//
+// Resolution uses the PubGrub algorithm (https://github.com/dart-lang/pub/blob/master/doc/solver.md) - if there is
+// no solution, a *ResolutionError is returned explaining the conflict.
+func ResolveDependencies(ctx context.Context, rootName string, requirements []*modconfig.ModVersionConstraint, source DependencySource, preferred map[string]*semver.Version) (map[string]*versionmap.ResolvedVersionConstraint, error) {
+	r := newDependencyResolver(rootName, requirements, source, preferred)
+	return r.solve(ctx)
+}
+
+// dependencyResolver holds the state of a single resolution
+type dependencyResolver struct {
+	source       DependencySource
+	root         *resolverPackage
+	requirements []*modconfig.ModVersionConstraint
+	preferred    map[string]*semver.Version
+
+	packages map[string]*resolverPackage
+	// the incompatibilities which refer to each package
+	incompatibilities map[string][]*incompatibility
+	solution          *partialSolution
+}
+
+func newDependencyResolver(rootName string, requirements []*modconfig.ModVersionConstraint, source DependencySource, preferred map[string]*semver.Version) *dependencyResolver {
+	root := &resolverPackage{
+		name:     rootName,
+		versions: versionmap.ResolvedVersionConstraintList{{Name: rootName}},
+		isRoot:   true,
+	}
+	return &dependencyResolver{
+		source:            source,
+		root:              root,
+		requirements:      requirements,
+		preferred:         preferred,
+		packages:          map[string]*resolverPackage{rootName: root},
+		incompatibilities: make(map[string][]*incompatibility),
+		solution:          newPartialSolution(),
+	}
+}
+
+func (r *dependencyResolver) solve(ctx context.Context) (map[string]*versionmap.ResolvedVersionConstraint, error) {
+	// the root package must be selected
+	r.addIncompatibility(newIncompatibility([]*term{{pkg: r.root, versions: r.root.all(), positive: false}}, causeRoot, nil, nil))
+
+	next := r.root.name
+	for next != "" {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		if err := r.propagate(next); err != nil {
+			return nil, err
+		}
+
+		var err error
+		next, err = r.chooseNextVersion(ctx)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	res := make(map[string]*versionmap.ResolvedVersionConstraint)
+	for name, idx := range r.solution.decisions {
+		if name == r.root.name {
+			continue
+		}
+		res[name] = r.packages[name].versions[idx]
+	}
+	return res, nil
+}
+
+// getPackage returns the package with the given name, retrieving its available versions if needed
+func (r *dependencyResolver) getPackage(ctx context.Context, name string) (*resolverPackage, error) {
+	if p, ok := r.packages[name]; ok {
+		return p, nil
+	}
+
+	available, err := r.source.AvailableVersions(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	// only consider semver versions, newest first
+	var versions versionmap.ResolvedVersionConstraintList
+	for _, v := range available {
+		if v.Version != nil {
+			versions = append(versions, v)
+		}
+	}
+	sort.Stable(sort.Reverse(versions))
+
+	p := &resolverPackage{
+		name:     name,
+		versions: versions,
+	}
+	r.packages[name] = p
+	return p, nil
+}
+
+func (r *dependencyResolver) addIncompatibility(i *incompatibility) {
+	for _, t := range i.terms {
+		r.incompatibilities[t.pkg.name] = append(r.incompatibilities[t.pkg.name], i)
+	}
+}
+
+// propagate derives the assignments implied by the incompatibilities, starting with those for the given package,
+// resolving any conflicts found
+func (r *dependencyResolver) propagate(name string) error {
+	changed := []string{name}
+	for len(changed) > 0 {
+		name := changed[len(changed)-1]
+		changed = changed[:len(changed)-1]
+
+		// process the most recent incompatibilities first, as they are the most likely to derive new assignments
+		incompatibilities := r.incompatibilities[name]
+		for i := len(incompatibilities) - 1; i >= 0; i-- {
+			derived, conflict := r.propagateIncompatibility(incompatibilities[i])
+			if conflict {
+				rootCause, err := r.resolveConflict(incompatibilities[i])
+				if err != nil {
+					return err
+				}
+				// after backtracking, all but one of the terms of the root cause are satisfied, so propagating it
+				// derives an assignment for the remaining term
+				derived, _ = r.propagateIncompatibility(rootCause)
+				changed = []string{derived}
+				break
+			}
+			if derived != "" {
+				changed = append(changed, derived)
+			}
+		}
+	}
+	return nil
+}
+
+// propagateIncompatibility derives an assignment if all but one of the terms of the incompatibility are satisfied,
+// returning the name of the package derived, or returns conflict=true if all the terms are satisfied
+func (r *dependencyResolver) propagateIncompatibility(i *incompatibility) (derived string, conflict bool) {
+	var unsatisfied *term
+	for _, t := range i.terms {
+		switch r.solution.relation(t) {
+		case relationDisjoint:
+			// the incompatibility cannot be satisfied, so nothing can be derived
+			return "", false
+		case relationOverlapping:
+			if unsatisfied != nil {
+				// more than one term is not satisfied, so nothing can be derived
+				return "", false
+			}
+			unsatisfied = t
+		}
+	}
+	if unsatisfied == nil {
+		return "", true
+	}
+
+	// to avoid satisfying the incompatibility, the unsatisfied term must be false
+	r.solution.derive(unsatisfied.inverse(), i)
+	return unsatisfied.pkg.name, false
+}
+
+// resolveConflict derives the root cause of a conflict, an incompatibility which is satisfied by the partial solution,
+// and backtracks until the root cause is no longer satisfied. If the root cause means there is no solution, a
+// *ResolutionError explaining it is returned
+func (r *dependencyResolver) resolveConflict(i *incompatibility) (*incompatibility, error) {
+	createdIncompatibility := false
+	for !i.isFailure() {
+		// find the term whose satisfier was assigned most recently
+		var mostRecentTerm *term
+		var mostRecentSatisfier *assignment
+		var difference *term
+		// the decision level to backtrack to - always keep the root decision
+		previousSatisfierLevel := 1
+
+		for _, t := range i.terms {
+			satisfier := r.solution.satisfier(t)
+			switch {
+			case mostRecentSatisfier == nil:
+				mostRecentTerm = t
+				mostRecentSatisfier = satisfier
+			case mostRecentSatisfier.index < satisfier.index:
+				previousSatisfierLevel = max(previousSatisfierLevel, mostRecentSatisfier.decisionLevel)
+				mostRecentTerm = t
+				mostRecentSatisfier = satisfier
+				difference = nil
+			default:
+				previousSatisfierLevel = max(previousSatisfierLevel, satisfier.decisionLevel)
+			}
+
+			if mostRecentTerm == t {
+				// if the satisfier only partially satisfies the term, the rest of the term was satisfied by an
+				// earlier assignment
+				difference = mostRecentSatisfier.term.difference(mostRecentTerm)
+				if difference != nil {
+					previousSatisfierLevel = max(previousSatisfierLevel, r.solution.satisfier(difference.inverse()).decisionLevel)
+				}
+			}
+		}
+
+		// if the most recent satisfier is the only satisfier at its decision level, or is a decision, backtracking
+		// to the previous decision level means the incompatibility is no longer satisfied
+		if previousSatisfierLevel < mostRecentSatisfier.decisionLevel || mostRecentSatisfier.cause == nil {
+			r.solution.backtrack(previousSatisfierLevel)
+			if createdIncompatibility {
+				r.addIncompatibility(i)
+			}
+			return i, nil
+		}
+
+		// otherwise derive a new incompatibility from this one and the cause of the most recent satisfier, which
+		// does not refer to the most recent satisfier's package (unless it was only partially satisfied)
+		var terms []*term
+		for _, t := range i.terms {
+			if t != mostRecentTerm {
+				terms = append(terms, t)
+			}
+		}
+		for _, t := range mostRecentSatisfier.cause.terms {
+			if t.pkg.name != mostRecentSatisfier.term.pkg.name {
+				terms = append(terms, t)
+			}
+		}
+		if difference != nil {
+			terms = append(terms, difference.inverse())
+		}
+		i = newIncompatibility(terms, causeConflict, i, mostRecentSatisfier.cause)
+		createdIncompatibility = true
+	}
+
+	return nil, &ResolutionError{Explanation: explainIncompatibility(i)}
+}
+
+// chooseNextVersion decides the version of a package which is required but has no version decided, returning the
+// name of the package, or "" if every required package has a version decided
+func (r *dependencyResolver) chooseNextVersion(ctx context.Context) (string, error) {
+	// choose the package with the fewest versions allowed, so conflicts are found as early as possible
+	var next *term
+	for _, t := range r.solution.undecided() {
+		if next == nil || countVersions(t) < countVersions(next) {
+			next = t
+		}
+	}
+	if next == nil {
+		return "", nil
+	}
+
+	idx := r.chooseVersion(next)
+	if idx < 0 {
+		// no versions are allowed - this will cause a conflict when propagated
+		r.addIncompatibility(newIncompatibility([]*term{next}, causeNoVersions, nil, nil))
+		return next.pkg.name, nil
+	}
+
+	dependencies, err := r.dependencyIncompatibilities(ctx, next.pkg, idx)
+	if err != nil {
+		return "", err
+	}
+
+	// add the dependencies of the version, but only decide the version if that does not immediately cause a conflict -
+	// if it does, propagation will derive that this version cannot be selected
+	conflict := false
+	for _, i := range dependencies {
+		r.addIncompatibility(i)
+
+		satisfied := true
+		for _, t := range i.terms {
+			if t.pkg.name != next.pkg.name && !r.solution.satisfies(t) {
+				satisfied = false
+				break
+			}
+		}
+		conflict = conflict || satisfied
+	}
+	if !conflict {
+		r.solution.decide(next.pkg, idx)
+	}
+	return next.pkg.name, nil
+}
+
+// chooseVersion returns the index of the version to try for the package, or -1 if no versions are allowed by the
+// term - the preferred version if it is allowed, otherwise the newest allowed version
+func (r *dependencyResolver) chooseVersion(t *term) int {
+	if preferred, ok := r.preferred[t.pkg.name]; ok {
+		for i, v := range t.pkg.versions {
+			if t.versions.Bit(i) == 1 && v.Version.Equal(preferred) {
+				return i
+			}
+		}
+	}
+	for i := range t.pkg.versions {
+		if t.versions.Bit(i) == 1 {
+			return i
+		}
+	}
+	return -1
+}
+
+// dependencyIncompatibilities returns an incompatibility for each version constrained requirement of the given
+// package version
+func (r *dependencyResolver) dependencyIncompatibilities(ctx context.Context, pkg *resolverPackage, idx int) ([]*incompatibility, error) {
+	requirements := r.requirements
+	if !pkg.isRoot {
+		var err error
+		requirements, err = r.source.Requirements(ctx, pkg.versions[idx])
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var res []*incompatibility
+	for _, requirement := range requirements {
+		constraint := requirement.VersionConstraint()
+		if constraint == nil || requirement.Name == pkg.name {
+			continue
+		}
+		dependency, err := r.getPackage(ctx, requirement.Name)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, newIncompatibility([]*term{
+			{pkg: pkg, versions: pkg.single(idx), positive: true},
+			{pkg: dependency, versions: dependency.matching(constraint), positive: false},
+		}, causeDependency, nil, nil))
+	}
+	return res, nil
+}
+
+func countVersions(t *term) int {
+	count := 0
+	for i := range t.pkg.versions {
+		count += int(t.versions.Bit(i))
+	}
+	return count
+}
+
+// assignment is a term added to the partial solution, either a decision (selecting a single version of a package)
+// or a derivation (a term which must be true given the earlier assignments)
+type assignment struct {
+	term          *term
+	decisionLevel int
+	index         int
+	// the incompatibility the term was derived from, nil for decisions
+	cause *incompatibility
+}
+
+// partialSolution is the ordered list of assignments made so far
+type partialSolution struct {
+	assignments []*assignment
+	// the index of the version decided for each package
+	decisions map[string]int
+	// the intersection of the assigned terms for each package
+	terms map[string]*term
+}
+
+func newPartialSolution() *partialSolution {
+	return &partialSolution{
+		decisions: make(map[string]int),
+		terms:     make(map[string]*term),
+	}
+}
+
+func (s *partialSolution) decide(pkg *resolverPackage, idx int) {
+	s.decisions[pkg.name] = idx
+	s.assign(&assignment{
+		term:          &term{pkg: pkg, versions: pkg.single(idx), positive: true},
+		decisionLevel: len(s.decisions),
+		index:         len(s.assignments),
+	})
+}
+
+func (s *partialSolution) derive(t *term, cause *incompatibility) {
+	s.assign(&assignment{
+		term:          t,
+		decisionLevel: len(s.decisions),
+		index:         len(s.assignments),
+		cause:         cause,
+	})
+}
+
+func (s *partialSolution) assign(a *assignment) {
+	s.assignments = append(s.assignments, a)
+	name := a.term.pkg.name
+	if existing, ok := s.terms[name]; ok {
+		s.terms[name] = existing.intersect(a.term)
+	} else {
+		s.terms[name] = a.term
+	}
+}
+
+// backtrack removes the assignments made after the given decision level
+func (s *partialSolution) backtrack(decisionLevel int) {
+	assignments := s.assignments
+	s.assignments = nil
+	s.decisions = make(map[string]int)
+	s.terms = make(map[string]*term)
+	for _, a := range assignments {
+		if a.decisionLevel > decisionLevel {
+			break
+		}
+		if a.cause == nil {
+			s.decisions[a.term.pkg.name] = firstVersion(a.term)
+		}
+		s.assign(a)
+	}
+}
+
+// relation returns the relation between the assignments for the term's package and the term
+func (s *partialSolution) relation(t *term) termRelation {
+	existing, ok := s.terms[t.pkg.name]
+	if !ok {
+		switch {
+		case t.isEmpty():
+			// the term can never be true
+			return relationDisjoint
+		case isTautology(t):
+			return relationSubset
+		}
+		return relationOverlapping
+	}
+	return existing.relation(t)
+}
+
+func (s *partialSolution) satisfies(t *term) bool {
+	return s.relation(t) == relationSubset
+}
+
+// satisfier returns the earliest assignment after which the term is satisfied
+func (s *partialSolution) satisfier(t *term) *assignment {
+	// a term which is always true is satisfied before any assignment
+	if isTautology(t) {
+		return &assignment{term: t, decisionLevel: 0, index: -1}
+	}
+
+	var assigned *term
+	for _, a := range s.assignments {
+		if a.term.pkg.name != t.pkg.name {
+			continue
+		}
+		if assigned == nil {
+			assigned = a.term
+		} else {
+			assigned = assigned.intersect(a.term)
+		}
+		if assigned.satisfies(t) {
+			return a
+		}
+	}
+	// the term must be satisfied by the partial solution for this to be called
+	panic(fmt.Sprintf("no satisfier for %s", t))
+}
+
+// undecided returns the positive terms for packages which are required but have no version decided, in the order
+// the packages were first required
+func (s *partialSolution) undecided() []*term {
+	var res []*term
+	seen := make(map[string]struct{})
+	for _, a := range s.assignments {
+		name := a.term.pkg.name
+		if _, ok := seen[name]; ok {
+			continue
+		}
+		seen[name] = struct{}{}
+		if _, decided := s.decisions[name]; decided {
+			continue
+		}
+		if t := s.terms[name]; t.positive {
+			res = append(res, t)
+		}
+	}
+	return res
+}
+
+// isTautology returns whether the term is always true - i.e. it is negative and excludes no versions, as happens
+// when a requirement matches no versions
+func isTautology(t *term) bool {
+	return !t.positive && t.versions.Sign() == 0
+}
+
+func firstVersion(t *term) int {
+	for i := range t.pkg.versions {
+		if t.versions.Bit(i) == 1 {
+			return i
+		}
+	}
+	return -1
+}
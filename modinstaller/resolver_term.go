@@ -0,0 +1,384 @@
+package modinstaller
+
+import (
+	"fmt"
+	"math/big"
+	"slices"
+	"strings"
+
+	"github.com/turbot/pipe-fittings/versionhelpers"
+	"github.com/turbot/pipe-fittings/versionmap"
+)
+
+// resolverPackage is a mod considered by the dependency resolver, along with its available versions
+// sets of versions of the package are represented as bit sets, where bit i is set if versions[i] is in the set
+type resolverPackage struct {
+	name string
+	// the available versions, newest first
+	versions versionmap.ResolvedVersionConstraintList
+	isRoot   bool
+	// the constraints on this package, with the version sets they match - used to describe version sets
+	constraints []packageConstraint
+}
+
+type packageConstraint struct {
+	constraint string
+	versions   *big.Int
+}
+
+// all returns the set of all versions of the package
+func (p *resolverPackage) all() *big.Int {
+	res := new(big.Int).Lsh(big.NewInt(1), uint(len(p.versions)))
+	return res.Sub(res, big.NewInt(1))
+}
+
+// single returns the set containing just the version with the given index
+func (p *resolverPackage) single(idx int) *big.Int {
+	return new(big.Int).SetBit(new(big.Int), idx, 1)
+}
+
+// matching returns the set of versions of the package matching the given constraint
+func (p *resolverPackage) matching(constraint *versionhelpers.Constraints) *big.Int {
+	res := new(big.Int)
+	for i, v := range p.versions {
+		if constraint.Check(v.Version) {
+			res.SetBit(res, i, 1)
+		}
+	}
+	if !slices.ContainsFunc(p.constraints, func(c packageConstraint) bool { return c.constraint == constraint.Original }) {
+		p.constraints = append(p.constraints, packageConstraint{constraint: constraint.Original, versions: res})
+		slices.SortFunc(p.constraints, func(a, b packageConstraint) int { return strings.Compare(a.constraint, b.constraint) })
+	}
+	return res
+}
+
+// describe returns a description of the given set of versions of the package, using the constraint which matches
+// the set, if there is one
+func (p *resolverPackage) describe(versions *big.Int) string {
+	for _, c := range p.constraints {
+		if c.versions.Cmp(versions) == 0 {
+			return c.constraint
+		}
+	}
+	if versions.Sign() == 0 {
+		return "(no versions)"
+	}
+	if versions.Cmp(p.all()) == 0 {
+		return "(any version)"
+	}
+
+	// describe each run of consecutive versions as a range - as versions are ordered newest first, a run goes from
+	// the highest index (the oldest version) to the lowest
+	var ranges []string
+	for i := len(p.versions) - 1; i >= 0; i-- {
+		if versions.Bit(i) == 0 {
+			continue
+		}
+		start := i
+		for i > 0 && versions.Bit(i-1) == 1 {
+			i--
+		}
+		if start == i {
+			ranges = append(ranges, p.versions[i].Version.String())
+		} else {
+			ranges = append(ranges, fmt.Sprintf(">=%s <=%s", p.versions[start].Version.String(), p.versions[i].Version.String()))
+		}
+	}
+	return strings.Join(ranges, " || ")
+}
+
+// termRelation is the relation between the versions allowed by two terms
+type termRelation int
+
+const (
+	// relationSubset - every version allowed by the first term is allowed by the second
+	relationSubset termRelation = iota
+	// relationDisjoint - no version allowed by the first term is allowed by the second
+	relationDisjoint
+	// relationOverlapping - some versions allowed by the first term are allowed by the second
+	relationOverlapping
+)
+
+// term is a statement about a package which may be true or false for a given selection of versions
+// a positive term is true if a version in the set is selected, a negative term is true if no version in the set is
+// selected (either because another version is selected, or because the package is not selected at all)
+type term struct {
+	pkg      *resolverPackage
+	versions *big.Int
+	positive bool
+}
+
+func (t *term) inverse() *term {
+	return &term{pkg: t.pkg, versions: t.versions, positive: !t.positive}
+}
+
+// isEmpty returns whether the term cannot be true
+func (t *term) isEmpty() bool {
+	return t.positive && t.versions.Sign() == 0
+}
+
+// intersect returns a term which is true when both this term and the other term, which must be for the same
+// package, are true
+func (t *term) intersect(other *term) *term {
+	switch {
+	case t.positive && other.positive:
+		return &term{pkg: t.pkg, versions: new(big.Int).And(t.versions, other.versions), positive: true}
+	case t.positive:
+		return &term{pkg: t.pkg, versions: new(big.Int).AndNot(t.versions, other.versions), positive: true}
+	case other.positive:
+		return &term{pkg: t.pkg, versions: new(big.Int).AndNot(other.versions, t.versions), positive: true}
+	default:
+		return &term{pkg: t.pkg, versions: new(big.Int).Or(t.versions, other.versions), positive: false}
+	}
+}
+
+// difference returns a term which is true when this term is true and the other term is not, or nil if there is no
+// such term
+func (t *term) difference(other *term) *term {
+	res := t.intersect(other.inverse())
+	if res.isEmpty() {
+		return nil
+	}
+	return res
+}
+
+// relation returns the relation between the versions allowed by this term and those allowed by the other term,
+// which must be for the same package
+func (t *term) relation(other *term) termRelation {
+	subset := new(big.Int)
+	switch {
+	case t.positive && other.positive:
+		if subset.AndNot(t.versions, other.versions).Sign() == 0 {
+			return relationSubset
+		}
+		if subset.And(t.versions, other.versions).Sign() == 0 {
+			return relationDisjoint
+		}
+	case t.positive:
+		if subset.And(t.versions, other.versions).Sign() == 0 {
+			return relationSubset
+		}
+		if subset.AndNot(t.versions, other.versions).Sign() == 0 {
+			return relationDisjoint
+		}
+	case other.positive:
+		// a negative term allows the package not to be selected, so cannot be a subset of a positive term
+		if subset.AndNot(other.versions, t.versions).Sign() == 0 {
+			return relationDisjoint
+		}
+	default:
+		if subset.AndNot(other.versions, t.versions).Sign() == 0 {
+			return relationSubset
+		}
+	}
+	return relationOverlapping
+}
+
+// satisfies returns whether this term being true means the other term is true
+func (t *term) satisfies(other *term) bool {
+	return t.relation(other) == relationSubset
+}
+
+// String returns the description of the versions of the package allowed by a positive term
+func (t *term) String() string {
+	if t.pkg.isRoot {
+		return t.pkg.name
+	}
+	return fmt.Sprintf("%s %s", t.pkg.name, t.pkg.describe(t.versions))
+}
+
+// incompatibilityCause is the reason an incompatibility was added
+type incompatibilityCause int
+
+const (
+	// causeRoot - the root package must be selected
+	causeRoot incompatibilityCause = iota
+	// causeDependency - a package version depends on another package
+	causeDependency
+	// causeNoVersions - no versions of a package match the versions which are allowed
+	causeNoVersions
+	// causeConflict - the incompatibility was derived from two other incompatibilities during conflict resolution
+	causeConflict
+)
+
+// incompatibility is a set of terms which cannot all be true
+type incompatibility struct {
+	terms []*term
+	cause incompatibilityCause
+	// for causeConflict, the incompatibilities this was derived from
+	conflict *incompatibility
+	other    *incompatibility
+}
+
+func newIncompatibility(terms []*term, cause incompatibilityCause, conflict, other *incompatibility) *incompatibility {
+	// merge terms for the same package
+	var merged []*term
+	byName := make(map[string]int)
+	for _, t := range terms {
+		if idx, ok := byName[t.pkg.name]; ok {
+			merged[idx] = merged[idx].intersect(t)
+			continue
+		}
+		byName[t.pkg.name] = len(merged)
+		merged = append(merged, t)
+	}
+
+	// the root package is always selected, so a positive root term adds nothing to a derived incompatibility
+	if cause == causeConflict && len(merged) > 1 {
+		merged = slices.DeleteFunc(merged, func(t *term) bool { return t.positive && t.pkg.isRoot })
+	}
+
+	return &incompatibility{
+		terms:    merged,
+		cause:    cause,
+		conflict: conflict,
+		other:    other,
+	}
+}
+
+// isFailure returns whether the incompatibility means there is no solution - i.e. every term is always true
+func (i *incompatibility) isFailure() bool {
+	for _, t := range i.terms {
+		if !isTautology(t) && !(t.positive && t.pkg.isRoot) {
+			return false
+		}
+	}
+	return true
+}
+
+func (i *incompatibility) isDerived() bool {
+	return i.cause == causeConflict
+}
+
+func (i *incompatibility) String() string {
+	switch i.cause {
+	case causeRoot:
+		return fmt.Sprintf("%s is required", i.terms[0].pkg.name)
+	case causeDependency:
+		// the depending term is a single version - describe it as that version
+		depender, dependency := i.terms[0].String(), i.terms[1]
+		if !i.terms[0].pkg.isRoot {
+			depender = fmt.Sprintf("%s %s", i.terms[0].pkg.name, i.terms[0].pkg.versions[i.terms[0].versions.BitLen()-1].Version)
+		}
+		if dependency.versions.Sign() == 0 {
+			return fmt.Sprintf("%s depends on %s, which matches no available versions", depender, dependency)
+		}
+		return fmt.Sprintf("%s depends on %s", depender, dependency)
+	case causeNoVersions:
+		t := i.terms[0]
+		if len(t.pkg.versions) == 0 {
+			return fmt.Sprintf("no versions of %s are available", t.pkg.name)
+		}
+		return fmt.Sprintf("no versions of %s match %s", t.pkg.name, t.pkg.describe(t.versions))
+	}
+
+	if i.isFailure() {
+		return "version solving failed"
+	}
+
+	var positive, negative []string
+	for _, t := range i.terms {
+		if t.positive {
+			positive = append(positive, t.String())
+		} else {
+			negative = append(negative, t.String())
+		}
+	}
+
+	switch {
+	case len(i.terms) == 1 && len(positive) == 1:
+		return fmt.Sprintf("%s is forbidden", positive[0])
+	case len(i.terms) == 1:
+		return fmt.Sprintf("%s is required", negative[0])
+	case len(negative) == 0:
+		if len(positive) == 2 {
+			return fmt.Sprintf("%s is incompatible with %s", positive[0], positive[1])
+		}
+		return fmt.Sprintf("one of %s must be false", strings.Join(positive, " or "))
+	case len(positive) == 0:
+		return fmt.Sprintf("either %s", strings.Join(negative, " or "))
+	case len(positive) == 1 && len(negative) == 1:
+		return fmt.Sprintf("%s requires %s", positive[0], negative[0])
+	default:
+		return fmt.Sprintf("if %s then %s", strings.Join(positive, " and "), strings.Join(negative, " or "))
+	}
+}
+
+// explanationWriter builds a human-readable derivation of the incompatibility which caused resolution to fail
+type explanationWriter struct {
+	lines []string
+	// the numbers of the lines of derived incompatibilities which are referred to from more than one line
+	lineNumbers map[*incompatibility]int
+	refCounts   map[*incompatibility]int
+}
+
+func explainIncompatibility(root *incompatibility) string {
+	if !root.isDerived() {
+		return fmt.Sprintf("Because %s, version solving failed.", root)
+	}
+	w := &explanationWriter{
+		lineNumbers: make(map[*incompatibility]int),
+		refCounts:   make(map[*incompatibility]int),
+	}
+	w.countRefs(root)
+	w.visit(root, false)
+	return strings.Join(w.lines, "\n")
+}
+
+func (w *explanationWriter) countRefs(i *incompatibility) {
+	w.refCounts[i]++
+	if w.refCounts[i] == 1 && i.isDerived() {
+		w.countRefs(i.conflict)
+		w.countRefs(i.other)
+	}
+}
+
+func (w *explanationWriter) visit(i *incompatibility, numbered bool) {
+	numbered = numbered || w.refCounts[i] > 1
+	conflict, other := i.conflict, i.other
+
+	switch {
+	case conflict.isDerived() && other.isDerived():
+		conflictLine, conflictNumbered := w.lineNumbers[conflict]
+		otherLine, otherNumbered := w.lineNumbers[other]
+		switch {
+		case conflictNumbered && otherNumbered:
+			w.write(i, fmt.Sprintf("Because %s (%d) and %s (%d), %s.", conflict, conflictLine, other, otherLine, i), numbered)
+		case conflictNumbered:
+			w.visit(other, false)
+			w.write(i, fmt.Sprintf("And because %s (%d), %s.", conflict, conflictLine, i), numbered)
+		case otherNumbered:
+			w.visit(conflict, false)
+			w.write(i, fmt.Sprintf("And because %s (%d), %s.", other, otherLine, i), numbered)
+		default:
+			// number the first derivation so it can be referred to once the second has been explained
+			w.visit(conflict, true)
+			w.visit(other, false)
+			w.write(i, fmt.Sprintf("And because %s (%d), %s.", conflict, w.lineNumbers[conflict], i), numbered)
+		}
+
+	case conflict.isDerived() || other.isDerived():
+		derived, external := conflict, other
+		if !derived.isDerived() {
+			derived, external = other, conflict
+		}
+		if line, ok := w.lineNumbers[derived]; ok {
+			w.write(i, fmt.Sprintf("Because %s and %s (%d), %s.", external, derived, line, i), numbered)
+		} else {
+			w.visit(derived, false)
+			w.write(i, fmt.Sprintf("And because %s, %s.", external, i), numbered)
+		}
+
+	default:
+		w.write(i, fmt.Sprintf("Because %s and %s, %s.", conflict, other, i), numbered)
+	}
+}
+
+func (w *explanationWriter) write(i *incompatibility, line string, numbered bool) {
+	if numbered {
+		number := len(w.lineNumbers) + 1
+		w.lineNumbers[i] = number
+		line = fmt.Sprintf("%s (%d)", line, number)
+	}
+	w.lines = append(w.lines, line)
+}
@@ -0,0 +1,202 @@
+package modinstaller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/turbot/pipe-fittings/modconfig"
+	"github.com/turbot/pipe-fittings/versionmap"
+)
+
+// memoryDependencySource is a DependencySource backed by a map of mod name to version to the requirements of that
+// version, e.g. "a": {"1.0.0": {"c@^1.0"}}
+type memoryDependencySource map[string]map[string][]string
+
+func (s memoryDependencySource) AvailableVersions(_ context.Context, modName string) (versionmap.ResolvedVersionConstraintList, error) {
+	var res versionmap.ResolvedVersionConstraintList
+	for v := range s[modName] {
+		res = append(res, &versionmap.ResolvedVersionConstraint{
+			DependencyVersion: modconfig.DependencyVersion{Version: semver.MustParse(v)},
+			Name:              modName,
+			GitRefStr:         "refs/tags/v" + v,
+		})
+	}
+	return res, nil
+}
+
+func (s memoryDependencySource) Requirements(_ context.Context, version *versionmap.ResolvedVersionConstraint) ([]*modconfig.ModVersionConstraint, error) {
+	requirements, ok := s[version.Name][version.Version.String()]
+	if !ok {
+		return nil, fmt.Errorf("unknown mod version %s", version.DependencyPath())
+	}
+	return mustParseRequirements(requirements...), nil
+}
+
+func mustParseRequirements(requirements ...string) []*modconfig.ModVersionConstraint {
+	var res []*modconfig.ModVersionConstraint
+	for _, r := range requirements {
+		c, err := modconfig.NewModVersionConstraint(r)
+		if err != nil {
+			panic(err)
+		}
+		res = append(res, c)
+	}
+	return res
+}
+
+func TestResolveDependencies(t *testing.T) {
+	tests := []struct {
+		name         string
+		source       memoryDependencySource
+		requirements []string
+		preferred    map[string]string
+		want         map[string]string
+		wantErr      string
+	}{
+		{
+			name: "newest versions",
+			source: memoryDependencySource{
+				"a": {"1.0.0": {"c@^1.0"}, "1.1.0": {"c@>=1.0"}},
+				"b": {"1.0.0": {"c@^1.2"}},
+				"c": {"1.0.0": nil, "1.2.0": nil, "1.3.0": nil},
+			},
+			requirements: []string{"a@^1.0", "b@^1.0"},
+			want:         map[string]string{"a": "1.1.0", "b": "1.0.0", "c": "1.3.0"},
+		},
+		{
+			name: "shared dependency",
+			source: memoryDependencySource{
+				"a": {"1.0.0": {"c@^1.0"}},
+				"b": {"1.0.0": {"c@<1.3"}},
+				"c": {"1.0.0": nil, "1.2.0": nil, "1.3.0": nil, "2.0.0": nil},
+			},
+			requirements: []string{"a@^1.0", "b@^1.0"},
+			want:         map[string]string{"a": "1.0.0", "b": "1.0.0", "c": "1.2.0"},
+		},
+		{
+			name: "backtrack to older version",
+			source: memoryDependencySource{
+				"a": {"1.0.0": {"c@^1.0"}, "2.0.0": {"c@^2.0"}},
+				"b": {"1.0.0": {"c@^1.0"}},
+				"c": {"1.0.0": nil, "2.0.0": nil},
+			},
+			requirements: []string{"a", "b@^1.0"},
+			want:         map[string]string{"a": "1.0.0", "b": "1.0.0", "c": "1.0.0"},
+		},
+		{
+			name: "backtrack through transitive dependencies",
+			source: memoryDependencySource{
+				"a": {"1.0.0": {"b@^1.0"}, "1.1.0": {"b@^2.0"}},
+				"b": {"1.0.0": {"d@^1.0"}, "2.0.0": {"d@^2.0"}},
+				"c": {"1.0.0": {"d@^1.0"}},
+				"d": {"1.0.0": nil, "2.0.0": nil},
+			},
+			requirements: []string{"a@^1.0", "c@^1.0"},
+			want:         map[string]string{"a": "1.0.0", "b": "1.0.0", "c": "1.0.0", "d": "1.0.0"},
+		},
+		{
+			name: "preferred versions",
+			source: memoryDependencySource{
+				"a": {"1.0.0": {"c@^1.0"}, "1.1.0": {"c@^1.0"}},
+				"c": {"1.0.0": nil, "1.2.0": nil},
+			},
+			requirements: []string{"a@^1.0"},
+			preferred:    map[string]string{"a": "1.0.0", "c": "1.2.0"},
+			want:         map[string]string{"a": "1.0.0", "c": "1.2.0"},
+		},
+		{
+			name: "preferred version not allowed",
+			source: memoryDependencySource{
+				"a": {"1.0.0": nil, "2.0.0": nil},
+			},
+			requirements: []string{"a@^2.0"},
+			preferred:    map[string]string{"a": "1.0.0"},
+			want:         map[string]string{"a": "2.0.0"},
+		},
+		{
+			name: "non version requirements are ignored",
+			source: memoryDependencySource{
+				"a": {"1.0.0": {"c#main"}},
+			},
+			requirements: []string{"a@^1.0", "b#develop"},
+			want:         map[string]string{"a": "1.0.0"},
+		},
+		{
+			name: "incompatible shared dependency",
+			source: memoryDependencySource{
+				"a": {"1.0.0": {"c@^1.0"}},
+				"b": {"1.0.0": {"c@^2.0"}},
+				"c": {"1.0.0": nil, "2.0.0": nil},
+			},
+			requirements: []string{"a@^1.0", "b@^1.0"},
+			wantErr: "Because b 1.0.0 depends on c ^2.0 and a 1.0.0 depends on c ^1.0, b ^1.0 is incompatible with a ^1.0.\n" +
+				"And because mod.root depends on a ^1.0, b ^1.0 is forbidden.\n" +
+				"And because mod.root depends on b ^1.0, version solving failed.",
+		},
+		{
+			name: "no matching versions",
+			source: memoryDependencySource{
+				"a": {"1.0.0": {"c@^3.0"}},
+				"c": {"1.0.0": nil, "2.0.0": nil},
+			},
+			requirements: []string{"a@^1.0"},
+			wantErr:      "Because a 1.0.0 depends on c ^3.0, which matches no available versions and mod.root depends on a ^1.0, version solving failed.",
+		},
+		{
+			name:         "mod with no versions",
+			source:       memoryDependencySource{},
+			requirements: []string{"a@^1.0"},
+			wantErr:      "Because mod.root depends on a ^1.0, which matches no available versions, version solving failed.",
+		},
+		{
+			name: "conflict found after backtracking",
+			source: memoryDependencySource{
+				"a": {"1.0.0": {"b@^1.0"}, "2.0.0": {"b@^2.0"}},
+				"b": {"1.0.0": {"d@^1.0"}, "2.0.0": {"d@^2.0"}},
+				"c": {"1.0.0": {"d@^3.0"}},
+				"d": {"1.0.0": nil, "2.0.0": nil, "3.0.0": nil},
+			},
+			requirements: []string{"a", "c@^1.0"},
+			wantErr: "Because a 1.0.0 depends on b ^1.0 and b 1.0.0 depends on d ^1.0, a 1.0.0 requires d ^1.0.\n" +
+				"And because a 2.0.0 depends on b ^2.0, if a * then d ^1.0 or b ^2.0.\n" +
+				"And because b 2.0.0 depends on d ^2.0, a * requires d >=1.0.0 <=2.0.0.\n" +
+				"And because c 1.0.0 depends on d ^3.0, c ^1.0 is incompatible with a *.\n" +
+				"And because mod.root depends on a *, c ^1.0 is forbidden.\n" +
+				"And because mod.root depends on c ^1.0, version solving failed.",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			preferred := make(map[string]*semver.Version)
+			for name, v := range tt.preferred {
+				preferred[name] = semver.MustParse(v)
+			}
+
+			got, err := ResolveDependencies(context.Background(), "mod.root", mustParseRequirements(tt.requirements...), tt.source, preferred)
+			if tt.wantErr != "" {
+				var resolutionErr *ResolutionError
+				if !errors.As(err, &resolutionErr) {
+					t.Fatalf("ResolveDependencies() error = %v, want ResolutionError", err)
+				}
+				if resolutionErr.Explanation != tt.wantErr {
+					t.Errorf("ResolveDependencies() explanation =\n%s\nwant\n%s", resolutionErr.Explanation, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ResolveDependencies() error = %v", err)
+			}
+
+			gotVersions := make(map[string]string)
+			for name, v := range got {
+				gotVersions[name] = v.Version.String()
+			}
+			if fmt.Sprint(gotVersions) != fmt.Sprint(tt.want) {
+				t.Errorf("ResolveDependencies() = %v, want %v", gotVersions, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,265 @@
+package modinstaller
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"hash"
+	"strings"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/turbot/pipe-fittings/modconfig"
+	"golang.org/x/crypto/blake2b"
+	"golang.org/x/crypto/ssh"
+)
+
+// the namespace git uses when signing with ssh keys
+const sshSignatureNamespace = "git"
+
+// verifyTagSignature verifies that the given tag of the cloned repository is signed by one of the public keys of
+// the signature
+//
+// For ssh signatures, this is the signature git adds when signing a tag with an ssh key (gpg.format=ssh).
+// For minisign signatures, the minisign signature block (beginning with the 'untrusted comment:' line) must be
+// appended to the tag message - the signed payload is the tag object without the signature block, as for git
+// native signatures.
+func verifyTagSignature(repo *git.Repository, gitRefName plumbing.ReferenceName, signature *modconfig.ModSignature) error {
+	ref, err := repo.Reference(gitRefName, true)
+	if err != nil {
+		return fmt.Errorf("could not find tag '%s': %w", gitRefName.Short(), err)
+	}
+	tag, err := repo.TagObject(ref.Hash())
+	if err != nil {
+		return fmt.Errorf("tag '%s' is not signed - only annotated tags can be signed", gitRefName.Short())
+	}
+
+	switch signature.Type {
+	case modconfig.ModSignatureTypeSSH:
+		if tag.PGPSignature == "" {
+			return fmt.Errorf("tag '%s' is not signed", tag.Name)
+		}
+		payload, err := tagPayload(tag)
+		if err != nil {
+			return err
+		}
+		return verifySSHSignature(payload, tag.PGPSignature, signature.PublicKeys)
+	case modconfig.ModSignatureTypeMinisign:
+		idx := strings.LastIndex(tag.Message, "untrusted comment:")
+		if idx == -1 {
+			return fmt.Errorf("tag '%s' has no minisign signature", tag.Name)
+		}
+		unsigned := *tag
+		unsigned.Message = tag.Message[:idx]
+		payload, err := tagPayload(&unsigned)
+		if err != nil {
+			return err
+		}
+		return verifyMinisignSignature(payload, tag.Message[idx:], signature.PublicKeys)
+	default:
+		return fmt.Errorf("unsupported signature type '%s'", signature.Type)
+	}
+}
+
+// tagPayload returns the signed content of a tag - the encoded tag object, excluding the signature
+func tagPayload(tag *object.Tag) ([]byte, error) {
+	encoded := &plumbing.MemoryObject{}
+	if err := tag.EncodeWithoutSignature(encoded); err != nil {
+		return nil, err
+	}
+	reader, err := encoded.Reader()
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+	var payload bytes.Buffer
+	if _, err := payload.ReadFrom(reader); err != nil {
+		return nil, err
+	}
+	return payload.Bytes(), nil
+}
+
+// sshSignature is the wire format of an ssh signature, following the SSHSIG magic preamble
+// (see https://github.com/openssh/openssh-portable/blob/master/PROTOCOL.sshsig)
+type sshSignature struct {
+	Version       uint32
+	PublicKey     []byte
+	Namespace     string
+	Reserved      string
+	HashAlgorithm string
+	Signature     []byte
+}
+
+// sshSignedData is the data which is signed to create an ssh signature, following the SSHSIG magic preamble
+type sshSignedData struct {
+	Namespace     string
+	Reserved      string
+	HashAlgorithm string
+	Hash          []byte
+}
+
+const sshSignatureMagic = "SSHSIG"
+
+func verifySSHSignature(payload []byte, armored string, publicKeys []string) error {
+	blob, err := decodeArmoredSSHSignature(armored)
+	if err != nil {
+		return err
+	}
+	if !bytes.HasPrefix(blob, []byte(sshSignatureMagic)) {
+		return fmt.Errorf("invalid ssh signature")
+	}
+	var sig sshSignature
+	if err := ssh.Unmarshal(blob[len(sshSignatureMagic):], &sig); err != nil {
+		return fmt.Errorf("invalid ssh signature: %w", err)
+	}
+	if sig.Version != 1 {
+		return fmt.Errorf("unsupported ssh signature version %d", sig.Version)
+	}
+	if sig.Namespace != sshSignatureNamespace {
+		return fmt.Errorf("ssh signature has namespace '%s' - expected '%s'", sig.Namespace, sshSignatureNamespace)
+	}
+
+	signingKey, err := ssh.ParsePublicKey(sig.PublicKey)
+	if err != nil {
+		return fmt.Errorf("invalid ssh signature public key: %w", err)
+	}
+	if !isTrustedSSHKey(signingKey, publicKeys) {
+		return fmt.Errorf("tag is signed by untrusted key %s", ssh.FingerprintSHA256(signingKey))
+	}
+
+	var h hash.Hash
+	switch sig.HashAlgorithm {
+	case "sha256":
+		h = sha256.New()
+	case "sha512":
+		h = sha512.New()
+	default:
+		return fmt.Errorf("unsupported ssh signature hash algorithm '%s'", sig.HashAlgorithm)
+	}
+	h.Write(payload)
+
+	var signature ssh.Signature
+	if err := ssh.Unmarshal(sig.Signature, &signature); err != nil {
+		return fmt.Errorf("invalid ssh signature: %w", err)
+	}
+	signedData := append([]byte(sshSignatureMagic), ssh.Marshal(sshSignedData{
+		Namespace:     sig.Namespace,
+		Reserved:      sig.Reserved,
+		HashAlgorithm: sig.HashAlgorithm,
+		Hash:          h.Sum(nil),
+	})...)
+	if err := signingKey.Verify(signedData, &signature); err != nil {
+		return fmt.Errorf("ssh signature verification failed: %w", err)
+	}
+	return nil
+}
+
+func decodeArmoredSSHSignature(armored string) ([]byte, error) {
+	const header, footer = "-----BEGIN SSH SIGNATURE-----", "-----END SSH SIGNATURE-----"
+	armored = strings.TrimSpace(armored)
+	if !strings.HasPrefix(armored, header) || !strings.HasSuffix(armored, footer) {
+		return nil, fmt.Errorf("invalid ssh signature")
+	}
+	body := strings.Join(strings.Fields(armored[len(header):len(armored)-len(footer)]), "")
+	blob, err := base64.StdEncoding.DecodeString(body)
+	if err != nil {
+		return nil, fmt.Errorf("invalid ssh signature: %w", err)
+	}
+	return blob, nil
+}
+
+func isTrustedSSHKey(signingKey ssh.PublicKey, publicKeys []string) bool {
+	for _, k := range publicKeys {
+		trustedKey, _, _, _, err := ssh.ParseAuthorizedKey([]byte(k))
+		if err != nil {
+			continue
+		}
+		if bytes.Equal(trustedKey.Marshal(), signingKey.Marshal()) {
+			return true
+		}
+	}
+	return false
+}
+
+// minisign signature algorithms - the signature is of the payload, or of its BLAKE2b-512 hash
+const (
+	minisignAlgorithm          = "Ed"
+	minisignHashedAlgorithm    = "ED"
+	minisignKeyIdLength        = 8
+	minisignPublicKeyLength    = 2 + minisignKeyIdLength + ed25519.PublicKeySize
+	minisignSignatureLength    = 2 + minisignKeyIdLength + ed25519.SignatureSize
+	minisignTrustedCommentLine = "trusted comment: "
+)
+
+type minisignPublicKey struct {
+	keyId     uint64
+	publicKey ed25519.PublicKey
+}
+
+// parseMinisignPublicKey parses a minisign public key - either the base64 key, or the contents of a minisign
+// public key file, including the untrusted comment
+func parseMinisignPublicKey(key string) (*minisignPublicKey, error) {
+	lines := strings.Split(strings.TrimSpace(key), "\n")
+	decoded, err := base64.StdEncoding.DecodeString(strings.TrimSpace(lines[len(lines)-1]))
+	if err != nil {
+		return nil, err
+	}
+	if len(decoded) != minisignPublicKeyLength || string(decoded[:2]) != minisignAlgorithm {
+		return nil, fmt.Errorf("invalid minisign public key")
+	}
+	return &minisignPublicKey{
+		keyId:     binary.LittleEndian.Uint64(decoded[2 : 2+minisignKeyIdLength]),
+		publicKey: ed25519.PublicKey(decoded[2+minisignKeyIdLength:]),
+	}, nil
+}
+
+func verifyMinisignSignature(payload []byte, signatureBlock string, publicKeys []string) error {
+	// the signature block is: untrusted comment, signature, trusted comment, global signature
+	lines := strings.Split(strings.TrimSpace(signatureBlock), "\n")
+	if len(lines) != 4 || !strings.HasPrefix(lines[2], minisignTrustedCommentLine) {
+		return fmt.Errorf("invalid minisign signature")
+	}
+	sig, err := base64.StdEncoding.DecodeString(strings.TrimSpace(lines[1]))
+	if err != nil || len(sig) != minisignSignatureLength {
+		return fmt.Errorf("invalid minisign signature")
+	}
+	trustedComment := strings.TrimPrefix(strings.TrimRight(lines[2], "\r"), minisignTrustedCommentLine)
+	globalSig, err := base64.StdEncoding.DecodeString(strings.TrimSpace(lines[3]))
+	if err != nil || len(globalSig) != ed25519.SignatureSize {
+		return fmt.Errorf("invalid minisign signature")
+	}
+
+	algorithm := string(sig[:2])
+	message := payload
+	switch algorithm {
+	case minisignAlgorithm:
+	case minisignHashedAlgorithm:
+		hashed := blake2b.Sum512(payload)
+		message = hashed[:]
+	default:
+		return fmt.Errorf("unsupported minisign signature algorithm '%s'", algorithm)
+	}
+	keyId := binary.LittleEndian.Uint64(sig[2 : 2+minisignKeyIdLength])
+	signature := sig[2+minisignKeyIdLength:]
+
+	for _, k := range publicKeys {
+		publicKey, err := parseMinisignPublicKey(k)
+		if err != nil || publicKey.keyId != keyId {
+			continue
+		}
+		if !ed25519.Verify(publicKey.publicKey, message, signature) {
+			return fmt.Errorf("minisign signature verification failed")
+		}
+		// the global signature covers the signature and the trusted comment
+		if !ed25519.Verify(publicKey.publicKey, append(append([]byte{}, signature...), trustedComment...), globalSig) {
+			return fmt.Errorf("minisign trusted comment verification failed")
+		}
+		return nil
+	}
+	return fmt.Errorf("tag is signed by untrusted minisign key %X", keyId)
+}
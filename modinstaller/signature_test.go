@@ -0,0 +1,240 @@
+package modinstaller
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha512"
+	"encoding/base64"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-billy/v5/memfs"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/storage/memory"
+	"github.com/turbot/pipe-fittings/modconfig"
+	"golang.org/x/crypto/blake2b"
+	"golang.org/x/crypto/ssh"
+)
+
+// newTaggedRepo creates an in-memory repository with a single commit, tagged v1.0.0 with an annotated tag -
+// sign is passed the tag and the signed payload, and may set the tag signature or message
+func newTaggedRepo(t *testing.T, sign func(tag *object.Tag, payload []byte)) *git.Repository {
+	t.Helper()
+	repo, err := git.Init(memory.NewStorage(), memfs.New())
+	if err != nil {
+		t.Fatal(err)
+	}
+	wt, err := repo.Worktree()
+	if err != nil {
+		t.Fatal(err)
+	}
+	f, err := wt.Filesystem.Create("mod.pp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _ = f.Write([]byte(`mod "m1" {}`))
+	f.Close()
+	if _, err := wt.Add("mod.pp"); err != nil {
+		t.Fatal(err)
+	}
+	author := &object.Signature{Name: "test", Email: "test@example.com", When: time.Unix(1700000000, 0)}
+	commit, err := wt.Commit("initial", &git.CommitOptions{Author: author})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tag := &object.Tag{
+		Name:       "v1.0.0",
+		Tagger:     *author,
+		Message:    "release 1.0.0\n",
+		TargetType: plumbing.CommitObject,
+		Target:     commit,
+	}
+	if sign != nil {
+		payload, err := tagPayload(tag)
+		if err != nil {
+			t.Fatal(err)
+		}
+		sign(tag, payload)
+	}
+	obj := repo.Storer.NewEncodedObject()
+	if err := tag.Encode(obj); err != nil {
+		t.Fatal(err)
+	}
+	hash, err := repo.Storer.SetEncodedObject(obj)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.Storer.SetReference(plumbing.NewHashReference("refs/tags/v1.0.0", hash)); err != nil {
+		t.Fatal(err)
+	}
+	return repo
+}
+
+func sshSign(t *testing.T, signer ssh.Signer, namespace string, payload []byte) string {
+	t.Helper()
+	hash := sha512.Sum512(payload)
+	signedData := append([]byte(sshSignatureMagic), ssh.Marshal(sshSignedData{
+		Namespace:     namespace,
+		HashAlgorithm: "sha512",
+		Hash:          hash[:],
+	})...)
+	signature, err := signer.Sign(rand.Reader, signedData)
+	if err != nil {
+		t.Fatal(err)
+	}
+	blob := append([]byte(sshSignatureMagic), ssh.Marshal(sshSignature{
+		Version:       1,
+		PublicKey:     signer.PublicKey().Marshal(),
+		Namespace:     namespace,
+		HashAlgorithm: "sha512",
+		Signature:     ssh.Marshal(signature),
+	})...)
+	return "-----BEGIN SSH SIGNATURE-----\n" + base64.StdEncoding.EncodeToString(blob) + "\n-----END SSH SIGNATURE-----\n"
+}
+
+func newSSHKey(t *testing.T) (ssh.Signer, string) {
+	t.Helper()
+	_, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signer, err := ssh.NewSignerFromKey(privateKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signer, string(ssh.MarshalAuthorizedKey(signer.PublicKey()))
+}
+
+// minisignSign returns the minisign public key and the signature block for the payload
+func minisignSign(t *testing.T, payload []byte) (string, string) {
+	t.Helper()
+	publicKey, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyId := []byte{1, 2, 3, 4, 5, 6, 7, 8}
+	hashed := blake2b.Sum512(payload)
+	signature := ed25519.Sign(privateKey, hashed[:])
+	trustedComment := "timestamp:1700000000"
+	globalSignature := ed25519.Sign(privateKey, append(append([]byte{}, signature...), trustedComment...))
+
+	key := base64.StdEncoding.EncodeToString(append(append([]byte(minisignAlgorithm), keyId...), publicKey...))
+	block := "untrusted comment: signature from minisign secret key\n" +
+		base64.StdEncoding.EncodeToString(append(append([]byte(minisignHashedAlgorithm), keyId...), signature...)) + "\n" +
+		minisignTrustedCommentLine + trustedComment + "\n" +
+		base64.StdEncoding.EncodeToString(globalSignature) + "\n"
+	return key, block
+}
+
+func TestVerifyTagSignature(t *testing.T) {
+	signer, publicKey := newSSHKey(t)
+	_, otherPublicKey := newSSHKey(t)
+
+	var minisignKey string
+
+	tests := []struct {
+		name      string
+		sign      func(tag *object.Tag, payload []byte)
+		signature func() *modconfig.ModSignature
+		wantErr   string
+	}{
+		{
+			name: "ssh",
+			sign: func(tag *object.Tag, payload []byte) { tag.PGPSignature = sshSign(t, signer, "git", payload) },
+			signature: func() *modconfig.ModSignature {
+				return &modconfig.ModSignature{Type: modconfig.ModSignatureTypeSSH, PublicKeys: []string{otherPublicKey, publicKey}}
+			},
+		},
+		{
+			name: "ssh untrusted key",
+			sign: func(tag *object.Tag, payload []byte) { tag.PGPSignature = sshSign(t, signer, "git", payload) },
+			signature: func() *modconfig.ModSignature {
+				return &modconfig.ModSignature{Type: modconfig.ModSignatureTypeSSH, PublicKeys: []string{otherPublicKey}}
+			},
+			wantErr: "untrusted key",
+		},
+		{
+			name: "ssh wrong namespace",
+			sign: func(tag *object.Tag, payload []byte) { tag.PGPSignature = sshSign(t, signer, "file", payload) },
+			signature: func() *modconfig.ModSignature {
+				return &modconfig.ModSignature{Type: modconfig.ModSignatureTypeSSH, PublicKeys: []string{publicKey}}
+			},
+			wantErr: "namespace",
+		},
+		{
+			name: "ssh tampered tag",
+			sign: func(tag *object.Tag, payload []byte) {
+				tag.PGPSignature = sshSign(t, signer, "git", payload)
+				tag.Message = "release 1.0.1\n"
+			},
+			signature: func() *modconfig.ModSignature {
+				return &modconfig.ModSignature{Type: modconfig.ModSignatureTypeSSH, PublicKeys: []string{publicKey}}
+			},
+			wantErr: "verification failed",
+		},
+		{
+			name: "unsigned",
+			signature: func() *modconfig.ModSignature {
+				return &modconfig.ModSignature{Type: modconfig.ModSignatureTypeSSH, PublicKeys: []string{publicKey}}
+			},
+			wantErr: "not signed",
+		},
+		{
+			name: "minisign",
+			sign: func(tag *object.Tag, payload []byte) {
+				var block string
+				minisignKey, block = minisignSign(t, payload)
+				tag.Message += block
+			},
+			signature: func() *modconfig.ModSignature {
+				return &modconfig.ModSignature{Type: modconfig.ModSignatureTypeMinisign, PublicKeys: []string{minisignKey}}
+			},
+		},
+		{
+			name: "minisign tampered trusted comment",
+			sign: func(tag *object.Tag, payload []byte) {
+				var block string
+				minisignKey, block = minisignSign(t, payload)
+				tag.Message += strings.Replace(block, "timestamp:1700000000", "timestamp:1800000000", 1)
+			},
+			signature: func() *modconfig.ModSignature {
+				return &modconfig.ModSignature{Type: modconfig.ModSignatureTypeMinisign, PublicKeys: []string{minisignKey}}
+			},
+			wantErr: "trusted comment verification failed",
+		},
+		{
+			name: "minisign untrusted key",
+			sign: func(tag *object.Tag, payload []byte) {
+				_, block := minisignSign(t, payload)
+				tag.Message += block
+			},
+			signature: func() *modconfig.ModSignature {
+				key, _ := minisignSign(t, nil)
+				// use a different key id
+				decoded, _ := base64.StdEncoding.DecodeString(key)
+				decoded[2] = 9
+				return &modconfig.ModSignature{Type: modconfig.ModSignatureTypeMinisign, PublicKeys: []string{base64.StdEncoding.EncodeToString(decoded)}}
+			},
+			wantErr: "untrusted minisign key",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newTaggedRepo(t, tt.sign)
+			err := verifyTagSignature(repo, "refs/tags/v1.0.0", tt.signature())
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("verifyTagSignature() error = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("verifyTagSignature() error = %v, want error containing '%s'", err, tt.wantErr)
+			}
+		})
+	}
+}
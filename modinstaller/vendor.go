@@ -0,0 +1,153 @@
+package modinstaller
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	filehelpers "github.com/turbot/go-kit/files"
+	"github.com/turbot/go-kit/helpers"
+	"github.com/turbot/pipe-fittings/filepaths"
+	"github.com/turbot/pipe-fittings/ociinstaller"
+	"github.com/turbot/pipe-fittings/utils"
+	"github.com/turbot/pipe-fittings/versionmap"
+)
+
+// VendorResult is the result of populating a mod mirror from the lock file
+type VendorResult struct {
+	MirrorPath string
+	// the dependency paths of the mods added to the mirror
+	Added []string
+	// the dependency paths of the mods which were already in the mirror
+	Existing []string
+}
+
+func (r *VendorResult) String() string {
+	var b strings.Builder
+	for _, dependencyPath := range r.Added {
+		fmt.Fprintf(&b, "added %s\n", dependencyPath)
+	}
+	fmt.Fprintf(&b, "%d %s added to mod mirror %s", len(r.Added), utils.Pluralize("mod", len(r.Added)), r.MirrorPath)
+	if len(r.Existing) > 0 {
+		fmt.Fprintf(&b, " (%d already present)", len(r.Existing))
+	}
+	return b.String()
+}
+
+// VendorWorkspaceDependencies adds an archive of every mod version in the lock file to the mod mirror at mirrorPath
+// (defaulting to the mirror directory of the workspace), so the workspace dependencies can be installed offline
+func VendorWorkspaceDependencies(ctx context.Context, opts *InstallOpts, mirrorPath string) (_ *VendorResult, err error) {
+	utils.LogTime("cmd.VendorWorkspaceDependencies")
+	defer func() {
+		utils.LogTime("cmd.VendorWorkspaceDependencies end")
+		if r := recover(); r != nil {
+			err = helpers.ToError(r)
+		}
+	}()
+
+	installer, err := NewModInstaller(opts)
+	if err != nil {
+		return nil, err
+	}
+	if installer.mirror != nil {
+		return nil, fmt.Errorf("cannot populate the mod mirror when installing offline")
+	}
+	if mirrorPath == "" {
+		mirrorPath = opts.MirrorPath
+	}
+	if mirrorPath == "" {
+		mirrorPath = filepaths.WorkspaceModMirrorPath(installer.workspacePath)
+	}
+	return installer.vendorMods(ctx, newModMirror(mirrorPath))
+}
+
+func (i *ModInstaller) vendorMods(ctx context.Context, mirror *modMirror) (*VendorResult, error) {
+	lock := i.installData.Lock
+	res := &VendorResult{MirrorPath: mirror.path}
+
+	// locked versions which are not installed have been moved to MissingVersions - these are downloaded
+	locked := lock.InstallCache.FlatMap()
+	for dependencyPath, version := range lock.MissingVersions.FlatMap() {
+		locked[dependencyPath] = version
+	}
+
+	for dependencyPath, version := range locked {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		// file path dependencies are not installed from a remote, so are not mirrored
+		if version.FilePath != "" {
+			continue
+		}
+		if mirror.hasArchive(dependencyPath) {
+			res.Existing = append(res.Existing, dependencyPath)
+			continue
+		}
+		if err := i.vendorMod(ctx, mirror, version); err != nil {
+			return nil, err
+		}
+		res.Added = append(res.Added, dependencyPath)
+	}
+
+	sort.Strings(res.Added)
+	sort.Strings(res.Existing)
+	return res, nil
+}
+
+// vendorMod adds an archive of the given locked mod version to the mirror. The installed mod is archived if its
+// content matches the lock file, otherwise the locked version is downloaded.
+func (i *ModInstaller) vendorMod(ctx context.Context, mirror *modMirror, version *versionmap.InstalledModVersion) error {
+	dependencyPath := version.DependencyPath()
+	modDir := i.getDependencyDestPath(dependencyPath)
+	if !i.installedContentMatches(version, modDir) {
+		tmpDir, err := os.MkdirTemp("", "mod-vendor")
+		if err != nil {
+			return err
+		}
+		defer os.RemoveAll(tmpDir)
+
+		modDir = filepath.Join(tmpDir, dependencyPath)
+		if err := i.fetchMod(ctx, version.ResolvedVersionConstraint, modDir); err != nil {
+			return fmt.Errorf("failed to download mod '%s': %w", dependencyPath, err)
+		}
+		// branches are expected to change, but a tagged version must match the lock file
+		if version.Branch == "" && version.ContentHash != "" && !i.installedContentMatches(version, modDir) {
+			return fmt.Errorf("content of mod '%s' does not match the lock file - the version may have been modified since it was locked", dependencyPath)
+		}
+	}
+
+	archivePath := mirror.archivePath(dependencyPath)
+	if err := os.MkdirAll(filepath.Dir(archivePath), 0755); err != nil {
+		return err
+	}
+	// write to a temporary file and rename, so an interrupted vendor never leaves a partial archive in the mirror
+	f, err := os.CreateTemp(filepath.Dir(archivePath), ".vendor-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	if err := ociinstaller.PackageMod(modDir, f); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), archivePath)
+}
+
+// installedContentMatches returns whether the mod in modDir matches the content hash in the lock file - if the lock
+// file has no content hash, any installed mod matches
+func (i *ModInstaller) installedContentMatches(version *versionmap.InstalledModVersion, modDir string) bool {
+	if !filehelpers.DirectoryExists(modDir) {
+		return false
+	}
+	if version.ContentHash == "" {
+		return true
+	}
+	contentHash, err := versionmap.HashModDirectory(modDir)
+	return err == nil && contentHash == version.ContentHash
+}
@@ -0,0 +1,194 @@
+package modinstaller
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/go-git/go-git/v5/plumbing"
+	filehelpers "github.com/turbot/go-kit/files"
+	"github.com/turbot/go-kit/helpers"
+	"github.com/turbot/pipe-fittings/modconfig"
+	"github.com/turbot/pipe-fittings/utils"
+	"github.com/turbot/pipe-fittings/versionmap"
+)
+
+// ModDrift describes an installed mod whose content does not match the content hash recorded in the lock file
+type ModDrift struct {
+	DependencyPath string
+	LockedHash     string
+	InstalledHash  string
+}
+
+// VerifyResult is the result of verifying the installed mods against the content hashes in the lock file
+type VerifyResult struct {
+	// mods whose content matches the lock file
+	Verified []string
+	// mods whose content has changed since they were installed
+	Modified []*ModDrift
+	// mods in the lock file which are not installed
+	Missing []string
+	// mods with no content hash in the lock file (installed before content hashes were recorded)
+	Unrecorded []string
+}
+
+// HasDrift returns whether any installed mod does not match the lock file
+func (r *VerifyResult) HasDrift() bool {
+	return len(r.Modified) > 0 || len(r.Missing) > 0
+}
+
+func (r *VerifyResult) String() string {
+	var b strings.Builder
+	for _, drift := range r.Modified {
+		fmt.Fprintf(&b, "%s: content has changed\n  locked:    %s\n  installed: %s\n", drift.DependencyPath, drift.LockedHash, drift.InstalledHash)
+	}
+	for _, dependencyPath := range r.Missing {
+		fmt.Fprintf(&b, "%s: not installed\n", dependencyPath)
+	}
+	for _, dependencyPath := range r.Unrecorded {
+		fmt.Fprintf(&b, "%s: no content hash recorded - run mod install to record it\n", dependencyPath)
+	}
+	fmt.Fprintf(&b, "%d %s verified", len(r.Verified), utils.Pluralize("mod", len(r.Verified)))
+	return b.String()
+}
+
+// VerifyWorkspaceDependencies rehashes each installed mod and compares it to the content hash recorded in the
+// lock file, reporting any drift
+func VerifyWorkspaceDependencies(ctx context.Context, opts *InstallOpts) (_ *VerifyResult, err error) {
+	utils.LogTime("cmd.VerifyWorkspaceDependencies")
+	defer func() {
+		utils.LogTime("cmd.VerifyWorkspaceDependencies end")
+		if r := recover(); r != nil {
+			err = helpers.ToError(r)
+		}
+	}()
+
+	installer, err := NewModInstaller(opts)
+	if err != nil {
+		return nil, err
+	}
+	return installer.verifyInstalledMods(ctx)
+}
+
+func (i *ModInstaller) verifyInstalledMods(ctx context.Context) (*VerifyResult, error) {
+	lock := i.installData.Lock
+	res := &VerifyResult{}
+
+	// mods which are locked but not installed have been moved to MissingVersions
+	for dependencyPath := range lock.MissingVersions.FlatMap() {
+		res.Missing = append(res.Missing, dependencyPath)
+	}
+
+	for dependencyPath, dep := range lock.InstallCache.FlatMap() {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		// file path dependencies are not installed in the mods directory
+		if dep.FilePath != "" {
+			continue
+		}
+		if dep.ContentHash == "" {
+			res.Unrecorded = append(res.Unrecorded, dependencyPath)
+			continue
+		}
+		installPath := i.getDependencyDestPath(dependencyPath)
+		if !filehelpers.DirectoryExists(installPath) {
+			res.Missing = append(res.Missing, dependencyPath)
+			continue
+		}
+		installedHash, err := versionmap.HashModDirectory(installPath)
+		if err != nil {
+			return nil, err
+		}
+		if installedHash != dep.ContentHash {
+			res.Modified = append(res.Modified, &ModDrift{
+				DependencyPath: dependencyPath,
+				LockedHash:     dep.ContentHash,
+				InstalledHash:  installedHash,
+			})
+			continue
+		}
+		res.Verified = append(res.Verified, dependencyPath)
+	}
+
+	sort.Strings(res.Verified)
+	sort.Strings(res.Missing)
+	sort.Strings(res.Unrecorded)
+	sort.Slice(res.Modified, func(a, b int) bool { return res.Modified[a].DependencyPath < res.Modified[b].DependencyPath })
+	return res, nil
+}
+
+// verifyInstalledContent hashes the mod installed at installPath and, if a hash was recorded in the lock file for
+// this version, verifies it matches - a mismatch means the content of the version has changed since it was locked,
+// e.g. the tag was moved. When updating, the locked hash is not checked, as an update may intentionally reinstall a
+// version which has changed.
+func (i *ModInstaller) verifyInstalledContent(resolvedRef *versionmap.ResolvedVersionConstraint, installPath string) (string, error) {
+	contentHash, err := versionmap.HashModDirectory(installPath)
+	if err != nil {
+		return "", fmt.Errorf("could not hash mod '%s': %w", resolvedRef.DependencyPath(), err)
+	}
+
+	// branches are expected to change
+	if i.updating() || resolvedRef.Branch != "" {
+		return contentHash, nil
+	}
+	if lockedHash := i.installData.Lock.GetLockedContentHash(resolvedRef.DependencyPath()); lockedHash != "" && lockedHash != contentHash {
+		return "", fmt.Errorf("content of mod '%s' does not match the lock file (locked %s, downloaded %s) - the version may have been modified since it was locked. Run 'mod update' to accept the new content", resolvedRef.DependencyPath(), lockedHash, contentHash)
+	}
+	return contentHash, nil
+}
+
+// verifyModSignature verifies the git tag of the mod installed at installPath, if the requirement specifies a signature
+func (i *ModInstaller) verifyModSignature(requiredModVersion *modconfig.ModVersionConstraint, resolvedRef *versionmap.ResolvedVersionConstraint, installPath string) error {
+	if requiredModVersion.Signature == nil {
+		return nil
+	}
+	if resolvedRef.GitRefStr == "" {
+		return fmt.Errorf("cannot verify signature of mod '%s' - it was not installed from a git tag", resolvedRef.DependencyPath())
+	}
+	// a mod installed from a mirror archive has no git history - the signature was verified when the version was
+	// locked, so instead require the content to match the locked content hash (which is checked after installation)
+	if i.mirror != nil && !filehelpers.DirectoryExists(filepath.Join(installPath, ".git")) {
+		if i.installData.Lock.GetLockedContentHash(resolvedRef.DependencyPath()) == "" {
+			return fmt.Errorf("cannot verify signature of mod '%s' - it was installed from a mirror archive and the lock file has no content hash for it", resolvedRef.DependencyPath())
+		}
+		return nil
+	}
+	repo, err := i.openRepo(installPath)
+	if err != nil {
+		return fmt.Errorf("cannot verify signature of mod '%s': %w", resolvedRef.DependencyPath(), err)
+	}
+	if err := verifyTagSignature(repo, plumbing.ReferenceName(resolvedRef.GitRefStr), requiredModVersion.Signature); err != nil {
+		return fmt.Errorf("signature verification failed for mod '%s': %w", resolvedRef.DependencyPath(), err)
+	}
+	return nil
+}
+
+// verifyExisting verifies the signature of an already installed mod, if the requirement specifies a signature, and
+// records its content hash if the lock file has none (i.e. it was installed before content hashes were recorded)
+func (i *ModInstaller) verifyExisting(requiredModVersion *modconfig.ModVersionConstraint, dependencyMod *DependencyMod) error {
+	installedVersion := dependencyMod.InstalledVersion
+	if installedVersion.FilePath != "" {
+		return nil
+	}
+	// the mod may have been installed to the shadow directory by this installation
+	installPath := i.getDependencyShadowPath(installedVersion.DependencyPath())
+	if !filehelpers.DirectoryExists(installPath) {
+		installPath = i.getDependencyDestPath(installedVersion.DependencyPath())
+	}
+
+	if err := i.verifyModSignature(requiredModVersion, installedVersion.ResolvedVersionConstraint, installPath); err != nil {
+		return err
+	}
+
+	if installedVersion.ContentHash == "" {
+		contentHash, err := versionmap.HashModDirectory(installPath)
+		if err != nil {
+			return fmt.Errorf("could not hash mod '%s': %w", installedVersion.DependencyPath(), err)
+		}
+		installedVersion.ContentHash = contentHash
+	}
+	return nil
+}
@@ -0,0 +1,131 @@
+package modinstaller
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/turbot/pipe-fittings/modconfig"
+	"github.com/turbot/pipe-fittings/versionmap"
+)
+
+func installedTestMod(t *testing.T, modsPath, name, version string) (*versionmap.InstalledModVersion, string) {
+	t.Helper()
+	dep := &versionmap.InstalledModVersion{
+		ResolvedVersionConstraint: &versionmap.ResolvedVersionConstraint{
+			DependencyVersion: modconfig.DependencyVersion{Version: semver.MustParse(version)},
+			Name:              name,
+		},
+	}
+	installPath := filepath.Join(modsPath, dep.DependencyPath())
+	for file, content := range map[string]string{"mod.pp": `mod "m" {}`, "queries/q1.pp": `query "q1" {}`, ".git/HEAD": "ref"} {
+		path := filepath.Join(installPath, file)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	contentHash, err := versionmap.HashModDirectory(installPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dep.ContentHash = contentHash
+	return dep, installPath
+}
+
+func TestVerifyInstalledMods(t *testing.T) {
+	modsPath := t.TempDir()
+	unchanged, _ := installedTestMod(t, modsPath, "github.com/turbot/unchanged", "1.0.0")
+	modified, modifiedPath := installedTestMod(t, modsPath, "github.com/turbot/modified", "1.0.0")
+	gitOnly, gitOnlyPath := installedTestMod(t, modsPath, "github.com/turbot/git-only", "1.0.0")
+	deleted, deletedPath := installedTestMod(t, modsPath, "github.com/turbot/deleted", "1.0.0")
+	unrecorded, _ := installedTestMod(t, modsPath, "github.com/turbot/unrecorded", "1.0.0")
+	unrecorded.ContentHash = ""
+
+	if err := os.WriteFile(filepath.Join(modifiedPath, "queries", "q1.pp"), []byte(`query "q1" { sql = "select 2" }`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	// changes to git metadata are not drift
+	if err := os.WriteFile(filepath.Join(gitOnlyPath, ".git", "FETCH_HEAD"), []byte("abc"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.RemoveAll(deletedPath); err != nil {
+		t.Fatal(err)
+	}
+
+	i := &ModInstaller{
+		modsPath: modsPath,
+		installData: &InstallData{Lock: &versionmap.WorkspaceLock{
+			InstallCache: versionmap.InstalledDependencyVersionsMap{
+				"mod.root": {
+					unchanged.Name:  unchanged,
+					modified.Name:   modified,
+					gitOnly.Name:    gitOnly,
+					deleted.Name:    deleted,
+					unrecorded.Name: unrecorded,
+				},
+			},
+		}},
+	}
+	res, err := i.verifyInstalledMods(context.Background())
+	if err != nil {
+		t.Fatalf("verifyInstalledMods() error = %v", err)
+	}
+
+	if got := strings.Join(res.Verified, ","); got != "github.com/turbot/git-only@v1.0.0,github.com/turbot/unchanged@v1.0.0" {
+		t.Errorf("Verified = %s", got)
+	}
+	if len(res.Modified) != 1 || res.Modified[0].DependencyPath != "github.com/turbot/modified@v1.0.0" || res.Modified[0].LockedHash != modified.ContentHash {
+		t.Errorf("Modified = %v", res.Modified)
+	}
+	if got := strings.Join(res.Missing, ","); got != "github.com/turbot/deleted@v1.0.0" {
+		t.Errorf("Missing = %s", got)
+	}
+	if got := strings.Join(res.Unrecorded, ","); got != "github.com/turbot/unrecorded@v1.0.0" {
+		t.Errorf("Unrecorded = %s", got)
+	}
+	if !res.HasDrift() {
+		t.Errorf("HasDrift() = false, want true")
+	}
+}
+
+func TestVerifyInstalledContent(t *testing.T) {
+	modsPath := t.TempDir()
+	locked, installPath := installedTestMod(t, modsPath, "github.com/turbot/m1", "1.0.0")
+
+	newInstaller := func(command string) *ModInstaller {
+		return &ModInstaller{
+			command: command,
+			installData: &InstallData{Lock: &versionmap.WorkspaceLock{
+				InstallCache: versionmap.InstalledDependencyVersionsMap{},
+				// the locked version has been deleted, so is missing
+				MissingVersions: versionmap.InstalledDependencyVersionsMap{"mod.root": {locked.Name: locked}},
+			}},
+		}
+	}
+
+	// reinstalling the locked content succeeds
+	contentHash, err := newInstaller("install").verifyInstalledContent(locked.ResolvedVersionConstraint, installPath)
+	if err != nil || contentHash != locked.ContentHash {
+		t.Fatalf("verifyInstalledContent() = %s, %v, want %s", contentHash, err, locked.ContentHash)
+	}
+
+	// the tag was moved, so the content differs
+	if err := os.WriteFile(filepath.Join(installPath, "mod.pp"), []byte(`mod "m" { title = "moved" }`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := newInstaller("install").verifyInstalledContent(locked.ResolvedVersionConstraint, installPath); err == nil || !strings.Contains(err.Error(), "does not match the lock file") {
+		t.Errorf("verifyInstalledContent() error = %v, want lock file mismatch", err)
+	}
+
+	// updating accepts the new content
+	contentHash, err = newInstaller("update").verifyInstalledContent(locked.ResolvedVersionConstraint, installPath)
+	if err != nil || contentHash == locked.ContentHash {
+		t.Errorf("verifyInstalledContent() when updating = %s, %v", contentHash, err)
+	}
+}
@@ -4,4 +4,5 @@ type ImageType string
 
 const (
 	ImageTypePlugin ImageType = "plugin"
+	ImageTypeMod    ImageType = "mod"
 )
@@ -0,0 +1,343 @@
+package ociinstaller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/turbot/pipe-fittings/statushooks"
+	"golang.org/x/time/rate"
+	"oras.land/oras-go/v2"
+	"oras.land/oras-go/v2/registry/remote"
+)
+
+const (
+	downloadChunkSize = 32 * 1024
+	// the number of layers of an image downloaded concurrently, unless configured otherwise
+	defaultDownloadConcurrency = 3
+	// the number of times an interrupted layer download is resumed before failing, unless configured otherwise
+	defaultDownloadRetries = 3
+)
+
+// DownloadManager downloads the layers of images to a cache directory before they are copied to their destination.
+//
+// Layers are downloaded to a partial file named after their digest, so a download which is interrupted, either
+// by a network error or by the process exiting, resumes from where it stopped rather than starting again (provided
+// the source supports ranged requests). Progress of each layer is reported using the statushooks.DownloadProgress in
+// the context. A bandwidth limit applies to all downloads made by the manager.
+type DownloadManager struct {
+	cacheDir    string
+	concurrency int
+	retries     int
+	limiter     *rate.Limiter
+	// a lock per digest, so a blob required by several concurrent downloads is only downloaded once - locks are
+	// removed once no download holds or is waiting for them
+	blobLocksMut sync.Mutex
+	blobLocks    map[digest.Digest]*blobLock
+}
+
+// blobLock is the lock of a blob, counting the downloads which hold or are waiting for it
+type blobLock struct {
+	sync.Mutex
+	refs int
+}
+
+type DownloadManagerOption = func(*DownloadManager)
+
+// WithDownloadConcurrency sets the number of layers of an image downloaded concurrently
+func WithDownloadConcurrency(concurrency int) DownloadManagerOption {
+	return func(m *DownloadManager) {
+		m.concurrency = concurrency
+	}
+}
+
+// WithDownloadRetries sets the number of times an interrupted layer download is resumed before failing
+func WithDownloadRetries(retries int) DownloadManagerOption {
+	return func(m *DownloadManager) {
+		m.retries = retries
+	}
+}
+
+// WithBandwidthLimit limits the total download rate of the manager - zero means unlimited
+func WithBandwidthLimit(bytesPerSecond int) DownloadManagerOption {
+	return func(m *DownloadManager) {
+		if bytesPerSecond <= 0 {
+			m.limiter = nil
+			return
+		}
+		m.limiter = rate.NewLimiter(rate.Limit(bytesPerSecond), downloadChunkSize)
+	}
+}
+
+// NewDownloadManager creates a DownloadManager which downloads layers to cacheDir
+func NewDownloadManager(cacheDir string, opts ...DownloadManagerOption) *DownloadManager {
+	m := &DownloadManager{
+		cacheDir:    cacheDir,
+		concurrency: defaultDownloadConcurrency,
+		retries:     defaultDownloadRetries,
+		blobLocks:   make(map[digest.Digest]*blobLock),
+	}
+	for _, opt := range opts {
+		opt(m)
+	}
+	return m
+}
+
+// target returns a target which fetches the layers of the named image from src through the cache
+func (m *DownloadManager) target(src oras.ReadOnlyTarget, image string) *cachingTarget {
+	return &cachingTarget{
+		ReadOnlyTarget: src,
+		manager:        m,
+		image:          image,
+	}
+}
+
+// download downloads the blob to the cache, resuming any partial download, and returns the path of the blob
+func (m *DownloadManager) download(ctx context.Context, src oras.ReadOnlyTarget, image string, desc ocispec.Descriptor) (string, error) {
+	if err := desc.Digest.Validate(); err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(m.cacheDir, 0755); err != nil {
+		return "", err
+	}
+	unlock := m.lockBlob(desc.Digest)
+	defer unlock()
+
+	blobPath := filepath.Join(m.cacheDir, desc.Digest.Algorithm().String()+"-"+desc.Digest.Encoded())
+	event := statushooks.DownloadProgressEvent{
+		Image:  image,
+		Layer:  layerName(desc),
+		Digest: string(desc.Digest),
+		Total:  desc.Size,
+	}
+
+	// the blob may have been downloaded by a previous pull which failed on a later layer - it is verified as the
+	// cache directory may have been modified since
+	if info, err := os.Stat(blobPath); err == nil && info.Size() == desc.Size {
+		if err := verifyBlob(blobPath, desc); err == nil {
+			event.Completed, event.Resumed, event.Done = desc.Size, desc.Size, true
+			statushooks.UpdateDownloadProgress(ctx, event)
+			return blobPath, nil
+		}
+		log.Printf("[TRACE] DownloadManager: cached blob %s is corrupt - downloading it again", blobPath)
+		_ = os.Remove(blobPath)
+	}
+
+	partialPath := blobPath + ".partial"
+	var err error
+	for attempt := 0; attempt <= m.retries; attempt++ {
+		if err = m.downloadPartial(ctx, src, desc, partialPath, event); err == nil {
+			break
+		}
+		if ctx.Err() != nil {
+			return "", err
+		}
+		log.Printf("[TRACE] DownloadManager: download of %s %s failed (attempt %d): %s", image, desc.Digest, attempt+1, err)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to download %s layer %s: %w", image, layerName(desc), err)
+	}
+
+	if err := verifyBlob(partialPath, desc); err != nil {
+		// the partial file is unusable - remove it so the next attempt starts again
+		_ = os.Remove(partialPath)
+		return "", fmt.Errorf("failed to download %s layer %s: %w", image, layerName(desc), err)
+	}
+	if err := os.Rename(partialPath, blobPath); err != nil {
+		return "", err
+	}
+	event.Completed, event.Done = desc.Size, true
+	statushooks.UpdateDownloadProgress(ctx, event)
+	return blobPath, nil
+}
+
+// lockBlob locks the blob with the given digest, returning a function which unlocks it
+func (m *DownloadManager) lockBlob(d digest.Digest) func() {
+	m.blobLocksMut.Lock()
+	lock, ok := m.blobLocks[d]
+	if !ok {
+		lock = &blobLock{}
+		m.blobLocks[d] = lock
+	}
+	lock.refs++
+	m.blobLocksMut.Unlock()
+
+	lock.Lock()
+	return func() {
+		lock.Unlock()
+		m.blobLocksMut.Lock()
+		defer m.blobLocksMut.Unlock()
+		lock.refs--
+		if lock.refs == 0 {
+			delete(m.blobLocks, d)
+		}
+	}
+}
+
+// downloadPartial downloads the remainder of the blob to the partial file
+func (m *DownloadManager) downloadPartial(ctx context.Context, src oras.ReadOnlyTarget, desc ocispec.Descriptor, partialPath string, event statushooks.DownloadProgressEvent) error {
+	f, err := os.OpenFile(partialPath, os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	offset := info.Size()
+	if offset == desc.Size {
+		// the download completed but was not verified
+		return nil
+	}
+	if offset > desc.Size {
+		offset = 0
+	}
+
+	rc, err := src.Fetch(ctx, desc)
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+
+	if offset > 0 {
+		if seeker, ok := rc.(io.Seeker); ok {
+			if _, err := seeker.Seek(offset, io.SeekStart); err != nil {
+				offset = 0
+			}
+		} else {
+			// the source does not support ranged requests so the blob must be downloaded in full
+			offset = 0
+		}
+	}
+	if err := f.Truncate(offset); err != nil {
+		return err
+	}
+	if _, err := f.Seek(offset, io.SeekStart); err != nil {
+		return err
+	}
+
+	event.Completed, event.Resumed = offset, offset
+	statushooks.UpdateDownloadProgress(ctx, event)
+
+	buf := make([]byte, downloadChunkSize)
+	for event.Completed < desc.Size {
+		n, readErr := rc.Read(buf)
+		if n > 0 {
+			// wait for the bytes actually read - a read may return less than a full chunk
+			if m.limiter != nil {
+				if err := m.limiter.WaitN(ctx, n); err != nil {
+					return err
+				}
+			}
+			if _, err := f.Write(buf[:n]); err != nil {
+				return err
+			}
+			event.Completed += int64(n)
+			statushooks.UpdateDownloadProgress(ctx, event)
+		}
+		if readErr == io.EOF {
+			break
+		}
+		if readErr != nil {
+			return readErr
+		}
+	}
+	if event.Completed != desc.Size {
+		return fmt.Errorf("downloaded %d of %d bytes", event.Completed, desc.Size)
+	}
+	return nil
+}
+
+// verifyBlob checks the content of the downloaded blob matches its digest
+func verifyBlob(path string, desc ocispec.Descriptor) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	verifier := desc.Digest.Verifier()
+	if _, err := io.Copy(verifier, f); err != nil {
+		return err
+	}
+	if !verifier.Verified() {
+		return fmt.Errorf("digest mismatch - expected %s", desc.Digest)
+	}
+	return nil
+}
+
+// cachingTarget is a read only target which fetches layers through the download manager cache - manifests are
+// fetched from the source target directly
+type cachingTarget struct {
+	oras.ReadOnlyTarget
+	manager *DownloadManager
+	image   string
+
+	mut sync.Mutex
+	// the cached blobs fetched by the target
+	blobPaths []string
+}
+
+func (t *cachingTarget) Fetch(ctx context.Context, desc ocispec.Descriptor) (io.ReadCloser, error) {
+	if isManifestMediaType(desc.MediaType) || desc.MediaType == ocispec.MediaTypeEmptyJSON {
+		return t.ReadOnlyTarget.Fetch(ctx, desc)
+	}
+	blobPath, err := t.manager.download(ctx, t.ReadOnlyTarget, t.image, desc)
+	if err != nil {
+		return nil, err
+	}
+	t.mut.Lock()
+	t.blobPaths = append(t.blobPaths, blobPath)
+	t.mut.Unlock()
+	return os.Open(blobPath)
+}
+
+// removeCachedBlobs removes the cached blobs fetched by the target - this is called once the image has been
+// copied to its destination, so they are no longer needed to resume the download
+func (t *cachingTarget) removeCachedBlobs() {
+	t.mut.Lock()
+	defer t.mut.Unlock()
+	for _, blobPath := range t.blobPaths {
+		if err := os.Remove(blobPath); err != nil && !errors.Is(err, os.ErrNotExist) {
+			log.Printf("[TRACE] DownloadManager: failed to remove cached blob %s: %s", blobPath, err)
+		}
+	}
+	t.blobPaths = nil
+}
+
+func isManifestMediaType(mediaType string) bool {
+	switch mediaType {
+	case ocispec.MediaTypeImageManifest, ocispec.MediaTypeImageIndex,
+		"application/vnd.docker.distribution.manifest.v2+json", "application/vnd.docker.distribution.manifest.list.v2+json":
+		return true
+	}
+	return false
+}
+
+// layerName returns the title of the layer, or its digest if it has no title
+func layerName(desc ocispec.Descriptor) string {
+	if title := desc.Annotations[ocispec.AnnotationTitle]; title != "" {
+		return title
+	}
+	return string(desc.Digest)
+}
+
+// imageName returns the name of the image with the given reference in the source target, for progress events
+func imageName(src oras.ReadOnlyTarget, reference string) string {
+	if repo, ok := src.(*remote.Repository); ok {
+		if _, err := digest.Parse(reference); err == nil {
+			return repo.Reference.Registry + "/" + repo.Reference.Repository + "@" + reference
+		}
+		return repo.Reference.Registry + "/" + repo.Reference.Repository + ":" + reference
+	}
+	return reference
+}
@@ -0,0 +1,273 @@
+package ociinstaller
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/turbot/pipe-fittings/statushooks"
+	"oras.land/oras-go/v2"
+	"oras.land/oras-go/v2/content/oci"
+)
+
+// recordingProgress records download progress events
+type recordingProgress struct {
+	mut    sync.Mutex
+	events []statushooks.DownloadProgressEvent
+}
+
+func (p *recordingProgress) UpdateDownloadProgress(_ context.Context, event statushooks.DownloadProgressEvent) {
+	p.mut.Lock()
+	defer p.mut.Unlock()
+	p.events = append(p.events, event)
+}
+
+// flakyTarget fails the first read of each blob after failAfter bytes, and counts the bytes read
+type flakyTarget struct {
+	oras.ReadOnlyTarget
+	failAfter int64
+	mut       sync.Mutex
+	failed    map[string]bool
+	bytesRead int64
+}
+
+func (t *flakyTarget) Fetch(ctx context.Context, desc ocispec.Descriptor) (io.ReadCloser, error) {
+	rc, err := t.ReadOnlyTarget.Fetch(ctx, desc)
+	if err != nil {
+		return nil, err
+	}
+	t.mut.Lock()
+	defer t.mut.Unlock()
+	fail := t.failAfter > 0 && !t.failed[string(desc.Digest)]
+	if t.failed == nil {
+		t.failed = make(map[string]bool)
+	}
+	t.failed[string(desc.Digest)] = true
+	return &flakyReader{ReadCloser: rc, target: t, remaining: t.failAfter, fail: fail}, nil
+}
+
+type flakyReader struct {
+	io.ReadCloser
+	target    *flakyTarget
+	remaining int64
+	fail      bool
+}
+
+func (r *flakyReader) Read(p []byte) (int, error) {
+	if r.fail {
+		if r.remaining <= 0 {
+			return 0, errors.New("connection reset")
+		}
+		if int64(len(p)) > r.remaining {
+			p = p[:r.remaining]
+		}
+	}
+	n, err := r.ReadCloser.Read(p)
+	r.remaining -= int64(n)
+	r.target.mut.Lock()
+	r.target.bytesRead += int64(n)
+	r.target.mut.Unlock()
+	return n, err
+}
+
+func (r *flakyReader) Seek(offset int64, whence int) (int64, error) {
+	return r.ReadCloser.(io.Seeker).Seek(offset, whence)
+}
+
+func pushTestBlob(t *testing.T, ctx context.Context, target oras.Target, size int) ([]byte, ocispec.Descriptor) {
+	t.Helper()
+	data := make([]byte, size)
+	if _, err := rand.Read(data); err != nil {
+		t.Fatal(err)
+	}
+	desc, err := pushBlob(ctx, target, "application/octet-stream", data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data, desc
+}
+
+func TestDownloadManager_Resume(t *testing.T) {
+	repo, err := oci.New(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	progress := &recordingProgress{}
+	ctx := statushooks.AddDownloadProgressToContext(context.Background(), progress)
+	data, desc := pushTestBlob(t, ctx, repo, 100*1024)
+
+	// a previous download was interrupted after 40KB
+	cacheDir := t.TempDir()
+	partialPath := filepath.Join(cacheDir, "sha256-"+desc.Digest.Encoded()+".partial")
+	if err := os.WriteFile(partialPath, data[:40*1024], 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	src := &flakyTarget{ReadOnlyTarget: repo}
+	m := NewDownloadManager(cacheDir)
+	blobPath, err := m.download(ctx, src, "test/image", desc)
+	if err != nil {
+		t.Fatalf("download() error = %v", err)
+	}
+	if got, _ := os.ReadFile(blobPath); !bytes.Equal(got, data) {
+		t.Error("download() content does not match the blob")
+	}
+	if src.bytesRead != int64(len(data)-40*1024) {
+		t.Errorf("download() read %d bytes from the source, want %d", src.bytesRead, len(data)-40*1024)
+	}
+	if _, err := os.Stat(partialPath); !os.IsNotExist(err) {
+		t.Error("download() did not remove the partial file")
+	}
+
+	first, last := progress.events[0], progress.events[len(progress.events)-1]
+	if first.Resumed != 40*1024 || first.Total != desc.Size {
+		t.Errorf("first progress event = %+v, want resumed 40KB", first)
+	}
+	if !last.Done || last.Completed != desc.Size {
+		t.Errorf("last progress event = %+v, want done", last)
+	}
+}
+
+func TestDownloadManager_Retry(t *testing.T) {
+	ctx := context.Background()
+	repo, err := oci.New(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, desc := pushTestBlob(t, ctx, repo, 100*1024)
+
+	// the connection drops after 50KB - the retry resumes from there
+	src := &flakyTarget{ReadOnlyTarget: repo, failAfter: 50 * 1024}
+	blobPath, err := NewDownloadManager(t.TempDir()).download(ctx, src, "test/image", desc)
+	if err != nil {
+		t.Fatalf("download() error = %v", err)
+	}
+	if got, _ := os.ReadFile(blobPath); !bytes.Equal(got, data) {
+		t.Error("download() content does not match the blob")
+	}
+	if src.bytesRead != int64(len(data)) {
+		t.Errorf("download() read %d bytes from the source, want %d", src.bytesRead, len(data))
+	}
+
+	// with no retries the download fails, leaving the partial file to resume from
+	cacheDir := t.TempDir()
+	src = &flakyTarget{ReadOnlyTarget: repo, failAfter: 50 * 1024}
+	if _, err := NewDownloadManager(cacheDir, WithDownloadRetries(0)).download(ctx, src, "test/image", desc); err == nil {
+		t.Fatal("download() with no retries expected error")
+	}
+	if info, err := os.Stat(filepath.Join(cacheDir, "sha256-"+desc.Digest.Encoded()+".partial")); err != nil || info.Size() != 50*1024 {
+		t.Errorf("partial file = %v, %v, want 50KB", info, err)
+	}
+}
+
+func TestDownloadManager_CachedBlob(t *testing.T) {
+	ctx := context.Background()
+	repo, err := oci.New(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, desc := pushTestBlob(t, ctx, repo, 64*1024)
+
+	// a cached blob of the right size but the wrong content is downloaded again
+	cacheDir := t.TempDir()
+	blobPath := filepath.Join(cacheDir, "sha256-"+desc.Digest.Encoded())
+	if err := os.WriteFile(blobPath, make([]byte, len(data)), 0644); err != nil {
+		t.Fatal(err)
+	}
+	src := &flakyTarget{ReadOnlyTarget: repo}
+	m := NewDownloadManager(cacheDir)
+	if _, err := m.download(ctx, src, "test/image", desc); err != nil {
+		t.Fatalf("download() error = %v", err)
+	}
+	if got, _ := os.ReadFile(blobPath); !bytes.Equal(got, data) {
+		t.Error("download() reused a corrupt cached blob")
+	}
+	if src.bytesRead != int64(len(data)) {
+		t.Errorf("download() read %d bytes from the source, want %d", src.bytesRead, len(data))
+	}
+
+	// a valid cached blob is reused, and concurrent downloads of it leave no locks behind
+	src = &flakyTarget{ReadOnlyTarget: repo}
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := m.download(ctx, src, "test/image", desc); err != nil {
+				t.Errorf("download() error = %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+	if src.bytesRead != 0 {
+		t.Errorf("download() read %d bytes from the source for a cached blob, want 0", src.bytesRead)
+	}
+	if len(m.blobLocks) != 0 {
+		t.Errorf("download() left %d blob locks, want 0", len(m.blobLocks))
+	}
+}
+
+func TestDownloadManager_BandwidthLimit(t *testing.T) {
+	ctx := context.Background()
+	repo, err := oci.New(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, desc := pushTestBlob(t, ctx, repo, 96*1024)
+
+	// the first 32KB chunk is allowed immediately, the remaining 64KB takes a second at 64KB/s
+	start := time.Now()
+	if _, err := NewDownloadManager(t.TempDir(), WithBandwidthLimit(64*1024)).download(ctx, repo, "test/image", desc); err != nil {
+		t.Fatalf("download() error = %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < 800*time.Millisecond {
+		t.Errorf("download() took %s, want at least 800ms", elapsed)
+	}
+}
+
+func TestDownloadManager_Pull(t *testing.T) {
+	repo, err := oci.New(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	progress := &recordingProgress{}
+	ctx := statushooks.AddDownloadProgressToContext(context.Background(), progress)
+
+	files := map[string]string{"mod.pp": `mod "m1" {}`}
+	if _, err := PushMod(ctx, repo, writeTestMod(t, files), "1.0.0", &ModImageConfig{}); err != nil {
+		t.Fatal(err)
+	}
+
+	cacheDir := t.TempDir()
+	downloader := NewModOciDownloader()
+	downloader.DownloadManager = NewDownloadManager(cacheDir)
+	destDir := t.TempDir()
+	image, err := downloader.DownloadFrom(ctx, repo, "1.0.0", destDir)
+	if err != nil {
+		t.Fatalf("DownloadFrom() error = %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(destDir, image.Data.ArchiveFile)); err != nil {
+		t.Errorf("DownloadFrom() did not download the mod archive: %v", err)
+	}
+	if entries, _ := os.ReadDir(cacheDir); len(entries) != 0 {
+		t.Errorf("DownloadFrom() left %d files in the download cache", len(entries))
+	}
+
+	var archiveDone bool
+	for _, event := range progress.events {
+		if event.Layer == ModArchiveFileName && event.Done {
+			archiveDone = true
+		}
+	}
+	if !archiveDone {
+		t.Errorf("no progress event for the mod archive: %+v", progress.events)
+	}
+}
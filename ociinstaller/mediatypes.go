@@ -25,3 +25,11 @@ func MediaTypePluginDocsLayer() string {
 func MediaTypePluginSpcLayer() string {
 	return fmt.Sprintf("application/vnd.turbot.%s.plugin.spc.layer.v1+tar", app_specific.AppName)
 }
+
+func MediaTypeModConfig() string {
+	return fmt.Sprintf("application/vnd.turbot.%s.mod.config.v1+json", app_specific.AppName)
+}
+
+func MediaTypeModLayer() string {
+	return fmt.Sprintf("application/vnd.turbot.%s.mod.layer.v1.tar+gzip", app_specific.AppName)
+}
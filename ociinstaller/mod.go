@@ -0,0 +1,54 @@
+package ociinstaller
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+
+	"oras.land/oras-go/v2"
+	"oras.land/oras-go/v2/registry"
+)
+
+// ModRepository is a repository of mod images - either a remote registry repository or a local store,
+// e.g. an OCI layout directory mirrored from a registry
+type ModRepository interface {
+	oras.ReadOnlyTarget
+	registry.TagLister
+}
+
+// NewModRepository connects to the remote registry repository with the given ref (e.g. ghcr.io/turbot/mod),
+// using credentials from the docker credentials store
+func NewModRepository(ref string) (ModRepository, error) {
+	return newRemoteRepository(ref, "")
+}
+
+// ListModTags returns all tags of the mod repository
+func ListModTags(ctx context.Context, repo ModRepository) ([]string, error) {
+	return registry.Tags(ctx, repo)
+}
+
+// InstallMod downloads the mod image with the given reference (a tag or digest) from the repository and extracts
+// the mod to destDir
+func InstallMod(ctx context.Context, repo ModRepository, reference string, destDir string) (*OciImage[*ModImage, *ModImageConfig], error) {
+	tempDir, err := os.MkdirTemp("", "mod_oci")
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err := os.RemoveAll(tempDir); err != nil {
+			log.Printf("[TRACE] Failed to delete temp dir '%s' after installing mod: %s", tempDir, err)
+		}
+	}()
+
+	image, err := NewModOciDownloader().DownloadFrom(ctx, repo, reference, tempDir)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := ExtractModArchive(filepath.Join(tempDir, image.Data.ArchiveFile), destDir); err != nil {
+		return nil, fmt.Errorf("mod installation failed: %s", err)
+	}
+	return image, nil
+}
@@ -0,0 +1,10 @@
+package ociinstaller
+
+type ModImage struct {
+	ArchiveFile   string
+	ArchiveDigest string
+}
+
+func (s *ModImage) Type() ImageType {
+	return ImageTypeMod
+}
@@ -0,0 +1,88 @@
+package ociinstaller
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"oras.land/oras-go/v2"
+)
+
+// modMediaTypeProvider provides the media types of a mod image - mods are not platform specific, so the only layer
+// is the mod archive
+type modMediaTypeProvider struct{}
+
+func (modMediaTypeProvider) MediaTypeForPlatform(ImageType) ([]string, error) {
+	return []string{MediaTypeModLayer()}, nil
+}
+
+func (modMediaTypeProvider) SharedMediaTypes(ImageType) []string {
+	return nil
+}
+
+func (modMediaTypeProvider) ConfigMediaTypes() []string {
+	return []string{MediaTypeModConfig()}
+}
+
+type ModOciDownloader struct {
+	OciDownloader[*ModImage, *ModImageConfig]
+}
+
+func (p *ModOciDownloader) EmptyConfig() *ModImageConfig {
+	return &ModImageConfig{}
+}
+
+func NewModOciDownloader() *ModOciDownloader {
+	res := &ModOciDownloader{}
+
+	// create the base downloader, passing res as the image provider
+	ociDownloader := NewOciDownloader[*ModImage, *ModImageConfig]("", modMediaTypeProvider{}, res)
+
+	res.OciDownloader = *ociDownloader
+
+	return res
+}
+
+func (p *ModOciDownloader) GetImageData(layers []ocispec.Descriptor) (*ModImage, error) {
+	foundLayers := FindLayersForMediaType(layers, MediaTypeModLayer())
+	if len(foundLayers) != 1 {
+		return nil, fmt.Errorf("invalid mod image - should contain 1 mod archive, found %d", len(foundLayers))
+	}
+	if foundLayers[0].Annotations[ocispec.AnnotationTitle] == "" {
+		return nil, fmt.Errorf("invalid mod image - mod archive has no title annotation")
+	}
+	return &ModImage{
+		ArchiveFile:   foundLayers[0].Annotations[ocispec.AnnotationTitle],
+		ArchiveDigest: string(foundLayers[0].Digest),
+	}, nil
+}
+
+// DownloadFrom downloads the mod image with the given reference (a tag or digest) from the repository to destDir
+func (p *ModOciDownloader) DownloadFrom(ctx context.Context, repo oras.ReadOnlyTarget, reference string, destDir string) (*OciImage[*ModImage, *ModImageConfig], error) {
+	image := p.newOciImage()
+
+	imageDesc, configDesc, configBytes, layers, err := p.PullFrom(ctx, repo, reference, destDir)
+	if err != nil {
+		return nil, err
+	}
+	if configDesc.MediaType != MediaTypeModConfig() {
+		return nil, fmt.Errorf("'%s' is not a mod image - config media type is %s", reference, configDesc.MediaType)
+	}
+	image.OCIDescriptor = imageDesc
+
+	// unmarshal the config
+	image.Config = p.EmptyConfig()
+	if err := json.Unmarshal(configBytes, image.Config); err != nil {
+		return nil, err
+	}
+	if image.Config.GetSchemaVersion() == "" {
+		image.Config.SetSchemaVersion(DefaultConfigSchema)
+	}
+
+	image.Data, err = p.GetImageData(layers)
+	if err != nil {
+		return nil, err
+	}
+	return image, nil
+}
@@ -0,0 +1,207 @@
+package ociinstaller
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/turbot/pipe-fittings/app_specific"
+	"oras.land/oras-go/v2"
+)
+
+// ModArchiveFileName is the title of the mod archive layer of a mod image
+const ModArchiveFileName = "mod.tar.gz"
+
+// PackageMod writes a gzipped tar archive of the mod in modDir to w - this is the layer of a mod image
+//
+// The git directory and the workspace data directory (which contains the installed dependencies of the mod) are
+// excluded. File modification times and ownership are not included, so packaging the same files always produces the
+// same archive.
+func PackageMod(modDir string, w io.Writer) error {
+	gzipWriter := gzip.NewWriter(w)
+	tarWriter := tar.NewWriter(gzipWriter)
+
+	err := filepath.Walk(modDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		relPath, err := filepath.Rel(modDir, path)
+		if err != nil {
+			return err
+		}
+		if relPath == "." {
+			return nil
+		}
+		if info.IsDir() && isExcludedModDir(relPath) {
+			return filepath.SkipDir
+		}
+		// only directories and regular files are packaged
+		if !info.IsDir() && !info.Mode().IsRegular() {
+			return nil
+		}
+
+		header := &tar.Header{
+			Name: filepath.ToSlash(relPath),
+			Mode: int64(info.Mode().Perm()),
+		}
+		if info.IsDir() {
+			header.Typeflag = tar.TypeDir
+			header.Name += "/"
+			return tarWriter.WriteHeader(header)
+		}
+
+		header.Typeflag = tar.TypeReg
+		header.Size = info.Size()
+		if err := tarWriter.WriteHeader(header); err != nil {
+			return err
+		}
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		_, err = io.Copy(tarWriter, f)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("failed to package mod '%s': %w", modDir, err)
+	}
+
+	if err := tarWriter.Close(); err != nil {
+		return err
+	}
+	return gzipWriter.Close()
+}
+
+func isExcludedModDir(relPath string) bool {
+	return relPath == ".git" || (app_specific.WorkspaceDataDir != "" && relPath == app_specific.WorkspaceDataDir)
+}
+
+// PushMod packages the mod in modDir and pushes it to the target as a mod image, tagged with tag
+// (which should be the mod version)
+func PushMod(ctx context.Context, target oras.Target, modDir string, tag string, config *ModImageConfig) (ocispec.Descriptor, error) {
+	var archive bytes.Buffer
+	if err := PackageMod(modDir, &archive); err != nil {
+		return ocispec.Descriptor{}, err
+	}
+	layerDesc, err := pushBlob(ctx, target, MediaTypeModLayer(), archive.Bytes())
+	if err != nil {
+		return ocispec.Descriptor{}, err
+	}
+	layerDesc.Annotations = map[string]string{
+		ocispec.AnnotationTitle: ModArchiveFileName,
+	}
+
+	if config.GetSchemaVersion() == "" {
+		config.SetSchemaVersion(DefaultConfigSchema)
+	}
+	configBytes, err := json.Marshal(config)
+	if err != nil {
+		return ocispec.Descriptor{}, err
+	}
+	configDesc, err := pushBlob(ctx, target, MediaTypeModConfig(), configBytes)
+	if err != nil {
+		return ocispec.Descriptor{}, err
+	}
+
+	manifestDesc, err := oras.PackManifest(ctx, target, oras.PackManifestVersion1_1, "", oras.PackManifestOptions{
+		ConfigDescriptor: &configDesc,
+		Layers:           []ocispec.Descriptor{layerDesc},
+	})
+	if err != nil {
+		return ocispec.Descriptor{}, err
+	}
+	if err := target.Tag(ctx, manifestDesc, tag); err != nil {
+		return ocispec.Descriptor{}, err
+	}
+	return manifestDesc, nil
+}
+
+func pushBlob(ctx context.Context, target oras.Target, mediaType string, data []byte) (ocispec.Descriptor, error) {
+	desc := ocispec.Descriptor{
+		MediaType: mediaType,
+		Digest:    digest.FromBytes(data),
+		Size:      int64(len(data)),
+	}
+	if exists, err := target.Exists(ctx, desc); err != nil || exists {
+		return desc, err
+	}
+	return desc, target.Push(ctx, desc, bytes.NewReader(data))
+}
+
+// ExtractModArchive extracts a mod archive created by PackageMod to destDir
+func ExtractModArchive(archivePath string, destDir string) error {
+	f, err := os.Open(archivePath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	gzipReader, err := gzip.NewReader(f)
+	if err != nil {
+		return err
+	}
+	defer gzipReader.Close()
+
+	// Limit the amount of data being extracted to prevent decompression bombs
+	limitedReader := &io.LimitedReader{R: gzipReader, N: defaultMaxDecompressedSize}
+	tarReader := tar.NewReader(limitedReader)
+
+	if err := os.MkdirAll(destDir, 0755); err != nil {
+		return err
+	}
+	for {
+		header, err := tarReader.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+
+		// do not allow entries to be written outside destDir
+		name := filepath.FromSlash(header.Name)
+		if !filepath.IsLocal(name) {
+			return fmt.Errorf("invalid path '%s' in mod archive", header.Name)
+		}
+		destPath := filepath.Join(destDir, name)
+
+		switch header.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(destPath, 0755); err != nil {
+				return err
+			}
+		case tar.TypeReg:
+			if err := extractModFile(tarReader, destPath, os.FileMode(header.Mode).Perm()); err != nil {
+				return err
+			}
+		default:
+			return fmt.Errorf("unsupported entry '%s' in mod archive", header.Name)
+		}
+		if limitedReader.N <= 0 {
+			return fmt.Errorf("mod archive exceeds the limit of %d bytes", defaultMaxDecompressedSize)
+		}
+	}
+	return nil
+}
+
+func extractModFile(r io.Reader, destPath string, mode os.FileMode) error {
+	if err := os.MkdirAll(filepath.Dir(destPath), 0755); err != nil {
+		return err
+	}
+	outFile, err := os.OpenFile(destPath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, mode)
+	if err != nil {
+		return err
+	}
+	defer outFile.Close()
+	_, err = io.Copy(outFile, r)
+	return err
+}
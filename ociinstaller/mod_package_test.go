@@ -0,0 +1,173 @@
+package ociinstaller
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"oras.land/oras-go/v2/content/oci"
+)
+
+func writeTestMod(t *testing.T, files map[string]string) string {
+	t.Helper()
+	modDir := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(modDir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return modDir
+}
+
+func readTestMod(t *testing.T, dir string) map[string]string {
+	t.Helper()
+	res := make(map[string]string)
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		relPath, _ := filepath.Rel(dir, path)
+		res[filepath.ToSlash(relPath)] = string(content)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return res
+}
+
+func TestPackageMod(t *testing.T) {
+	modDir := writeTestMod(t, map[string]string{
+		"mod.pp":          `mod "m1" {}`,
+		"queries/q1.pp":   `query "q1" {}`,
+		".git/HEAD":       "ref: refs/heads/main",
+		"docs/readme.md":  "# m1",
+		"queries/q2.sql":  "select 1",
+		"nested/.git/foo": "bar",
+	})
+
+	var first, second bytes.Buffer
+	if err := PackageMod(modDir, &first); err != nil {
+		t.Fatalf("PackageMod() error = %v", err)
+	}
+	if err := PackageMod(modDir, &second); err != nil {
+		t.Fatalf("PackageMod() error = %v", err)
+	}
+	if !bytes.Equal(first.Bytes(), second.Bytes()) {
+		t.Errorf("PackageMod() produced different archives for the same mod")
+	}
+
+	gzipReader, err := gzip.NewReader(&first)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tarReader := tar.NewReader(gzipReader)
+	var got []string
+	for {
+		header, err := tarReader.Next()
+		if err != nil {
+			break
+		}
+		if header.Typeflag == tar.TypeReg {
+			got = append(got, header.Name)
+		}
+	}
+	sort.Strings(got)
+	want := []string{"docs/readme.md", "mod.pp", "nested/.git/foo", "queries/q1.pp", "queries/q2.sql"}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("PackageMod() files = %v, want %v", got, want)
+	}
+}
+
+func TestPushAndInstallMod(t *testing.T) {
+	ctx := context.Background()
+	// a local OCI layout stands in for the registry
+	repo, err := oci.New(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	files := map[string]string{
+		"mod.pp":        `mod "m1" {}`,
+		"queries/q1.pp": `query "q1" {}`,
+	}
+	config := &ModImageConfig{}
+	config.Mod.Name = "m1"
+	config.Mod.Version = "1.0.0"
+	manifestDesc, err := PushMod(ctx, repo, writeTestMod(t, files), "1.0.0", config)
+	if err != nil {
+		t.Fatalf("PushMod() error = %v", err)
+	}
+	if _, err := PushMod(ctx, repo, writeTestMod(t, map[string]string{"mod.pp": `mod "m1" {}`}), "v1.1.0", &ModImageConfig{}); err != nil {
+		t.Fatalf("PushMod() error = %v", err)
+	}
+
+	tags, err := ListModTags(ctx, repo)
+	if err != nil {
+		t.Fatalf("ListModTags() error = %v", err)
+	}
+	sort.Strings(tags)
+	if strings.Join(tags, ",") != "1.0.0,v1.1.0" {
+		t.Errorf("ListModTags() = %v, want [1.0.0 v1.1.0]", tags)
+	}
+
+	// install by tag and by digest
+	for _, reference := range []string{"1.0.0", manifestDesc.Digest.String()} {
+		destDir := filepath.Join(t.TempDir(), "m1")
+		image, err := InstallMod(ctx, repo, reference, destDir)
+		if err != nil {
+			t.Fatalf("InstallMod(%s) error = %v", reference, err)
+		}
+		if image.OCIDescriptor.Digest != manifestDesc.Digest {
+			t.Errorf("InstallMod(%s) digest = %s, want %s", reference, image.OCIDescriptor.Digest, manifestDesc.Digest)
+		}
+		if image.Config.Mod.Name != "m1" || image.Config.Mod.Version != "1.0.0" {
+			t.Errorf("InstallMod(%s) config = %+v", reference, image.Config.Mod)
+		}
+		got := readTestMod(t, destDir)
+		if len(got) != len(files) || got["mod.pp"] != files["mod.pp"] || got["queries/q1.pp"] != files["queries/q1.pp"] {
+			t.Errorf("InstallMod(%s) files = %v, want %v", reference, got, files)
+		}
+	}
+}
+
+func TestExtractModArchiveRejectsPathTraversal(t *testing.T) {
+	var archive bytes.Buffer
+	gzipWriter := gzip.NewWriter(&archive)
+	tarWriter := tar.NewWriter(gzipWriter)
+	content := []byte("escaped")
+	if err := tarWriter.WriteHeader(&tar.Header{Name: "../escaped.txt", Typeflag: tar.TypeReg, Mode: 0644, Size: int64(len(content))}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tarWriter.Write(content); err != nil {
+		t.Fatal(err)
+	}
+	tarWriter.Close()
+	gzipWriter.Close()
+
+	dir := t.TempDir()
+	archivePath := filepath.Join(dir, ModArchiveFileName)
+	if err := os.WriteFile(archivePath, archive.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := ExtractModArchive(archivePath, filepath.Join(dir, "mod")); err == nil {
+		t.Errorf("ExtractModArchive() expected an error for an entry outside the destination")
+	}
+	if _, err := os.Stat(filepath.Join(dir, "escaped.txt")); err == nil {
+		t.Errorf("ExtractModArchive() wrote a file outside the destination")
+	}
+}
@@ -36,6 +36,8 @@ type OciDownloader[I OciImageData, C OciImageConfig] struct {
 	baseImageRef       string
 	MediaTypesProvider MediaTypeProvider
 	ImageProvider      ImageProvider[I, C]
+	// if set, layers are downloaded through the download manager, so they can be resumed and report progress
+	DownloadManager *DownloadManager
 }
 
 // NewOciDownloader creates and returns a OciDownloader instance
@@ -63,30 +65,33 @@ func (o *OciDownloader[I, C]) Pull(ctx context.Context, ref string, mediaTypes [
 	tag := split[len(split)-1]
 	log.Println("[TRACE] OciDownloader.Pull:", "preparing to pull ref", ref, "tag", tag, "destDir", destDir)
 
-	// Create the target file store
-	memoryStore := memory.New()
-	fileStore, err := file.NewWithFallbackStorage(destDir, memoryStore)
+	// Connect to the remote repository
+	repo, err := newRemoteRepository(ref, o.baseImageRef)
 	if err != nil {
 		return nil, nil, nil, nil, err
 	}
-	defer fileStore.Close()
 
-	// Connect to the remote repository
+	return o.PullFrom(ctx, repo, tag, destDir)
+}
+
+// newRemoteRepository connects to the remote repository for the given ref, using credentials from the docker
+// credentials store (unless this is the base image repository)
+func newRemoteRepository(ref string, baseImageRef string) (*remote.Repository, error) {
 	repo, err := remote.NewRepository(ref)
 	if err != nil {
-		return nil, nil, nil, nil, err
+		return nil, err
 	}
 
 	// Get credentials from the docker credentials store
 	storeOpts := credentials.StoreOptions{}
 	var credStore *credentials.DynamicStore
-	if strings.HasPrefix(ref, o.baseImageRef) {
+	if baseImageRef != "" && strings.HasPrefix(ref, baseImageRef) {
 		credStore, err = credentials.NewStore("", storeOpts)
 	} else {
 		credStore, err = credentials.NewStoreFromDocker(storeOpts)
 	}
 	if err != nil {
-		return nil, nil, nil, nil, err
+		return nil, err
 	}
 
 	// Prepare the auth client for the registry and credential store
@@ -95,16 +100,44 @@ func (o *OciDownloader[I, C]) Pull(ctx context.Context, ref string, mediaTypes [
 		Cache:      auth.DefaultCache,
 		Credential: credentials.Credential(credStore), // Use the credential store
 	}
+	return repo, nil
+}
+
+/*
+PullFrom downloads the image with the given `reference` (a tag or digest) from the `src` target to the supplied `destDir`
+
+Returns
+
+	imageDescription, configDescription, config, imageLayers, error
+*/
+func (o *OciDownloader[I, C]) PullFrom(ctx context.Context, src oras.ReadOnlyTarget, reference string, destDir string) (*ocispec.Descriptor, *ocispec.Descriptor, []byte, []ocispec.Descriptor, error) {
+	// Create the target file store
+	memoryStore := memory.New()
+	fileStore, err := file.NewWithFallbackStorage(destDir, memoryStore)
+	if err != nil {
+		return nil, nil, nil, nil, err
+	}
+	defer fileStore.Close()
 
 	// Copy from the remote repository to the file store
 	log.Println("[TRACE] OciDownloader.Pull:", "pulling...")
 
 	copyOpt := oras.DefaultCopyOptions
-	manifestDescriptor, err := oras.Copy(ctx, repo, tag, fileStore, tag, copyOpt)
+	var cachingSrc *cachingTarget
+	if o.DownloadManager != nil {
+		cachingSrc = o.DownloadManager.target(src, imageName(src, reference))
+		src = cachingSrc
+		copyOpt.Concurrency = o.DownloadManager.concurrency
+	}
+	manifestDescriptor, err := oras.Copy(ctx, src, reference, fileStore, reference, copyOpt)
 	if err != nil {
-		log.Println("[TRACE] OciDownloader.Pull:", "failed to pull", ref, err)
+		log.Println("[TRACE] OciDownloader.Pull:", "failed to pull", reference, err)
 		return nil, nil, nil, nil, err
 	}
+	if cachingSrc != nil {
+		// the image has been copied - the cached layers are not needed to resume the download
+		cachingSrc.removeCachedBlobs()
+	}
 	log.Println("[TRACE] OciDownloader.Pull:", "manifest", manifestDescriptor.Digest, manifestDescriptor.MediaType)
 
 	// FIXME: this seems redundant as oras.Copy() already downloads all artifacts, but that's the only I found
@@ -26,3 +26,11 @@ type PluginImageConfig struct {
 		Version      string `json:"version"`
 	}
 }
+
+type ModImageConfig struct {
+	OciConfigBase
+	Mod struct {
+		Name    string `json:"name,omitempty"`
+		Version string `json:"version"`
+	}
+}
@@ -17,6 +17,7 @@ import (
 	"github.com/turbot/pipe-fittings/filepaths"
 	"github.com/turbot/pipe-fittings/utils"
 	putils "github.com/turbot/pipe-fittings/utils"
+	"golang.org/x/sync/errgroup"
 )
 
 var versionFileUpdateLock = &sync.Mutex{}
@@ -38,6 +39,7 @@ func InstallPlugin(ctx context.Context, imageRef string, constraint string, sub
 
 	ref := NewImageRef(imageRef)
 	imageDownloader := NewPluginOciDownloader(baseImageRef, mediaTypesProvider)
+	imageDownloader.DownloadManager = config.downloadManager
 
 	sub <- struct{}{}
 	image, err := imageDownloader.Download(ctx, ref, ImageTypePlugin, tempDir.Path)
@@ -49,6 +51,14 @@ func InstallPlugin(ctx context.Context, imageRef string, constraint string, sub
 	constraintRef := image.ImageRef.DisplayImageRefConstraintOverride(constraint)
 	pluginPath := filepaths.EnsurePluginInstallDir(constraintRef)
 
+	// verify the image before installing anything
+	var verification *pluginVerification
+	if config.verificationRequired() {
+		if verification, err = verifyDownloadedPlugin(ctx, image, ref, baseImageRef, constraint, config); err != nil {
+			return nil, err
+		}
+	}
+
 	sub <- struct{}{}
 	if err = installPluginBinary(image, tempDir.Path, pluginPath); err != nil {
 		return nil, fmt.Errorf("plugin installation failed: %s", err)
@@ -62,16 +72,95 @@ func InstallPlugin(ctx context.Context, imageRef string, constraint string, sub
 			return nil, fmt.Errorf("plugin installation failed: %s", err)
 		}
 	}
+	if err = installPluginSbom(verification, pluginPath); err != nil {
+		return nil, fmt.Errorf("plugin installation failed: %s", err)
+	}
 	sub <- struct{}{}
-	if err := updatePluginVersionFiles(ctx, image, constraint); err != nil {
+	if err := updatePluginVersionFiles(ctx, image, constraint, verification); err != nil {
 		return nil, err
 	}
 	return image, nil
 }
 
+// PluginInstallRequest is a plugin to install using InstallPlugins
+type PluginInstallRequest struct {
+	ImageRef   string
+	Constraint string
+}
+
+// PluginInstallResult is the result of installing a plugin using InstallPlugins
+type PluginInstallResult struct {
+	Request PluginInstallRequest
+	Image   *OciImage[*PluginImage, *PluginImageConfig]
+	Error   error
+}
+
+// InstallPlugins installs plugins concurrently, with at most maxConcurrency installing at once. The result of each
+// install is returned in the order of the requests - a failed install does not stop the others.
+//
+// Unless a download manager is given in the options, one using the download cache directory is shared by the
+// installs, so a download interrupted by a failed install is resumed when the install is retried.
+// The progress beacons of all installs are sent to sub, if it is not nil.
+func InstallPlugins(ctx context.Context, requests []PluginInstallRequest, maxConcurrency int, sub chan struct{}, baseImageRef string, mediaTypesProvider MediaTypeProvider, opts ...PluginInstallOption) []PluginInstallResult {
+	config := &pluginInstallConfig{}
+	for _, opt := range opts {
+		opt(config)
+	}
+	if config.downloadManager == nil {
+		opts = append(opts, WithDownloadManager(NewDownloadManager(filepaths.EnsureDownloadCacheDir())))
+	}
+
+	results := make([]PluginInstallResult, len(requests))
+	var g errgroup.Group
+	if maxConcurrency > 0 {
+		g.SetLimit(maxConcurrency)
+	}
+	for idx, request := range requests {
+		g.Go(func() error {
+			// forward the beacons of this install
+			installSub := make(chan struct{})
+			done := make(chan struct{})
+			go func() {
+				defer close(done)
+				for range installSub {
+					if sub != nil {
+						sub <- struct{}{}
+					}
+				}
+			}()
+
+			image, err := InstallPlugin(ctx, request.ImageRef, request.Constraint, installSub, baseImageRef, mediaTypesProvider, opts...)
+			close(installSub)
+			<-done
+			results[idx] = PluginInstallResult{Request: request, Image: image, Error: err}
+			return nil
+		})
+	}
+	_ = g.Wait()
+	return results
+}
+
+// verifyDownloadedPlugin verifies the downloaded plugin image against the repository it was downloaded from
+func verifyDownloadedPlugin(ctx context.Context, image *OciImage[*PluginImage, *PluginImageConfig], ref *ImageRef, baseImageRef string, constraint string, config *pluginInstallConfig) (*pluginVerification, error) {
+	repo, err := newRemoteRepository(ref.ActualImageRef(), baseImageRef)
+	if err != nil {
+		return nil, err
+	}
+
+	var installedVersion *versionfile2.InstalledVersion
+	if config.pinDigest {
+		v, err := versionfile2.LoadPluginVersionFile(ctx)
+		if err != nil {
+			return nil, err
+		}
+		installedVersion = v.Plugins[image.ImageRef.DisplayImageRefConstraintOverride(constraint)]
+	}
+	return verifyPluginImage(ctx, repo, ref.DisplayImageRef(), *image.OCIDescriptor, installedVersion, image.Config.Plugin.Version, config)
+}
+
 // updatePluginVersionFiles updates the global versions.json to add installation of the plugin
 // also adds a version file in the plugin installation directory with the information
-func updatePluginVersionFiles(ctx context.Context, image *OciImage[*PluginImage, *PluginImageConfig], constraint string) error {
+func updatePluginVersionFiles(ctx context.Context, image *OciImage[*PluginImage, *PluginImageConfig], constraint string, verification *pluginVerification) error {
 	versionFileUpdateLock.Lock()
 	defer versionFileUpdateLock.Unlock()
 
@@ -98,6 +187,11 @@ func updatePluginVersionFiles(ctx context.Context, image *OciImage[*PluginImage,
 	installedVersion.InstalledFrom = image.ImageRef.ActualImageRef()
 	installedVersion.LastCheckedDate = timeNow
 	installedVersion.InstallDate = timeNow
+	installedVersion.SignatureVerified = verification != nil && verification.signatureVerified
+	installedVersion.SbomDigest = ""
+	if verification != nil {
+		installedVersion.SbomDigest = verification.sbomDigest
+	}
 
 	v.Plugins[pluginFullName] = installedVersion
 
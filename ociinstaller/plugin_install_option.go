@@ -2,6 +2,19 @@ package ociinstaller
 
 type pluginInstallConfig struct {
 	skipConfigFile bool
+	// PEM encoded public keys - if set, the image must have a cosign signature made by one of them
+	publicKeys [][]byte
+	// if set, a reinstall of an installed plugin version must have the image digest recorded in the version file
+	pinDigest bool
+	// if set, an SBOM attached to the image as a referrer is stored in the plugin install directory
+	extractSbom bool
+	// if set, the image is downloaded using the download manager
+	downloadManager *DownloadManager
+}
+
+// verificationRequired returns whether any of the verification options are set
+func (c *pluginInstallConfig) verificationRequired() bool {
+	return len(c.publicKeys) > 0 || c.pinDigest || c.extractSbom
 }
 
 type PluginInstallOption = func(config *pluginInstallConfig)
@@ -11,3 +24,34 @@ func WithSkipConfig(skipConfigFile bool) PluginInstallOption {
 		o.skipConfigFile = skipConfigFile
 	}
 }
+
+// WithPublicKeys requires the plugin image to have a cosign signature which verifies against one of the given PEM
+// encoded public keys (ECDSA, RSA or Ed25519)
+func WithPublicKeys(publicKeys ...[]byte) PluginInstallOption {
+	return func(o *pluginInstallConfig) {
+		o.publicKeys = append(o.publicKeys, publicKeys...)
+	}
+}
+
+// WithPinnedDigest requires that, when a plugin version which is already installed is installed again, the image
+// digest matches the digest recorded in the plugin version file
+func WithPinnedDigest(pinDigest bool) PluginInstallOption {
+	return func(o *pluginInstallConfig) {
+		o.pinDigest = pinDigest
+	}
+}
+
+// WithSbom stores an SBOM attached to the plugin image as an OCI referrer in the plugin install directory
+func WithSbom(extractSbom bool) PluginInstallOption {
+	return func(o *pluginInstallConfig) {
+		o.extractSbom = extractSbom
+	}
+}
+
+// WithDownloadManager downloads the plugin image using the download manager, which resumes interrupted downloads,
+// reports download progress and applies any bandwidth limit
+func WithDownloadManager(downloadManager *DownloadManager) PluginInstallOption {
+	return func(o *pluginInstallConfig) {
+		o.downloadManager = downloadManager
+	}
+}
@@ -0,0 +1,259 @@
+package ociinstaller
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/turbot/pipe-fittings/versionfile"
+	"oras.land/oras-go/v2"
+	"oras.land/oras-go/v2/content"
+	"oras.land/oras-go/v2/errdef"
+	"oras.land/oras-go/v2/registry"
+)
+
+const (
+	// MediaTypeCosignSimpleSigning is the media type of the payload layers of a cosign signature manifest
+	MediaTypeCosignSimpleSigning = "application/vnd.dev.cosign.simplesigning.v1+json"
+	// the layer annotation holding the base64 encoded signature of the payload
+	cosignSignatureAnnotation = "dev.cosignproject.cosign/signature"
+)
+
+// the artifact types of SBOM referrers, and the file name each is stored as
+var sbomFileNames = map[string]string{
+	"application/spdx+json":          "sbom.spdx.json",
+	"application/vnd.cyclonedx+json": "sbom.cdx.json",
+	"application/vnd.syft+json":      "sbom.syft.json",
+}
+
+// cosignPayload is the simple signing payload signed by cosign
+type cosignPayload struct {
+	Critical struct {
+		Image struct {
+			DockerManifestDigest string `json:"docker-manifest-digest"`
+		} `json:"image"`
+		Type string `json:"type"`
+	} `json:"critical"`
+}
+
+// pluginVerification is the result of verifying a plugin image
+type pluginVerification struct {
+	signatureVerified bool
+	sbom              []byte
+	sbomFileName      string
+	sbomDigest        string
+}
+
+// verifyPluginImage verifies the plugin image with the given manifest descriptor, as configured by the install options:
+//   - the image digest is checked against the digest of the same plugin version in the plugin version file
+//   - the cosign signature of the image is verified against the configured public keys
+//   - an SBOM attached to the image is fetched
+//
+// Signatures are looked up using the cosign tag convention, i.e. the signature of sha256:<hex> is tagged
+// sha256-<hex>.sig in the same repository.
+func verifyPluginImage(ctx context.Context, target oras.ReadOnlyGraphTarget, imageRef string, imageDesc ocispec.Descriptor, installedVersion *versionfile.InstalledVersion, version string, config *pluginInstallConfig) (*pluginVerification, error) {
+	res := &pluginVerification{}
+
+	if config.pinDigest {
+		if err := verifyPinnedDigest(imageRef, imageDesc, installedVersion, version); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(config.publicKeys) > 0 {
+		publicKeys, err := parsePublicKeys(config.publicKeys)
+		if err != nil {
+			return nil, err
+		}
+		if err := verifyCosignSignature(ctx, target, imageRef, imageDesc, publicKeys); err != nil {
+			return nil, err
+		}
+		res.signatureVerified = true
+	}
+
+	if config.extractSbom {
+		sbomDesc, sbom, err := fetchSbom(ctx, target, imageDesc)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch SBOM for plugin image %s: %w", imageRef, err)
+		}
+		if sbom == nil {
+			log.Printf("[TRACE] verifyPluginImage: plugin image %s has no SBOM", imageRef)
+		} else {
+			res.sbom = sbom
+			res.sbomFileName = sbomFileNames[sbomDesc.ArtifactType]
+			res.sbomDigest = string(sbomDesc.Digest)
+		}
+	}
+	return res, nil
+}
+
+// verifyPinnedDigest checks that if this plugin version is already installed, it was installed from the same image
+func verifyPinnedDigest(imageRef string, imageDesc ocispec.Descriptor, installedVersion *versionfile.InstalledVersion, version string) error {
+	if installedVersion == nil || installedVersion.ImageDigest == "" || installedVersion.Version != version {
+		return nil
+	}
+	if installedVersion.ImageDigest != string(imageDesc.Digest) {
+		return fmt.Errorf("plugin image %s has digest %s but version %s was installed from %s - the image has been replaced since it was installed", imageRef, imageDesc.Digest, version, installedVersion.ImageDigest)
+	}
+	return nil
+}
+
+// verifyCosignSignature checks that the image has a cosign signature, made by one of the public keys, of its digest
+func verifyCosignSignature(ctx context.Context, target oras.ReadOnlyGraphTarget, imageRef string, imageDesc ocispec.Descriptor, publicKeys []crypto.PublicKey) error {
+	signatureTag := strings.Replace(string(imageDesc.Digest), ":", "-", 1) + ".sig"
+	signatureDesc, err := target.Resolve(ctx, signatureTag)
+	if err != nil {
+		if errors.Is(err, errdef.ErrNotFound) {
+			return fmt.Errorf("plugin image %s is not signed - no signature found for %s", imageRef, imageDesc.Digest)
+		}
+		return fmt.Errorf("failed to resolve the signature of plugin image %s: %w", imageRef, err)
+	}
+	manifest, err := fetchManifest(ctx, target, signatureDesc)
+	if err != nil {
+		return fmt.Errorf("failed to fetch the signature of plugin image %s: %w", imageRef, err)
+	}
+
+	signed := false
+	for _, layer := range manifest.Layers {
+		if layer.MediaType != MediaTypeCosignSimpleSigning {
+			continue
+		}
+		signature, err := base64.StdEncoding.DecodeString(layer.Annotations[cosignSignatureAnnotation])
+		if err != nil || len(signature) == 0 {
+			continue
+		}
+		payloadBytes, err := content.FetchAll(ctx, target, layer)
+		if err != nil {
+			return fmt.Errorf("failed to fetch the signature payload of plugin image %s: %w", imageRef, err)
+		}
+		if !verifySignature(publicKeys, payloadBytes, signature) {
+			continue
+		}
+		// the signature is valid - check it is a signature of this image
+		var payload cosignPayload
+		if err := json.Unmarshal(payloadBytes, &payload); err != nil {
+			return fmt.Errorf("invalid signature payload for plugin image %s: %w", imageRef, err)
+		}
+		if payload.Critical.Image.DockerManifestDigest != string(imageDesc.Digest) {
+			return fmt.Errorf("plugin image %s signature does not match the image - the signature is for %s, the image is %s", imageRef, payload.Critical.Image.DockerManifestDigest, imageDesc.Digest)
+		}
+		signed = true
+		break
+	}
+	if !signed {
+		return fmt.Errorf("plugin image %s signature verification failed - it is not signed by any of the configured public keys", imageRef)
+	}
+	return nil
+}
+
+// verifySignature returns whether the signature of the payload was made by one of the public keys
+func verifySignature(publicKeys []crypto.PublicKey, payload []byte, signature []byte) bool {
+	hash := sha256.Sum256(payload)
+	for _, publicKey := range publicKeys {
+		switch key := publicKey.(type) {
+		case *ecdsa.PublicKey:
+			if ecdsa.VerifyASN1(key, hash[:], signature) {
+				return true
+			}
+		case *rsa.PublicKey:
+			if rsa.VerifyPKCS1v15(key, crypto.SHA256, hash[:], signature) == nil {
+				return true
+			}
+		case ed25519.PublicKey:
+			if ed25519.Verify(key, payload, signature) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// parsePublicKeys parses PEM encoded PKIX public keys - each PEM may contain several keys
+func parsePublicKeys(pemKeys [][]byte) ([]crypto.PublicKey, error) {
+	var res []crypto.PublicKey
+	for _, pemKey := range pemKeys {
+		rest := pemKey
+		for {
+			var block *pem.Block
+			block, rest = pem.Decode(rest)
+			if block == nil {
+				break
+			}
+			publicKey, err := x509.ParsePKIXPublicKey(block.Bytes)
+			if err != nil {
+				return nil, fmt.Errorf("invalid public key: %w", err)
+			}
+			switch publicKey.(type) {
+			case *ecdsa.PublicKey, *rsa.PublicKey, ed25519.PublicKey:
+				res = append(res, publicKey)
+			default:
+				return nil, fmt.Errorf("unsupported public key type %T", publicKey)
+			}
+		}
+	}
+	if len(res) == 0 {
+		return nil, fmt.Errorf("no valid PEM encoded public keys were provided")
+	}
+	return res, nil
+}
+
+// fetchSbom returns the descriptor and content of the first SBOM attached to the image as a referrer - if there
+// is no SBOM it returns nil content
+func fetchSbom(ctx context.Context, target oras.ReadOnlyGraphTarget, imageDesc ocispec.Descriptor) (ocispec.Descriptor, []byte, error) {
+	referrers, err := registry.Referrers(ctx, target, imageDesc, "")
+	if err != nil {
+		return ocispec.Descriptor{}, nil, err
+	}
+	for _, referrer := range referrers {
+		if _, ok := sbomFileNames[referrer.ArtifactType]; !ok {
+			continue
+		}
+		manifest, err := fetchManifest(ctx, target, referrer)
+		if err != nil {
+			return ocispec.Descriptor{}, nil, err
+		}
+		if len(manifest.Layers) == 0 {
+			continue
+		}
+		sbom, err := content.FetchAll(ctx, target, manifest.Layers[0])
+		if err != nil {
+			return ocispec.Descriptor{}, nil, err
+		}
+		return referrer, sbom, nil
+	}
+	return ocispec.Descriptor{}, nil, nil
+}
+
+func fetchManifest(ctx context.Context, target content.Fetcher, desc ocispec.Descriptor) (*ocispec.Manifest, error) {
+	manifestBytes, err := content.FetchAll(ctx, target, desc)
+	if err != nil {
+		return nil, err
+	}
+	var manifest ocispec.Manifest
+	if err := json.Unmarshal(manifestBytes, &manifest); err != nil {
+		return nil, err
+	}
+	return &manifest, nil
+}
+
+// installPluginSbom writes the SBOM fetched during verification to the plugin install directory
+func installPluginSbom(verification *pluginVerification, destDir string) error {
+	if verification == nil || verification.sbom == nil {
+		return nil
+	}
+	return os.WriteFile(filepath.Join(destDir, verification.sbomFileName), verification.sbom, 0644) //nolint:gosec // the SBOM is not sensitive
+}
@@ -0,0 +1,195 @@
+package ociinstaller
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"strings"
+	"testing"
+
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/turbot/pipe-fittings/versionfile"
+	"oras.land/oras-go/v2"
+	"oras.land/oras-go/v2/content/oci"
+)
+
+func newTestSigningKey(t *testing.T) (*ecdsa.PrivateKey, []byte) {
+	t.Helper()
+	privateKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := x509.MarshalPKIXPublicKey(&privateKey.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return privateKey, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})
+}
+
+// pushTestPluginImage pushes a minimal image to stand in for a plugin image
+func pushTestPluginImage(t *testing.T, ctx context.Context, target oras.Target, tag string) ocispec.Descriptor {
+	t.Helper()
+	layerDesc, err := pushBlob(ctx, target, "application/vnd.turbot.steampipe.plugin.linux-amd64.layer.v1+gzip", []byte(tag))
+	if err != nil {
+		t.Fatal(err)
+	}
+	manifestDesc, err := oras.PackManifest(ctx, target, oras.PackManifestVersion1_1, "application/vnd.turbot.steampipe.plugin", oras.PackManifestOptions{
+		Layers: []ocispec.Descriptor{layerDesc},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := target.Tag(ctx, manifestDesc, tag); err != nil {
+		t.Fatal(err)
+	}
+	return manifestDesc
+}
+
+// signTestPluginImage pushes a cosign signature for the image, signing a payload for signedDigest
+func signTestPluginImage(t *testing.T, ctx context.Context, target oras.Target, imageDesc ocispec.Descriptor, signedDigest string, key *ecdsa.PrivateKey) {
+	t.Helper()
+	payload := fmt.Sprintf(`{"critical":{"identity":{"docker-reference":"test/plugin"},"image":{"docker-manifest-digest":"%s"},"type":"cosign container image signature"},"optional":null}`, signedDigest)
+	hash := sha256.Sum256([]byte(payload))
+	signature, err := ecdsa.SignASN1(rand.Reader, key, hash[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	layerDesc, err := pushBlob(ctx, target, MediaTypeCosignSimpleSigning, []byte(payload))
+	if err != nil {
+		t.Fatal(err)
+	}
+	layerDesc.Annotations = map[string]string{cosignSignatureAnnotation: base64.StdEncoding.EncodeToString(signature)}
+	signatureDesc, err := oras.PackManifest(ctx, target, oras.PackManifestVersion1_1, "application/vnd.dev.cosign.artifact.sig.v1+json", oras.PackManifestOptions{
+		Layers: []ocispec.Descriptor{layerDesc},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := target.Tag(ctx, signatureDesc, strings.Replace(string(imageDesc.Digest), ":", "-", 1)+".sig"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestVerifyPluginImage(t *testing.T) {
+	ctx := context.Background()
+	repo, err := oci.New(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, publicKey := newTestSigningKey(t)
+	otherKey, otherPublicKey := newTestSigningKey(t)
+
+	signed := pushTestPluginImage(t, ctx, repo, "signed")
+	signTestPluginImage(t, ctx, repo, signed, string(signed.Digest), key)
+	unsigned := pushTestPluginImage(t, ctx, repo, "unsigned")
+	wrongKey := pushTestPluginImage(t, ctx, repo, "wrong-key")
+	signTestPluginImage(t, ctx, repo, wrongKey, string(wrongKey.Digest), otherKey)
+	// a valid signature copied from another image
+	copied := pushTestPluginImage(t, ctx, repo, "copied")
+	signTestPluginImage(t, ctx, repo, copied, string(signed.Digest), key)
+
+	tests := []struct {
+		name    string
+		image   ocispec.Descriptor
+		keys    [][]byte
+		wantErr string
+	}{
+		{name: "signed", image: signed, keys: [][]byte{otherPublicKey, publicKey}},
+		{name: "unsigned", image: unsigned, keys: [][]byte{publicKey}, wantErr: "is not signed"},
+		{name: "wrong key", image: wrongKey, keys: [][]byte{publicKey}, wantErr: "not signed by any of the configured public keys"},
+		{name: "signature for another image", image: copied, keys: [][]byte{publicKey}, wantErr: "signature does not match the image"},
+		{name: "invalid key", image: signed, keys: [][]byte{[]byte("not a key")}, wantErr: "no valid PEM encoded public keys"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := &pluginInstallConfig{}
+			WithPublicKeys(tt.keys...)(config)
+			res, err := verifyPluginImage(ctx, repo, "test/plugin", tt.image, nil, "1.0.0", config)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("verifyPluginImage() error = %v, want error containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("verifyPluginImage() error = %v", err)
+			}
+			if !res.signatureVerified {
+				t.Error("verifyPluginImage() signatureVerified = false, want true")
+			}
+		})
+	}
+}
+
+func TestVerifyPluginImage_PinnedDigest(t *testing.T) {
+	ctx := context.Background()
+	repo, err := oci.New(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	image := pushTestPluginImage(t, ctx, repo, "1.0.0")
+	config := &pluginInstallConfig{}
+	WithPinnedDigest(true)(config)
+
+	installedVersion := versionfile.EmptyInstalledVersion()
+	installedVersion.Version = "1.0.0"
+	installedVersion.ImageDigest = string(image.Digest)
+	if _, err := verifyPluginImage(ctx, repo, "test/plugin", image, installedVersion, "1.0.0", config); err != nil {
+		t.Errorf("verifyPluginImage() for the pinned digest error = %v", err)
+	}
+	// a different version may have a different digest
+	if _, err := verifyPluginImage(ctx, repo, "test/plugin", image, installedVersion, "1.1.0", config); err != nil {
+		t.Errorf("verifyPluginImage() for a new version error = %v", err)
+	}
+
+	installedVersion.ImageDigest = "sha256:0000000000000000000000000000000000000000000000000000000000000000"
+	if _, err := verifyPluginImage(ctx, repo, "test/plugin", image, installedVersion, "1.0.0", config); err == nil || !strings.Contains(err.Error(), "the image has been replaced") {
+		t.Errorf("verifyPluginImage() for a replaced image error = %v, want digest mismatch", err)
+	}
+}
+
+func TestVerifyPluginImage_Sbom(t *testing.T) {
+	ctx := context.Background()
+	repo, err := oci.New(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	image := pushTestPluginImage(t, ctx, repo, "1.0.0")
+	config := &pluginInstallConfig{}
+	WithSbom(true)(config)
+
+	res, err := verifyPluginImage(ctx, repo, "test/plugin", image, nil, "1.0.0", config)
+	if err != nil {
+		t.Fatalf("verifyPluginImage() error = %v", err)
+	}
+	if res.sbom != nil {
+		t.Errorf("verifyPluginImage() for an image with no SBOM returned %s", res.sbom)
+	}
+
+	sbom := []byte(`{"spdxVersion":"SPDX-2.3"}`)
+	sbomLayer, err := pushBlob(ctx, repo, "application/spdx+json", sbom)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sbomDesc, err := oras.PackManifest(ctx, repo, oras.PackManifestVersion1_1, "application/spdx+json", oras.PackManifestOptions{
+		Subject: &image,
+		Layers:  []ocispec.Descriptor{sbomLayer},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	res, err = verifyPluginImage(ctx, repo, "test/plugin", image, nil, "1.0.0", config)
+	if err != nil {
+		t.Fatalf("verifyPluginImage() error = %v", err)
+	}
+	if string(res.sbom) != string(sbom) || res.sbomFileName != "sbom.spdx.json" || res.sbomDigest != string(sbomDesc.Digest) {
+		t.Errorf("verifyPluginImage() sbom = %s %s %s", res.sbom, res.sbomFileName, res.sbomDigest)
+	}
+}
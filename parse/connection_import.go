@@ -4,6 +4,8 @@ import (
 	"fmt"
 	"github.com/turbot/pipe-fittings/modconfig"
 	"path"
+	"slices"
+	"strings"
 
 	filehelpers "github.com/turbot/go-kit/files"
 
@@ -57,6 +59,15 @@ func DecodeConnectionImport(configPath string, block *hcl.Block) (*modconfig.Con
 		return nil, diags
 	}
 
+	if !slices.Contains(modconfig.ValidConnectionImportTypes, connectionImport.GetType()) {
+		diags = append(diags, &hcl.Diagnostic{
+			Severity: hcl.DiagError,
+			Summary:  fmt.Sprintf("invalid connection_import type '%s', must be one of: %s", connectionImport.GetType(), strings.Join(modconfig.ValidConnectionImportTypes, ", ")),
+			Subject:  &block.DefRange,
+		})
+		return nil, diags
+	}
+
 	// moreDiags := connection.Validate()
 	// if len(moreDiags) > 0 {
 	// 	diags = append(diags, moreDiags...)
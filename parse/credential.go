@@ -57,7 +57,7 @@ func DecodeCredential(configPath string, block *hcl.Block) (credential.Credentia
 
 	// build an eval context just containing functions
 	evalCtx := &hcl.EvalContext{
-		Functions: funcs.ContextFunctions(configPath),
+		Functions: funcs.ConnectionFunctions(configPath),
 		Variables: make(map[string]cty.Value),
 	}
 
@@ -19,6 +19,10 @@ var notifierBlockSchema = &hcl.BodySchema{
 			Name:     schema.AttributeTypeTitle,
 			Required: false,
 		},
+		{
+			Name:     schema.AttributeTypeRouting,
+			Required: false,
+		},
 	},
 	Blocks: []hcl.BlockHeaderSchema{
 		{
@@ -27,7 +31,7 @@ var notifierBlockSchema = &hcl.BodySchema{
 	},
 }
 
-func DecodeNotifier(configPath string, block *hcl.Block, evalCtx *hcl.EvalContext) (*modconfig.NotifierImpl, hcl.Diagnostics) {
+func DecodeNotifier(configPath string, block *hcl.Block, evalCtx *hcl.EvalContext, fileData map[string][]byte) (*modconfig.NotifierImpl, hcl.Diagnostics) {
 	var diags hcl.Diagnostics
 	if len(block.Labels) != 1 {
 		diags = hcl.Diagnostics{
@@ -67,7 +71,7 @@ func DecodeNotifier(configPath string, block *hcl.Block, evalCtx *hcl.EvalContex
 				continue
 			}
 
-			moreDiags = notify.SetAttributes(b.Body, evalCtx)
+			moreDiags = notify.SetAttributes(b.Body, evalCtx, fileData[b.DefRange.Filename])
 			if len(moreDiags) > 0 {
 				diags = append(diags, moreDiags...)
 				continue
@@ -94,6 +98,11 @@ func DecodeNotifier(configPath string, block *hcl.Block, evalCtx *hcl.EvalContex
 		diags = append(diags, moreDiags...)
 	}
 
+	moreDiags = notifier.SetAttributes(content.Attributes, evalCtx)
+	if len(moreDiags) > 0 {
+		diags = append(diags, moreDiags...)
+	}
+
 	validationDiags := notifier.Validate()
 	if len(validationDiags) > 0 {
 		diags = append(diags, validationDiags...)
@@ -835,6 +835,12 @@ func GetIntegrationBlockSchema(integrationType string) *hcl.BodySchema {
 		return modconfig.IntegrationEmailBlockSchema
 	case schema.IntegrationTypeMsTeams:
 		return modconfig.IntegrationTeamsBlockSchema
+	case schema.IntegrationTypePagerDuty:
+		return modconfig.IntegrationPagerDutyBlockSchema
+	case schema.IntegrationTypeOpsgenie:
+		return modconfig.IntegrationOpsgenieBlockSchema
+	case schema.IntegrationTypeDiscord:
+		return modconfig.IntegrationDiscordBlockSchema
 	default:
 		return nil
 	}
@@ -39,7 +39,7 @@ func DecodePipelingConnection(configPath string, block *hcl.Block) (connection.P
 
 	// build an eval context just containing functions
 	evalCtx := &hcl.EvalContext{
-		Functions: funcs.ContextFunctions(configPath),
+		Functions: funcs.ConnectionFunctions(configPath),
 		Variables: make(map[string]cty.Value),
 	}
 
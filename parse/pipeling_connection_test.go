@@ -0,0 +1,45 @@
+package parse
+
+import (
+	"testing"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/turbot/pipe-fittings/app_specific_connection"
+	"github.com/turbot/pipe-fittings/connection"
+	"github.com/turbot/pipe-fittings/sanitize"
+)
+
+func TestDecodePipelingConnection_Sensitive(t *testing.T) {
+	defer func(original map[string]app_specific_connection.ConnectionFunc) {
+		app_specific_connection.ConnectionTypeRegistry = original
+	}(app_specific_connection.ConnectionTypeRegistry)
+	app_specific_connection.ConnectionTypeRegistry = nil
+	app_specific_connection.RegisterConnections(connection.NewGithubConnection)
+
+	src := `
+connection "github" "default" {
+  token = sensitive("s3nsitive-connection-value")
+}
+`
+	file, diags := hclsyntax.ParseConfig([]byte(src), "connections.fpc", hcl.InitialPos)
+	if diags.HasErrors() {
+		t.Fatal(diags)
+	}
+	content, diags := file.Body.Content(&hcl.BodySchema{Blocks: []hcl.BlockHeaderSchema{{Type: "connection", LabelNames: []string{"type", "name"}}}})
+	if diags.HasErrors() {
+		t.Fatal(diags)
+	}
+
+	conn, diags := DecodePipelingConnection("", content.Blocks[0])
+	if diags.HasErrors() {
+		t.Fatalf("DecodePipelingConnection() error = %v", diags)
+	}
+	if token := conn.(*connection.GithubConnection).Token; token == nil || *token != "s3nsitive-connection-value" {
+		t.Errorf("DecodePipelingConnection() token = %v", token)
+	}
+	// the sensitive value is redacted wherever it appears
+	if got := sanitize.Instance.SanitizeString("token is s3nsitive-connection-value"); got != "token is "+sanitize.RedactedStr {
+		t.Errorf("SanitizeString() = %s", got)
+	}
+}
@@ -119,6 +119,10 @@ var WorkspaceProfileBlockSchema = &hcl.BodySchema{
 			Type:       "options",
 			LabelNames: []string{schema.LabelType},
 		},
+		{
+			Type:       schema.BlockTypeModHost,
+			LabelNames: []string{schema.LabelHost},
+		},
 	},
 }
 
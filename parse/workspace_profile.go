@@ -11,6 +11,7 @@ import (
 	"github.com/turbot/pipe-fittings/app_specific"
 	"github.com/turbot/pipe-fittings/error_helpers"
 	"github.com/turbot/pipe-fittings/hclhelpers"
+	"github.com/turbot/pipe-fittings/modconfig"
 	"github.com/turbot/pipe-fittings/schema"
 	"github.com/turbot/pipe-fittings/workspace_profile"
 )
@@ -188,11 +189,31 @@ func decodeWorkspaceProfile[T workspace_profile.WorkspaceProfile](block *hcl.Blo
 				diags = append(diags, moreDiags...)
 			}
 			foundOptions[optionsBlockType] = struct{}{}
+		case schema.BlockTypeModHost:
+			modHostProfile, ok := any(resource).(workspace_profile.ModHostProfile)
+			if !ok {
+				diags = append(diags, &hcl.Diagnostic{
+					Severity: hcl.DiagError,
+					Summary:  "mod_host blocks are not supported for this workspace profile",
+					Subject:  hclhelpers.BlockRangePointer(block),
+				})
+				break
+			}
+			modHost := &modconfig.ModHost{
+				Host:      block.Labels[0],
+				DeclRange: hclhelpers.BlockRange(block),
+			}
+			moreDiags := gohcl.DecodeBody(block.Body, parseCtx.EvalCtx, modHost)
+			if moreDiags.HasErrors() {
+				diags = append(diags, moreDiags...)
+				break
+			}
+			diags = append(diags, modHostProfile.AddModHost(modHost)...)
 		default:
 			// this should never happen
 			diags = append(diags, &hcl.Diagnostic{
 				Severity: hcl.DiagError,
-				Summary:  fmt.Sprintf("invalid block type '%s' - only 'options' blocks are supported for workspace profiles", block.Type),
+				Summary:  fmt.Sprintf("invalid block type '%s' - only 'options' and 'mod_host' blocks are supported for workspace profiles", block.Type),
 				Subject:  hclhelpers.BlockRangePointer(block),
 			})
 		}
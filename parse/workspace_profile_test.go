@@ -0,0 +1,63 @@
+package parse
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/turbot/pipe-fittings/app_specific"
+	"github.com/turbot/pipe-fittings/workspace_profile"
+)
+
+func TestLoadWorkspaceProfiles_ModHosts(t *testing.T) {
+	defer func(original string) { app_specific.ConfigExtension = original }(app_specific.ConfigExtension)
+	app_specific.ConfigExtension = ".ppc"
+
+	configPath := t.TempDir()
+	config := `
+workspace "default" {
+  mod_host "gitlab.example.com" {
+    type       = "gitlab"
+    url_mode   = "ssh"
+    ssh_user   = "gitlab"
+  }
+  mod_host "git.example.com" {
+    token = "secret"
+  }
+}
+
+workspace "dev" {
+  base = workspace.default
+}
+`
+	if err := os.WriteFile(filepath.Join(configPath, "workspaces.ppc"), []byte(config), 0644); err != nil {
+		t.Fatal(err)
+	}
+	profiles, err := LoadWorkspaceProfiles[*workspace_profile.PowerpipeWorkspaceProfile](configPath)
+	if err != nil {
+		t.Fatalf("LoadWorkspaceProfiles() error = %v", err)
+	}
+
+	modHosts := profiles["default"].GetModHosts()
+	if len(modHosts) != 2 || modHosts[0].Host != "gitlab.example.com" || *modHosts[0].UrlMode != "ssh" || *modHosts[1].Token != "secret" {
+		t.Fatalf("GetModHosts() = %v", modHosts)
+	}
+	// mod hosts are inherited from the base profile
+	if len(profiles["dev"].GetModHosts()) != 2 {
+		t.Errorf("GetModHosts() for profile with base = %v", profiles["dev"].GetModHosts())
+	}
+
+	invalid := `
+workspace "default" {
+  mod_host "git.example.com" {
+    url_mode = "ftp"
+  }
+}
+`
+	if err := os.WriteFile(filepath.Join(configPath, "workspaces.ppc"), []byte(invalid), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadWorkspaceProfiles[*workspace_profile.PowerpipeWorkspaceProfile](configPath); err == nil {
+		t.Errorf("LoadWorkspaceProfiles() expected error for invalid url_mode")
+	}
+}
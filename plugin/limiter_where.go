@@ -0,0 +1,450 @@
+package plugin
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"unicode"
+)
+
+// limiterWhere is a parsed limiter 'where' clause, which filters the calls a limiter applies to by their scope values,
+// e.g. "service = 's3' and region in ('us-east-1', 'us-east-2')"
+//
+// The supported syntax is a subset of SQL: comparisons of a scope key with a quoted value using =, != or <>,
+// [not] in (...), [not] like and is [not] null, combined with and, or, not and parentheses.
+type limiterWhere interface {
+	matches(scopeValues map[string]string) bool
+}
+
+type whereAnd struct{ left, right limiterWhere }
+
+func (w *whereAnd) matches(scopeValues map[string]string) bool {
+	return w.left.matches(scopeValues) && w.right.matches(scopeValues)
+}
+
+type whereOr struct{ left, right limiterWhere }
+
+func (w *whereOr) matches(scopeValues map[string]string) bool {
+	return w.left.matches(scopeValues) || w.right.matches(scopeValues)
+}
+
+type whereNot struct{ expr limiterWhere }
+
+func (w *whereNot) matches(scopeValues map[string]string) bool {
+	return !w.expr.matches(scopeValues)
+}
+
+// whereIn is a comparison of a scope key with a list of values - '=' is an 'in' with a single value
+type whereIn struct {
+	key    string
+	values []string
+	negate bool
+}
+
+func (w *whereIn) matches(scopeValues map[string]string) bool {
+	value, ok := scopeValues[w.key]
+	if !ok {
+		// as in SQL, a comparison with null is never true
+		return false
+	}
+	for _, v := range w.values {
+		if v == value {
+			return !w.negate
+		}
+	}
+	return w.negate
+}
+
+type whereLike struct {
+	key     string
+	pattern *regexp.Regexp
+	negate  bool
+}
+
+func (w *whereLike) matches(scopeValues map[string]string) bool {
+	value, ok := scopeValues[w.key]
+	if !ok {
+		return false
+	}
+	return w.pattern.MatchString(value) != w.negate
+}
+
+type whereNull struct {
+	key    string
+	negate bool
+}
+
+func (w *whereNull) matches(scopeValues map[string]string) bool {
+	_, ok := scopeValues[w.key]
+	return ok == w.negate
+}
+
+// parseLimiterWhere parses a limiter where clause
+func parseLimiterWhere(where string) (limiterWhere, error) {
+	tokens, err := tokenizeWhere(where)
+	if err != nil {
+		return nil, fmt.Errorf("invalid where clause '%s': %w", where, err)
+	}
+	p := &whereParser{tokens: tokens}
+	expr, err := p.parseOr()
+	if err == nil && !p.done() {
+		err = fmt.Errorf("unexpected '%s'", p.peek().text)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid where clause '%s': %w", where, err)
+	}
+	return expr, nil
+}
+
+type whereTokenType int
+
+const (
+	whereTokenIdent whereTokenType = iota
+	whereTokenString
+	whereTokenOperator
+)
+
+type whereToken struct {
+	tokenType whereTokenType
+	text      string
+}
+
+// isKeyword returns whether the token is the given (case insensitive) keyword
+func (t whereToken) isKeyword(keyword string) bool {
+	return t.tokenType == whereTokenIdent && strings.EqualFold(t.text, keyword)
+}
+
+func tokenizeWhere(where string) ([]whereToken, error) {
+	var tokens []whereToken
+	runes := []rune(where)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '\'':
+			// a quoted string - a quote is escaped by doubling it
+			var value strings.Builder
+			i++
+			for {
+				if i >= len(runes) {
+					return nil, fmt.Errorf("unterminated string")
+				}
+				if runes[i] == '\'' {
+					if i+1 < len(runes) && runes[i+1] == '\'' {
+						value.WriteRune('\'')
+						i += 2
+						continue
+					}
+					i++
+					break
+				}
+				value.WriteRune(runes[i])
+				i++
+			}
+			tokens = append(tokens, whereToken{whereTokenString, value.String()})
+		case r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r):
+			start := i
+			for i < len(runes) && (runes[i] == '_' || runes[i] == '.' || unicode.IsLetter(runes[i]) || unicode.IsDigit(runes[i])) {
+				i++
+			}
+			text := string(runes[start:i])
+			tokenType := whereTokenIdent
+			if unicode.IsDigit(runes[start]) {
+				// numbers are compared as strings, as all scope values are strings
+				tokenType = whereTokenString
+			}
+			tokens = append(tokens, whereToken{tokenType, text})
+		case strings.HasPrefix(string(runes[i:]), "!=") || strings.HasPrefix(string(runes[i:]), "<>"):
+			tokens = append(tokens, whereToken{whereTokenOperator, "!="})
+			i += 2
+		case strings.ContainsRune("=(),", r):
+			tokens = append(tokens, whereToken{whereTokenOperator, string(r)})
+			i++
+		default:
+			return nil, fmt.Errorf("unexpected character '%c'", r)
+		}
+	}
+	return tokens, nil
+}
+
+type whereParser struct {
+	tokens []whereToken
+	pos    int
+}
+
+func (p *whereParser) done() bool {
+	return p.pos >= len(p.tokens)
+}
+
+func (p *whereParser) peek() whereToken {
+	if p.done() {
+		return whereToken{whereTokenOperator, "end of clause"}
+	}
+	return p.tokens[p.pos]
+}
+
+func (p *whereParser) next() whereToken {
+	t := p.peek()
+	p.pos++
+	return t
+}
+
+// acceptKeyword consumes the next token if it is the given keyword
+func (p *whereParser) acceptKeyword(keyword string) bool {
+	if !p.done() && p.peek().isKeyword(keyword) {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *whereParser) expectOperator(operator string) error {
+	if t := p.next(); t.tokenType != whereTokenOperator || t.text != operator {
+		return fmt.Errorf("expected '%s' but found '%s'", operator, t.text)
+	}
+	return nil
+}
+
+func (p *whereParser) expectString() (string, error) {
+	t := p.next()
+	if t.tokenType != whereTokenString {
+		return "", fmt.Errorf("expected a quoted value but found '%s'", t.text)
+	}
+	return t.text, nil
+}
+
+func (p *whereParser) parseOr() (limiterWhere, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.acceptKeyword("or") {
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = &whereOr{left, right}
+	}
+	return left, nil
+}
+
+func (p *whereParser) parseAnd() (limiterWhere, error) {
+	left, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+	for p.acceptKeyword("and") {
+		right, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		left = &whereAnd{left, right}
+	}
+	return left, nil
+}
+
+func (p *whereParser) parseNot() (limiterWhere, error) {
+	if p.acceptKeyword("not") {
+		expr, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		return &whereNot{expr}, nil
+	}
+	return p.parseComparison()
+}
+
+func (p *whereParser) parseComparison() (limiterWhere, error) {
+	t := p.next()
+	if t.tokenType == whereTokenOperator && t.text == "(" {
+		expr, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		return expr, p.expectOperator(")")
+	}
+	if t.tokenType != whereTokenIdent {
+		return nil, fmt.Errorf("expected a scope key but found '%s'", t.text)
+	}
+	key := t.text
+
+	switch op := p.next(); {
+	case op.tokenType == whereTokenOperator && (op.text == "=" || op.text == "!="):
+		value, err := p.expectString()
+		if err != nil {
+			return nil, err
+		}
+		return &whereIn{key: key, values: []string{value}, negate: op.text == "!="}, nil
+	case op.isKeyword("is"):
+		negate := p.acceptKeyword("not")
+		if !p.acceptKeyword("null") {
+			return nil, fmt.Errorf("expected 'null' but found '%s'", p.peek().text)
+		}
+		return &whereNull{key: key, negate: negate}, nil
+	case op.isKeyword("not"), op.isKeyword("in"), op.isKeyword("like"):
+		negate := op.isKeyword("not")
+		if negate {
+			op = p.next()
+		}
+		if op.isKeyword("like") {
+			pattern, err := p.expectString()
+			if err != nil {
+				return nil, err
+			}
+			return &whereLike{key: key, pattern: likePattern(pattern), negate: negate}, nil
+		}
+		if !op.isKeyword("in") {
+			return nil, fmt.Errorf("expected 'in' or 'like' but found '%s'", op.text)
+		}
+		values, err := p.parseList()
+		if err != nil {
+			return nil, err
+		}
+		return &whereIn{key: key, values: values, negate: negate}, nil
+	default:
+		return nil, fmt.Errorf("expected a comparison but found '%s'", op.text)
+	}
+}
+
+// parseList parses a parenthesised list of quoted values
+func (p *whereParser) parseList() ([]string, error) {
+	if err := p.expectOperator("("); err != nil {
+		return nil, err
+	}
+	var values []string
+	for {
+		value, err := p.expectString()
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, value)
+		if t := p.next(); t.tokenType != whereTokenOperator || (t.text != "," && t.text != ")") {
+			return nil, fmt.Errorf("expected ',' or ')' but found '%s'", t.text)
+		} else if t.text == ")" {
+			return values, nil
+		}
+	}
+}
+
+// likePattern converts a SQL like pattern to a regular expression - % matches any characters and _ any one character
+func likePattern(pattern string) *regexp.Regexp {
+	var res strings.Builder
+	res.WriteString("^")
+	for _, r := range pattern {
+		switch r {
+		case '%':
+			res.WriteString(".*")
+		case '_':
+			res.WriteString(".")
+		default:
+			res.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+	res.WriteString("$")
+	return regexp.MustCompile(res.String())
+}
+
+// whereEqual returns whether two parsed where clauses are the same, ignoring differences in their text such as
+// spacing, the case of keywords and the order of 'in' values
+func whereEqual(a, b limiterWhere) bool {
+	switch a := a.(type) {
+	case *whereAnd:
+		b, ok := b.(*whereAnd)
+		return ok && whereEqual(a.left, b.left) && whereEqual(a.right, b.right)
+	case *whereOr:
+		b, ok := b.(*whereOr)
+		return ok && whereEqual(a.left, b.left) && whereEqual(a.right, b.right)
+	case *whereNot:
+		b, ok := b.(*whereNot)
+		return ok && whereEqual(a.expr, b.expr)
+	case *whereIn:
+		b, ok := b.(*whereIn)
+		if !ok || a.key != b.key || a.negate != b.negate {
+			return false
+		}
+		aValues, bValues := slices.Clone(a.values), slices.Clone(b.values)
+		slices.Sort(aValues)
+		slices.Sort(bValues)
+		return slices.Equal(slices.Compact(aValues), slices.Compact(bValues))
+	case *whereLike:
+		b, ok := b.(*whereLike)
+		return ok && a.key == b.key && a.negate == b.negate && a.pattern.String() == b.pattern.String()
+	case *whereNull:
+		b, ok := b.(*whereNull)
+		return ok && *a == *b
+	}
+	return false
+}
+
+// isUnsatisfiable returns whether the where clause can never match any scope values - it detects contradictory
+// comparisons combined with 'and', e.g. "region = 'us-east-1' and region = 'us-east-2'"
+func isUnsatisfiable(expr limiterWhere) bool {
+	switch w := expr.(type) {
+	case *whereOr:
+		return isUnsatisfiable(w.left) && isUnsatisfiable(w.right)
+	case *whereAnd:
+		if isUnsatisfiable(w.left) || isUnsatisfiable(w.right) {
+			return true
+		}
+		allowed, excluded, isNull := conjunctionConstraints(w)
+		for key, values := range allowed {
+			if isNull[key] {
+				return true
+			}
+			remaining := 0
+			for value := range values {
+				if !excluded[key][value] {
+					remaining++
+				}
+			}
+			if remaining == 0 {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// conjunctionConstraints returns, for each scope key compared in a conjunction of comparisons, the values allowed
+// by its 'in' comparisons, the values excluded by its 'not in' comparisons and whether it must be null -
+// comparisons which are not simple, e.g. 'or' expressions, are ignored
+func conjunctionConstraints(expr limiterWhere) (allowed map[string]map[string]bool, excluded map[string]map[string]bool, isNull map[string]bool) {
+	allowed = make(map[string]map[string]bool)
+	excluded = make(map[string]map[string]bool)
+	isNull = make(map[string]bool)
+
+	var collect func(limiterWhere)
+	collect = func(expr limiterWhere) {
+		switch w := expr.(type) {
+		case *whereAnd:
+			collect(w.left)
+			collect(w.right)
+		case *whereNull:
+			if !w.negate {
+				isNull[w.key] = true
+			}
+		case *whereIn:
+			if w.negate {
+				if excluded[w.key] == nil {
+					excluded[w.key] = make(map[string]bool)
+				}
+				for _, v := range w.values {
+					excluded[w.key][v] = true
+				}
+				return
+			}
+			values := make(map[string]bool)
+			for _, v := range w.values {
+				// intersect with the values allowed by any previous comparison
+				if existing, ok := allowed[w.key]; !ok || existing[v] {
+					values[v] = true
+				}
+			}
+			allowed[w.key] = values
+		}
+	}
+	collect(expr)
+	return allowed, excluded, isNull
+}
@@ -1,7 +1,7 @@
 package plugin
 
 import (
-	"sort"
+	"slices"
 	"strings"
 
 	"github.com/hashicorp/hcl/v2"
@@ -46,8 +46,9 @@ func (l *RateLimiter) OnDecoded(block *hcl.Block) {
 }
 
 func (l *RateLimiter) scopeString() string {
-	scope := l.Scope
-	sort.Strings(scope)
+	// sort a copy, so the order of the scope of the limiter is preserved
+	scope := slices.Clone(l.Scope)
+	slices.Sort(scope)
 	return strings.Join(scope, "'")
 }
 
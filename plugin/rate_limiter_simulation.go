@@ -0,0 +1,475 @@
+package plugin
+
+import (
+	"container/heap"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+const (
+	LimiterIssueInvalid     = "invalid"
+	LimiterIssueUnreachable = "unreachable"
+	LimiterIssueUnmatched   = "unmatched"
+	LimiterIssueOverlapping = "overlapping"
+)
+
+// LimiterWorkloadStream is a stream of calls with the same scope values for SimulateRateLimiters, e.g. calls to
+// list the buckets of one region of one connection
+type LimiterWorkloadStream struct {
+	Name string
+	// the scope values of each call, e.g. {"connection": "aws_prod", "service": "s3", "region": "us-east-1"}
+	ScopeValues map[string]string
+	// the number of calls made
+	Calls int
+	// the time between calls being made - if zero, all calls are made at the start of the workload
+	Interval time.Duration
+	// how long each call takes once it has passed the limiters - this is how long it holds a concurrency slot
+	CallDuration time.Duration
+}
+
+// LimiterIssue is a problem with the limiters of a plugin found by ValidateRateLimiters
+type LimiterIssue struct {
+	Kind    string `json:"kind"`
+	Limiter string `json:"limiter"`
+	// for overlapping limiters, the limiter the limiter overlaps
+	Other   string `json:"other,omitempty"`
+	Message string `json:"message"`
+}
+
+// LimiterSimulation is the result of simulating a workload using SimulateRateLimiters
+type LimiterSimulation struct {
+	// the time from the start of the workload until the last call completes
+	Duration time.Duration          `json:"duration"`
+	Streams  []*LimiterStreamResult `json:"streams"`
+	Limiters []*LimiterResult       `json:"limiters"`
+	Issues   []LimiterIssue         `json:"issues,omitempty"`
+}
+
+// LimiterStreamResult is the simulated behaviour of the calls of a workload stream
+type LimiterStreamResult struct {
+	Name  string `json:"name"`
+	Calls int    `json:"calls"`
+	// the limiters which apply to the calls of the stream
+	Limiters []string `json:"limiters"`
+	// calls per second, from the first call being made until the last call completing - zero if they took no time
+	Throughput  float64       `json:"throughput"`
+	TotalWait   time.Duration `json:"total_wait"`
+	AverageWait time.Duration `json:"average_wait"`
+	MaxWait     time.Duration `json:"max_wait"`
+	// the limiter which caused the most waiting - empty if no call waited
+	BindingLimiter string `json:"binding_limiter,omitempty"`
+}
+
+// LimiterResult is the simulated behaviour of a limiter
+type LimiterResult struct {
+	Name string `json:"name"`
+	// the number of calls the limiter applied to
+	Calls int `json:"calls"`
+	// the number of buckets the limiter created - one for each combination of the values of its scope keys
+	Buckets int `json:"buckets"`
+	// the total time calls waited because of the limiter
+	Wait time.Duration `json:"wait"`
+}
+
+// simulatedLimiter is a limiter and its parsed where clause
+type simulatedLimiter struct {
+	*RateLimiter
+	where  limiterWhere
+	result *LimiterResult
+	// the buckets of the limiter keyed by the values of its scope keys
+	buckets map[string]*simulatedBucket
+}
+
+// simulatedBucket is the token bucket and concurrency slots for one combination of scope values
+type simulatedBucket struct {
+	limiter *rate.Limiter
+	// the time each concurrency slot is released
+	slots []time.Time
+}
+
+// appliesTo returns whether the limiter applies to calls with the given scope values
+func (l *simulatedLimiter) appliesTo(scopeValues map[string]string) bool {
+	return l.where == nil || l.where.matches(scopeValues)
+}
+
+// bucket returns the bucket for the given scope values, creating it if needed
+func (l *simulatedLimiter) bucket(scopeValues map[string]string, start time.Time) *simulatedBucket {
+	scope := append([]string{}, l.Scope...)
+	sort.Strings(scope)
+	keyValues := make([]string, len(scope))
+	for i, key := range scope {
+		keyValues[i] = key + "=" + scopeValues[key]
+	}
+	bucketKey := strings.Join(keyValues, ",")
+
+	if b, ok := l.buckets[bucketKey]; ok {
+		return b
+	}
+	b := &simulatedBucket{}
+	if l.FillRate != nil && *l.FillRate > 0 {
+		bucketSize := int(math.Ceil(float64(*l.FillRate)))
+		if l.BucketSize != nil && *l.BucketSize > 0 {
+			bucketSize = int(*l.BucketSize)
+		}
+		b.limiter = rate.NewLimiter(rate.Limit(*l.FillRate), bucketSize)
+		// the bucket starts full
+		b.limiter.AllowN(start, 0)
+	}
+	l.buckets[bucketKey] = b
+	l.result.Buckets = len(l.buckets)
+	return b
+}
+
+// slotFree returns the earliest time at which a concurrency slot of the bucket is free - the zero time if a slot is
+// free now, or the limiter has no max concurrency
+func (b *simulatedBucket) slotFree(maxConcurrency *int64) time.Time {
+	if maxConcurrency == nil || *maxConcurrency <= 0 || int64(len(b.slots)) < *maxConcurrency {
+		return time.Time{}
+	}
+	return b.slots[b.earliestSlot()]
+}
+
+// acquireSlot acquires the concurrency slot of the bucket which was released first, returning its index - if the
+// limiter has no max concurrency the index is -1. The caller must have checked a slot is free using slotFree
+func (b *simulatedBucket) acquireSlot(maxConcurrency *int64) int {
+	if maxConcurrency == nil || *maxConcurrency <= 0 {
+		return -1
+	}
+	if int64(len(b.slots)) < *maxConcurrency {
+		b.slots = append(b.slots, time.Time{})
+		return len(b.slots) - 1
+	}
+	return b.earliestSlot()
+}
+
+func (b *simulatedBucket) earliestSlot() int {
+	earliest := 0
+	for i, released := range b.slots {
+		if released.Before(b.slots[earliest]) {
+			earliest = i
+		}
+	}
+	return earliest
+}
+
+// simulatedCall is a call of a workload stream
+type simulatedCall struct {
+	stream  int
+	index   int
+	arrival time.Duration
+}
+
+// pendingCall is a call which has not yet passed the limiters
+type pendingCall struct {
+	simulatedCall
+	// the order the call was made, so calls ready at the same time are handled in the order they were made
+	seq int
+	// the time the call next tries to acquire its concurrency slots
+	ready time.Time
+}
+
+// pendingCallQueue is a heap of the pending calls, ordered by the time they are ready
+type pendingCallQueue []*pendingCall
+
+func (q pendingCallQueue) Len() int { return len(q) }
+func (q pendingCallQueue) Less(i, j int) bool {
+	if !q[i].ready.Equal(q[j].ready) {
+		return q[i].ready.Before(q[j].ready)
+	}
+	return q[i].seq < q[j].seq
+}
+func (q pendingCallQueue) Swap(i, j int) { q[i], q[j] = q[j], q[i] }
+func (q *pendingCallQueue) Push(x any)   { *q = append(*q, x.(*pendingCall)) }
+func (q *pendingCallQueue) Pop() any {
+	old := *q
+	call := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return call
+}
+
+// SimulateRateLimiters simulates a workload of calls to a plugin with the given limiters, reporting the throughput
+// and wait time of each stream of calls and which limiter is binding.
+//
+// Each call waits for a concurrency slot of every limiter with a max concurrency which applies to it, then reserves a
+// token from the bucket of every limiter with a fill rate which applies to it, waiting for the longest of the
+// reservations - as the plugin SDK does. Calls are simulated in the order they acquire their concurrency slots,
+// interleaving the streams, using a virtual clock so the result is deterministic.
+func SimulateRateLimiters(limiters []*RateLimiter, workload []*LimiterWorkloadStream) (*LimiterSimulation, error) {
+	issues := ValidateRateLimiters(limiters, workload)
+	for _, issue := range issues {
+		if issue.Kind == LimiterIssueInvalid {
+			return nil, fmt.Errorf("limiter %s: %s", issue.Limiter, issue.Message)
+		}
+	}
+	simLimiters, err := newSimulatedLimiters(limiters)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &LimiterSimulation{Issues: issues}
+	for _, l := range simLimiters {
+		res.Limiters = append(res.Limiters, l.result)
+	}
+
+	// the limiters applying to each stream
+	streamLimiters := make([][]*simulatedLimiter, len(workload))
+	waitByLimiter := make([]map[string]time.Duration, len(workload))
+	for i, stream := range workload {
+		streamResult := &LimiterStreamResult{Name: stream.Name, Calls: stream.Calls, Limiters: []string{}}
+		for _, l := range simLimiters {
+			if l.appliesTo(stream.ScopeValues) {
+				streamLimiters[i] = append(streamLimiters[i], l)
+				streamResult.Limiters = append(streamResult.Limiters, l.Name)
+			}
+		}
+		res.Streams = append(res.Streams, streamResult)
+		waitByLimiter[i] = make(map[string]time.Duration)
+	}
+
+	// the virtual clock starts at an arbitrary fixed time
+	start := time.Unix(0, 0)
+	calls := workloadCalls(workload)
+	queue := make(pendingCallQueue, len(calls))
+	for i, call := range calls {
+		queue[i] = &pendingCall{simulatedCall: call, seq: i, ready: start.Add(call.arrival)}
+	}
+	heap.Init(&queue)
+
+	firstArrival := make([]time.Duration, len(workload))
+	lastCompletion := make([]time.Duration, len(workload))
+	for queue.Len() > 0 {
+		call := heap.Pop(&queue).(*pendingCall)
+		stream := workload[call.stream]
+		arrival := start.Add(call.arrival)
+
+		// wait for a concurrency slot of every limiter - if any are busy, the call tries again once they have all
+		// been released, holding no slots or tokens in the meantime
+		retry, busyLimiter := call.ready, ""
+		for _, l := range streamLimiters[call.stream] {
+			if free := l.bucket(stream.ScopeValues, start).slotFree(l.MaxConcurrency); free.After(retry) {
+				retry, busyLimiter = free, l.Name
+			}
+		}
+		if retry.After(call.ready) {
+			waitByLimiter[call.stream][busyLimiter] += retry.Sub(call.ready)
+			call.ready = retry
+			heap.Push(&queue, call)
+			continue
+		}
+		acquired := call.ready
+
+		// then acquire the slots and reserve a token from the bucket of each limiter, waiting for the longest
+		// reservation - calls are handled in the order they acquire their slots, so tokens are reserved in time order
+		type slot struct {
+			bucket *simulatedBucket
+			index  int
+		}
+		var slots []slot
+		var maxDelay time.Duration
+		var slowestBucket string
+		for _, l := range streamLimiters[call.stream] {
+			b := l.bucket(stream.ScopeValues, start)
+			l.result.Calls++
+			if index := b.acquireSlot(l.MaxConcurrency); index >= 0 {
+				slots = append(slots, slot{b, index})
+			}
+			if b.limiter == nil {
+				continue
+			}
+			if delay := b.limiter.ReserveN(acquired, 1).DelayFrom(acquired); delay > maxDelay {
+				maxDelay, slowestBucket = delay, l.Name
+			}
+		}
+		started := acquired.Add(maxDelay)
+		completed := started.Add(stream.CallDuration)
+		for _, s := range slots {
+			s.bucket.slots[s.index] = completed
+		}
+
+		// record the wait against the limiters which caused it
+		streamResult := res.Streams[call.stream]
+		if maxDelay > 0 {
+			waitByLimiter[call.stream][slowestBucket] += maxDelay
+		}
+		wait := started.Sub(arrival)
+		streamResult.TotalWait += wait
+		if wait > streamResult.MaxWait {
+			streamResult.MaxWait = wait
+		}
+		if call.index == 0 {
+			firstArrival[call.stream] = call.arrival
+		}
+		if c := completed.Sub(start); c > lastCompletion[call.stream] {
+			lastCompletion[call.stream] = c
+		}
+		if c := completed.Sub(start); c > res.Duration {
+			res.Duration = c
+		}
+	}
+
+	limiterResults := make(map[string]*LimiterResult)
+	for _, l := range simLimiters {
+		limiterResults[l.Name] = l.result
+	}
+	for i, streamResult := range res.Streams {
+		if streamResult.Calls == 0 {
+			continue
+		}
+		streamResult.AverageWait = streamResult.TotalWait / time.Duration(streamResult.Calls)
+		if elapsed := lastCompletion[i] - firstArrival[i]; elapsed > 0 {
+			streamResult.Throughput = float64(streamResult.Calls) / elapsed.Seconds()
+		}
+		var bindingWait time.Duration
+		for _, l := range streamLimiters[i] {
+			wait := waitByLimiter[i][l.Name]
+			limiterResults[l.Name].Wait += wait
+			if wait > bindingWait {
+				bindingWait, streamResult.BindingLimiter = wait, l.Name
+			}
+		}
+	}
+	return res, nil
+}
+
+// ValidateRateLimiters checks the limiters of a plugin, reporting:
+//   - limiters with an invalid where clause
+//   - limiters whose where clause can never match, e.g. "region = 'us-east-1' and region = 'us-east-2'"
+//   - limiters which match none of the calls of the workload, if a workload is given
+//   - pairs of limiters with the same scope which apply to the same calls - only the stricter of the two has any effect
+func ValidateRateLimiters(limiters []*RateLimiter, workload []*LimiterWorkloadStream) []LimiterIssue {
+	var issues []LimiterIssue
+	var valid []*simulatedLimiter
+	for _, limiter := range limiters {
+		l, err := newSimulatedLimiter(limiter)
+		if err != nil {
+			issues = append(issues, LimiterIssue{Kind: LimiterIssueInvalid, Limiter: limiter.Name, Message: err.Error()})
+			continue
+		}
+		if l.where != nil && isUnsatisfiable(l.where) {
+			issues = append(issues, LimiterIssue{
+				Kind:    LimiterIssueUnreachable,
+				Limiter: l.Name,
+				Message: fmt.Sprintf("limiter %s can never apply - its where clause '%s' is contradictory", l.Name, *l.Where),
+			})
+			continue
+		}
+		valid = append(valid, l)
+	}
+
+	if len(workload) > 0 {
+		for _, l := range valid {
+			matched := false
+			for _, stream := range workload {
+				if l.appliesTo(stream.ScopeValues) {
+					matched = true
+					break
+				}
+			}
+			if !matched {
+				issues = append(issues, LimiterIssue{
+					Kind:    LimiterIssueUnmatched,
+					Limiter: l.Name,
+					Message: fmt.Sprintf("limiter %s does not apply to any calls of the workload", l.Name),
+				})
+			}
+		}
+	}
+
+	for i, l := range valid {
+		for _, other := range valid[i+1:] {
+			if l.scopeString() != other.scopeString() {
+				continue
+			}
+			if overlap, description := limitersOverlap(l, other, workload); overlap {
+				issues = append(issues, LimiterIssue{
+					Kind:    LimiterIssueOverlapping,
+					Limiter: l.Name,
+					Other:   other.Name,
+					Message: fmt.Sprintf("limiters %s and %s have the same scope and both apply to %s - only the stricter limit has any effect", l.Name, other.Name, description),
+				})
+			}
+		}
+	}
+	return issues
+}
+
+// limitersOverlap returns whether two limiters apply to the same calls - if there is a workload, they overlap if
+// they both apply to a stream of it, otherwise if either has no where clause, so applies to all calls, or they have
+// the same where clause
+func limitersOverlap(l, other *simulatedLimiter, workload []*LimiterWorkloadStream) (bool, string) {
+	if len(workload) == 0 {
+		switch {
+		case l.where == nil && other.where == nil:
+			return true, "all calls"
+		case l.where == nil:
+			return true, fmt.Sprintf("calls where %s", *other.Where)
+		case other.where == nil || whereEqual(l.where, other.where):
+			return true, fmt.Sprintf("calls where %s", *l.Where)
+		}
+		return false, ""
+	}
+	var streams []string
+	for _, stream := range workload {
+		if l.appliesTo(stream.ScopeValues) && other.appliesTo(stream.ScopeValues) {
+			streams = append(streams, stream.Name)
+		}
+	}
+	if len(streams) == 0 {
+		return false, ""
+	}
+	return true, "the calls of " + strings.Join(streams, ", ")
+}
+
+func newSimulatedLimiters(limiters []*RateLimiter) ([]*simulatedLimiter, error) {
+	res := make([]*simulatedLimiter, len(limiters))
+	for i, limiter := range limiters {
+		l, err := newSimulatedLimiter(limiter)
+		if err != nil {
+			return nil, err
+		}
+		res[i] = l
+	}
+	return res, nil
+}
+
+func newSimulatedLimiter(limiter *RateLimiter) (*simulatedLimiter, error) {
+	l := &simulatedLimiter{
+		RateLimiter: limiter,
+		result:      &LimiterResult{Name: limiter.Name},
+		buckets:     make(map[string]*simulatedBucket),
+	}
+	if limiter.Where != nil && strings.TrimSpace(*limiter.Where) != "" {
+		where, err := parseLimiterWhere(*limiter.Where)
+		if err != nil {
+			return nil, err
+		}
+		l.where = where
+	}
+	return l, nil
+}
+
+// workloadCalls returns the calls of the workload in the order they are made - calls made at the same time are
+// interleaved across the streams
+func workloadCalls(workload []*LimiterWorkloadStream) []simulatedCall {
+	var calls []simulatedCall
+	for i, stream := range workload {
+		for c := 0; c < stream.Calls; c++ {
+			calls = append(calls, simulatedCall{stream: i, index: c, arrival: time.Duration(c) * stream.Interval})
+		}
+	}
+	sort.SliceStable(calls, func(i, j int) bool {
+		if calls[i].arrival != calls[j].arrival {
+			return calls[i].arrival < calls[j].arrival
+		}
+		if calls[i].index != calls[j].index {
+			return calls[i].index < calls[j].index
+		}
+		return calls[i].stream < calls[j].stream
+	})
+	return calls
+}
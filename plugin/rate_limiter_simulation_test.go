@@ -0,0 +1,233 @@
+package plugin
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func testLimiter(name string, bucketSize int64, fillRate float32, maxConcurrency int64, where string, scope ...string) *RateLimiter {
+	l := &RateLimiter{Name: name, Scope: scope}
+	if bucketSize > 0 {
+		l.BucketSize = &bucketSize
+	}
+	if fillRate > 0 {
+		l.FillRate = &fillRate
+	}
+	if maxConcurrency > 0 {
+		l.MaxConcurrency = &maxConcurrency
+	}
+	if where != "" {
+		l.Where = &where
+	}
+	return l
+}
+
+func TestLimiterWhere(t *testing.T) {
+	scopeValues := map[string]string{"connection": "aws_prod", "service": "s3", "region": "us-east-1"}
+	tests := []struct {
+		where string
+		want  bool
+	}{
+		{"service = 's3'", true},
+		{"service != 's3'", false},
+		{"service <> 'ec2'", true},
+		{"service = 's3' and region = 'us-west-2'", false},
+		{"service = 'ec2' or region = 'us-east-1'", true},
+		{"not (service = 'ec2' or region = 'us-west-2')", true},
+		{"region in ('us-east-1', 'us-east-2')", true},
+		{"region not in ('us-east-1', 'us-east-2')", false},
+		{"connection like 'aws_%'", true},
+		{"connection not like 'aws_dev%'", true},
+		{"connection like 'aws_'", false},
+		{"action is null", true},
+		{"action = 'List'", false},
+		{"action != 'List'", false},
+		{"service IS NOT NULL AND region LIKE 'us-%'", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.where, func(t *testing.T) {
+			where, err := parseLimiterWhere(tt.where)
+			if err != nil {
+				t.Fatalf("parseLimiterWhere() error = %v", err)
+			}
+			if got := where.matches(scopeValues); got != tt.want {
+				t.Errorf("matches() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	for _, invalid := range []string{"service =", "service = 's3", "service == 's3'", "(service = 's3'", "service in 's3'", "service = 's3' region = 'a'"} {
+		if _, err := parseLimiterWhere(invalid); err == nil {
+			t.Errorf("parseLimiterWhere(%s) expected error", invalid)
+		}
+	}
+}
+
+func TestSimulateRateLimiters_FillRate(t *testing.T) {
+	limiters := []*RateLimiter{testLimiter("aws_global", 10, 10, 0, "")}
+	workload := []*LimiterWorkloadStream{{Name: "list", ScopeValues: map[string]string{"connection": "aws"}, Calls: 30}}
+
+	res, err := SimulateRateLimiters(limiters, workload)
+	if err != nil {
+		t.Fatalf("SimulateRateLimiters() error = %v", err)
+	}
+	// the first 10 calls use the full bucket, the remaining 20 wait for it to fill at 10 per second
+	stream := res.Streams[0]
+	if res.Duration != 2*time.Second || stream.MaxWait != 2*time.Second {
+		t.Errorf("duration = %s, max wait = %s, want 2s", res.Duration, stream.MaxWait)
+	}
+	if stream.Throughput != 15 {
+		t.Errorf("throughput = %v, want 15", stream.Throughput)
+	}
+	if stream.BindingLimiter != "aws_global" || res.Limiters[0].Calls != 30 || res.Limiters[0].Wait != stream.TotalWait {
+		t.Errorf("binding limiter = %s, limiter result = %+v", stream.BindingLimiter, res.Limiters[0])
+	}
+}
+
+func TestSimulateRateLimiters_Scopes(t *testing.T) {
+	limiters := []*RateLimiter{
+		testLimiter("aws_global", 100, 100, 0, ""),
+		// s3 calls are limited per region
+		testLimiter("aws_s3", 5, 5, 0, "service = 's3'", "region"),
+	}
+	workload := []*LimiterWorkloadStream{
+		{Name: "s3 us-east-1", ScopeValues: map[string]string{"service": "s3", "region": "us-east-1"}, Calls: 10},
+		{Name: "s3 us-west-2", ScopeValues: map[string]string{"service": "s3", "region": "us-west-2"}, Calls: 10},
+		{Name: "ec2", ScopeValues: map[string]string{"service": "ec2", "region": "us-east-1"}, Calls: 10},
+	}
+
+	res, err := SimulateRateLimiters(limiters, workload)
+	if err != nil {
+		t.Fatalf("SimulateRateLimiters() error = %v", err)
+	}
+	for _, stream := range res.Streams[:2] {
+		// each region has its own bucket, so each stream waits 1s for its last 5 calls
+		if stream.BindingLimiter != "aws_s3" || stream.MaxWait != time.Second || strings.Join(stream.Limiters, ",") != "aws_global,aws_s3" {
+			t.Errorf("stream %s = %+v", stream.Name, stream)
+		}
+	}
+	if ec2 := res.Streams[2]; ec2.BindingLimiter != "" || ec2.TotalWait != 0 {
+		t.Errorf("stream ec2 = %+v, want no wait", ec2)
+	}
+	if s3 := res.Limiters[1]; s3.Buckets != 2 || s3.Calls != 20 {
+		t.Errorf("limiter aws_s3 = %+v, want 2 buckets and 20 calls", s3)
+	}
+}
+
+func TestSimulateRateLimiters_MaxConcurrency(t *testing.T) {
+	limiters := []*RateLimiter{
+		testLimiter("aws_concurrency", 0, 0, 2, ""),
+		testLimiter("aws_global", 100, 100, 0, ""),
+	}
+	workload := []*LimiterWorkloadStream{{Name: "list", Calls: 4, CallDuration: time.Second}}
+
+	res, err := SimulateRateLimiters(limiters, workload)
+	if err != nil {
+		t.Fatalf("SimulateRateLimiters() error = %v", err)
+	}
+	stream := res.Streams[0]
+	if res.Duration != 2*time.Second || stream.MaxWait != time.Second || stream.TotalWait != 2*time.Second {
+		t.Errorf("duration = %s, stream = %+v", res.Duration, stream)
+	}
+	if stream.BindingLimiter != "aws_concurrency" || stream.Throughput != 2 {
+		t.Errorf("binding limiter = %s, throughput = %v", stream.BindingLimiter, stream.Throughput)
+	}
+}
+
+func TestSimulateRateLimiters_ConcurrencyAndFillRate(t *testing.T) {
+	limiters := []*RateLimiter{
+		testLimiter("conc", 0, 0, 1, "s = 'a'"),
+		testLimiter("rate", 1, 1, 0, ""),
+	}
+	workload := []*LimiterWorkloadStream{
+		{Name: "a", ScopeValues: map[string]string{"s": "a"}, Calls: 5, CallDuration: 10 * time.Second},
+		{Name: "b", ScopeValues: map[string]string{"s": "b"}, Calls: 20},
+	}
+
+	res, err := SimulateRateLimiters(limiters, workload)
+	if err != nil {
+		t.Fatalf("SimulateRateLimiters() error = %v", err)
+	}
+	// calls waiting for the concurrency slot do not hold tokens, so the calls of b take the tokens as they are added,
+	// one per second, and the remaining calls of a take a token once the slot is released
+	if b := res.Streams[1]; b.MaxWait != 20*time.Second || b.Throughput != 1 || b.BindingLimiter != "rate" {
+		t.Errorf("stream b = %+v, want the last call to start after 20s", b)
+	}
+	a := res.Streams[0]
+	if res.Duration != 61*time.Second || a.MaxWait != 51*time.Second || a.BindingLimiter != "conc" {
+		t.Errorf("duration = %s, stream a = %+v", res.Duration, a)
+	}
+	if conc, rate := res.Limiters[0], res.Limiters[1]; conc.Calls != 5 || rate.Calls != 25 {
+		t.Errorf("limiter results = %+v, %+v", conc, rate)
+	}
+}
+
+func TestValidateRateLimiters(t *testing.T) {
+	limiters := []*RateLimiter{
+		testLimiter("contradictory", 10, 10, 0, "region = 'us-east-1' and region in ('us-west-1', 'us-west-2')", "region"),
+		testLimiter("excluded", 10, 10, 0, "region = 'us-east-1' and region != 'us-east-1'"),
+		testLimiter("invalid", 10, 10, 0, "region = us-east-1'"),
+		testLimiter("s3", 10, 10, 0, "service = 's3'", "region"),
+		testLimiter("s3_list", 10, 10, 0, "service = 's3' and action like 'List%'", "region"),
+		testLimiter("s3_connection", 10, 10, 0, "service = 's3'", "connection"),
+		testLimiter("ec2", 10, 10, 0, "service = 'ec2'"),
+	}
+	workload := []*LimiterWorkloadStream{
+		{Name: "list buckets", ScopeValues: map[string]string{"service": "s3", "action": "ListBuckets", "region": "us-east-1"}, Calls: 1},
+	}
+
+	var got []string
+	for _, issue := range ValidateRateLimiters(limiters, workload) {
+		got = append(got, issue.Kind+" "+issue.Limiter+" "+issue.Other)
+	}
+	want := []string{
+		"unreachable contradictory ",
+		"unreachable excluded ",
+		"invalid invalid ",
+		"unmatched ec2 ",
+		"overlapping s3 s3_list",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("ValidateRateLimiters() =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+
+	// with no workload, limiters overlap if they have the same scope and where clause
+	issues := ValidateRateLimiters([]*RateLimiter{limiters[3], testLimiter("s3_copy", 5, 5, 0, "service = 's3'", "region")}, nil)
+	if len(issues) != 1 || issues[0].Kind != LimiterIssueOverlapping {
+		t.Errorf("ValidateRateLimiters() with no workload = %+v, want one overlapping issue", issues)
+	}
+
+	noWorkloadTests := []struct {
+		name    string
+		other   *RateLimiter
+		overlap bool
+	}{
+		// where clauses are compared after parsing, so differences in spacing are ignored
+		{"spacing", testLimiter("s3_spaced", 5, 5, 0, "service='s3'", "region"), true},
+		{"in", testLimiter("s3_in", 5, 5, 0, "service IN ('s3')", "region"), true},
+		// a limiter with no where clause applies to all calls, so overlaps any limiter with the same scope
+		{"no where", testLimiter("global", 5, 5, 0, "", "region"), true},
+		{"no where different scope", testLimiter("global", 5, 5, 0, "", "connection"), false},
+		{"different where", testLimiter("ec2", 5, 5, 0, "service = 'ec2'", "region"), false},
+	}
+	for _, tt := range noWorkloadTests {
+		t.Run(tt.name, func(t *testing.T) {
+			issues := ValidateRateLimiters([]*RateLimiter{limiters[3], tt.other}, nil)
+			if overlap := len(issues) == 1 && issues[0].Kind == LimiterIssueOverlapping; overlap != tt.overlap || len(issues) > 1 {
+				t.Errorf("ValidateRateLimiters() with no workload = %+v, want overlap %v", issues, tt.overlap)
+			}
+		})
+	}
+
+	// comparing scopes does not reorder the scope of a limiter
+	scoped := testLimiter("scoped", 5, 5, 0, "", "region", "connection")
+	ValidateRateLimiters([]*RateLimiter{scoped, testLimiter("other", 5, 5, 0, "", "connection", "region")}, nil)
+	if strings.Join(scoped.Scope, ",") != "region,connection" {
+		t.Errorf("ValidateRateLimiters() changed the scope of a limiter to %v", scoped.Scope)
+	}
+
+	if _, err := SimulateRateLimiters(limiters, workload); err == nil || !strings.Contains(err.Error(), "limiter invalid") {
+		t.Errorf("SimulateRateLimiters() with an invalid limiter error = %v", err)
+	}
+}
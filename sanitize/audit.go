@@ -0,0 +1,41 @@
+package sanitize
+
+// the reasons a value is redacted
+const (
+	// RedactionReasonField - the value is of a field which is excluded from output
+	RedactionReasonField = "field"
+	// RedactionReasonSensitiveTag - the value is of a struct field with the tag `sensitive:"true"`
+	RedactionReasonSensitiveTag = "sensitive_tag"
+	// RedactionReasonSensitiveMark - the value is marked as sensitive, e.g. using the sensitive() function
+	RedactionReasonSensitiveMark = "sensitive_mark"
+	// RedactionReasonSecretValue - the value contains a known secret value
+	RedactionReasonSecretValue = "secret_value"
+	// RedactionReasonDetector - a detector found a secret in the value
+	RedactionReasonDetector = "detector"
+)
+
+// auditRootPath is the path of the value being sanitized
+const auditRootPath = "$"
+
+// AuditReport lists the values redacted when sanitizing a value
+type AuditReport struct {
+	Redactions []Redaction `json:"redactions"`
+}
+
+// Redaction describes a single redacted value
+type Redaction struct {
+	// Path is the location of the redacted value, e.g. $.connection.token or $.items[0]
+	Path   string `json:"path"`
+	Reason string `json:"reason"`
+	// Field is the excluded field, for field redactions
+	Field string `json:"field,omitempty"`
+	// Detector is the name of the detector which found the secret, for detector redactions
+	Detector string `json:"detector,omitempty"`
+}
+
+func (r *AuditReport) add(redaction Redaction) {
+	if r == nil {
+		return
+	}
+	r.Redactions = append(r.Redactions, redaction)
+}
@@ -0,0 +1,86 @@
+package sanitize
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/turbot/terraform-components/lang/marks"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// AddSensitiveValues registers the values marked as sensitive within v, e.g. using the sensitive() function, as
+// secret values, so they are redacted wherever they appear once v has been unmarked
+func (s *Sanitizer) AddSensitiveValues(v cty.Value) {
+	unmarked, pathMarks := v.UnmarkDeepWithPaths()
+	for _, pm := range pathMarks {
+		if _, ok := pm.Marks[marks.Sensitive]; !ok {
+			continue
+		}
+		sensitiveValue, err := pm.Path.Apply(unmarked)
+		if err != nil {
+			continue
+		}
+		_ = cty.Walk(sensitiveValue, func(_ cty.Path, v cty.Value) (bool, error) {
+			if v.IsKnown() && !v.IsNull() && v.Type() == cty.String {
+				s.AddSecretValue(v.AsString())
+			}
+			return true, nil
+		})
+	}
+}
+
+// sanitizeCty returns a copy of v, of the same type, with the values marked as sensitive redacted, and the
+// secrets in its strings redacted. The returned value is unmarked
+func (w *valueSanitizer) sanitizeCty(v cty.Value, path string) cty.Value {
+	unmarked, pathMarks := v.UnmarkDeepWithPaths()
+
+	var sensitivePaths []cty.Path
+	for _, pm := range pathMarks {
+		if _, ok := pm.Marks[marks.Sensitive]; ok {
+			sensitivePaths = append(sensitivePaths, pm.Path)
+		}
+	}
+
+	res, err := cty.Transform(unmarked, func(p cty.Path, v cty.Value) (cty.Value, error) {
+		for _, sensitivePath := range sensitivePaths {
+			if !p.Equals(sensitivePath) {
+				continue
+			}
+			if v.IsNull() {
+				return v, nil
+			}
+			w.report.add(Redaction{Path: path + ctyPathString(p), Reason: RedactionReasonSensitiveMark})
+			// keep the type of the value, so the value can be used within collections
+			if v.Type() == cty.String {
+				return cty.StringVal(RedactedStr), nil
+			}
+			return cty.NullVal(v.Type()), nil
+		}
+
+		if v.IsKnown() && !v.IsNull() && v.Type() == cty.String {
+			return cty.StringVal(w.sanitizer.redactString(v.AsString(), path+ctyPathString(p), w.report)), nil
+		}
+		return v, nil
+	})
+	if err != nil {
+		return cty.DynamicVal
+	}
+	return res
+}
+
+func ctyPathString(p cty.Path) string {
+	var sb strings.Builder
+	for _, step := range p {
+		switch s := step.(type) {
+		case cty.GetAttrStep:
+			sb.WriteString("." + s.Name)
+		case cty.IndexStep:
+			if s.Key.Type() == cty.String {
+				sb.WriteString("." + s.Key.AsString())
+			} else {
+				sb.WriteString(fmt.Sprintf("[%s]", s.Key.AsBigFloat().Text('f', -1)))
+			}
+		}
+	}
+	return sb.String()
+}
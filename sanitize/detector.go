@@ -0,0 +1,72 @@
+package sanitize
+
+import (
+	"regexp"
+)
+
+// excludePatternDetectorName is the name of the detectors created from SanitizerOptions.ExcludePatterns
+const excludePatternDetectorName = "exclude_pattern"
+
+// Detector finds secrets in the strings being sanitized. Detectors may be registered with a Sanitizer at runtime
+// using RegisterDetector
+type Detector interface {
+	// Name identifies the detector in audit reports
+	Name() string
+	// Detect returns the start and end offsets of each secret in v
+	Detect(v string) [][2]int
+}
+
+// RegexDetector is a Detector which redacts every match of a regex
+type RegexDetector struct {
+	name string
+	re   *regexp.Regexp
+}
+
+func NewRegexDetector(name, pattern string) (*RegexDetector, error) {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+	return &RegexDetector{name: name, re: re}, nil
+}
+
+func (d *RegexDetector) Name() string {
+	return d.name
+}
+
+func (d *RegexDetector) Detect(v string) [][2]int {
+	var res [][2]int
+	for _, m := range d.re.FindAllStringIndex(v, -1) {
+		res = append(res, [2]int{m[0], m[1]})
+	}
+	return res
+}
+
+// captureGroupDetector is a Detector which redacts a named capture group of a regex
+type captureGroupDetector struct {
+	name  string
+	re    *regexp.Regexp
+	group string
+}
+
+func (d *captureGroupDetector) Name() string {
+	return d.name
+}
+
+func (d *captureGroupDetector) Detect(v string) [][2]int {
+	idx := d.re.SubexpIndex(d.group)
+	var res [][2]int
+	for _, m := range d.re.FindAllStringSubmatchIndex(v, -1) {
+		if m[2*idx] >= 0 {
+			res = append(res, [2]int{m[2*idx], m[2*idx+1]})
+		}
+	}
+	return res
+}
+
+// dbConnectionPasswordDetector redacts the password of database connection strings
+var dbConnectionPasswordDetector = &captureGroupDetector{
+	name:  "database_connection_password",
+	re:    regexp.MustCompile(`(?P<protocol>[^:]+://)(?P<username>[^:]+):(?P<password>[^@]+)(?P<rest>@.+)`),
+	group: "password",
+}
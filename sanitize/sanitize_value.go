@@ -0,0 +1,218 @@
+package sanitize
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/zclconf/go-cty/cty"
+)
+
+// sensitiveTag is the struct tag which marks a field as containing a secret, e.g. `sensitive:"true"`
+const sensitiveTag = "sensitive"
+
+var ctyValueType = reflect.TypeOf(cty.Value{})
+
+// RegisterSensitiveFields adds the fields tagged `sensitive:"true"` of the given structs, and of any structs they
+// contain, to the fields which are redacted. Both the json and cty names of the fields are added, so the fields are
+// redacted from any output, not just when sanitizing the struct itself
+func (s *Sanitizer) RegisterSensitiveFields(values ...any) {
+	for _, v := range values {
+		for _, f := range sensitiveFieldNames(reflect.TypeOf(v), map[reflect.Type]struct{}{}) {
+			s.addExcludeField(f)
+		}
+	}
+}
+
+func sensitiveFieldNames(t reflect.Type, visited map[reflect.Type]struct{}) []string {
+	for t != nil && (t.Kind() == reflect.Pointer || t.Kind() == reflect.Slice || t.Kind() == reflect.Map) {
+		t = t.Elem()
+	}
+	if t == nil || t.Kind() != reflect.Struct {
+		return nil
+	}
+	if _, ok := visited[t]; ok {
+		return nil
+	}
+	visited[t] = struct{}{}
+
+	var res []string
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		if field.Tag.Get(sensitiveTag) == "true" {
+			for _, tag := range []string{"json", "cty"} {
+				if name := tagName(field, tag); name != "" {
+					res = append(res, name)
+				}
+			}
+			continue
+		}
+		res = append(res, sensitiveFieldNames(field.Type, visited)...)
+	}
+	return res
+}
+
+// tagName returns the name of the field in the given tag, or "" if the tag is not set or the field is excluded
+func tagName(field reflect.StructField, tag string) string {
+	name, _, _ := strings.Cut(field.Tag.Get(tag), ",")
+	if name == "-" {
+		return ""
+	}
+	return name
+}
+
+// SanitizeValue returns a copy of v, of the same type, with its secrets redacted, along with a report of the
+// redacted values. The following are redacted:
+//   - struct fields tagged `sensitive:"true"`
+//   - struct fields and map entries whose names are excluded fields
+//   - cty values marked as sensitive, e.g. using the sensitive() function
+//   - secrets found in strings by the detectors, and known secret values
+func (s *Sanitizer) SanitizeValue(v any) (any, *AuditReport) {
+	report := &AuditReport{}
+	if v == nil {
+		return nil, report
+	}
+
+	w := valueSanitizer{sanitizer: s, report: report, copies: map[uintptr]reflect.Value{}}
+	return w.sanitize(reflect.ValueOf(v), auditRootPath).Interface(), report
+}
+
+// valueSanitizer walks a value, building a sanitized copy
+type valueSanitizer struct {
+	sanitizer *Sanitizer
+	report    *AuditReport
+	// copies are the sanitized copies of the pointers visited so far, so cyclic values are copied without recursing
+	// indefinitely
+	copies map[uintptr]reflect.Value
+}
+
+func (w *valueSanitizer) sanitize(v reflect.Value, path string) reflect.Value {
+	switch v.Kind() {
+	case reflect.Interface:
+		if v.IsNil() {
+			return v
+		}
+		res := reflect.New(v.Type()).Elem()
+		res.Set(w.sanitize(v.Elem(), path))
+		return res
+
+	case reflect.Pointer:
+		if v.IsNil() {
+			return v
+		}
+		if res, ok := w.copies[v.Pointer()]; ok && res.Type() == v.Type() {
+			return res
+		}
+		res := reflect.New(v.Type().Elem())
+		w.copies[v.Pointer()] = res
+		res.Elem().Set(w.sanitize(v.Elem(), path))
+		return res
+
+	case reflect.Struct:
+		if v.Type() == ctyValueType {
+			return reflect.ValueOf(w.sanitizeCty(v.Interface().(cty.Value), path))
+		}
+
+		// copy the struct, including unexported fields, then replace the exported fields with sanitized copies
+		res := reflect.New(v.Type()).Elem()
+		res.Set(v)
+		for i := 0; i < v.NumField(); i++ {
+			field := v.Type().Field(i)
+			if !field.IsExported() {
+				continue
+			}
+			// the fields of embedded structs are promoted, so do not add to the path
+			fieldPath := path
+			name := field.Name
+			if !field.Anonymous {
+				if jsonName := tagName(field, "json"); jsonName != "" {
+					name = jsonName
+				}
+				fieldPath = path + "." + name
+			}
+
+			switch {
+			case field.Tag.Get(sensitiveTag) == "true":
+				res.Field(i).Set(w.redact(v.Field(i), Redaction{Path: fieldPath, Reason: RedactionReasonSensitiveTag}))
+			case !field.Anonymous && w.sanitizer.FieldExcluded(name):
+				res.Field(i).Set(w.redact(v.Field(i), Redaction{Path: fieldPath, Reason: RedactionReasonField, Field: strings.ToLower(name)}))
+			default:
+				res.Field(i).Set(w.sanitize(v.Field(i), fieldPath))
+			}
+		}
+		return res
+
+	case reflect.Map:
+		if v.IsNil() {
+			return v
+		}
+		res := reflect.MakeMapWithSize(v.Type(), v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			key := fmt.Sprint(iter.Key().Interface())
+			keyPath := path + "." + key
+			if w.sanitizer.FieldExcluded(key) {
+				res.SetMapIndex(iter.Key(), w.redact(iter.Value(), Redaction{Path: keyPath, Reason: RedactionReasonField, Field: strings.ToLower(key)}))
+				continue
+			}
+			res.SetMapIndex(iter.Key(), w.sanitize(iter.Value(), keyPath))
+		}
+		return res
+
+	case reflect.Slice:
+		// leave byte slices as they are
+		if v.IsNil() || v.Type().Elem().Kind() == reflect.Uint8 {
+			return v
+		}
+		res := reflect.MakeSlice(v.Type(), v.Len(), v.Len())
+		for i := 0; i < v.Len(); i++ {
+			res.Index(i).Set(w.sanitize(v.Index(i), fmt.Sprintf("%s[%d]", path, i)))
+		}
+		return res
+
+	case reflect.Array:
+		res := reflect.New(v.Type()).Elem()
+		for i := 0; i < v.Len(); i++ {
+			res.Index(i).Set(w.sanitize(v.Index(i), fmt.Sprintf("%s[%d]", path, i)))
+		}
+		return res
+
+	case reflect.String:
+		res := reflect.New(v.Type()).Elem()
+		res.SetString(w.sanitizer.redactString(v.String(), path, w.report))
+		return res
+
+	default:
+		return v
+	}
+}
+
+// redact returns the redacted value to use in place of v, reporting the redaction - empty values are not redacted
+func (w *valueSanitizer) redact(v reflect.Value, redaction Redaction) reflect.Value {
+	if v.IsZero() {
+		return v
+	}
+	w.report.add(redaction)
+	return redactedValue(v.Type())
+}
+
+// redactedValue returns the value used in place of a redacted value of the given type - strings (and pointers to
+// strings) are replaced with RedactedStr, other types with their zero value
+func redactedValue(t reflect.Type) reflect.Value {
+	res := reflect.New(t).Elem()
+	switch t.Kind() {
+	case reflect.String:
+		res.SetString(RedactedStr)
+	case reflect.Interface:
+		if reflect.TypeOf(RedactedStr).AssignableTo(t) {
+			res.Set(reflect.ValueOf(RedactedStr))
+		}
+	case reflect.Pointer:
+		res = reflect.New(t.Elem())
+		res.Elem().Set(redactedValue(t.Elem()))
+	}
+	return res
+}
@@ -8,40 +8,35 @@ import (
 	"regexp"
 	"slices"
 	"sort"
+	"strings"
+	"sync"
 
-	"github.com/turbot/go-kit/helpers"
 	"github.com/turbot/steampipe-plugin-code/secrets"
 )
 
 const RedactedStr = "REDACTED"
 
+// minSecretValueLength is the length below which known secret values are not redacted, to avoid redacting
+// unrelated text which happens to contain a very short secret
+const minSecretValueLength = 4
+
 var NullSanitizer = NewSanitizer(SanitizerOptions{})
 
+// Instance is the sanitizer used for all output. As well as these fields, the fields of connections, credentials and
+// integrations which are tagged `sensitive:"true"` are redacted - these are registered when the types are registered
 var Instance = NewSanitizer(SanitizerOptions{
 	ExcludeFields: []string{
-		"password",
 		"secretaccesskey",
 		"sessiontoken",
-		"smtp_password",
-		"api_key",
-		"app_key",
-		"api_token",
-		"alert_api_key",
-		"incident_api_key",
 		// "key", // we can't sanitize key because of each.key
-		"token",
 		"cloud_token",
 		"aws_access_key_id",
 		"aws_secret_access_key",
 		"aws_session_token",
 		"clientsecret",
-		"access_token",
-		"access_key",
 		"accesskey",
-		"secret_key",
 		"secretkey",
 		"client_id",
-		"client_secret",
 		"tenant_id",
 		"sourcerecord",
 		"cert",
@@ -101,9 +96,22 @@ type SanitizerOptions struct {
 }
 
 type Sanitizer struct {
-	regexes             []*regexp.Regexp
-	fieldPatternRegexes []*regexp.Regexp
-	excludeFields       map[string]struct{}
+	fieldRegexes  []fieldRegex
+	detectors     []Detector
+	excludeFields map[string]struct{}
+	// mut guards the fields and detectors, which may be registered at runtime
+	mut sync.RWMutex
+
+	// secretValues are values known to be secret, e.g. those resolved from a secret manager, keyed by what they
+	// were resolved from - they are retained for the life of the process, as the secrets may be in use until then
+	secretValues    map[string]string
+	secretValuesMut sync.RWMutex
+}
+
+// fieldRegex matches the value of an excluded field in JSON
+type fieldRegex struct {
+	field string
+	re    *regexp.Regexp
 }
 
 var codePluginExcludedRegex = []string{
@@ -115,64 +123,128 @@ var codePluginExcludedRegex = []string{
 
 func NewSanitizer(opts SanitizerOptions) *Sanitizer {
 	s := &Sanitizer{
-		excludeFields: helpers.SliceToLookup(opts.ExcludeFields),
-	}
-
-	builtInExcludeFields := opts.ExcludeFields
-	codePluginMatchers := secrets.Matchers()
-
-	for _, sm := range codePluginMatchers {
-		if !slices.Contains(builtInExcludeFields, sm.Type()) {
-			builtInExcludeFields = append(builtInExcludeFields, sm.Type())
-		}
+		excludeFields: make(map[string]struct{}),
+		secretValues:  make(map[string]string),
 	}
 
 	// first convert exclude fields to regex regexes to exclude the fields from both JSON and YAML
 	for _, f := range opts.ExcludeFields {
-		re, err := regexp.Compile(getExcludeFromJsonRegex(f))
-		if err != nil {
-			slog.Warn("Invalid regex pattern", slog.String("pattern", f), "error", err)
-			continue
-		}
-		s.fieldPatternRegexes = append(s.fieldPatternRegexes, re)
-
+		s.addExcludeField(f)
 	}
 
 	// add in custom regexes
 	for _, p := range opts.ExcludePatterns {
-		re, err := regexp.Compile(p)
+		d, err := NewRegexDetector(excludePatternDetectorName, p)
 		if err != nil {
 			slog.Warn("Invalid regex pattern", slog.String("pattern", p), "error", err)
 			continue
 		}
-		s.regexes = append(s.regexes, re)
+		s.detectors = append(s.detectors, d)
 	}
 
 	if opts.ImportCodeMatchers {
-		for _, sm := range codePluginMatchers {
+		for _, sm := range secrets.Matchers() {
 			// basic_auth: matches URLs with a specific scheme (like http, https, ftp, etc.), followed by a user and password before an @
 			if !slices.Contains(codePluginExcludedRegex, sm.Type()) {
-				s.regexes = append(s.regexes, sm.DenyList()...)
+				for _, re := range sm.DenyList() {
+					s.detectors = append(s.detectors, &RegexDetector{name: sm.Type(), re: re})
+				}
 			}
 		}
 	}
+
+	// The database connection string is also redacted by the Basic Auth redaction, it will actually redact more than the
+	// plain db redaction
+	s.detectors = append(s.detectors, dbConnectionPasswordDetector)
 	return s
 }
 
+// addExcludeField adds a field whose value is redacted - field names are case insensitive
+func (s *Sanitizer) addExcludeField(f string) {
+	f = strings.ToLower(f)
+
+	s.mut.Lock()
+	defer s.mut.Unlock()
+
+	if _, exists := s.excludeFields[f]; exists {
+		return
+	}
+	re, err := regexp.Compile(getExcludeFromJsonRegex(regexp.QuoteMeta(f)))
+	if err != nil {
+		slog.Warn("Invalid regex pattern", slog.String("pattern", f), "error", err)
+		return
+	}
+	s.excludeFields[f] = struct{}{}
+	s.fieldRegexes = append(s.fieldRegexes, fieldRegex{field: f, re: re})
+}
+
 func (s *Sanitizer) FieldExcluded(v string) bool {
-	_, excluded := s.excludeFields[v]
+	s.mut.RLock()
+	defer s.mut.RUnlock()
+
+	_, excluded := s.excludeFields[strings.ToLower(v)]
 	return excluded
 }
 
-func (s *Sanitizer) SanitizeString(v string) string {
-	type replacement struct {
-		start int
-		end   int
+// RegisterDetector adds detectors which find secrets in the strings being sanitized
+func (s *Sanitizer) RegisterDetector(detectors ...Detector) {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+
+	s.detectors = append(s.detectors, detectors...)
+}
+
+// AddSecretValue registers a value which must be redacted wherever it appears
+func (s *Sanitizer) AddSecretValue(v string) {
+	s.SetSecretValue(v, v)
+}
+
+// SetSecretValue registers a value which must be redacted wherever it appears, replacing the value previously
+// registered with the same key, e.g. when the secret a reference resolves to is rotated
+func (s *Sanitizer) SetSecretValue(key, v string) {
+	s.secretValuesMut.Lock()
+	defer s.secretValuesMut.Unlock()
+
+	if len(v) < minSecretValueLength {
+		delete(s.secretValues, key)
+		return
 	}
-	var replacements []*replacement
+	s.secretValues[key] = v
+}
+
+func (s *Sanitizer) SanitizeString(v string) string {
+	return s.redactString(v, auditRootPath, nil)
+}
+
+// SanitizeStringWithReport sanitizes the string, returning a report of the redactions
+func (s *Sanitizer) SanitizeStringWithReport(v string) (string, *AuditReport) {
+	report := &AuditReport{}
+	return s.redactString(v, auditRootPath, report), report
+}
+
+// redactString redacts the secrets in v, adding the redactions to the report, if it is not nil
+func (s *Sanitizer) redactString(v string, path string, report *AuditReport) string {
+	return s.applySpans(v, s.findSpans(v, path), report)
+}
+
+// redactionSpan is a range of a string which is redacted
+type redactionSpan struct {
+	start int
+	end   int
+	// matchStart is the start of the text which was matched to find the span, which may include context before the
+	// redacted range, e.g. the field name
+	matchStart int
+	redaction  Redaction
+}
+
+// findSpans returns the ranges of v to redact, ordered by start, with overlapping ranges merged
+func (s *Sanitizer) findSpans(v string, path string) []*redactionSpan {
+	var spans []*redactionSpan
+
+	s.mut.RLock()
 	// first field replacements - for this we will replace _just the first capture group_
-	for _, re := range s.fieldPatternRegexes {
-		for _, m := range re.FindAllStringSubmatchIndex(v, -1) {
+	for _, f := range s.fieldRegexes {
+		for _, m := range f.re.FindAllStringSubmatchIndex(v, -1) {
 			var startOffset, endOffset int
 			if len(m) > 2 {
 				// If the regexp in the secret matcher has a match group, then use it
@@ -185,62 +257,90 @@ func (s *Sanitizer) SanitizeString(v string) string {
 				startOffset = m[0]
 				endOffset = m[1]
 			}
-			replacements = append(replacements, &replacement{
-				start: startOffset,
-				end:   endOffset,
+			spans = append(spans, &redactionSpan{
+				start:      startOffset,
+				end:        endOffset,
+				matchStart: m[0],
+				redaction:  Redaction{Path: path, Reason: RedactionReasonField, Field: f.field},
 			})
 		}
 	}
-	// now full regex replacements - replace the full match
-	for _, re := range s.regexes {
-		for _, m := range re.FindAllStringSubmatchIndex(v, -1) {
-			// use the full match as the secret.
-			startOffset := m[0]
-			endOffset := m[1]
-
-			replacements = append(replacements, &replacement{
-				start: startOffset,
-				end:   endOffset,
+	// now the detectors - regexes replace the full match
+	for _, d := range s.detectors {
+		for _, m := range d.Detect(v) {
+			spans = append(spans, &redactionSpan{
+				start:      m[0],
+				end:        m[1],
+				matchStart: m[0],
+				redaction:  Redaction{Path: path, Reason: RedactionReasonDetector, Detector: d.Name()},
 			})
 		}
 	}
+	s.mut.RUnlock()
+
+	// now known secret values
+	s.secretValuesMut.RLock()
+	for _, secret := range s.secretValues {
+		for offset := 0; offset < len(v); {
+			idx := strings.Index(v[offset:], secret)
+			if idx < 0 {
+				break
+			}
+			start := offset + idx
+			spans = append(spans, &redactionSpan{
+				start:      start,
+				end:        start + len(secret),
+				matchStart: start,
+				redaction:  Redaction{Path: path, Reason: RedactionReasonSecretValue},
+			})
+			offset = start + len(secret)
+		}
+	}
+	s.secretValuesMut.RUnlock()
 
-	// now order replacements and remove overlaps
-	sort.Slice(replacements, func(i, j int) bool {
-		return replacements[i].start < replacements[j].start
+	// now order spans and remove overlaps
+	sort.SliceStable(spans, func(i, j int) bool {
+		return spans[i].start < spans[j].start
 	})
-	var newReplacements []*replacement
-	var lastReplacement *replacement
-	for _, r := range replacements {
-		if lastReplacement != nil && r.start < lastReplacement.end {
-			slog.Debug("Overlapping replacements", "r1_start", r.start, "r1_end", r.end, "r2_start", lastReplacement.start, "r2_end", lastReplacement.end)
-			// expand previous replacement
-			lastReplacement.end = r.end
+	var res []*redactionSpan
+	var last *redactionSpan
+	for _, r := range spans {
+		if last != nil && r.start < last.end {
+			slog.Debug("Overlapping replacements", "r1_start", r.start, "r1_end", r.end, "r2_start", last.start, "r2_end", last.end)
+			// expand previous span
+			if r.end > last.end {
+				last.end = r.end
+			}
+			if r.matchStart < last.matchStart {
+				last.matchStart = r.matchStart
+			}
 			continue
 		}
-		newReplacements = append(newReplacements, r)
-		lastReplacement = r
+		res = append(res, r)
+		last = r
 	}
+	return res
+}
 
-	// now apply replacements in reverse order so the indexes remain valid
-	for i := len(newReplacements) - 1; i >= 0; i-- {
-		r := newReplacements[i]
+// applySpans replaces the given spans of v, which must be ordered and not overlap, with RedactedStr
+func (s *Sanitizer) applySpans(v string, spans []*redactionSpan, report *AuditReport) string {
+	// apply replacements in reverse order so the indexes remain valid
+	var redactions []Redaction
+	for i := len(spans) - 1; i >= 0; i-- {
+		r := spans[i]
+		// do not report a value which has already been redacted, e.g. when sanitizing sanitized output
+		if v[r.start:r.end] == RedactedStr {
+			continue
+		}
 		v = v[:r.start] + RedactedStr + v[r.end:]
+		redactions = append(redactions, r.redaction)
+	}
+	for i := len(redactions) - 1; i >= 0; i-- {
+		report.add(redactions[i])
 	}
-
-	v = redactDbConnectionPassword(v)
 	return v
 }
 
-// The database connection string is also redacted by the Basic Auth redaction, it will actually redact more than the
-// plain db redaction
-func redactDbConnectionPassword(connectionString string) string {
-	// Define the regex to match and capture only the password part
-	re := regexp.MustCompile(`(?P<protocol>[^:]+://)(?P<username>[^:]+):(?P<password>[^@]+)(?P<rest>@.+)`)
-	// Replace only the password part with "REDACTED"
-	return re.ReplaceAllString(connectionString, `${protocol}${username}:REDACTED${rest}`)
-}
-
 // Sanitize takes any value and returns a sanitized version of the value.
 // If the value is a string, then it is sanitized.
 // Otherwise the value is marshaled to JSON and then sanitized.
@@ -275,22 +375,19 @@ func (s *Sanitizer) Sanitize(v any) any {
 	return res
 }
 
+// SanitizeStruct returns a copy of v with its secrets redacted - see SanitizeValue
 func SanitizeStruct[T any](s *Sanitizer, v T) (T, error) {
 	var empty T
 
-	jsonBytes, err := json.Marshal(v)
-	if err != nil {
-		return empty, err
+	res, _ := s.SanitizeValue(v)
+	if res == nil {
+		return empty, nil
 	}
-	valStr := string(jsonBytes)
-	sanitizedString := s.SanitizeString(valStr)
-
-	if sanitizedString == valStr {
-		return v, err
+	sanitized, ok := res.(T)
+	if !ok {
+		return empty, fmt.Errorf("unable to sanitize %T", v)
 	}
-
-	err = json.Unmarshal([]byte(sanitizedString), &empty)
-	return empty, err
+	return sanitized, nil
 }
 
 func (s *Sanitizer) SanitizeKeyValue(k string, v any) any {
@@ -0,0 +1,85 @@
+package sanitize
+
+import (
+	"context"
+	"log/slog"
+)
+
+// SlogHandler is a slog.Handler middleware which redacts the secrets in the message and attributes of each record
+// before passing it to the wrapped handler, so logs are sanitized without each call site sanitizing its values
+type SlogHandler struct {
+	handler   slog.Handler
+	sanitizer *Sanitizer
+}
+
+// NewSlogHandler wraps handler, sanitizing records using the given sanitizer
+//
+// e.g. slog.SetDefault(slog.New(sanitize.NewSlogHandler(slog.NewJSONHandler(os.Stderr, nil), sanitize.Instance)))
+//
+// Note that the handler wrapped must not be the handler of the slog default logger, as slog.SetDefault redirects
+// the output of that handler to the new default logger
+func NewSlogHandler(handler slog.Handler, s *Sanitizer) *SlogHandler {
+	return &SlogHandler{
+		handler:   handler,
+		sanitizer: s,
+	}
+}
+
+func (h *SlogHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.handler.Enabled(ctx, level)
+}
+
+func (h *SlogHandler) Handle(ctx context.Context, r slog.Record) error {
+	res := slog.NewRecord(r.Time, r.Level, h.sanitizer.SanitizeString(r.Message), r.PC)
+	r.Attrs(func(a slog.Attr) bool {
+		res.AddAttrs(h.sanitizeAttr(a))
+		return true
+	})
+	return h.handler.Handle(ctx, res)
+}
+
+func (h *SlogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	sanitized := make([]slog.Attr, len(attrs))
+	for i, a := range attrs {
+		sanitized[i] = h.sanitizeAttr(a)
+	}
+	return NewSlogHandler(h.handler.WithAttrs(sanitized), h.sanitizer)
+}
+
+func (h *SlogHandler) WithGroup(name string) slog.Handler {
+	return NewSlogHandler(h.handler.WithGroup(name), h.sanitizer)
+}
+
+func (h *SlogHandler) sanitizeAttr(a slog.Attr) slog.Attr {
+	// resolve LogValuers so the values they log are sanitized
+	v := a.Value.Resolve()
+	if a.Key != "" && h.sanitizer.FieldExcluded(a.Key) {
+		return slog.String(a.Key, RedactedStr)
+	}
+
+	switch v.Kind() {
+	case slog.KindString:
+		return slog.String(a.Key, h.sanitizer.SanitizeString(v.String()))
+	case slog.KindGroup:
+		group := v.Group()
+		sanitized := make([]slog.Attr, len(group))
+		for i, ga := range group {
+			sanitized[i] = h.sanitizeAttr(ga)
+		}
+		return slog.Attr{Key: a.Key, Value: slog.GroupValue(sanitized...)}
+	case slog.KindAny:
+		switch val := v.Any().(type) {
+		case error:
+			// handlers log errors using their message, so sanitize that
+			return slog.String(a.Key, h.sanitizer.SanitizeString(val.Error()))
+		case []byte:
+			return slog.String(a.Key, h.sanitizer.SanitizeString(string(val)))
+		default:
+			res, _ := h.sanitizer.SanitizeValue(val)
+			return slog.Any(a.Key, res)
+		}
+	default:
+		// numbers, bools, times and durations
+		return slog.Attr{Key: a.Key, Value: v}
+	}
+}
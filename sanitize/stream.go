@@ -0,0 +1,214 @@
+package sanitize
+
+import (
+	"io"
+	"sync"
+)
+
+// defaultStreamLookback is the number of bytes held back when sanitizing a stream, so that secrets split across
+// writes are redacted
+const defaultStreamLookback = 4096
+
+// streamReadSize is the size of the reads made from the underlying reader of a Reader
+const streamReadSize = 32 * 1024
+
+type streamConfig struct {
+	lookback int
+}
+
+type StreamOption func(*streamConfig)
+
+// WithLookback sets the number of bytes held back when sanitizing a stream. Secrets (including the context a
+// detector needs to match them, e.g. the field name) which are no longer than this are redacted, however they are
+// split across writes
+func WithLookback(lookback int) StreamOption {
+	return func(c *streamConfig) {
+		c.lookback = lookback
+	}
+}
+
+func newStreamConfig(opts []StreamOption) *streamConfig {
+	cfg := &streamConfig{
+		lookback: defaultStreamLookback,
+	}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	return cfg
+}
+
+// streamRedactor sanitizes a stream of data, holding back the last lookback bytes until either more data arrives
+// or the stream is flushed. A secret which is longer than the lookback is output redacted as far as it has been
+// written, holding back only its start, up to the lookback, so the rest of it is still matched
+type streamRedactor struct {
+	sanitizer *Sanitizer
+	lookback  int
+	pending   []byte
+	// redacted is the length of the start of pending which has already been output, redacted - this is the start of
+	// a secret which ran to the end of the data, held back so the rest of the secret is matched in the next write
+	redacted int
+}
+
+func newStreamRedactor(s *Sanitizer, cfg *streamConfig) *streamRedactor {
+	return &streamRedactor{
+		sanitizer: s,
+		lookback:  cfg.lookback,
+	}
+}
+
+// next adds p to the stream, returning the sanitized data which is ready to be output
+func (r *streamRedactor) next(p []byte) string {
+	r.pending = append(r.pending, p...)
+	if len(r.pending)-r.redacted <= r.lookback {
+		return ""
+	}
+
+	v := string(r.pending)
+	spans := r.sanitizer.findSpans(v, auditRootPath)
+
+	// any secret which is not yet complete must start within the lookback, so output everything before it - if a
+	// secret which has been found (or the text matched to find it) spans the cut, output the whole of it, redacted
+	cut := len(v) - r.lookback
+	for _, span := range spans {
+		if span.matchStart < cut && span.end > cut {
+			cut = span.end
+		}
+	}
+	return r.output(v, spans, cut)
+}
+
+// output returns the data before cut, with the secrets found in it redacted, and holds back the rest. The spans must
+// be ordered and not overlap
+func (r *streamRedactor) output(v string, spans []*redactionSpan, cut int) string {
+	start := r.redacted
+	var ready []*redactionSpan
+	var last *redactionSpan
+	for _, span := range spans {
+		if span.end > cut {
+			break
+		}
+		last = span
+		if span.start < r.redacted {
+			// the rest of a secret whose start has already been output, redacted
+			start = max(start, span.end)
+			continue
+		}
+		shifted := *span
+		shifted.start -= start
+		shifted.end -= start
+		ready = append(ready, &shifted)
+	}
+	res := r.sanitizer.applySpans(v[start:cut], ready, nil)
+
+	r.pending = append(r.pending[:0], v[cut:]...)
+	r.redacted = 0
+	// a secret which runs to the end of the output may continue in the next write - hold back its start, up to the
+	// lookback, so the rest of it is still matched
+	if cut == len(v) && last != nil && last.end == len(v) {
+		head := v[last.matchStart:min(last.matchStart+r.lookback, len(v))]
+		r.pending = append(r.pending[:0], head...)
+		r.redacted = len(head)
+	}
+	return res
+}
+
+// flush returns the sanitized data which has been held back
+func (r *streamRedactor) flush() string {
+	v := string(r.pending)
+	res := r.output(v, r.sanitizer.findSpans(v, auditRootPath), len(v))
+	r.pending = r.pending[:0]
+	r.redacted = 0
+	return res
+}
+
+// Writer is an io.Writer which redacts the secrets in the data written to it before writing it to the underlying
+// writer. To redact secrets split across writes, the last bytes written are held back until more data is written,
+// or the Writer is flushed or closed
+type Writer struct {
+	w      io.Writer
+	stream *streamRedactor
+	mut    sync.Mutex
+}
+
+func NewWriter(w io.Writer, s *Sanitizer, opts ...StreamOption) *Writer {
+	return &Writer{
+		w:      w,
+		stream: newStreamRedactor(s, newStreamConfig(opts)),
+	}
+}
+
+func (w *Writer) Write(p []byte) (int, error) {
+	w.mut.Lock()
+	defer w.mut.Unlock()
+
+	if out := w.stream.next(p); out != "" {
+		if _, err := io.WriteString(w.w, out); err != nil {
+			return 0, err
+		}
+	}
+	return len(p), nil
+}
+
+// Flush writes the data which has been held back to the underlying writer. Flushing part way through a stream
+// means a secret split across the flush is not redacted
+func (w *Writer) Flush() error {
+	w.mut.Lock()
+	defer w.mut.Unlock()
+
+	if out := w.stream.flush(); out != "" {
+		if _, err := io.WriteString(w.w, out); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Close flushes the Writer, then closes the underlying writer, if it is an io.Closer
+func (w *Writer) Close() error {
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	if closer, ok := w.w.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
+
+// Reader is an io.Reader which redacts the secrets in the data read from the underlying reader
+type Reader struct {
+	r      io.Reader
+	stream *streamRedactor
+	buf    []byte
+	out    []byte
+	err    error
+}
+
+func NewReader(r io.Reader, s *Sanitizer, opts ...StreamOption) *Reader {
+	return &Reader{
+		r:      r,
+		stream: newStreamRedactor(s, newStreamConfig(opts)),
+	}
+}
+
+func (r *Reader) Read(p []byte) (int, error) {
+	for len(r.out) == 0 {
+		if r.err != nil {
+			return 0, r.err
+		}
+		if r.buf == nil {
+			r.buf = make([]byte, streamReadSize)
+		}
+
+		n, err := r.r.Read(r.buf)
+		r.out = append(r.out, r.stream.next(r.buf[:n])...)
+		if err != nil {
+			// the underlying reader is done (or failed), so output the data held back
+			r.out = append(r.out, r.stream.flush()...)
+			r.err = err
+		}
+	}
+
+	n := copy(p, r.out)
+	r.out = r.out[n:]
+	return n, nil
+}
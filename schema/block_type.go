@@ -57,13 +57,19 @@ const (
 	BlockTypeOption            = "option"
 	BlockTypeCapture           = "capture"
 	BlockTypeMethod            = "method"
+	BlockTypeBlackout          = "blackout"
+	BlockTypeFallback          = "fallback"
+	BlockTypeTemplate          = "template"
+	BlockTypeField             = "field"
+	BlockTypeLink              = "link"
+	BlockTypeModHost           = "mod_host"
 
 	AttributeTypeValue   = "value"
 	AttributeTypeType    = "type"
 	AttributeTypeDefault = "default"
 	AttributeTypeEnum    = "enum"
 	AttributeTypeFormat  = "format"
-	
+
 	AttributeTypeSensitive = "sensitive"
 	// Pipeline param block
 	AttributeTypeOptional = "optional"
@@ -132,6 +138,11 @@ const (
 	AttributeTypeToken         = "token"
 	AttributeTypeSigningSecret = "signing_secret"
 	AttributeTypeWebhookUrl    = "webhook_url"
+	AttributeTypeRoutingKey    = "routing_key"
+	AttributeTypeSeverity      = "severity"
+	AttributeTypeAlertApiKey   = "alert_api_key"
+	AttributeTypeApiUrl        = "api_url"
+	AttributeTypePriority      = "priority"
 
 	AttributeTypeIntegration = "integration"
 	AttributeTypeCredential  = "credential"
@@ -152,27 +163,33 @@ const (
 	AttributeTypeResponseBody    = "response_body"
 	AttributeTypeStatusCode      = "status_code"
 	AttributeTypeStatus          = "status"
+	AttributeTypeConnection      = "connection"
 
 	// Used byy Pipeline step
 	AttributeTypePipeline = "pipeline"
 
 	// Used by input and message step
-	AttributeTypeOptions      = "options"
-	AttributeTypeResponseUrl  = "response_url"
-	AttributeTypeSmtpHost     = "smtp_host"
-	AttributeTypeSmtpPassword = "smtp_password"
-	AttributeTypeSmtpPort     = "smtp_port"
-	AttributeTypeSmtpServer   = "smtp_server"
-	AttributeTypeSmtpTls      = "smtp_tls"
-	AttributeTypeSmtpUsername = "smtp_username"
-	AttributeTypeSmtpsPort    = "smtps_port"
-	AttributeTypeLabel        = "label"
-	AttributeTypeSelected     = "selected"
-	AttributeTypeNotifier     = "notifier"
-	AttributeTypeNotifies     = "notifies"
-	AttributeTypeNotifierName = "notifier_name"
-	AttributeTypeMarkdown     = "markdown"
-	AttributeTypeStyle        = "style"
+	AttributeTypeOptions       = "options"
+	AttributeTypeResponseUrl   = "response_url"
+	AttributeTypeColor         = "color"
+	AttributeTypeShort         = "short"
+	AttributeTypeSmtpHost      = "smtp_host"
+	AttributeTypeSmtpPassword  = "smtp_password"
+	AttributeTypeSmtpPort      = "smtp_port"
+	AttributeTypeSmtpServer    = "smtp_server"
+	AttributeTypeSmtpTls       = "smtp_tls"
+	AttributeTypeSmtpUsername  = "smtp_username"
+	AttributeTypeSmtpsPort     = "smtps_port"
+	AttributeTypeLabel         = "label"
+	AttributeTypeSelected      = "selected"
+	AttributeTypeNotifier      = "notifier"
+	AttributeTypeNotifies      = "notifies"
+	AttributeTypeNotifierName  = "notifier_name"
+	AttributeTypeWhen          = "when"
+	AttributeTypeEscalateAfter = "escalate_after"
+	AttributeTypeRouting       = "routing"
+	AttributeTypeMarkdown      = "markdown"
+	AttributeTypeStyle         = "style"
 
 	AttributeTypeMessage = "message"
 
@@ -209,6 +226,11 @@ const (
 	AttributeTypeSchedule   = "schedule"
 	AttributeTypePrimaryKey = "primary_key"
 	AttributeTypeEnabled    = "enabled"
+	AttributeTypeTimezone   = "timezone"
+	AttributeTypeJitter     = "jitter"
+	AttributeTypeCatchUp    = "catch_up"
+	AttributeTypeStart      = "start"
+	AttributeTypeEnd        = "end"
 
 	// HTTP Trigger attributes
 	AttributeTypeExecutionMode = "execution_mode"
@@ -224,13 +246,17 @@ const (
 	TriggerTypeHttp     = "http"
 
 	// Integration Types
-	IntegrationTypeSlack   = "slack"
-	IntegrationTypeEmail   = "email"
-	IntegrationTypeMsTeams = "msteams"
-	IntegrationTypeHttp    = "http"
+	IntegrationTypeSlack     = "slack"
+	IntegrationTypeEmail     = "email"
+	IntegrationTypeMsTeams   = "msteams"
+	IntegrationTypeHttp      = "http"
+	IntegrationTypePagerDuty = "pagerduty"
+	IntegrationTypeOpsgenie  = "opsgenie"
+	IntegrationTypeDiscord   = "discord"
 
 	LabelName = "name"
 	LabelType = "type"
+	LabelHost = "host"
 
 	ResourceTypeSnapshot = "snapshot"
 	AttributeArgs        = "args"
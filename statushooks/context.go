@@ -11,6 +11,7 @@ var (
 	contextKeySnapshotProgress = contexthelpers.ContextKey("snapshot_progress")
 	contextKeyStatusHook       = contexthelpers.ContextKey("status_hook")
 	contextKeyMessageRenderer  = contexthelpers.ContextKey("message_renderer")
+	contextKeyDownloadProgress = contexthelpers.ContextKey("download_progress")
 )
 
 func DisableStatusHooks(ctx context.Context) context.Context {
@@ -47,6 +48,21 @@ func SnapshotProgressFromContext(ctx context.Context) SnapshotProgress {
 	return NullProgress
 }
 
+func AddDownloadProgressToContext(ctx context.Context, downloadProgress DownloadProgress) context.Context {
+	return context.WithValue(ctx, contextKeyDownloadProgress, downloadProgress)
+}
+
+func DownloadProgressFromContext(ctx context.Context) DownloadProgress {
+	if ctx == nil {
+		return NullDownloadProgress
+	}
+	if val, ok := ctx.Value(contextKeyDownloadProgress).(DownloadProgress); ok {
+		return val
+	}
+	// no download progress in context - return null progress
+	return NullDownloadProgress
+}
+
 func AddMessageRendererToContext(ctx context.Context, messageRenderer MessageRenderer) context.Context {
 	return context.WithValue(ctx, contextKeyMessageRenderer, messageRenderer)
 }
@@ -0,0 +1,96 @@
+package statushooks
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// DownloadProgressEvent reports the progress of downloading a single layer of an image
+type DownloadProgressEvent struct {
+	// the image being downloaded
+	Image string
+	// the layer title, or digest if it has no title
+	Layer  string
+	Digest string
+	// the number of bytes of the layer downloaded so far, including any resumed from a previous download
+	Completed int64
+	Total     int64
+	// the number of bytes resumed from a previous, interrupted download
+	Resumed int64
+	Done    bool
+}
+
+type DownloadProgress interface {
+	UpdateDownloadProgress(context.Context, DownloadProgressEvent)
+}
+
+func UpdateDownloadProgress(ctx context.Context, event DownloadProgressEvent) {
+	DownloadProgressFromContext(ctx).UpdateDownloadProgress(ctx, event)
+}
+
+// NullDownloadProgress is an empty implementation of DownloadProgress
+var NullDownloadProgress = &nullDownloadProgress{}
+
+type nullDownloadProgress struct{}
+
+func (*nullDownloadProgress) UpdateDownloadProgress(context.Context, DownloadProgressEvent) {}
+
+// DownloadProgressReporter is an implementation of DownloadProgress which shows the total progress of all
+// downloads using the status hooks
+type DownloadProgressReporter struct {
+	// layer progress keyed by image and digest
+	layers map[string]DownloadProgressEvent
+	mut    sync.Mutex
+}
+
+func NewDownloadProgressReporter() *DownloadProgressReporter {
+	return &DownloadProgressReporter{
+		layers: make(map[string]DownloadProgressEvent),
+	}
+}
+
+func (r *DownloadProgressReporter) UpdateDownloadProgress(ctx context.Context, event DownloadProgressEvent) {
+	r.mut.Lock()
+	defer r.mut.Unlock()
+
+	r.layers[event.Image+"@"+event.Digest] = event
+	SetStatus(ctx, r.message())
+}
+
+func (r *DownloadProgressReporter) message() string {
+	var completed, total int64
+	images := make(map[string]bool)
+	for _, layer := range r.layers {
+		completed += layer.Completed
+		total += layer.Total
+		if !layer.Done {
+			images[layer.Image] = true
+		}
+	}
+	if len(images) == 0 {
+		return fmt.Sprintf("Downloaded %s", formatBytes(total))
+	}
+
+	names := make([]string, 0, len(images))
+	for image := range images {
+		names = append(names, image)
+	}
+	sort.Strings(names)
+	return fmt.Sprintf("Downloading %s (%s of %s)", strings.Join(names, ", "), formatBytes(completed), formatBytes(total))
+}
+
+func formatBytes(bytes int64) string {
+	const unit = 1024
+	if bytes < unit {
+		return fmt.Sprintf("%d B", bytes)
+	}
+	div, exp := int64(unit), 0
+	for n := bytes / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %cB", float64(bytes)/float64(div), "KMGTPE"[exp])
+}
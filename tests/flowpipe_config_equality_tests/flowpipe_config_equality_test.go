@@ -128,6 +128,36 @@ var flowpipeConfigEqualityTestCases = []flowpipeConfigEqualityTestCase{
 		compare: "./config_notifier_base_f",
 		equal:   false,
 	},
+	{
+		title:   "test: base_g == base_g",
+		base:    "./config_notifier_base_g",
+		compare: "./config_notifier_base_g",
+		equal:   true,
+	},
+	{
+		title:   "test: base_g != base_h (when)",
+		base:    "./config_notifier_base_g",
+		compare: "./config_notifier_base_h",
+		equal:   false,
+	},
+	{
+		title:   "test: base_g != base_i (fallback)",
+		base:    "./config_notifier_base_g",
+		compare: "./config_notifier_base_i",
+		equal:   false,
+	},
+	{
+		title:   "test: base_g != base_j (routing)",
+		base:    "./config_notifier_base_g",
+		compare: "./config_notifier_base_j",
+		equal:   false,
+	},
+	{
+		title:   "test: base_g != base_k (escalate_after)",
+		base:    "./config_notifier_base_g",
+		compare: "./config_notifier_base_k",
+		equal:   false,
+	},
 }
 
 const (
@@ -132,6 +132,42 @@ var invalidConfigTests = []invalidConfigTestSetup{
 		configDirs:    []string{"./mods/bad_email_integration_smtp_tls"},
 		containsError: "Attribute smtp_tls specified with invalid value dummy: email.my_email_app",
 	},
+	{
+		title:         "Invalid pagerduty integration - invalid severity value",
+		modDir:        "",
+		configDirs:    []string{"./mods/bad_pagerduty_integration_severity"},
+		containsError: "Attribute severity specified with invalid value urgent: pagerduty.my_pagerduty_app",
+	},
+	{
+		title:         "Invalid opsgenie integration - missing required attribute: alert_api_key",
+		modDir:        "",
+		configDirs:    []string{"./mods/bad_opsgenie_integration_missing_alert_api_key"},
+		containsError: "Attribute alert_api_key must be defined: opsgenie.my_opsgenie_app",
+	},
+	{
+		title:         "Invalid discord integration - channel with webhook_url",
+		modDir:        "",
+		configDirs:    []string{"./mods/bad_discord_integration_channel"},
+		containsError: "Attribute channel only applies when attribute token is provided: discord.my_discord_app",
+	},
+	{
+		title:         "Invalid aws connection - source connection not found",
+		modDir:        "",
+		configDirs:    []string{"./mods/bad_aws_connection_source_missing"},
+		containsError: "source connection aws.missing of connection aws.member not found",
+	},
+	{
+		title:         "Invalid aws connection - cyclic source connection",
+		modDir:        "",
+		configDirs:    []string{"./mods/bad_aws_connection_source_cycle"},
+		containsError: "has a cyclic source_connection chain",
+	},
+	{
+		title:         "Invalid aws connection - duration out of range",
+		modDir:        "",
+		configDirs:    []string{"./mods/bad_aws_connection_duration"},
+		containsError: "duration must be between 900 and 43200 seconds",
+	},
 	{
 		title:         "Invalid notifier - no notify block provided",
 		modDir:        "",
@@ -168,6 +204,36 @@ var invalidConfigTests = []invalidConfigTestSetup{
 		configDirs:    []string{"./mods/bad_notify_unexpected_attribute_channel"},
 		containsError: "Attribute 'channel' is not a valid attribute for email type integration",
 	},
+	{
+		title:         "Invalid notify block - invalid attribute 'channel' in pagerduty integration",
+		modDir:        "",
+		configDirs:    []string{"./mods/bad_notify_unexpected_attribute_channel_pagerduty"},
+		containsError: "Attribute 'channel' is not a valid attribute for pagerduty type integration",
+	},
+	{
+		title:         "Invalid notify block - when references an unknown variable",
+		modDir:        "",
+		configDirs:    []string{"./mods/bad_notify_when_variable"},
+		containsError: "Invalid reference in 'when' expression: priority, only severity, tags may be referenced",
+	},
+	{
+		title:         "Invalid notify block - invalid escalate_after duration",
+		modDir:        "",
+		configDirs:    []string{"./mods/bad_notify_escalate_after"},
+		containsError: "Attribute 'escalate_after' specified with invalid duration soon",
+	},
+	{
+		title:         "Invalid notify block - when in fallback block",
+		modDir:        "",
+		configDirs:    []string{"./mods/bad_notify_fallback_when"},
+		containsError: "Attribute 'when' is not valid in a fallback block",
+	},
+	{
+		title:         "Invalid notifier - invalid routing",
+		modDir:        "",
+		configDirs:    []string{"./mods/bad_notifier_routing"},
+		containsError: "Attribute routing specified with invalid value random, valid values are all, first",
+	},
 	{
 		title:             "Duplicate message step",
 		modDir:            "./mods/duplicate_message_step",
@@ -175,6 +241,27 @@ var invalidConfigTests = []invalidConfigTestSetup{
 		ignoreConfigParse: true,
 		containsError:     "duplicate step name 'message.test' - step names must be unique",
 	},
+	{
+		title:             "Invalid message template severity",
+		modDir:            "./mods/bad_message_template_severity",
+		configDirs:        []string{"./mods/bad_message_template_severity"},
+		ignoreConfigParse: true,
+		containsError:     "Attribute severity specified with invalid value urgent",
+	},
+	{
+		title:             "Invalid message template color",
+		modDir:            "./mods/bad_message_template_color",
+		configDirs:        []string{"./mods/bad_message_template_color"},
+		ignoreConfigParse: true,
+		containsError:     "Attribute color specified with invalid value green, specify a hex colour, e.g. #E01E5A",
+	},
+	{
+		title:             "Message step without text or template",
+		modDir:            "./mods/bad_message_step_missing_text",
+		configDirs:        []string{"./mods/bad_message_step_missing_text"},
+		ignoreConfigParse: true,
+		containsError:     "Message step requires either the text attribute or a template block",
+	},
 	{
 		title:             "Bad notifier reference to a string rather than an object",
 		modDir:            "./mods/bad_notifier_reference",
@@ -0,0 +1,6 @@
+
+
+mod "pipeline_with_bad_integration" {
+    title = "Test mod"
+    description = "Use this mod for testing references within pipeline and from one pipeline to another"
+}
@@ -0,0 +1,10 @@
+mod "bad_message" {
+
+}
+
+pipeline "message_without_text" {
+
+  step "message" "test" {
+    notifier = notifier.default
+  }
+}
@@ -0,0 +1,18 @@
+mod "bad_template" {
+
+}
+
+pipeline "input_with_bad_color" {
+
+  step "input" "test" {
+    notifier = notifier.default
+    type     = "button"
+
+    template {
+      title = "Approve?"
+      color = "green"
+    }
+
+    option "Approve" {}
+  }
+}
@@ -0,0 +1,15 @@
+mod "bad_template" {
+
+}
+
+pipeline "message_with_bad_severity" {
+
+  step "message" "test" {
+    notifier = notifier.default
+
+    template {
+      title    = "Deployment failed"
+      severity = "urgent"
+    }
+  }
+}
@@ -97,6 +97,31 @@ var tests = []testSetup{
 		file:          "./pipelines/invalid_trigger_bad_cron.fp",
 		containsError: "bad cron format. Specify valid intervals hourly, daily, weekly, monthly or valid cron expression:",
 	},
+	{
+		title:         "invalid schedule trigger - timezone",
+		file:          "./pipelines/invalid_schedule_trigger_timezone.fp",
+		containsError: "Invalid timezone: Mars/Olympus_Mons. Specify a valid IANA time zone name",
+	},
+	{
+		title:         "invalid schedule trigger - jitter",
+		file:          "./pipelines/invalid_schedule_trigger_jitter.fp",
+		containsError: "Invalid jitter: a little. Specify a positive duration",
+	},
+	{
+		title:         "invalid schedule trigger - catch_up",
+		file:          "./pipelines/invalid_schedule_trigger_catch_up.fp",
+		containsError: "Invalid catch_up policy: some. Valid values are: none, latest, all",
+	},
+	{
+		title:         "invalid schedule trigger - blackout with both schedule and date range",
+		file:          "./pipelines/invalid_schedule_trigger_blackout.fp",
+		containsError: "A blackout must specify either 'schedule' and 'duration', or 'start' and 'end', but not both",
+	},
+	{
+		title:         "invalid schedule trigger - blackout end before start",
+		file:          "./pipelines/invalid_schedule_trigger_blackout_range.fp",
+		containsError: "The blackout 'end' must be after 'start'",
+	},
 	{
 		title:         "invalid loop - bad definition for transform step loop",
 		file:          "./pipelines/loop_invalid_transform.fp",
@@ -0,0 +1,17 @@
+pipeline "simple_with_trigger" {
+  description = "simple pipeline that will be referred to by a trigger"
+
+  step "transform" "simple_echo" {
+    value = "foo bar"
+  }
+}
+
+trigger "schedule" "invalid_blackout" {
+  schedule = "5 * * * *"
+  pipeline = pipeline.simple_with_trigger
+
+  blackout {
+    schedule = "0 0 * * 6"
+    start    = "2024-12-24"
+  }
+}
@@ -0,0 +1,17 @@
+pipeline "simple_with_trigger" {
+  description = "simple pipeline that will be referred to by a trigger"
+
+  step "transform" "simple_echo" {
+    value = "foo bar"
+  }
+}
+
+trigger "schedule" "invalid_blackout_range" {
+  schedule = "5 * * * *"
+  pipeline = pipeline.simple_with_trigger
+
+  blackout {
+    start = "2024-12-26"
+    end   = "2024-12-24"
+  }
+}
@@ -0,0 +1,13 @@
+pipeline "simple_with_trigger" {
+  description = "simple pipeline that will be referred to by a trigger"
+
+  step "transform" "simple_echo" {
+    value = "foo bar"
+  }
+}
+
+trigger "schedule" "invalid_catch_up" {
+  schedule = "5 * * * *"
+  catch_up = "some"
+  pipeline = pipeline.simple_with_trigger
+}
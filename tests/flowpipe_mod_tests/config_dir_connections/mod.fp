@@ -8,3 +8,13 @@ pipeline "static_creds_test" {
     value = connection.aws["prod_conn"].profile
   }
 }
+
+pipeline "http_connection_test" {
+  step "http" "list_users" {
+    url        = "users?active=true"
+    connection = connection.http.internal_api
+    request_headers = {
+      "X-Team" = "security"
+    }
+  }
+}
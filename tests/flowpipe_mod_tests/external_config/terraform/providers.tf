@@ -0,0 +1,40 @@
+variable "vault_token" {
+  type = string
+}
+
+provider "aws" {
+  region = "us-east-1"
+}
+
+provider "aws" {
+  alias   = "prod"
+  region  = "eu-west-1"
+  profile = "prod"
+
+  assume_role {
+    role_arn     = "arn:aws:iam::123456789012:role/deploy"
+    session_name = "terraform"
+  }
+}
+
+provider "azurerm" {
+  alias           = "gov"
+  environment     = "usgovernment"
+  tenant_id       = "00000000-0000-0000-0000-000000000000"
+  client_id       = "11111111-1111-1111-1111-111111111111"
+  client_secret   = "not-a-real-secret"
+  features {}
+}
+
+provider "vault" {
+  alias   = "prod"
+  address = "https://vault.example.com"
+  token   = var.vault_token
+}
+
+provider "random" {}
+
+resource "aws_s3_bucket" "logs" {
+  provider = aws.prod
+  bucket   = "logs"
+}
@@ -7,6 +7,7 @@ import (
 	"path"
 	"slices"
 	"testing"
+	"time"
 
 	"github.com/hashicorp/hcl/v2"
 	"github.com/spf13/viper"
@@ -410,6 +411,20 @@ func (suite *FlowpipeModTestSuite) TestFlowpipeConfigConnection() {
 	}
 	assert.Equal("prod1", *awsConn.Profile)
 
+	// chained role assumption is linked to its source connection
+	pcon = flowpipeConfig.PipelingConnections["aws.member_conn"]
+	memberConn, ok := pcon.(*connection.AwsConnection)
+	if !ok {
+		assert.Fail("aws.member_conn is not an AwsConnection")
+		return
+	}
+	assert.Equal("arn:aws:iam::123456789012:role/remediation", *memberConn.RoleArn)
+	assert.Equal("flowpipe", *memberConn.ExternalId)
+	assert.Equal("remediation", *memberConn.SessionName)
+	assert.Equal(3600, *memberConn.Duration)
+	assert.Equal("eu-west-2", *memberConn.Region)
+	assert.Equal(awsConn, memberConn.GetSourceConnection())
+
 	pcon = flowpipeConfig.PipelingConnections["slack.slack_conn"]
 	if helpers.IsNil(pcon) {
 		assert.Fail("slack.slack_conn connection not found")
@@ -423,6 +438,17 @@ func (suite *FlowpipeModTestSuite) TestFlowpipeConfigConnection() {
 	}
 	assert.Equal("abc1", *slackConn.Token)
 
+	// resolved connections are cached until the cache is invalidated
+	resolvedSlackConn, err2 := flowpipeConfig.ResolveConnection(suite.ctx, "slack.slack_conn")
+	require.Nil(err2)
+	assert.Equal("abc1", *resolvedSlackConn.(*connection.SlackConnection).Token)
+	cachedSlackConn, err2 := flowpipeConfig.ResolveConnection(suite.ctx, "slack.slack_conn")
+	require.Nil(err2)
+	assert.Same(resolvedSlackConn, cachedSlackConn)
+
+	_, err2 = flowpipeConfig.ResolveConnection(suite.ctx, "slack.missing")
+	assert.NotNil(err2)
+
 	// Check that the connection is loaded in the workspace
 	w, errorAndWarning := workspace.Load(suite.ctx, "./config_dir_connections", workspace.WithCredentials(flowpipeConfig.Credentials), workspace.WithPipelingConnections(flowpipeConfig.PipelingConnections))
 	require.NotNil(w)
@@ -466,6 +492,40 @@ func (suite *FlowpipeModTestSuite) TestFlowpipeConfigConnection() {
 	assert.Nil(err2)
 
 	assert.Equal("sfhshfhslfh", stepInputs["value"], "profile should be set to sfhshfhslfh")
+
+	// the http step applies the base url, headers and auth of the http connection
+	pcon = w.PipelingConnections["http.internal_api"]
+	httpConn, ok := pcon.(*connection.HttpConnection)
+	if !ok {
+		assert.Fail("http.internal_api is not an HttpConnection")
+		return
+	}
+	assert.Equal("https://api.example.com/v1", *httpConn.BaseUrl)
+	assert.Equal(connection.HttpAuthTypeBearer, httpConn.Auth.Type)
+
+	pipeline = pipelines["mod_with_connections.pipeline.http_connection_test"]
+	if pipeline == nil {
+		assert.Fail("pipeline not found")
+		return
+	}
+	assert.Equal("http.internal_api", pipeline.Steps[0].GetConnectionDependsOn()[0])
+
+	httpConnValue, err2 := httpConn.CtyValue()
+	require.Nil(err2)
+	evalContext.Variables["connection"] = cty.ObjectVal(map[string]cty.Value{
+		"http": cty.ObjectVal(map[string]cty.Value{
+			"internal_api": httpConnValue,
+		}),
+	})
+
+	stepInputs, err2 = pipeline.Steps[0].GetInputs(evalContext)
+	require.Nil(err2)
+
+	assert.Equal("https://api.example.com/v1/users?active=true", stepInputs["url"])
+	assert.Equal(map[string]interface{}{
+		"Authorization": "Bearer internal-api-token",
+		"X-Team":        "security",
+	}, stepInputs["request_headers"])
 }
 
 // verify credentials are converted to connections but DO NOT overwrite existing connections
@@ -738,6 +798,78 @@ func (suite *FlowpipeModTestSuite) TestFlowpipeIntegrationSerialiseDeserialise()
 	assert.Equal("xoxp-111111", *notifier2.GetNotifies()[0].Integration.(*modconfig.SlackIntegration).Token)
 }
 
+func (suite *FlowpipeModTestSuite) TestFlowpipeConfigIncidentIntegrations() {
+	assert := assert.New(suite.T())
+
+	flowpipeConfig, ew := flowpipeconfig.LoadFlowpipeConfig([]string{"./config_dir_incident_integrations"})
+	if ew.Error != nil {
+		assert.FailNow(ew.Error.Error())
+		return
+	}
+
+	if flowpipeConfig == nil {
+		assert.Fail("flowpipeConfig is nil")
+		return
+	}
+
+	pagerDuty, ok := flowpipeConfig.Integrations["pagerduty.my_pagerduty_app"].(*modconfig.PagerDutyIntegration)
+	if !ok {
+		assert.Fail("pagerduty integration not found")
+		return
+	}
+	assert.Equal("R0123456789ABCDEF0123456789ABCDE", *pagerDuty.RoutingKey)
+	assert.Equal("error", *pagerDuty.Severity)
+
+	opsgenie, ok := flowpipeConfig.Integrations["opsgenie.my_opsgenie_app"].(*modconfig.OpsgenieIntegration)
+	if !ok {
+		assert.Fail("opsgenie integration not found")
+		return
+	}
+	assert.Equal("https://api.eu.opsgenie.com", *opsgenie.ApiUrl)
+	assert.Equal("P2", *opsgenie.Priority)
+	assert.Equal([]string{"ops_team"}, opsgenie.To)
+
+	discord, ok := flowpipeConfig.Integrations["discord.discord_with_token"].(*modconfig.DiscordIntegration)
+	if !ok {
+		assert.Fail("discord integration not found")
+		return
+	}
+	assert.Equal("alerts", *discord.Channel)
+
+	notifier := flowpipeConfig.Notifiers["on_call"]
+	if notifier == nil {
+		assert.Fail("on_call notifier not found")
+		return
+	}
+
+	// marshall to JSON and back to ensure the new integration types round trip
+	jsonBytes, err := json.Marshal(notifier)
+	if err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+
+	var notifier2 modconfig.NotifierImpl
+	err = json.Unmarshal(jsonBytes, &notifier2)
+	if err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+
+	notifies := notifier2.GetNotifies()
+	assert.Equal(4, len(notifies))
+	assert.Equal("Flowpipe: Action Required", *notifies[0].Subject)
+	assert.Equal("error", *notifies[0].Integration.(*modconfig.PagerDutyIntegration).Severity)
+	assert.Equal([]string{"security_team", "jane@example.com"}, notifies[1].To)
+	assert.Equal("abcd1234-ab12-cd34-ef56-abcdef123456", *notifies[1].Integration.(*modconfig.OpsgenieIntegration).AlertApiKey)
+	assert.Equal("on-call", *notifies[2].Channel)
+	assert.Equal("https://discord.com/api/webhooks/123456789012345678/abcdef", *notifies[3].Integration.(*modconfig.DiscordIntegration).WebhookUrl)
+
+	ctyVal, err := notifier.CtyValue()
+	assert.Nil(err)
+	assert.Equal(4, ctyVal.GetAttr("notifies").LengthInt())
+}
+
 func (suite *FlowpipeModTestSuite) TestFlowpipeModWithOneIntegration() {
 	assert := assert.New(suite.T())
 
@@ -1601,6 +1733,95 @@ func (suite *FlowpipeModTestSuite) TestFlowpipeConfigWithConnectionImport() {
 	assert.Equal("17ImlCYdfZ3WJIrGk96gCpJn1fi1pLwVdrb23kj4", *flowpipeConfig.PipelingConnections["zendesk.sp1_zendesk_2"].(*connection.ZendeskConnection).Token)
 }
 
+func (suite *FlowpipeModTestSuite) TestFlowpipeConfigWithExternalConnectionImport() {
+	assert := assert.New(suite.T())
+
+	flowpipeConfig, err := flowpipeconfig.LoadFlowpipeConfig([]string{"./config_dir_with_external_connection_import", "./empty_mod"})
+	if err.Error != nil {
+		assert.FailNow(err.Error.Error())
+		return
+	}
+
+	conns := flowpipeConfig.PipelingConnections
+
+	// AWS config and credentials files - a profile in both files is imported once
+	assert.Equal("default", *conns["aws.cli_default"].(*connection.AwsConnection).Profile)
+	assert.Equal("us-east-1", *conns["aws.cli_default"].(*connection.AwsConnection).Region)
+	assert.Nil(conns["aws.cli_default"].(*connection.AwsConnection).AccessKey)
+	assert.Equal("dev", *conns["aws.cli_dev"].(*connection.AwsConnection).Profile)
+	assert.Equal("us-west-2", *conns["aws.cli_dev"].(*connection.AwsConnection).Region)
+	assert.Equal("security.audit", *conns["aws.cli_security_audit"].(*connection.AwsConnection).Profile)
+	assert.Nil(conns["aws.cli_security_audit"].(*connection.AwsConnection).Region)
+	assert.Equal("eu-west-1", *conns["aws.cli_ci"].(*connection.AwsConnection).Region)
+	assert.Nil(conns["aws.cli_corp"])
+
+	// gcloud configurations - the credentials gcloud stores for the account are used if they exist
+	assert.Equal("gcp.dev", conns["gcp.dev"].GetConnectionImpl().FullName)
+	assert.Contains(*conns["gcp.dev"].(*connection.GcpConnection).Credentials, "legacy_credentials/dev@example.com/adc.json")
+	assert.Nil(conns["gcp.prod"].(*connection.GcpConnection).Credentials)
+
+	// Terraform providers, filtered by alias
+	assert.Equal("us-east-1", *conns["aws.tf_default"].(*connection.AwsConnection).Region)
+	prod := conns["aws.tf_prod"].(*connection.AwsConnection)
+	assert.Equal("eu-west-1", *prod.Region)
+	assert.Equal("prod", *prod.Profile)
+	assert.Equal("arn:aws:iam::123456789012:role/deploy", *prod.RoleArn)
+	assert.Equal("terraform", *prod.SessionName)
+	assert.Contains(prod.GetConnectionImpl().DeclRange.Filename, "providers.tf")
+
+	gov := conns["azure.tf_gov"].(*connection.AzureConnection)
+	assert.Equal("AZUREUSGOVERNMENTCLOUD", *gov.Environment)
+	assert.Equal("11111111-1111-1111-1111-111111111111", *gov.ClientID)
+	assert.Equal("not-a-real-secret", *gov.ClientSecret)
+
+	// attributes referring to terraform variables cannot be imported
+	vault := conns["vault.tf_prod"].(*connection.VaultConnection)
+	assert.Equal("https://vault.example.com", *vault.Address)
+	assert.Nil(vault.Token)
+
+	// the default connections are still created for the other types
+	assert.NotNil(conns["aws.default"])
+}
+
+func (suite *FlowpipeModTestSuite) TestFlowpipeConfigWithInvalidConnectionImportType() {
+	assert := assert.New(suite.T())
+
+	_, err := flowpipeconfig.LoadFlowpipeConfig([]string{"./config_dir_with_invalid_connection_import", "./empty_mod"})
+	if err.Error == nil {
+		assert.FailNow("expected an error")
+		return
+	}
+	assert.Contains(err.Error.Error(), "invalid connection_import type 'pulumi'")
+}
+
+func (suite *FlowpipeModTestSuite) TestFlowpipeConfigConnectionExpiry() {
+	assert := assert.New(suite.T())
+
+	flowpipeConfig, err := flowpipeconfig.LoadFlowpipeConfig([]string{"./config_dir_connection_expiry", "./empty_mod"})
+	if err.Error != nil {
+		assert.FailNow(err.Error.Error())
+		return
+	}
+
+	res := flowpipeConfig.CheckConnectionExpiry(context.TODO(), 30*24*time.Hour)
+	assert.Nil(res.Error)
+	assert.Equal([]string{"connection http.expired credentials expired at 2020-01-01T00:00:00Z"}, res.Warnings)
+
+	// the expiry is recorded on the connections, so it is available in their cty values
+	conns := flowpipeConfig.PipelingConnections
+	assert.Equal(time.Date(2100, 1, 1, 0, 0, 0, 0, time.UTC), conns["http.long_lived"].GetConnectionImpl().Expiry.UTC())
+	assert.Nil(conns["http.opaque"].GetConnectionImpl().Expiry)
+
+	ctyValue, ctyErr := conns["http.expired"].CtyValue()
+	assert.Nil(ctyErr)
+	assert.Equal("2020-01-01T00:00:00Z", ctyValue.GetAttr("expiry").AsString())
+
+	// warn about all connections which expire within the next 100 years
+	res = flowpipeConfig.CheckConnectionExpiry(context.TODO(), 100*365*24*time.Hour)
+	assert.Equal(2, len(res.Warnings))
+	assert.Contains(res.Warnings[1], "connection http.long_lived credentials expire in")
+}
+
 func (suite *FlowpipeModTestSuite) TestFlowpipeConfigIntegration() {
 	assert := assert.New(suite.T())
 	require := require.New(suite.T())
@@ -2429,6 +2650,50 @@ func (suite *FlowpipeModTestSuite) TestModMessageStep() {
 	}
 
 	assert.NotNil(messageStepInterface.GetErrorConfig(nil, false))
+
+	pipeline = mod.ResourceMaps.Pipelines["mod_message_step.pipeline.message_step_with_template"]
+	if pipeline == nil {
+		assert.Fail("pipeline not found")
+		return
+	}
+
+	messageStep, ok = pipeline.Steps[1].(*modconfig.PipelineStepMessage)
+	if !ok {
+		assert.Fail("message step is not of type PipelineStepMessage")
+		return
+	}
+
+	require.NotNil(messageStep.Template)
+	assert.Equal("", messageStep.Text)
+	assert.Equal("Deployment failed", *messageStep.Template.Title)
+	assert.Equal("error", *messageStep.Template.Severity)
+	assert.Contains(messageStep.GetDependsOn(), "transform.deploy")
+	require.Equal(2, len(messageStep.Template.Fields))
+	assert.Nil(messageStep.Template.Body)
+	assert.NotNil(messageStep.Template.UnresolvedAttributes["body"])
+	assert.Equal("Environment", messageStep.Template.Fields[0].Name)
+	assert.True(*messageStep.Template.Fields[0].Short)
+	assert.Nil(messageStep.Template.Fields[1].Value)
+	assert.NotNil(messageStep.Template.Fields[1].UnresolvedAttributes["value"])
+	require.Equal(1, len(messageStep.Template.Links))
+	assert.Equal("https://example.com/runs/123", *messageStep.Template.Links[0].Url)
+
+	pipeline = mod.ResourceMaps.Pipelines["mod_message_step.pipeline.input_step_with_template"]
+	if pipeline == nil {
+		assert.Fail("pipeline not found")
+		return
+	}
+
+	inputStep, ok := pipeline.Steps[0].(*modconfig.PipelineStepInput)
+	if !ok {
+		assert.Fail("input step is not of type PipelineStepInput")
+		return
+	}
+
+	require.NotNil(inputStep.Template)
+	assert.Equal("Approve deployment?", *inputStep.Template.Title)
+	assert.Equal("#36A64F", inputStep.Template.ResolvedColor())
+	assert.Equal(2, len(inputStep.OptionList))
 }
 
 func (suite *FlowpipeModTestSuite) TestModDynamicPipeRef() {
@@ -65,3 +65,59 @@ pipeline "message_step_with_error" {
         value = "Hello World!"
     }
 }
+
+pipeline "message_step_with_template" {
+
+    param "environment" {
+        default = "production"
+    }
+
+    step "transform" "deploy" {
+        value = "v1.2.3"
+    }
+
+    step "message" "hello" {
+        notifier = notifier.default
+
+        template {
+            title    = "Deployment failed"
+            body     = "The deployment to **${param.environment}** failed, see the [run log](https://example.com/runs/123)."
+            severity = "error"
+
+            field "Environment" {
+                value = param.environment
+                short = true
+            }
+
+            field "Version" {
+                value = step.transform.deploy.value
+                short = true
+            }
+
+            link "View run" {
+                url = "https://example.com/runs/123"
+            }
+        }
+    }
+}
+
+pipeline "input_step_with_template" {
+
+    step "input" "approve" {
+        notifier = notifier.default
+        type     = "button"
+
+        template {
+            title = "Approve deployment?"
+            color = "#36A64F"
+        }
+
+        option "Approve" {
+            style = "ok"
+        }
+
+        option "Deny" {
+            style = "alert"
+        }
+    }
+}
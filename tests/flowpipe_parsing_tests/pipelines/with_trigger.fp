@@ -18,6 +18,24 @@ trigger "schedule" "my_hourly_trigger_interval" {
   pipeline = pipeline.simple_with_trigger
 }
 
+trigger "schedule" "trigger_with_schedule_options" {
+  schedule = "0 9 * * 1-5"
+  timezone = "Europe/London"
+  jitter   = "5m"
+  catch_up = "latest"
+  pipeline = pipeline.simple_with_trigger
+
+  blackout {
+    schedule = "0 0 1 * *"
+    duration = "24h"
+  }
+
+  blackout {
+    start = "2024-12-24"
+    end   = "2024-12-26"
+  }
+}
+
 
 trigger "schedule" "trigger_with_args" {
   schedule = "5 * * * *"
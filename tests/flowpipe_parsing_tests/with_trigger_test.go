@@ -3,6 +3,7 @@ package pipeline_test
 import (
 	"context"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/turbot/pipe-fittings/load_mod"
@@ -63,6 +64,28 @@ func TestPipelineWithTrigger(t *testing.T) {
 
 	assert.Equal("daily", st.Schedule)
 
+	scheduleOptionsTrigger := triggers["local.trigger.schedule.trigger_with_schedule_options"]
+	if scheduleOptionsTrigger == nil {
+		assert.Fail("trigger_with_schedule_options trigger not found")
+		return
+	}
+
+	sot, ok := scheduleOptionsTrigger.Config.(*modconfig.TriggerSchedule)
+	if !ok {
+		assert.Fail("trigger_with_schedule_options trigger is not a schedule trigger")
+		return
+	}
+
+	assert.Equal("Europe/London", sot.Timezone)
+	assert.Equal(5*time.Minute, *sot.Jitter)
+	assert.Equal(modconfig.CatchUpPolicyLatest, sot.CatchUp)
+	assert.Equal(2, len(sot.Blackouts))
+	assert.Equal("0 0 1 * *", sot.Blackouts[0].Schedule)
+	assert.Equal(24*time.Hour, *sot.Blackouts[0].Duration)
+	assert.Equal("2024-12-24T00:00:00Z", sot.Blackouts[1].Start.Format(time.RFC3339))
+	// a date-only end is inclusive of that day
+	assert.Equal("2024-12-27T00:00:00Z", sot.Blackouts[1].End.Format(time.RFC3339))
+
 	triggerWithArgs := triggers["local.trigger.schedule.trigger_with_args"]
 	if triggerWithArgs == nil {
 		assert.Fail("trigger_with_args trigger not found")
@@ -51,6 +51,7 @@ func SetAppSpecificConstants() {
 		connection.NewJumpCloudConnection,
 		connection.NewMastodonConnection,
 		connection.NewMicrosoftTeamsConnection,
+		connection.NewMssqlConnection,
 		connection.NewOktaConnection,
 		connection.NewOpenAIConnection,
 		connection.NewOpsgenieConnection,
@@ -59,10 +60,12 @@ func SetAppSpecificConstants() {
 		connection.NewSendGridConnection,
 		connection.NewServiceNowConnection,
 		connection.NewSlackConnection,
+		connection.NewSnowflakeConnection,
 		connection.NewSqliteConnection,
 		connection.NewSteampipePgConnection,
 		connection.NewTrelloConnection,
 		connection.NewGuardrailsConnection,
+		connection.NewHttpConnection,
 		connection.NewPipesConnection,
 		connection.NewUptimeRobotConnection,
 		connection.NewUrlscanConnection,
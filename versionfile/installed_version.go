@@ -11,6 +11,8 @@ type InstalledVersion struct {
 	InstalledFrom      string `json:"installed_from,omitempty"`
 	LastCheckedDate    string `json:"last_checked_date,omitempty"`
 	InstallDate        string `json:"install_date,omitempty"`
+	SignatureVerified  bool   `json:"signature_verified,omitempty"`
+	SbomDigest         string `json:"sbom_digest,omitempty"`
 	StructVersion      int64  `json:"struct_version"`
 }
 
@@ -0,0 +1,66 @@
+package versionmap
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// contentHashPrefix identifies the algorithm used by HashModDirectory
+const contentHashPrefix = "h1:"
+
+// HashModDirectory returns the content hash of the mod installed in dir, of form h1:<base64 sha256>
+//
+// The hash is the SHA-256 of a summary listing the SHA-256 and slash separated relative path of each file, sorted by
+// path - so it depends only on the file names and contents. Git directories are excluded, as git metadata changes
+// when a mod is checked for updates.
+func HashModDirectory(dir string) (string, error) {
+	var files []string
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() && info.Name() == ".git" {
+			return filepath.SkipDir
+		}
+		if info.Mode().IsRegular() {
+			relPath, err := filepath.Rel(dir, path)
+			if err != nil {
+				return err
+			}
+			files = append(files, filepath.ToSlash(relPath))
+		}
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	sort.Strings(files)
+
+	summary := sha256.New()
+	for _, file := range files {
+		fileHash, err := hashFile(filepath.Join(dir, filepath.FromSlash(file)))
+		if err != nil {
+			return "", err
+		}
+		fmt.Fprintf(summary, "%x  %s\n", fileHash, file)
+	}
+	return contentHashPrefix + base64.StdEncoding.EncodeToString(summary.Sum(nil)), nil
+}
+
+func hashFile(path string) ([]byte, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return nil, err
+	}
+	return h.Sum(nil), nil
+}
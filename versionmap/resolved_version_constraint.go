@@ -9,6 +9,8 @@ import (
 type InstalledModVersion struct {
 	*ResolvedVersionConstraint
 	Alias string `json:"alias"`
+	// the content hash of the installed mod, as returned by HashModDirectory
+	ContentHash string `json:"content_hash,omitempty"`
 }
 
 func (v InstalledModVersion) SatisfiesConstraint(requiredVersion *modconfig.ModVersionConstraint) bool {
@@ -32,13 +34,16 @@ func (v InstalledModVersion) SatisfiesConstraint(requiredVersion *modconfig.ModV
 }
 
 // ResolvedVersionConstraint is a struct to represent a version constraint which has been resolved to specific version
-// (either a git tag, git commit (for a branch constraint) or a file location)
+// (either a git tag, git commit (for a branch constraint), OCI image tag or a file location)
 type ResolvedVersionConstraint struct {
 	modconfig.DependencyVersion
 	Name string `json:"name,omitempty"`
 
-	Commit        string `json:"commit,omitempty"`
-	GitRefStr     string `json:"git_ref,omitempty"`
+	Commit    string `json:"commit,omitempty"`
+	GitRefStr string `json:"git_ref,omitempty"`
+	// for mods installed from an OCI registry, the image tag and the digest of the installed image manifest
+	OciTag        string `json:"oci_tag,omitempty"`
+	Digest        string `json:"digest,omitempty"`
 	StructVersion int    `json:"struct_version,omitempty"`
 }
 
@@ -62,6 +67,8 @@ func (c ResolvedVersionConstraint) Equals(other *ResolvedVersionConstraint) bool
 		c.Branch == other.Branch &&
 		c.Commit == other.Commit &&
 		c.GitRefStr == other.GitRefStr &&
+		c.OciTag == other.OciTag &&
+		c.Digest == other.Digest &&
 		c.FilePath == other.FilePath &&
 		c.Tag == other.Tag
 }
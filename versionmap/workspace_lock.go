@@ -253,6 +253,28 @@ func (l *WorkspaceLock) FindMod(dependencyName string) []*InstalledModVersion {
 	return res
 }
 
+// GetLockedContentHash returns the content hash recorded in the lock file for the given dependency path, including
+// dependencies which are locked but no longer installed, or "" if no hash is recorded
+func (l *WorkspaceLock) GetLockedContentHash(dependencyPath string) string {
+	for _, versions := range []InstalledDependencyVersionsMap{l.InstallCache, l.MissingVersions} {
+		if dep, ok := versions.FlatMap()[dependencyPath]; ok && dep.ContentHash != "" {
+			return dep.ContentHash
+		}
+	}
+	return ""
+}
+
+// GetLockedModVersionForPath returns the lock file entry for the given dependency path, including dependencies which
+// are locked but no longer installed, or nil if it is not locked
+func (l *WorkspaceLock) GetLockedModVersionForPath(dependencyPath string) *InstalledModVersion {
+	for _, versions := range []InstalledDependencyVersionsMap{l.InstallCache, l.MissingVersions} {
+		if dep, ok := versions.FlatMap()[dependencyPath]; ok {
+			return dep
+		}
+	}
+	return nil
+}
+
 // GetLockedModVersions builds a ResolvedVersionListMap with the resolved versions
 // for each item of the given versionConstraintMap found in the lock file
 func (l *WorkspaceLock) GetLockedModVersions(mods map[string]*modconfig.ModVersionConstraint, parent *modconfig.Mod) (ResolvedVersionListMap, error) {
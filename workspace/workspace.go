@@ -388,7 +388,10 @@ func (w *Workspace) loadWorkspaceLock(ctx context.Context) (*versionmap.Workspac
 			w.Mod.ShortName = modShortName
 		}
 
-		opts := &modinstaller.InstallOpts{WorkspaceMod: w.Mod, UpdateStrategy: constants.ModUpdateMinimal}
+		opts := &modinstaller.InstallOpts{WorkspaceMod: w.Mod, UpdateStrategy: constants.ModUpdateMinimal, Connections: w.PipelingConnections}
+		if modHosts, ok := viper.Get(constants.ArgModHosts).([]*modconfig.ModHost); ok {
+			opts.ModHosts = modHosts
+		}
 
 		installData, err := modinstaller.InstallWorkspaceDependencies(ctx, opts)
 		if err != nil {
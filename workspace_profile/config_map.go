@@ -6,6 +6,7 @@ import (
 	"strings"
 
 	"github.com/turbot/go-kit/helpers"
+	"github.com/turbot/pipe-fittings/modconfig"
 	"github.com/turbot/pipe-fittings/options"
 )
 
@@ -39,6 +40,13 @@ func (m ConfigMap) SetBoolItem(argValue *bool, argName string) {
 	}
 }
 
+// SetModHostsItem checks if any mod hosts are configured and if so, add to map with given key
+func (m ConfigMap) SetModHostsItem(modHosts []*modconfig.ModHost, argName string) {
+	if len(modHosts) > 0 {
+		m[argName] = modHosts
+	}
+}
+
 // PopulateConfigMapForOptions populates the config map for a given options object
 // NOTE: this mutates configMap
 func (m ConfigMap) PopulateConfigMapForOptions(o options.Options) {
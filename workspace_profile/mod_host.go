@@ -0,0 +1,31 @@
+package workspace_profile
+
+import (
+	"fmt"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/turbot/pipe-fittings/modconfig"
+)
+
+// ModHostProfile is implemented by workspace profiles which support mod_host blocks
+type ModHostProfile interface {
+	AddModHost(modHost *modconfig.ModHost) hcl.Diagnostics
+	GetModHosts() []*modconfig.ModHost
+}
+
+// addModHost validates modHost and adds it to modHosts, failing if the host is already configured
+func addModHost(modHosts []*modconfig.ModHost, modHost *modconfig.ModHost) ([]*modconfig.ModHost, hcl.Diagnostics) {
+	if diags := modHost.Validate(); diags.HasErrors() {
+		return modHosts, diags
+	}
+	for _, existing := range modHosts {
+		if existing.Host == modHost.Host {
+			return modHosts, hcl.Diagnostics{&hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  fmt.Sprintf("Duplicate mod_host '%s'", modHost.Host),
+				Subject:  &modHost.DeclRange,
+			}}
+		}
+	}
+	return append(modHosts, modHost), nil
+}
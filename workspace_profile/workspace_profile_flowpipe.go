@@ -8,6 +8,7 @@ import (
 	"github.com/turbot/pipe-fittings/constants"
 	"github.com/turbot/pipe-fittings/cty_helpers"
 	"github.com/turbot/pipe-fittings/hclhelpers"
+	"github.com/turbot/pipe-fittings/modconfig"
 	"github.com/turbot/pipe-fittings/options"
 	"github.com/zclconf/go-cty/cty"
 )
@@ -35,6 +36,9 @@ type FlowpipeWorkspaceProfile struct {
 	ProcessRetention        *int    `hcl:"process_retention" cty:"process_retention"`
 	BaseUrl                 *string `hcl:"base_url" cty:"base_url"`
 
+	// the git hosts mods are installed from, declared in mod_host blocks
+	ModHosts []*modconfig.ModHost `cty:"mod_hosts"`
+
 	DeclRange hcl.Range
 }
 
@@ -114,6 +118,9 @@ func (p *FlowpipeWorkspaceProfile) setBaseProperties() {
 	if p.BaseUrl == nil {
 		p.BaseUrl = p.Base.BaseUrl
 	}
+	if len(p.ModHosts) == 0 {
+		p.ModHosts = p.Base.ModHosts
+	}
 }
 
 // ConfigMap creates a config map containing all options to pass to viper
@@ -138,6 +145,7 @@ func (p *FlowpipeWorkspaceProfile) ConfigMap(cmd *cobra.Command) map[string]inte
 	res.SetIntItem(p.MaxConcurrencyQuery, constants.ArgMaxConcurrencyQuery)
 	res.SetIntItem(p.ProcessRetention, constants.ArgProcessRetention)
 	res.SetStringItem(p.BaseUrl, constants.ArgBaseUrl)
+	res.SetModHostsItem(p.ModHosts, constants.ArgModHosts)
 
 	return res
 }
@@ -151,6 +159,17 @@ func (p *FlowpipeWorkspaceProfile) GetInstallDir() *string {
 	return nil
 }
 
+// AddModHost adds the git host configuration from a mod_host block
+func (p *FlowpipeWorkspaceProfile) AddModHost(modHost *modconfig.ModHost) hcl.Diagnostics {
+	var diags hcl.Diagnostics
+	p.ModHosts, diags = addModHost(p.ModHosts, modHost)
+	return diags
+}
+
+func (p *FlowpipeWorkspaceProfile) GetModHosts() []*modconfig.ModHost {
+	return p.ModHosts
+}
+
 func (p *FlowpipeWorkspaceProfile) IsNil() bool {
 	return p == nil
 }
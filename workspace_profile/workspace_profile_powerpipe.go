@@ -12,6 +12,7 @@ import (
 	"github.com/turbot/pipe-fittings/cty_helpers"
 	"github.com/turbot/pipe-fittings/error_helpers"
 	"github.com/turbot/pipe-fittings/hclhelpers"
+	"github.com/turbot/pipe-fittings/modconfig"
 	"github.com/turbot/pipe-fittings/options"
 	"github.com/turbot/pipe-fittings/pipes"
 	"github.com/turbot/pipe-fittings/steampipeconfig"
@@ -43,6 +44,9 @@ type PowerpipeWorkspaceProfile struct {
 
 	ModLocation *string `hcl:"mod_location,optional" cty:"mod_location"`
 
+	// the git hosts mods are installed from, declared in mod_host blocks
+	ModHosts []*modconfig.ModHost `cty:"mod_hosts"`
+
 	Watch    *bool `hcl:"watch" cty:"watch"`
 	Input    *bool `hcl:"input" cty:"input"`
 	Progress *bool `hcl:"progress" cty:"progress"`
@@ -131,6 +135,9 @@ func (p *PowerpipeWorkspaceProfile) setBaseProperties() {
 	if p.ModLocation == nil {
 		p.ModLocation = p.Base.ModLocation
 	}
+	if len(p.ModHosts) == 0 {
+		p.ModHosts = p.Base.ModHosts
+	}
 
 	if p.Watch == nil {
 		p.Watch = p.Base.Watch
@@ -195,6 +202,7 @@ func (p *PowerpipeWorkspaceProfile) ConfigMap(cmd *cobra.Command) map[string]int
 	res.SetStringItem(p.SnapshotLocation, constants.ArgSnapshotLocation)
 
 	res.SetStringItem(p.ModLocation, constants.ArgModLocation)
+	res.SetModHostsItem(p.ModHosts, constants.ArgModHosts)
 
 	res.SetBoolItem(p.Watch, constants.ArgWatch)
 	res.SetBoolItem(p.Input, constants.ArgInput)
@@ -223,6 +231,17 @@ func (p *PowerpipeWorkspaceProfile) GetInstallDir() *string {
 	return nil
 }
 
+// AddModHost adds the git host configuration from a mod_host block
+func (p *PowerpipeWorkspaceProfile) AddModHost(modHost *modconfig.ModHost) hcl.Diagnostics {
+	var diags hcl.Diagnostics
+	p.ModHosts, diags = addModHost(p.ModHosts, modHost)
+	return diags
+}
+
+func (p *PowerpipeWorkspaceProfile) GetModHosts() []*modconfig.ModHost {
+	return p.ModHosts
+}
+
 func (p *PowerpipeWorkspaceProfile) IsNil() bool {
 	return p == nil
 }